@@ -41,6 +41,7 @@ import (
 	"github.com/gocrane/crane/pkg/providers/metricserver"
 	"github.com/gocrane/crane/pkg/providers/mock"
 	"github.com/gocrane/crane/pkg/providers/prom"
+	"github.com/gocrane/crane/pkg/providers/victoriametrics"
 	_ "github.com/gocrane/crane/pkg/querybuilder-providers/grpc"
 	_ "github.com/gocrane/crane/pkg/querybuilder-providers/metricserver"
 	_ "github.com/gocrane/crane/pkg/querybuilder-providers/prometheus"
@@ -106,16 +107,30 @@ func Run(ctx context.Context, opts *options.Options) error {
 		klog.Error(err, "failed to add health check endpoint")
 		return err
 	}
+	// the controllers add their readiness checks, such as the evpa estimators
+	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
+		klog.Error(err, "failed to add ready check endpoint")
+		return err
+	}
 	// initialize data sources and predictor
 	realtimeDataSources, historyDataSources, dataSourceProviders := initDataSources(mgr, opts)
 	predictorMgr := initPredictorManager(opts, realtimeDataSources, historyDataSources)
 
 	initScheme()
 	initWebhooks(mgr, opts)
-	initControllers(ctx, mgr, opts, predictorMgr, historyDataSources[providers.PrometheusDataSource])
+	historyDataSource, exists := historyDataSources[providers.PrometheusDataSource]
+	if !exists {
+		// victoria metrics serves the same queries as prometheus
+		historyDataSource = historyDataSources[providers.VictoriaMetricsDataSource]
+	}
+	initControllers(ctx, mgr, opts, predictorMgr, historyDataSource)
 	// initialize custom collector metrics
 	initMetricCollector(mgr)
-	runAll(ctx, mgr, predictorMgr, dataSourceProviders[providers.PrometheusDataSource], opts)
+	serverDataSource, exists := dataSourceProviders[providers.PrometheusDataSource]
+	if !exists {
+		serverDataSource = dataSourceProviders[providers.VictoriaMetricsDataSource]
+	}
+	runAll(ctx, mgr, predictorMgr, serverDataSource, opts)
 
 	return nil
 }
@@ -181,6 +196,14 @@ func initDataSources(mgr ctrl.Manager, opts *options.Options) (map[providers.Dat
 				klog.Exitf("unable to create datasource provider %v, err: %v", datasource, err)
 			}
 			hybridDataSources[providers.MockDataSource] = provider
+		case "victoriametrics":
+			provider, err := victoriametrics.NewProvider(&opts.DataSourceVictoriaMetricsConfig)
+			if err != nil {
+				klog.Exitf("unable to create datasource provider %v, err: %v", datasource, err)
+			}
+			hybridDataSources[providers.VictoriaMetricsDataSource] = provider
+			realtimeDataSources[providers.VictoriaMetricsDataSource] = provider
+			historyDataSources[providers.VictoriaMetricsDataSource] = provider
 		case "prometheus", "prom":
 			fallthrough
 		default:
@@ -261,12 +284,15 @@ func initControllers(ctx context.Context, mgr ctrl.Manager, opts *options.Option
 		}
 
 		if err := (&evpa.EffectiveVPAController{
-			Client:        mgr.GetClient(),
-			Scheme:        mgr.GetScheme(),
-			Recorder:      mgr.GetEventRecorderFor("effective-vpa-controller"),
-			OOMRecorder:   podOOMRecorder,
-			Predictor:     predictorMgr.GetPredictor(predictionapi.AlgorithmTypePercentile),
-			TargetFetcher: targetSelectorFetcher,
+			Client:            mgr.GetClient(),
+			Scheme:            mgr.GetScheme(),
+			Recorder:          mgr.GetEventRecorderFor("effective-vpa-controller"),
+			OOMRecorder:       podOOMRecorder,
+			Predictor:         predictorMgr.GetPredictor(predictionapi.AlgorithmTypePercentile),
+			ForecastPredictor: predictorMgr.GetPredictor(predictionapi.AlgorithmTypeDSP),
+			TargetFetcher:     targetSelectorFetcher,
+			HistoryProvider:   historyDataSource,
+			Config:            opts.EvpaControllerConfig,
 		}).SetupWithManager(mgr); err != nil {
 			klog.Exit(err, "unable to create controller", "controller", "EffectiveVPAController")
 		}
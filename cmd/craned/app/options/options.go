@@ -7,6 +7,7 @@ import (
 	componentbaseconfig "k8s.io/component-base/config"
 
 	"github.com/gocrane/crane/pkg/controller/ehpa"
+	"github.com/gocrane/crane/pkg/controller/evpa"
 	"github.com/gocrane/crane/pkg/prediction/config"
 	"github.com/gocrane/crane/pkg/providers"
 	serverconfig "github.com/gocrane/crane/pkg/server/config"
@@ -35,6 +36,8 @@ type Options struct {
 	DataSourceMockConfig providers.MockConfig
 	// DataSourceGrpcConfig is the config for grpc provider
 	DataSourceGrpcConfig providers.GrpcConfig
+	// DataSourceVictoriaMetricsConfig is the victoria metrics datasource config
+	DataSourceVictoriaMetricsConfig providers.PromConfig
 
 	// AlgorithmModelConfig
 	AlgorithmModelConfig config.AlgorithmModelConfig
@@ -51,6 +54,9 @@ type Options struct {
 
 	// EhpaControllerConfig is the configuration for Ehpa controller
 	EhpaControllerConfig ehpa.EhpaControllerConfig
+
+	// EvpaControllerConfig is the configuration for Evpa controller
+	EvpaControllerConfig evpa.EvpaControllerConfig
 }
 
 // NewOptions builds an empty options.
@@ -92,7 +98,7 @@ func (o *Options) AddFlags(flags *pflag.FlagSet) {
 
 	flags.DurationVar(&o.PredictionUpdateFrequency, "prediction-update-frequency-duration", 30*time.Second,
 		"Specifies the update frequency of the prediction.")
-	flags.StringSliceVar(&o.DataSource, "datasource", []string{"prom"}, "data source of the predictor, prom, mock is available")
+	flags.StringSliceVar(&o.DataSource, "datasource", []string{"prom"}, "data source of the predictor, prom, victoriametrics, mock is available")
 	flags.StringVar(&o.DataSourcePromConfig.Address, "prometheus-address", "", "prometheus address")
 	flags.StringVar(&o.DataSourcePromConfig.Auth.Username, "prometheus-auth-username", "", "prometheus auth username")
 	flags.StringVar(&o.DataSourcePromConfig.Auth.Password, "prometheus-auth-password", "", "prometheus auth password")
@@ -103,6 +109,16 @@ func (o *Options) AddFlags(flags *pflag.FlagSet) {
 	flags.DurationVar(&o.DataSourcePromConfig.Timeout, "prometheus-timeout", 3*time.Minute, "prometheus timeout")
 	flags.BoolVar(&o.DataSourcePromConfig.BRateLimit, "prometheus-bratelimit", false, "prometheus bratelimit")
 	flags.IntVar(&o.DataSourcePromConfig.MaxPointsLimitPerTimeSeries, "prometheus-maxpoints", 11000, "prometheus max points limit per time series")
+	flags.StringVar(&o.DataSourceVictoriaMetricsConfig.Address, "victoriametrics-address", "", "victoria metrics address, such as http://vmselect:8481/select/0/prometheus of the cluster version")
+	flags.StringVar(&o.DataSourceVictoriaMetricsConfig.Auth.Username, "victoriametrics-auth-username", "", "victoria metrics auth username")
+	flags.StringVar(&o.DataSourceVictoriaMetricsConfig.Auth.Password, "victoriametrics-auth-password", "", "victoria metrics auth password")
+	flags.StringVar(&o.DataSourceVictoriaMetricsConfig.Auth.BearerToken, "victoriametrics-auth-bearertoken", "", "victoria metrics auth bearertoken")
+	flags.IntVar(&o.DataSourceVictoriaMetricsConfig.QueryConcurrency, "victoriametrics-query-concurrency", 10, "victoria metrics query concurrency")
+	flags.BoolVar(&o.DataSourceVictoriaMetricsConfig.InsecureSkipVerify, "victoriametrics-insecure-skip-verify", false, "victoria metrics insecure skip verify")
+	flags.DurationVar(&o.DataSourceVictoriaMetricsConfig.KeepAlive, "victoriametrics-keepalive", 60*time.Second, "victoria metrics keep alive")
+	flags.DurationVar(&o.DataSourceVictoriaMetricsConfig.Timeout, "victoriametrics-timeout", 3*time.Minute, "victoria metrics timeout")
+	flags.BoolVar(&o.DataSourceVictoriaMetricsConfig.BRateLimit, "victoriametrics-bratelimit", false, "victoria metrics bratelimit")
+	flags.IntVar(&o.DataSourceVictoriaMetricsConfig.MaxPointsLimitPerTimeSeries, "victoriametrics-maxpoints", 30000, "victoria metrics max points limit per time series")
 	flags.StringVar(&o.DataSourceMockConfig.SeedFile, "seed-file", "", "mock provider seed file")
 	flags.StringVar(&o.DataSourceGrpcConfig.Address, "grpc-ds-address", "localhost:50051", "grpc data source server address")
 	flags.DurationVar(&o.DataSourceGrpcConfig.Timeout, "grpc-ds-timeout", time.Minute, "grpc timeout")
@@ -113,4 +129,13 @@ func (o *Options) AddFlags(flags *pflag.FlagSet) {
 	flags.StringSliceVar(&o.EhpaControllerConfig.PropagationConfig.AnnotationPrefixes, "ehpa-propagation-annotation-prefixes", []string{}, "propagate annotations whose key has the prefix to hpa")
 	flags.StringSliceVar(&o.EhpaControllerConfig.PropagationConfig.Labels, "ehpa-propagation-labels", []string{}, "propagate labels whose key is complete matching to hpa")
 	flags.StringSliceVar(&o.EhpaControllerConfig.PropagationConfig.Annotations, "ehpa-propagation-annotations", []string{}, "propagate annotations whose key is complete matching to hpa")
+	flags.IntVar(&o.EvpaControllerConfig.ChangeBudgetLimit, "evpa-change-budget-limit", 0, "max recommendation changes emitted by evpa in a budget period, high priority workloads are admitted first, 0 means unlimited")
+	flags.DurationVar(&o.EvpaControllerConfig.ChangeBudgetPeriod, "evpa-change-budget-period", time.Hour, "the period of evpa change budget")
+	flags.BoolVar(&o.EvpaControllerConfig.SchedulingCheck, "evpa-scheduling-check", false, "whether to drop the evpa recommendation with which the pod would not fit any node")
+	flags.StringVar(&o.EvpaControllerConfig.ApprovalWebhookURL, "evpa-approval-webhook-url", "", "the webhook to approve the evpa recommendation before it is surfaced, empty means no approval")
+	flags.DurationVar(&o.EvpaControllerConfig.ApprovalWebhookTimeout, "evpa-approval-webhook-timeout", 10*time.Second, "the timeout of calling the evpa approval webhook")
+	flags.BoolVar(&o.EvpaControllerConfig.KillSwitch, "evpa-kill-switch", false, "whether to stop all the evpa recommendation changes, the estimations are still computed")
+	flags.StringVar(&o.EvpaControllerConfig.KillSwitchConfigMap, "evpa-kill-switch-configmap", "", "the namespace/name of the configmap of the evpa kill switch, the changes are stopped if its 'disabled' is true")
+	flags.Int32Var(&o.EvpaControllerConfig.ChangeDampeningPercentage, "evpa-change-dampening-percentage", 0, "the min change in percent of the applied evpa recommendation to emit a new one unless the container was oom killed since its last scaling, 0 means no dampening")
+	flags.StringVar(&o.EvpaControllerConfig.CallerPrefix, "evpa-caller-prefix", "EVPACaller", "the prefix of the evpa predictor callers, the crane instances sharing a predictor should use distinct prefixes")
 }
@@ -0,0 +1,65 @@
+package estimator
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+// maxAllowedOf returns the max allowed of the resource in the container policy, the policy of the container
+// name overrides the wildcard policy
+func maxAllowedOf(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resourceName corev1.ResourceName) *resource.Quantity {
+	return allowedOf(evpa, containerName, resourceName, func(containerPolicy *autoscalingapi.ContainerResourcePolicy) corev1.ResourceList {
+		return containerPolicy.MaxAllowed
+	})
+}
+
+// minAllowedOf returns the min allowed of the resource in the container policy, the policy of the container
+// name overrides the wildcard policy
+func minAllowedOf(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resourceName corev1.ResourceName) *resource.Quantity {
+	return allowedOf(evpa, containerName, resourceName, func(containerPolicy *autoscalingapi.ContainerResourcePolicy) corev1.ResourceList {
+		return containerPolicy.MinAllowed
+	})
+}
+
+func allowedOf(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resourceName corev1.ResourceName, boundOf func(*autoscalingapi.ContainerResourcePolicy) corev1.ResourceList) *resource.Quantity {
+	if evpa.Spec.ResourcePolicy == nil {
+		return nil
+	}
+	var result *resource.Quantity
+	for i := range evpa.Spec.ResourcePolicy.ContainerPolicies {
+		containerPolicy := &evpa.Spec.ResourcePolicy.ContainerPolicies[i]
+		if containerPolicy.ContainerName != containerName && containerPolicy.ContainerName != "*" {
+			continue
+		}
+		quantity, exists := boundOf(containerPolicy)[resourceName]
+		if !exists {
+			continue
+		}
+		if result == nil || containerPolicy.ContainerName == containerName {
+			q := quantity.DeepCopy()
+			result = &q
+		}
+	}
+	return result
+}
+
+// clampToAllowed clamps each resource into [MinAllowed, MaxAllowed] of the container policy, the max allowed wins
+// if the bounds conflict. It returns the names of the clamped resources.
+func clampToAllowed(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resources corev1.ResourceList) []corev1.ResourceName {
+	var clamped []corev1.ResourceName
+	for resourceName, quantity := range resources {
+		if minAllowed := minAllowedOf(evpa, containerName, resourceName); minAllowed != nil && quantity.Cmp(*minAllowed) < 0 {
+			quantity = *minAllowed
+		}
+		if maxAllowed := maxAllowedOf(evpa, containerName, resourceName); maxAllowed != nil && quantity.Cmp(*maxAllowed) > 0 {
+			quantity = *maxAllowed
+		}
+		if current := resources[resourceName]; quantity.Cmp(current) != 0 {
+			resources[resourceName] = quantity
+			clamped = append(clamped, resourceName)
+		}
+	}
+	return clamped
+}
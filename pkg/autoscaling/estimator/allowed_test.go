@@ -0,0 +1,87 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestGetResourceEstimationAllowed(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.003),
+		"memory": newSeries(1000 * 1000 * 1000),
+	})
+
+	for _, test := range []struct {
+		desc       string
+		minAllowed corev1.ResourceList
+		maxAllowed corev1.ResourceList
+		cpu        string
+		memory     string
+	}{
+		{
+			desc:       "below min",
+			minAllowed: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+			cpu:        "50m",
+			memory:     "1Gi",
+		},
+		{
+			desc:       "above max",
+			maxAllowed: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1m"), corev1.ResourceMemory: resource.MustParse("900Mi")},
+			cpu:        "1m",
+			memory:     "900Mi",
+		},
+		{
+			// 1G is less than 1Gi
+			desc:       "inside",
+			minAllowed: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1m"), corev1.ResourceMemory: resource.MustParse("900M")},
+			maxAllowed: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+			cpu:        "3m",
+			memory:     "1G",
+		},
+		{
+			desc:       "per resource",
+			minAllowed: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m")},
+			cpu:        "10m",
+			memory:     "1G",
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			evpa := newTestEVPA("nginx")
+			evpa.Spec.ResourcePolicy.ContainerPolicies[0].MinAllowed = test.minAllowed
+			evpa.Spec.ResourcePolicy.ContainerPolicies[0].MaxAllowed = test.maxAllowed
+			resources, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+			assert.NoError(t, err)
+			assert.Equal(t, 0, resources.Cpu().Cmp(resource.MustParse(test.cpu)), resources.Cpu().String())
+			assert.Equal(t, 0, resources.Memory().Cmp(resource.MustParse(test.memory)), resources.Memory().String())
+		})
+	}
+}
+
+func TestClampToAllowed(t *testing.T) {
+	evpa := newTestEVPA("*", "nginx")
+	evpa.Spec.ResourcePolicy.ContainerPolicies[0].MinAllowed = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("64Mi")}
+	evpa.Spec.ResourcePolicy.ContainerPolicies[1].MinAllowed = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}
+
+	// the policy of the container name overrides the wildcard per resource
+	resources := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m"), corev1.ResourceMemory: resource.MustParse("1Mi")}
+	clamped := clampToAllowed(evpa, "nginx", resources)
+	assert.ElementsMatch(t, []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}, clamped)
+	assert.Equal(t, "200m", resources.Cpu().String())
+	assert.Equal(t, "64Mi", resources.Memory().String())
+
+	// the max allowed wins if the bounds conflict
+	evpa.Spec.ResourcePolicy.ContainerPolicies[1].MaxAllowed = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("150m")}
+	resources = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m")}
+	clampToAllowed(evpa, "nginx", resources)
+	assert.Equal(t, "150m", resources.Cpu().String())
+
+	resources = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("120m")}
+	assert.Empty(t, clampToAllowed(evpa, "sidecar", resources))
+	assert.Equal(t, "120m", resources.Cpu().String())
+}
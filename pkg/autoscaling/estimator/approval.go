@@ -0,0 +1,106 @@
+package estimator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ReasonPendingApproval means the recommendation is held until it is approved
+	ReasonPendingApproval = "PendingApproval"
+
+	defaultApprovalTimeout = 10 * time.Second
+)
+
+// ApprovalRequest is the proposed recommendation for a container
+type ApprovalRequest struct {
+	Namespace     string              `json:"namespace"`
+	Name          string              `json:"name"`
+	TargetKind    string              `json:"targetKind"`
+	TargetName    string              `json:"targetName"`
+	ContainerName string              `json:"containerName"`
+	Current       corev1.ResourceList `json:"current,omitempty"`
+	Proposed      corev1.ResourceList `json:"proposed"`
+}
+
+// ApprovalResponse is the decision of the approval
+type ApprovalResponse struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Approver decides whether a proposed recommendation can be surfaced
+type Approver interface {
+	Approve(ctx context.Context, request *ApprovalRequest) (*ApprovalResponse, error)
+}
+
+// WebhookApprover posts the approval request as json to the webhook and expects an ApprovalResponse
+type WebhookApprover struct {
+	URL     string
+	Client  *http.Client
+	Timeout time.Duration
+}
+
+var _ Approver = &WebhookApprover{}
+
+func NewWebhookApprover(url string, timeout time.Duration) *WebhookApprover {
+	if timeout <= 0 {
+		timeout = defaultApprovalTimeout
+	}
+	return &WebhookApprover{
+		URL:     url,
+		Client:  &http.Client{},
+		Timeout: timeout,
+	}
+}
+
+func (w *WebhookApprover) Approve(ctx context.Context, request *ApprovalRequest) (*ApprovalResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = defaultApprovalTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := w.Client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call approval webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval webhook response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("approval webhook returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	response := &ApprovalResponse{}
+	if err := json.Unmarshal(respBody, response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal approval webhook response: %v", err)
+	}
+	return response, nil
+}
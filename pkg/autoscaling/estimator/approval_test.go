@@ -0,0 +1,51 @@
+package estimator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func newFakeWebhook(t *testing.T, approved bool, delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &ApprovalRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(request))
+		assert.Equal(t, "nginx", request.ContainerName)
+		time.Sleep(delay)
+		_ = json.NewEncoder(w).Encode(&ApprovalResponse{Approved: approved, Reason: "by test"})
+	}))
+}
+
+func TestWebhookApprover(t *testing.T) {
+	request := &ApprovalRequest{
+		Namespace:     "default",
+		Name:          "evpa",
+		ContainerName: "nginx",
+		Proposed:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+	}
+
+	approving := newFakeWebhook(t, true, 0)
+	defer approving.Close()
+	response, err := NewWebhookApprover(approving.URL, time.Second).Approve(context.TODO(), request)
+	assert.NoError(t, err)
+	assert.True(t, response.Approved)
+
+	rejecting := newFakeWebhook(t, false, 0)
+	defer rejecting.Close()
+	response, err = NewWebhookApprover(rejecting.URL, time.Second).Approve(context.TODO(), request)
+	assert.NoError(t, err)
+	assert.False(t, response.Approved)
+	assert.Equal(t, "by test", response.Reason)
+
+	slow := newFakeWebhook(t, true, 200*time.Millisecond)
+	defer slow.Close()
+	_, err = NewWebhookApprover(slow.URL, 50*time.Millisecond).Approve(context.TODO(), request)
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+// GetResourceEstimations returns the recommended resources of the containers, the containers are estimated
+// concurrently and share the target workload selector. The current requirements are looked up by the container name,
+// a missing one is empty. The failed containers are missing in the result and their errors are aggregated, the
+// result of the others is returned along.
+func (e *PercentileResourceEstimator) GetResourceEstimations(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerNames []string, currRes map[string]*corev1.ResourceRequirements) (map[string]corev1.ResourceList, error) {
+	registerMetrics()
+	ctx = WithSelectorCache(ctx)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	result := make(map[string]corev1.ResourceList, len(containerNames))
+	containerErrs := map[string]error{}
+	for _, containerName := range containerNames {
+		containerRes := currRes[containerName]
+		if containerRes == nil {
+			containerRes = &corev1.ResourceRequirements{}
+		}
+		wg.Add(1)
+		go func(containerName string, containerRes *corev1.ResourceRequirements) {
+			defer runtime.HandleCrash()
+			defer wg.Done()
+			estimation, err := e.EstimateResources(ctx, evpa, config, containerName, containerRes)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				containerErrs[containerName] = err
+				return
+			}
+			setRecommendedValues(evpa, containerName, estimation.Resources)
+			result[containerName] = estimation.Resources
+		}(containerName, containerRes)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, containerName := range containerNames {
+		if err, exists := containerErrs[containerName]; exists {
+			errs = append(errs, fmt.Errorf("container %s: %w", containerName, err))
+		}
+	}
+	return result, utilerrors.NewAggregate(errs)
+}
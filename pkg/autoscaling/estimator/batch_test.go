@@ -0,0 +1,53 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestGetResourceEstimations(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"nginx/cpu":      newSeries(0.25),
+		"nginx/memory":   newSeries(256 * 1024 * 1024),
+		"sidecar/cpu":    newSeries(0.05),
+		"sidecar/memory": newSeries(64 * 1024 * 1024),
+	})
+	fetcher := &countingFetcher{SelectorFetcher: e.TargetFetcher}
+	e.TargetFetcher = fetcher
+	evpa := newTestEVPA("nginx", "sidecar", "logger")
+
+	// the logger has no samples
+	estimations, err := e.GetResourceEstimations(context.TODO(), evpa, map[string]string{}, []string{"nginx", "sidecar", "logger"}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "container logger")
+	assert.True(t, errors.Is(err, ErrNoSamples), err)
+	assert.Len(t, estimations, 2)
+	nginx, sidecar := estimations["nginx"], estimations["sidecar"]
+	assert.Equal(t, "250m", nginx.Cpu().String())
+	assert.Equal(t, "256Mi", nginx.Memory().String())
+	assert.Equal(t, "50m", sidecar.Cpu().String())
+	assert.Equal(t, "64Mi", sidecar.Memory().String())
+	assert.NotContains(t, estimations, "logger")
+	// the containers share the selector
+	assert.Equal(t, 1, fetcher.fetched)
+	assert.Equal(t, 1, predictor.called["logger/cpu"])
+
+	// the current requirements of the container are used
+	estimations, err = e.GetResourceEstimations(context.TODO(), evpa, map[string]string{"fallback-to-current": "true"}, []string{"nginx", "logger"}, map[string]*corev1.ResourceRequirements{
+		"logger": {Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		}},
+	})
+	assert.NoError(t, err)
+	logger := estimations["logger"]
+	assert.Equal(t, "10m", logger.Cpu().String())
+	assert.Len(t, estimations, 2)
+}
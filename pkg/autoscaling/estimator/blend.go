@@ -0,0 +1,45 @@
+package estimator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+// getBlendAlpha returns the '<prefix>-blend-alpha' weight of the percentile blended with the window max, 1 by
+// default which is the pure percentile
+func getBlendAlpha(config map[string]string, prefix string) (float64, error) {
+	value, exists := config[prefix+"-blend-alpha"]
+	if !exists {
+		return 1, nil
+	}
+	alpha, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s-blend-alpha failed: %v", prefix, err)
+	}
+	if math.IsNaN(alpha) || alpha < 0 || alpha > 1 {
+		return 0, fmt.Errorf("%s-blend-alpha must be in [0, 1], got %s", prefix, value)
+	}
+	return alpha, nil
+}
+
+// blendWithMax returns alpha * percentile + (1 - alpha) * the max of the samples. Both of them include the same
+// margin, so the blend is the same as blending before the margin is applied.
+func blendWithMax(resourceName corev1.ResourceName, percentile float64, samples []common.Sample, alpha float64, graph *ExplanationGraph) float64 {
+	if alpha == 1 {
+		return percentile
+	}
+	windowMax := percentile
+	for _, sample := range samples {
+		if sample.Value > windowMax {
+			windowMax = sample.Value
+		}
+	}
+	blended := alpha*percentile + (1-alpha)*windowMax
+	graph.addStep(resourceName, ExplanationNodeTransform, "blend", blended, fmt.Sprintf("blend %g of the percentile with the window max %g", alpha, windowMax))
+	return blended
+}
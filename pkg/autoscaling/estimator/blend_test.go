@@ -0,0 +1,46 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestEstimateResourcesBlendAlpha(t *testing.T) {
+	// the first sample is the percentile, the window max is far above it
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.5, 1, 2),
+		"memory": newSeries(512*mebibyte, 1024*mebibyte),
+	})
+
+	tests := []struct {
+		config   map[string]string
+		expected string
+	}{
+		{config: map[string]string{}, expected: "500m"},
+		{config: map[string]string{"cpu-blend-alpha": "1"}, expected: "500m"},
+		{config: map[string]string{"cpu-blend-alpha": "0"}, expected: "2"},
+		{config: map[string]string{"cpu-blend-alpha": "0.75"}, expected: "875m"},
+	}
+	for _, tt := range tests {
+		resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), tt.config, "nginx", &corev1.ResourceRequirements{})
+		assert.NoError(t, err, tt.config)
+		assert.Equal(t, tt.expected, resources.Cpu().String(), tt.config)
+		// the memory is the pure percentile
+		assert.Equal(t, "512Mi", resources.Memory().String(), tt.config)
+	}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-blend-alpha": "0.5"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+	assert.Equal(t, "768Mi", resources.Memory().String())
+
+	for _, value := range []string{"-0.1", "1.5", "NaN", "half"} {
+		_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-blend-alpha": value}, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,105 @@
+package estimator
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const defaultBlueGreenMinHistory = "24h"
+
+// blueGreenConfig attributes the pod series to the colors of a blue/green rollout, the color is the value of the
+// pod label
+type blueGreenConfig struct {
+	colorLabel string
+	// minHistory is the history the new color must accumulate before its samples are aggregated
+	minHistory time.Duration
+}
+
+// getBlueGreenConfig returns nil if 'blue-green-color-label' is not set
+func getBlueGreenConfig(config map[string]string) (*blueGreenConfig, error) {
+	colorLabel, exists := config["blue-green-color-label"]
+	if !exists || colorLabel == "" {
+		return nil, nil
+	}
+
+	minHistoryStr, exists := config["blue-green-min-history"]
+	if !exists {
+		minHistoryStr = defaultBlueGreenMinHistory
+	}
+	minHistory, err := utils.ParseDuration(minHistoryStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse blue-green-min-history failed: %v", err)
+	}
+
+	return &blueGreenConfig{colorLabel: colorLabel, minHistory: minHistory}, nil
+}
+
+// colorHistory is the series of a color and the time range they cover
+type colorHistory struct {
+	series   []*common.TimeSeries
+	earliest int64
+	latest   int64
+}
+
+// selectSeries prefers the history of the stable colors until the newest color accumulates enough history, so
+// the short history of the new color doesn't dominate. After that the series of all colors are aggregated.
+// Series of the pods unknown to the client, such as the deleted pods, are attributed to the stable colors.
+func (c *blueGreenConfig) selectSeries(tsList []*common.TimeSeries, pods []corev1.Pod) []*common.TimeSeries {
+	podColors := make(map[string]string, len(pods))
+	for _, pod := range pods {
+		podColors[pod.Name] = pod.Labels[c.colorLabel]
+	}
+
+	colors := map[string]*colorHistory{}
+	for _, ts := range tsList {
+		if len(ts.Samples) == 0 {
+			continue
+		}
+		color := podColors[seriesPodName(ts)]
+		history, exists := colors[color]
+		if !exists {
+			history = &colorHistory{earliest: ts.Samples[0].Timestamp, latest: ts.Samples[0].Timestamp}
+			colors[color] = history
+		}
+		history.series = append(history.series, ts)
+		for _, sample := range ts.Samples {
+			if sample.Timestamp < history.earliest {
+				history.earliest = sample.Timestamp
+			}
+			if sample.Timestamp > history.latest {
+				history.latest = sample.Timestamp
+			}
+		}
+	}
+	if len(colors) < 2 {
+		return tsList
+	}
+
+	newColor := ""
+	var newHistory *colorHistory
+	for color, history := range colors {
+		// pods of unknown color are never the new one
+		if color == "" {
+			continue
+		}
+		if newHistory == nil || history.earliest > newHistory.earliest {
+			newColor, newHistory = color, history
+		}
+	}
+	if newHistory == nil || time.Duration(newHistory.latest-newHistory.earliest)*time.Second >= c.minHistory {
+		return tsList
+	}
+
+	var result []*common.TimeSeries
+	for color, history := range colors {
+		if color != newColor {
+			result = append(result, history.series...)
+		}
+	}
+	return result
+}
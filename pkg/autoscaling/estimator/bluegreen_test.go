@@ -0,0 +1,72 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func newColorPod(name string, color string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"color": color}},
+	}
+}
+
+func TestEstimationBlueGreen(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(4),
+		"memory": newSeries(1024),
+	})
+	e.Clock = clock.NewFakeClock(now)
+	e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		newColorPod("nginx-blue", "blue"),
+		newColorPod("nginx-green", "green"),
+		newTestDeployment(2),
+	).Build()
+	// blue is stable for hours, green is just rolled out 30 minutes ago and is much hotter
+	history := &fakePodHistory{series: map[string][]*common.TimeSeries{
+		"cpu": {
+			newPodSeries("nginx-blue", now.Add(-3*time.Hour), 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1),
+			newPodSeries("nginx-green", now.Add(-30*time.Minute), 3, 3, 3),
+		},
+		"memory": {
+			newPodSeries("nginx-blue", now.Add(-3*time.Hour), 1024, 1024),
+			newPodSeries("nginx-green", now.Add(-30*time.Minute), 4096, 4096, 4096),
+		},
+	}}
+	e.History = history
+
+	config := map[string]string{
+		"blue-green-color-label":      "color",
+		"blue-green-min-history":      "1h",
+		"cpu-request-percentile":      "1.0",
+		"cpu-request-margin-fraction": "0",
+		"mem-request-percentile":      "1.0",
+		"mem-request-margin-fraction": "0",
+	}
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+	assert.Equal(t, "1Ki", resources.Memory().String())
+
+	// green has accumulated enough history, aggregate across both colors
+	history.series["cpu"][1] = newPodSeries("nginx-green", now.Add(-90*time.Minute), 3, 3, 3, 3, 3, 3, 3, 3, 3)
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "3", resources.Cpu().String())
+
+	config["blue-green-min-history"] = "1 hour"
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,149 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+)
+
+// RecommendationBounds is the confidence interval of a recommended resource, the quantities are estimated at the
+// lower, mid and upper percentiles
+type RecommendationBounds struct {
+	Lower resource.Quantity
+	Mid   resource.Quantity
+	Upper resource.Quantity
+}
+
+// boundPercentiles are the lower, mid and upper percentiles of a resource
+type boundPercentiles [3]string
+
+// getBoundPercentiles returns the percentiles of '<prefix>-percentile-lower', '-mid' and '-upper', p50, p90 and p99
+// by default, they must be ordered
+func getBoundPercentiles(config map[string]string, prefix string) (boundPercentiles, error) {
+	var percentiles boundPercentiles
+	var previous float64
+	for i, bound := range []struct {
+		name         string
+		defaultValue string
+	}{{"lower", "0.5"}, {"mid", "0.9"}, {"upper", "0.99"}} {
+		key := fmt.Sprintf("%s-percentile-%s", prefix, bound.name)
+		percentile, err := getPercentile(config, key, bound.defaultValue)
+		if err != nil {
+			return percentiles, err
+		}
+		value, _ := strconv.ParseFloat(percentile, 64)
+		if value < previous {
+			return percentiles, fmt.Errorf("%s must not be less than %s, got %s", key, percentiles[i-1], percentile)
+		}
+		percentiles[i] = percentile
+		previous = value
+	}
+	return percentiles, nil
+}
+
+// EstimateBounds returns the confidence interval of the cpu and memory, the percentile model is queried at the three
+// percentiles of the bounds, the history and the sample configs are the same as the point recommendation.
+func (e *PercentileResourceEstimator) EstimateBounds(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string) (map[corev1.ResourceName]RecommendationBounds, error) {
+	config = containerConfig(config, containerName)
+	cpuConfig, err := getCpuConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	memConfig, err := getMemConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyHistogramScale(config, cpuConfig, memConfig); err != nil {
+		return nil, err
+	}
+	cpuPercentiles, err := getBoundPercentiles(config, "cpu")
+	if err != nil {
+		return nil, err
+	}
+	memPercentiles, err := getBoundPercentiles(config, "mem")
+	if err != nil {
+		return nil, err
+	}
+	sampleSelection, err := getSampleSelection(config)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+	caller := e.caller(evpa)
+	controlled := controlledResourcesOf(evpa, containerName)
+
+	bounds := map[corev1.ResourceName]RecommendationBounds{}
+	var predictErrs []error
+	var noValueErrs []error
+	for _, query := range []struct {
+		resourceName corev1.ResourceName
+		config       *predictionconfig.Config
+		percentiles  boundPercentiles
+	}{
+		{corev1.ResourceCPU, cpuConfig, cpuPercentiles},
+		{corev1.ResourceMemory, memConfig, memPercentiles},
+	} {
+		if !controlled.controls(query.resourceName) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("estimation interrupted: %w", err)
+		}
+		metricNamer := newContainerMetricNamer(evpa, caller, containerName, query.resourceName, selector)
+		var quantities []resource.Quantity
+		for _, percentile := range query.percentiles {
+			// only the percentile differs from the point recommendation
+			cfg := *query.config
+			percentileConfig := *cfg.Percentile
+			percentileConfig.Percentile = percentile
+			cfg.Percentile = &percentileConfig
+
+			tsList, err := e.Predictor.QueryRealtimePredictedValuesOnce(ctx, metricNamer, cfg)
+			if err != nil {
+				predictErrs = append(predictErrs, err)
+				break
+			}
+			sample, selected := selectSample(seriesSamples(largestSeries(tsList, sampleSelection)), sampleSelection)
+			if !selected {
+				noValueErrs = append(noValueErrs, noValueError(ctx, e.Predictor, metricNamer))
+				break
+			}
+			quantities = append(quantities, boundQuantity(query.resourceName, sample.Value))
+		}
+		if len(quantities) != len(query.percentiles) {
+			continue
+		}
+		// the quantiles of a histogram are monotonic, keep them ordered in case the model is reset in between
+		for i := 1; i < len(quantities); i++ {
+			if quantities[i].Cmp(quantities[i-1]) < 0 {
+				quantities[i] = quantities[i-1].DeepCopy()
+			}
+		}
+		bounds[query.resourceName] = RecommendationBounds{Lower: quantities[0], Mid: quantities[1], Upper: quantities[2]}
+	}
+	countEstimationErrors(predictErrs, noValueErrs)
+
+	if len(bounds) == 0 {
+		return nil, allFailedError(predictErrs, noValueErrs)
+	}
+	return bounds, nil
+}
+
+func boundQuantity(resourceName corev1.ResourceName, value float64) resource.Quantity {
+	if resourceName == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(int64(value*1000), resource.DecimalSI)
+	}
+	return *resource.NewQuantity(int64(value), resource.BinarySI)
+}
@@ -0,0 +1,80 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestEstimateBounds(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu@0.5":     newSeries(0.1),
+		"cpu@0.9":     newSeries(0.25),
+		"cpu@0.99":    newSeries(0.5),
+		"memory@0.5":  newSeries(128 * 1024 * 1024),
+		"memory@0.8":  newSeries(256 * 1024 * 1024),
+		"memory@0.95": newSeries(512 * 1024 * 1024),
+	})
+
+	bounds, err := e.EstimateBounds(context.TODO(), newTestEVPA("nginx"), map[string]string{
+		"cpu-request-margin-fraction": "0.1",
+		"mem-percentile-mid":          "0.8",
+		"mem-percentile-upper":        "0.95",
+	}, "nginx")
+	assert.NoError(t, err)
+	cpu, memory := bounds[corev1.ResourceCPU], bounds[corev1.ResourceMemory]
+	assert.Equal(t, "100m", cpu.Lower.String())
+	assert.Equal(t, "250m", cpu.Mid.String())
+	assert.Equal(t, "500m", cpu.Upper.String())
+	assert.Equal(t, "128Mi", memory.Lower.String())
+	assert.Equal(t, "256Mi", memory.Mid.String())
+	assert.Equal(t, "512Mi", memory.Upper.String())
+
+	// three queries at the percentiles, the other configs are the same
+	var percentiles []string
+	for _, cfg := range predictor.onceQueries["nginx/cpu"] {
+		percentiles = append(percentiles, cfg.Percentile.Percentile)
+		assert.Equal(t, "0.1", cfg.Percentile.MarginFraction)
+		assert.Equal(t, "24h", cfg.Percentile.HistoryLength)
+	}
+	assert.Equal(t, []string{"0.5", "0.9", "0.99"}, percentiles)
+	percentiles = nil
+	for _, cfg := range predictor.onceQueries["nginx/memory"] {
+		percentiles = append(percentiles, cfg.Percentile.Percentile)
+	}
+	assert.Equal(t, []string{"0.5", "0.8", "0.95"}, percentiles)
+
+	// the bounds are kept ordered
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu@0.5":  newSeries(0.3),
+		"cpu@0.9":  newSeries(0.2),
+		"cpu@0.99": newSeries(0.4),
+	})
+	bounds, err = e.EstimateBounds(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx")
+	assert.NoError(t, err)
+	cpu = bounds[corev1.ResourceCPU]
+	assert.Equal(t, "300m", cpu.Mid.String())
+	assert.Equal(t, "400m", cpu.Upper.String())
+	assert.NotContains(t, bounds, corev1.ResourceMemory)
+
+	// all failed
+	e, predictor = newTestEstimator(map[string][]*common.TimeSeries{})
+	predictor.errs["cpu"] = fmt.Errorf("query failed")
+	_, err = e.EstimateBounds(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx")
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrNoSamples)
+
+	for _, invalid := range []map[string]string{
+		{"cpu-percentile-lower": "0.95"},
+		{"mem-percentile-upper": "0.6"},
+		{"cpu-percentile-mid": "90"},
+	} {
+		_, err = e.EstimateBounds(context.TODO(), newTestEVPA("nginx"), invalid, "nginx")
+		assert.Error(t, err, invalid)
+	}
+}
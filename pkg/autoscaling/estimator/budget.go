@@ -0,0 +1,133 @@
+package estimator
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ChangeCandidate is a recommendation change waiting for the change budget
+type ChangeCandidate struct {
+	// Key identify the change, such as namespace/workload/container
+	Key string
+	// Priority is the pod priority of the workload, higher value is admitted first
+	Priority int32
+}
+
+type pendingChange struct {
+	ChangeCandidate
+	lastSeen time.Time
+}
+
+// ChangeBudget limits the recommendation changes emitted in a period. When the budget is scarce,
+// changes of high priority workloads are admitted before low priority ones. Deferred changes stay
+// pending and keep reserving budget for its priority until they are admitted or expired.
+type ChangeBudget struct {
+	mu sync.Mutex
+
+	// Limit is the max changes admitted in one period, zero means unlimited
+	Limit int
+	// Period is the duration of one budget window
+	Period time.Duration
+	Clock  clock.Clock
+
+	windowStart time.Time
+	used        int
+	pending     map[string]pendingChange
+}
+
+func NewChangeBudget(limit int, period time.Duration) *ChangeBudget {
+	return &ChangeBudget{
+		Limit:   limit,
+		Period:  period,
+		Clock:   clock.RealClock{},
+		pending: make(map[string]pendingChange),
+	}
+}
+
+// Admit returns the candidates that can be emitted now. Candidates are ordered by priority,
+// pending changes from other callers with higher priority reserve the budget before the given candidates.
+func (b *ChangeBudget) Admit(candidates []ChangeCandidate) []ChangeCandidate {
+	if b.Limit <= 0 {
+		return candidates
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.Clock.Now()
+	if b.pending == nil {
+		b.pending = make(map[string]pendingChange)
+	}
+	if now.Sub(b.windowStart) >= b.Period {
+		b.windowStart = now
+		b.used = 0
+	}
+
+	// drop the pending changes that no caller asked for a long time
+	for key, change := range b.pending {
+		if now.Sub(change.lastSeen) > 2*b.Period {
+			delete(b.pending, key)
+		}
+	}
+
+	requested := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		requested[candidate.Key] = true
+		b.pending[candidate.Key] = pendingChange{ChangeCandidate: candidate, lastSeen: now}
+	}
+
+	var ordered []pendingChange
+	for _, change := range b.pending {
+		ordered = append(ordered, change)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		return ordered[i].Key < ordered[j].Key
+	})
+
+	var admitted []ChangeCandidate
+	remaining := b.Limit - b.used
+	for _, change := range ordered {
+		if remaining <= 0 {
+			break
+		}
+		remaining--
+		if requested[change.Key] {
+			admitted = append(admitted, change.ChangeCandidate)
+			delete(b.pending, change.Key)
+			b.used++
+		}
+	}
+
+	return admitted
+}
+
+// GetPodPriority return the priority of the pod spec, the priority class is resolved by client if priority is not set
+func GetPodPriority(ctx context.Context, kubeClient client.Client, podSpec *corev1.PodSpec) int32 {
+	if podSpec.Priority != nil {
+		return *podSpec.Priority
+	}
+
+	if podSpec.PriorityClassName == "" || kubeClient == nil {
+		return 0
+	}
+
+	priorityClass := &schedulingv1.PriorityClass{}
+	err := kubeClient.Get(ctx, client.ObjectKey{Name: podSpec.PriorityClassName}, priorityClass)
+	if err != nil {
+		klog.ErrorS(err, "Failed to get priority class.", "priorityClass", podSpec.PriorityClassName)
+		return 0
+	}
+
+	return priorityClass.Value
+}
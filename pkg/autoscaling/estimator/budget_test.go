@@ -0,0 +1,81 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func candidateKeys(candidates []ChangeCandidate) []string {
+	var keys []string
+	for _, candidate := range candidates {
+		keys = append(keys, candidate.Key)
+	}
+	return keys
+}
+
+func TestChangeBudgetAdmitByPriority(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	budget := NewChangeBudget(2, time.Hour)
+	budget.Clock = fakeClock
+
+	admitted := budget.Admit([]ChangeCandidate{
+		{Key: "low-1", Priority: 0},
+		{Key: "high-1", Priority: 1000},
+		{Key: "low-2", Priority: 0},
+		{Key: "high-2", Priority: 1000},
+	})
+	assert.Equal(t, []string{"high-1", "high-2"}, candidateKeys(admitted))
+
+	// budget exhausted in this period
+	assert.Empty(t, budget.Admit([]ChangeCandidate{{Key: "high-3", Priority: 2000}}))
+
+	// new period, the pending high priority change reserves the budget before low priority ones
+	fakeClock.Step(time.Hour)
+	admitted = budget.Admit([]ChangeCandidate{{Key: "low-1", Priority: 0}, {Key: "low-2", Priority: 0}})
+	assert.Equal(t, []string{"low-1"}, candidateKeys(admitted))
+
+	admitted = budget.Admit([]ChangeCandidate{{Key: "high-3", Priority: 2000}})
+	assert.Equal(t, []string{"high-3"}, candidateKeys(admitted))
+}
+
+func TestChangeBudgetUnlimited(t *testing.T) {
+	budget := NewChangeBudget(0, time.Hour)
+	candidates := []ChangeCandidate{{Key: "low", Priority: 0}, {Key: "high", Priority: 1000}}
+	assert.Equal(t, candidates, budget.Admit(candidates))
+}
+
+func TestChangeBudgetPendingExpired(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	budget := NewChangeBudget(1, time.Hour)
+	budget.Clock = fakeClock
+
+	assert.Len(t, budget.Admit([]ChangeCandidate{{Key: "high-1", Priority: 1000}}), 1)
+	assert.Empty(t, budget.Admit([]ChangeCandidate{{Key: "high-2", Priority: 1000}}))
+
+	// high-2 never come back, it should not reserve the budget forever
+	fakeClock.Step(3 * time.Hour)
+	admitted := budget.Admit([]ChangeCandidate{{Key: "low", Priority: 0}})
+	assert.Equal(t, []string{"low"}, candidateKeys(admitted))
+}
+
+func TestGetPodPriority(t *testing.T) {
+	priority := int32(100)
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&schedulingv1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "high-priority"},
+		Value:      1000,
+	}).Build()
+
+	assert.Equal(t, int32(100), GetPodPriority(context.TODO(), kubeClient, &corev1.PodSpec{Priority: &priority, PriorityClassName: "high-priority"}))
+	assert.Equal(t, int32(1000), GetPodPriority(context.TODO(), kubeClient, &corev1.PodSpec{PriorityClassName: "high-priority"}))
+	assert.Equal(t, int32(0), GetPodPriority(context.TODO(), kubeClient, &corev1.PodSpec{PriorityClassName: "not-exist"}))
+	assert.Equal(t, int32(0), GetPodPriority(context.TODO(), kubeClient, &corev1.PodSpec{}))
+}
@@ -0,0 +1,52 @@
+package estimator
+
+import (
+	"fmt"
+	"strconv"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/known"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const defaultBurstableCpuRequestPercentile = "0.9"
+
+// isBurstable tells whether the evpa is explicitly marked burstable by the annotation
+func isBurstable(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) (bool, error) {
+	value, exists := evpa.Annotations[known.EffectiveVerticalPodAutoscalerBurstableAnnotation]
+	if !exists {
+		return false, nil
+	}
+	burstable, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parse annotation %s failed: %v", known.EffectiveVerticalPodAutoscalerBurstableAnnotation, err)
+	}
+	return burstable, nil
+}
+
+// applyBurstableCpuConfig sizes the cpu request to the lower 'cpu-burstable-request-percentile' for the burstable
+// workloads. Sizing the request to p99 defeats the burst model, the limits are expected to cover the bursts on the
+// node-shared cpu.
+func applyBurstableCpuConfig(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, cfg *predictionconfig.Config, config map[string]string) error {
+	burstable, err := isBurstable(evpa)
+	if err != nil || !burstable || cfg.Percentile == nil {
+		return err
+	}
+
+	percentileStr, exists := config["cpu-burstable-request-percentile"]
+	if !exists {
+		percentileStr = defaultBurstableCpuRequestPercentile
+	}
+	percentile, err := utils.ParseFloat(percentileStr, 0)
+	if err != nil {
+		return fmt.Errorf("parse cpu-burstable-request-percentile failed: %v", err)
+	}
+	if percentile <= 0 || percentile > 1 {
+		return fmt.Errorf("cpu-burstable-request-percentile must be in (0,1], got %v", percentile)
+	}
+
+	cfg.Percentile.Percentile = percentileStr
+	return nil
+}
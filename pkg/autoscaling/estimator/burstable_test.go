@@ -0,0 +1,38 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/known"
+)
+
+func TestEstimationBurstableCpu(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.5),
+		"memory": newSeries(1024),
+	})
+
+	evpa := newTestEVPA("nginx")
+	_, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0.99", predictor.queries["nginx/cpu"].Percentile.Percentile)
+
+	evpa.Annotations = map[string]string{known.EffectiveVerticalPodAutoscalerBurstableAnnotation: "true"}
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0.9", predictor.queries["nginx/cpu"].Percentile.Percentile)
+	// memory is not burstable
+	assert.Equal(t, "0.99", predictor.queries["nginx/memory"].Percentile.Percentile)
+
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{"cpu-burstable-request-percentile": "0.75"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0.75", predictor.queries["nginx/cpu"].Percentile.Percentile)
+
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{"cpu-burstable-request-percentile": "75"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
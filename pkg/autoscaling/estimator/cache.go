@@ -0,0 +1,153 @@
+package estimator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+type predictionCacheEntry struct {
+	tsList   []*common.TimeSeries
+	expireAt time.Time
+}
+
+// PredictionCache memoizes the realtime predicted values for a short while, so the repeated estimations of the same
+// query, such as by the ensemble members or the reconciles of the evpa, don't hit the predictor again. It is safe for
+// concurrent use.
+type PredictionCache struct {
+	mu sync.Mutex
+	// entries is keyed by the unique key of the namer and the hash of the config
+	entries map[string]predictionCacheEntry
+}
+
+func NewPredictionCache() *PredictionCache {
+	return &PredictionCache{
+		entries: map[string]predictionCacheEntry{},
+	}
+}
+
+func (c *PredictionCache) get(key string, now time.Time) ([]*common.TimeSeries, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, exists := c.entries[key]
+	if !exists || !now.Before(entry.expireAt) {
+		return nil, false
+	}
+	return copySeries(entry.tsList), true
+}
+
+// add saves the values until the ttl elapses, the expired entries are evicted meanwhile
+func (c *PredictionCache) add(key string, tsList []*common.TimeSeries, ttl time.Duration, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, entry := range c.entries {
+		if !now.Before(entry.expireAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = predictionCacheEntry{tsList: copySeries(tsList), expireAt: now.Add(ttl)}
+}
+
+// copySeries copies the series, the cached ones are not affected by the preprocessing of the callers
+func copySeries(tsList []*common.TimeSeries) []*common.TimeSeries {
+	copied := make([]*common.TimeSeries, 0, len(tsList))
+	for _, ts := range tsList {
+		copied = append(copied, &common.TimeSeries{
+			Labels:  append([]common.Label(nil), ts.Labels...),
+			Samples: append([]common.Sample(nil), ts.Samples...),
+		})
+	}
+	return copied
+}
+
+func predictionCacheKey(namer metricnaming.MetricNamer, cfg *predictionconfig.Config) (string, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("marshal prediction config failed: %v", err)
+	}
+	return namer.BuildUniqueKey() + "/" + hashKey(string(data)), nil
+}
+
+// getPredictionCacheTTL returns the ttl of the 'prediction-cache-ttl', the sample interval of the config by default,
+// the predicted values don't change until the next sample. Zero disables the cache.
+func getPredictionCacheTTL(config map[string]string, cfg *predictionconfig.Config) (time.Duration, error) {
+	ttlStr, exists := config["prediction-cache-ttl"]
+	if !exists {
+		if cfg.Percentile == nil {
+			return 0, nil
+		}
+		ttlStr = cfg.Percentile.SampleInterval
+	}
+	ttl, err := utils.ParseDuration(ttlStr)
+	if err != nil {
+		return 0, fmt.Errorf("parse prediction-cache-ttl failed: %v", err)
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("prediction-cache-ttl %s must not be negative", ttlStr)
+	}
+	return ttl, nil
+}
+
+// queryCachedPredictedValues returns the cached predicted values of the resources and queries the others, only the
+// queried ones are spent from the budget. The successful and non-empty results are cached.
+func (e *PercentileResourceEstimator) queryCachedPredictedValues(ctx context.Context, config map[string]string, namers map[corev1.ResourceName]metricnaming.MetricNamer, secondaryNamers map[corev1.ResourceName]metricnaming.MetricNamer, configs map[corev1.ResourceName]*predictionconfig.Config, budget *queryBudget) (map[corev1.ResourceName]predictedValues, error) {
+	retry, err := getQueryRetry(config)
+	if err != nil {
+		return nil, err
+	}
+	if e.Cache == nil {
+		budget.spend(len(namers))
+		return e.queryRealtimePredictedValues(ctx, namers, secondaryNamers, retry)
+	}
+
+	now := e.now()
+	cached := map[corev1.ResourceName]predictedValues{}
+	missed := map[corev1.ResourceName]metricnaming.MetricNamer{}
+	keys := map[corev1.ResourceName]string{}
+	ttls := map[corev1.ResourceName]time.Duration{}
+	for resourceName, namer := range namers {
+		ttl, err := getPredictionCacheTTL(config, configs[resourceName])
+		if err != nil {
+			return nil, err
+		}
+		if ttl == 0 {
+			missed[resourceName] = namer
+			continue
+		}
+		key, err := predictionCacheKey(namer, configs[resourceName])
+		if err != nil {
+			return nil, err
+		}
+		if tsList, exists := e.Cache.get(key, now); exists {
+			cached[resourceName] = predictedValues{tsList: tsList, predictor: cachedPredictor}
+			continue
+		}
+		missed[resourceName] = namer
+		keys[resourceName] = key
+		ttls[resourceName] = ttl
+	}
+
+	budget.spend(len(missed))
+	predicted, err := e.queryRealtimePredictedValues(ctx, missed, secondaryNamers, retry)
+	if err != nil {
+		return nil, err
+	}
+	for resourceName, values := range predicted {
+		if key, exists := keys[resourceName]; exists && values.err == nil && len(values.tsList) > 0 {
+			e.Cache.add(key, values.tsList, ttls[resourceName], now)
+		}
+	}
+	for resourceName, values := range cached {
+		predicted[resourceName] = values
+	}
+	return predicted, nil
+}
@@ -0,0 +1,140 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func newCachedTestEstimator(series map[string][]*common.TimeSeries) (*PercentileResourceEstimator, *fakePredictor, *clock.FakeClock) {
+	e, predictor := newTestEstimator(series)
+	fakeClock := clock.NewFakeClock(time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC))
+	e.Clock = fakeClock
+	e.Cache = NewPredictionCache()
+	return e, predictor, fakeClock
+}
+
+func TestPredictionCache(t *testing.T) {
+	e, predictor, fakeClock := newCachedTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, 1, predictor.called["nginx/cpu"])
+	assert.Equal(t, 1, predictor.called["nginx/memory"])
+
+	// within the ttl of the sample interval, the predictor is not called
+	fakeClock.Step(30 * time.Second)
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, "256Mi", resources.Memory().String())
+	assert.Equal(t, 1, predictor.called["nginx/cpu"])
+	assert.Equal(t, 1, predictor.called["nginx/memory"])
+
+	// another container is another query
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx", "sidecar"), map[string]string{}, "sidecar", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, predictor.called["sidecar/cpu"])
+
+	// another config is another query
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-request-percentile": "0.9"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, predictor.called["nginx/cpu"])
+	assert.Equal(t, 1, predictor.called["nginx/memory"])
+
+	// expired
+	fakeClock.Step(time.Minute)
+	predictor.series["cpu"] = newSeries(0.5)
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+	assert.Equal(t, 3, predictor.called["nginx/cpu"])
+}
+
+func TestPredictionCacheTTL(t *testing.T) {
+	e, predictor, fakeClock := newCachedTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+
+	config := map[string]string{"prediction-cache-ttl": "5m"}
+	for i := 0; i < 3; i++ {
+		_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+		assert.NoError(t, err)
+		fakeClock.Step(time.Minute)
+	}
+	assert.Equal(t, 1, predictor.called["nginx/cpu"])
+
+	// zero disables the cache
+	config = map[string]string{"prediction-cache-ttl": "0s"}
+	for i := 0; i < 2; i++ {
+		_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, predictor.called["nginx/cpu"])
+
+	for _, ttl := range []string{"soon", "-1m"} {
+		_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"prediction-cache-ttl": ttl}, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, ttl)
+	}
+}
+
+func TestPredictionCacheSkipsFailures(t *testing.T) {
+	e, predictor, _ := newCachedTestEstimator(map[string][]*common.TimeSeries{
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	predictor.errs["cpu"] = fmt.Errorf("query failed")
+
+	// no retry, each estimation queries once
+	config := map[string]string{"query-max-retries": "0"}
+	for i := 0; i < 2; i++ {
+		_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+		assert.NoError(t, err)
+	}
+	// the failed query is not cached, the memory is
+	assert.Equal(t, 2, predictor.called["nginx/cpu"])
+	assert.Equal(t, 1, predictor.called["nginx/memory"])
+}
+
+func TestPredictionCacheConcurrent(t *testing.T) {
+	cache := NewPredictionCache()
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key-%d", i%3)
+			cache.add(key, newSeries(float64(i)), time.Minute, now)
+			tsList, exists := cache.get(key, now)
+			assert.True(t, exists)
+			assert.Len(t, tsList, 1)
+		}(i)
+	}
+	wg.Wait()
+
+	// the cached values are not affected by the callers
+	cache.add("key", newSeries(1), time.Minute, now)
+	tsList, _ := cache.get("key", now)
+	tsList[0].Samples[0].Value = 2
+	tsList, _ = cache.get("key", now)
+	assert.Equal(t, float64(1), tsList[0].Samples[0].Value)
+
+	// the expired entries are evicted
+	cache.add("other", newSeries(1), time.Minute, now.Add(time.Hour))
+	_, exists := cache.get("key", now.Add(time.Hour))
+	assert.False(t, exists)
+	assert.Len(t, cache.entries, 1)
+}
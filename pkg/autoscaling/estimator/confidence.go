@@ -0,0 +1,176 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const (
+	// ReasonLowConfidence means the recommendation is deferred until enough samples are collected
+	ReasonLowConfidence = "LowConfidence"
+
+	// MetadataConfidence is the metadata key of the confidence score of the recommendation in [0,1]
+	MetadataConfidence = "confidence"
+)
+
+// confidenceConfig gates the recommendation by the samples of the history, the recommendations of a too short or too
+// sparse history are not applied
+type confidenceConfig struct {
+	minSampleCount     int
+	minHistoryCoverage float64
+}
+
+// EstimationConfidence is the confidence of the last estimation of a container
+type EstimationConfidence struct {
+	// Score is the confidence score in [0,1], it is the lower of the history coverage and the ratio of the samples
+	// to the min-sample-count
+	Score float64
+	// Samples is the samples count of the sparsest resource
+	Samples int
+	// Confident is whether the thresholds are reached, the recommendation is deferred otherwise
+	Confident bool
+}
+
+// getConfidenceConfig returns the config of 'min-sample-count' and 'min-history-coverage', nil if neither is set
+func getConfidenceConfig(config map[string]string) (*confidenceConfig, error) {
+	minSampleCountStr, sampleCountExists := config["min-sample-count"]
+	minCoverageStr, coverageExists := config["min-history-coverage"]
+	if !sampleCountExists && !coverageExists {
+		return nil, nil
+	}
+	cfg := &confidenceConfig{}
+	if sampleCountExists {
+		minSampleCount, err := strconv.Atoi(minSampleCountStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse min-sample-count failed: %v", err)
+		}
+		if minSampleCount < 0 {
+			return nil, fmt.Errorf("min-sample-count must not be negative, got %d", minSampleCount)
+		}
+		cfg.minSampleCount = minSampleCount
+	}
+	minCoverage, err := utils.ParseFloat(minCoverageStr, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse min-history-coverage failed: %v", err)
+	}
+	if math.IsNaN(minCoverage) || minCoverage < 0 || minCoverage > 1 {
+		return nil, fmt.Errorf("min-history-coverage must be in [0,1], got %v", minCoverageStr)
+	}
+	cfg.minHistoryCoverage = minCoverage
+	return cfg, nil
+}
+
+// estimateConfidence counts the history samples of the cpu and the memory in the history length of their models, the
+// sparser resource decides the confidence
+func (e *PercentileResourceEstimator) estimateConfidence(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, cfg *confidenceConfig) (*EstimationConfidence, error) {
+	cpuConfig, err := getCpuConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	memConfig, err := getMemConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+
+	now := e.now()
+	confidence := &EstimationConfidence{Score: 1, Samples: math.MaxInt32, Confident: true}
+	for _, query := range []struct {
+		resourceName corev1.ResourceName
+		config       *predictionconfig.Config
+	}{
+		{corev1.ResourceCPU, cpuConfig},
+		{corev1.ResourceMemory, memConfig},
+	} {
+		historyLength, err := utils.ParseDuration(query.config.Percentile.HistoryLength)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s history length failed: %v", query.resourceName, err)
+		}
+		sampleInterval, err := utils.ParseDuration(query.config.Percentile.SampleInterval)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s sample interval failed: %v", query.resourceName, err)
+		}
+		expected := float64(historyLength / sampleInterval)
+		if expected <= 0 {
+			return nil, fmt.Errorf("%s history length %v is shorter than the sample interval %v", query.resourceName, historyLength, sampleInterval)
+		}
+
+		metricNamer := newContainerMetricNamer(evpa, e.caller(evpa), containerName, query.resourceName, selector)
+		samples, err := countHistorySamples(e.History, metricNamer, now.Add(-historyLength), now, sampleInterval)
+		if err != nil {
+			return nil, err
+		}
+		coverage := math.Min(float64(samples)/expected, 1)
+		score := coverage
+		if cfg.minSampleCount > 0 {
+			score = math.Min(score, float64(samples)/float64(cfg.minSampleCount))
+		}
+		confidence.Score = math.Min(confidence.Score, score)
+		if samples < confidence.Samples {
+			confidence.Samples = samples
+		}
+		if samples < cfg.minSampleCount || coverage < cfg.minHistoryCoverage {
+			confidence.Confident = false
+		}
+	}
+	return confidence, nil
+}
+
+// storeConfidence saves the confidence of the last estimation of the container
+func (e *PercentileResourceEstimator) storeConfidence(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, confidence *EstimationConfidence) {
+	e.confidences.Store(lastGoodKey(evpa, containerName), *confidence)
+}
+
+// forgetConfidences deletes the confidences of all containers of the evpa
+func (e *PercentileResourceEstimator) forgetConfidences(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) {
+	prefix := evpaReferent(evpa) + "/"
+	e.confidences.Range(func(key, value interface{}) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			e.confidences.Delete(key)
+		}
+		return true
+	})
+}
+
+// LastConfidence returns the confidence of the last estimation of the container, it is not found if the confidence is
+// not gated
+func (e *PercentileResourceEstimator) LastConfidence(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string) (EstimationConfidence, bool) {
+	value, exists := e.confidences.Load(lastGoodKey(evpa, containerName))
+	if !exists {
+		return EstimationConfidence{}, false
+	}
+	return value.(EstimationConfidence), true
+}
+
+// ConfidenceReporter is implemented by the estimators reporting the confidence of their estimations
+type ConfidenceReporter interface {
+	LastConfidence(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string) (EstimationConfidence, bool)
+}
+
+// ConfidenceOf returns the confidence of the last estimation of the container by the estimator instance, it is not
+// found if the estimator doesn't report the confidence
+func ConfidenceOf(instance ResourceEstimatorInstance, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string) (EstimationConfidence, bool) {
+	var estimator ResourceEstimator = instance
+	if wrapped, ok := instance.(resourceEstimatorInstance); ok {
+		estimator = wrapped.ResourceEstimator
+	}
+	reporter, ok := estimator.(ConfidenceReporter)
+	if !ok {
+		return EstimationConfidence{}, false
+	}
+	return reporter.LastConfidence(evpa, containerName)
+}
@@ -0,0 +1,82 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestEstimateResourcesLowConfidence(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	e.Clock = clock.NewFakeClock(time.Now())
+	// memory only has a sample every 2 steps, it covers half of the history
+	e.History = &fakeHistory{every: map[string]int{"cpu": 1, "memory": 2}}
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	evpa := newTestEVPA("nginx")
+
+	estimation, err := e.EstimateResources(context.TODO(), evpa, map[string]string{"min-history-coverage": "0.9"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonLowConfidence, estimation.Reason)
+	assert.Equal(t, "0.50", estimation.Metadata[MetadataConfidence])
+	assert.Equal(t, "1", estimation.Resources.Cpu().String())
+	assert.Equal(t, "250m", estimation.Computed.Cpu().String())
+
+	confidence, found := ConfidenceOf(resourceEstimatorInstance{ResourceEstimator: e}, evpa, "nginx")
+	assert.True(t, found)
+	assert.False(t, confidence.Confident)
+	assert.Equal(t, 0.5, confidence.Score)
+
+	// the coverage is enough, the samples are not
+	estimation, err = e.EstimateResources(context.TODO(), evpa, map[string]string{"min-history-coverage": "0.5", "min-sample-count": "100000"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonLowConfidence, estimation.Reason)
+
+	// enough samples, the recommendation is emitted
+	estimation, err = e.EstimateResources(context.TODO(), evpa, map[string]string{"min-history-coverage": "0.5", "min-sample-count": "100"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Empty(t, estimation.Reason)
+	assert.Equal(t, "250m", estimation.Resources.Cpu().String())
+	confidence, found = e.LastConfidence(evpa, "nginx")
+	assert.True(t, found)
+	assert.True(t, confidence.Confident)
+
+	// the confidences are forgotten with the estimation
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), evpa))
+	_, found = e.LastConfidence(evpa, "nginx")
+	assert.False(t, found)
+}
+
+func TestGetConfidenceConfig(t *testing.T) {
+	cfg, err := getConfidenceConfig(map[string]string{})
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	cfg, err = getConfidenceConfig(map[string]string{"min-sample-count": "10"})
+	assert.NoError(t, err)
+	assert.Equal(t, &confidenceConfig{minSampleCount: 10}, cfg)
+
+	for _, config := range []map[string]string{
+		{"min-sample-count": "-1"},
+		{"min-sample-count": "ten"},
+		{"min-history-coverage": "1.5"},
+		{"min-history-coverage": "most"},
+	} {
+		_, err = getConfidenceConfig(config)
+		assert.Error(t, err, config)
+	}
+}
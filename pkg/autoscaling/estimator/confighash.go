@@ -0,0 +1,27 @@
+package estimator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+)
+
+const (
+	// MetadataConfigHash is the metadata key of the hash of the resolved prediction config used by the recommendation
+	MetadataConfigHash = "config-hash"
+)
+
+// ConfigHash is the stable hash of the resolved cpu and memory prediction configs, after the defaults and the
+// overrides are applied. It is the same for the identical configs so a config drift can be detected.
+func ConfigHash(cpuConfig *predictionconfig.Config, memConfig *predictionconfig.Config) (string, error) {
+	// the struct fields are marshaled in the declaration order, so the output is stable
+	data, err := json.Marshal(struct {
+		Cpu    *predictionconfig.Config `json:"cpu"`
+		Memory *predictionconfig.Config `json:"memory"`
+	}{Cpu: cpuConfig, Memory: memConfig})
+	if err != nil {
+		return "", fmt.Errorf("marshal prediction config failed: %v", err)
+	}
+	return hashKey(string(data)), nil
+}
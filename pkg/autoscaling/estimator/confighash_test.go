@@ -0,0 +1,57 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestConfigHash(t *testing.T) {
+	config := map[string]string{"cpu-request-percentile": "0.95"}
+	hash, err := ConfigHash(cpuConfigOf(t, config), memConfigOf(t, config))
+	assert.NoError(t, err)
+
+	same, err := ConfigHash(cpuConfigOf(t, map[string]string{"cpu-request-percentile": "0.95"}), memConfigOf(t, config))
+	assert.NoError(t, err)
+	assert.Equal(t, hash, same)
+
+	changed, err := ConfigHash(cpuConfigOf(t, map[string]string{"cpu-request-percentile": "0.99"}), memConfigOf(t, config))
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, changed)
+
+	// cpu and memory are not interchangeable
+	swapped, err := ConfigHash(memConfigOf(t, config), cpuConfigOf(t, config))
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, swapped)
+}
+
+func TestEstimateResourcesConfigHash(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	currRes := &corev1.ResourceRequirements{}
+
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	hash := estimation.Metadata[MetadataConfigHash]
+	assert.NotEmpty(t, hash)
+
+	// the defaults are resolved, so the explicit default config hashes the same
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-request-percentile": "0.99"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, estimation.Metadata[MetadataConfigHash])
+
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-request-margin-fraction": "0.3"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, estimation.Metadata[MetadataConfigHash])
+
+	// no prediction config is used if all resources are static
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"static-cpu": "1", "static-mem": "1Gi"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.NotContains(t, estimation.Metadata, MetadataConfigHash)
+}
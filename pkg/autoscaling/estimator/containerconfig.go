@@ -0,0 +1,34 @@
+package estimator
+
+import (
+	"strings"
+)
+
+// containerKeyPrefix prefixes the explicit container-scoped keys, such as 'containers.sidecar.cpu-request-percentile'
+const containerKeyPrefix = "containers."
+
+// containerConfig returns the config of the container, the container-scoped keys of '<container>.<key>' or
+// 'containers.<container>.<key>', such as 'sidecar.cpu-request-percentile', override the global keys of the container.
+// The explicit 'containers.' form wins if both are set. The container names are DNS labels without dots, so the
+// scoped keys never collide with the global ones.
+func containerConfig(config map[string]string, containerName string) map[string]string {
+	var scoped map[string]string
+	for _, prefix := range []string{containerName + ".", containerKeyPrefix + containerName + "."} {
+		for key, value := range config {
+			if !strings.HasPrefix(key, prefix) || len(key) == len(prefix) {
+				continue
+			}
+			if scoped == nil {
+				scoped = make(map[string]string, len(config))
+				for globalKey, globalValue := range config {
+					scoped[globalKey] = globalValue
+				}
+			}
+			scoped[strings.TrimPrefix(key, prefix)] = value
+		}
+	}
+	if scoped == nil {
+		return config
+	}
+	return scoped
+}
@@ -0,0 +1,70 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestContainerConfig(t *testing.T) {
+	config := map[string]string{
+		"cpu-request-percentile":         "0.9",
+		"sidecar.cpu-request-percentile": "0.5",
+		"sidecar.mem-request-percentile": "0.6",
+		"sidecar.":                       "ignored",
+	}
+	scoped := containerConfig(config, "sidecar")
+	assert.Equal(t, "0.5", scoped["cpu-request-percentile"])
+	assert.Equal(t, "0.6", scoped["mem-request-percentile"])
+	assert.NotContains(t, scoped, "")
+	// the global config is intact
+	assert.Equal(t, "0.9", config["cpu-request-percentile"])
+
+	assert.Equal(t, "0.9", containerConfig(config, "nginx")["cpu-request-percentile"])
+	assert.NotContains(t, containerConfig(config, "nginx"), "mem-request-percentile")
+
+	// the explicit form wins over the short one
+	config = map[string]string{
+		"cpu-request-percentile":                    "0.9",
+		"sidecar.cpu-request-percentile":            "0.5",
+		"containers.sidecar.cpu-request-percentile": "0.7",
+		"containers.sidecar.mem-request-percentile": "0.6",
+		"containers.sidecar.":                       "ignored",
+	}
+	scoped = containerConfig(config, "sidecar")
+	assert.Equal(t, "0.7", scoped["cpu-request-percentile"])
+	assert.Equal(t, "0.6", scoped["mem-request-percentile"])
+	assert.NotContains(t, scoped, "")
+	assert.Equal(t, "0.9", containerConfig(config, "nginx")["cpu-request-percentile"])
+}
+
+func TestEstimateResourcesContainerConfig(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	config := map[string]string{
+		"cpu-request-percentile":                  "0.9",
+		"sidecar.cpu-request-percentile":          "0.5",
+		"sidecar.mem-request-margin-fraction":     "0.05",
+		"containers.nginx.mem-request-percentile": "0.95",
+	}
+	evpa := newTestEVPA("nginx", "sidecar")
+	for _, containerName := range []string{"nginx", "sidecar"} {
+		_, err := e.GetResourceEstimation(context.TODO(), evpa, config, containerName, &corev1.ResourceRequirements{})
+		assert.NoError(t, err)
+	}
+
+	// the container-scoped key wins over the global one
+	assert.Equal(t, "0.5", predictor.queries["sidecar/cpu"].Percentile.Percentile)
+	assert.Equal(t, "0.05", predictor.queries["sidecar/memory"].Percentile.MarginFraction)
+	// the other containers use the global key, then the default
+	assert.Equal(t, "0.9", predictor.queries["nginx/cpu"].Percentile.Percentile)
+	assert.Equal(t, "0.15", predictor.queries["nginx/memory"].Percentile.MarginFraction)
+	assert.Equal(t, "0.99", predictor.queries["sidecar/memory"].Percentile.Percentile)
+	assert.Equal(t, "0.95", predictor.queries["nginx/memory"].Percentile.Percentile)
+}
@@ -0,0 +1,61 @@
+package estimator
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+// controlledResources is the resources the container policy controls, nil means both the cpu and memory
+type controlledResources map[corev1.ResourceName]struct{}
+
+// containerPolicyOf returns the container policy of the container name, it overrides the wildcard policy
+func containerPolicyOf(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string) *autoscalingapi.ContainerResourcePolicy {
+	if evpa.Spec.ResourcePolicy == nil {
+		return nil
+	}
+	var result *autoscalingapi.ContainerResourcePolicy
+	for i := range evpa.Spec.ResourcePolicy.ContainerPolicies {
+		containerPolicy := &evpa.Spec.ResourcePolicy.ContainerPolicies[i]
+		if containerPolicy.ContainerName == containerName {
+			return containerPolicy
+		}
+		if containerPolicy.ContainerName == "*" {
+			result = containerPolicy
+		}
+	}
+	return result
+}
+
+// controlledResourcesOf returns the ControlledResources of the container policy, nil if it is not set or empty
+func controlledResourcesOf(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string) controlledResources {
+	containerPolicy := containerPolicyOf(evpa, containerName)
+	if containerPolicy == nil || containerPolicy.ControlledResources == nil || len(*containerPolicy.ControlledResources) == 0 {
+		return nil
+	}
+	result := controlledResources{}
+	for _, resourceName := range *containerPolicy.ControlledResources {
+		result[corev1.ResourceName(resourceName)] = struct{}{}
+	}
+	return result
+}
+
+// controls tells whether the resource should be estimated
+func (c controlledResources) controls(resourceName corev1.ResourceName) bool {
+	if c == nil {
+		return true
+	}
+	_, exists := c[resourceName]
+	return exists
+}
+
+// count returns the number of the cpu and memory controlled
+func (c controlledResources) count() int {
+	count := 0
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if c.controls(resourceName) {
+			count++
+		}
+	}
+	return count
+}
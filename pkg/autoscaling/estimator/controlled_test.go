@@ -0,0 +1,60 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestGetResourceEstimationControlledResources(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+
+	evpa := newTestEVPA("nginx")
+	evpa.Spec.ResourcePolicy.ContainerPolicies[0].ControlledResources = &[]autoscalingapi.ResourceName{"cpu"}
+	resources, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Contains(t, resources, corev1.ResourceCPU)
+	assert.NotContains(t, resources, corev1.ResourceMemory)
+	// the memory is not even queried
+	assert.Contains(t, predictor.called, "nginx/cpu")
+	assert.NotContains(t, predictor.called, "nginx/memory")
+	assert.NotContains(t, predictor.queries, "nginx/memory")
+
+	// both by default
+	evpa.Spec.ResourcePolicy.ContainerPolicies[0].ControlledResources = &[]autoscalingapi.ResourceName{}
+	resources, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Contains(t, resources, corev1.ResourceCPU)
+	assert.Contains(t, resources, corev1.ResourceMemory)
+}
+
+func TestControlledResourcesOf(t *testing.T) {
+	evpa := newTestEVPA("*", "nginx")
+	evpa.Spec.ResourcePolicy.ContainerPolicies[0].ControlledResources = &[]autoscalingapi.ResourceName{"memory"}
+	evpa.Spec.ResourcePolicy.ContainerPolicies[1].ControlledResources = &[]autoscalingapi.ResourceName{"cpu"}
+
+	// the policy of the container name overrides the wildcard
+	controlled := controlledResourcesOf(evpa, "nginx")
+	assert.True(t, controlled.controls(corev1.ResourceCPU))
+	assert.False(t, controlled.controls(corev1.ResourceMemory))
+	assert.Equal(t, 1, controlled.count())
+
+	controlled = controlledResourcesOf(evpa, "sidecar")
+	assert.False(t, controlled.controls(corev1.ResourceCPU))
+	assert.True(t, controlled.controls(corev1.ResourceMemory))
+
+	controlled = controlledResourcesOf(newTestEVPA(), "nginx")
+	assert.Nil(t, controlled)
+	assert.True(t, controlled.controls(corev1.ResourceCPU))
+	assert.True(t, controlled.controls(corev1.ResourceMemory))
+	assert.Equal(t, 2, controlled.count())
+}
@@ -0,0 +1,98 @@
+package estimator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const correlatedMetricPrefix = "cpu-correlated-metric-"
+
+// correlatedMetric is a proxy metric of the cpu, such as the goroutine count, it is normalized to cores by the
+// coefficient. The cpu is sized to the max of the cpu usage and the normalized correlated metrics.
+type correlatedMetric struct {
+	name        string
+	queryExpr   string
+	coefficient float64
+}
+
+// getCorrelatedMetrics parses 'cpu-correlated-metric-<name>-query' and 'cpu-correlated-metric-<name>-coefficient'
+func getCorrelatedMetrics(config map[string]string) ([]correlatedMetric, error) {
+	var result []correlatedMetric
+	for key, queryExpr := range config {
+		if !strings.HasPrefix(key, correlatedMetricPrefix) || !strings.HasSuffix(key, "-query") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, correlatedMetricPrefix), "-query")
+		coefficientKey := correlatedMetricPrefix + name + "-coefficient"
+		coefficientStr, exists := config[coefficientKey]
+		if !exists {
+			return nil, fmt.Errorf("%s is required for the correlated metric %s", coefficientKey, name)
+		}
+		coefficient, err := utils.ParseFloat(coefficientStr, 0)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s failed: %v", coefficientKey, err)
+		}
+		if coefficient <= 0 {
+			return nil, fmt.Errorf("%s must be positive, got %v", coefficientKey, coefficient)
+		}
+		result = append(result, correlatedMetric{name: name, queryExpr: queryExpr, coefficient: coefficient})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name < result[j].name
+	})
+	return result, nil
+}
+
+func newCorrelatedMetricNamer(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, caller string, metric correlatedMetric) *metricnaming.GeneralMetricNamer {
+	return &metricnaming.GeneralMetricNamer{
+		CallerName: caller,
+		Metric: &metricquery.Metric{
+			Type:       metricquery.PromQLMetricType,
+			MetricName: metric.name,
+			Prom: &metricquery.PromNamerInfo{
+				QueryExpr: metric.queryExpr,
+				Namespace: evpa.Namespace,
+			},
+		},
+	}
+}
+
+// estimateFromCorrelatedMetric returns the percentile with margin of the correlated metric normalized to cores
+func (e *PercentileResourceEstimator) estimateFromCorrelatedMetric(namer metricnaming.MetricNamer, cfg *predictionconfig.Config, metric correlatedMetric) (float64, bool, error) {
+	historyLength, err := utils.ParseDuration(cfg.Percentile.HistoryLength)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse history length failed: %v", err)
+	}
+	sampleInterval, err := utils.ParseDuration(cfg.Percentile.SampleInterval)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse sample interval failed: %v", err)
+	}
+	percentile, err := utils.ParseFloat(cfg.Percentile.Percentile, 0.99)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse percentile failed: %v", err)
+	}
+	marginFraction, err := utils.ParseFloat(cfg.Percentile.MarginFraction, 0)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse margin fraction failed: %v", err)
+	}
+
+	now := e.now()
+	tsList, err := e.History.QueryTimeSeries(namer, now.Add(-historyLength), now, sampleInterval)
+	if err != nil {
+		return 0, false, err
+	}
+	value, found := weightedPercentile(unweightedSamples(tsList), math.Min(percentile, 1))
+	if !found {
+		return 0, false, nil
+	}
+	return value * metric.coefficient * (1 + marginFraction), true, nil
+}
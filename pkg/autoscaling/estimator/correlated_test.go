@@ -0,0 +1,49 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestCorrelatedMetricsMax(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	e.Clock = clock.NewFakeClock(time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC))
+	goroutines := 500.0
+	e.History = &stepHistory{usage: map[string]func(time.Time) float64{
+		"goroutines": func(t time.Time) float64 {
+			return goroutines
+		},
+	}}
+	config := map[string]string{
+		"cpu-correlated-metric-goroutines-query":       "sum(go_goroutines{job=\"nginx\"})",
+		"cpu-correlated-metric-goroutines-coefficient": "0.001",
+		"cpu-request-margin-fraction":                  "0",
+	}
+
+	// the proxy metric drives the recommendation
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+	assert.Equal(t, "256Mi", resources.Memory().String())
+
+	// the raw cpu dominates
+	goroutines = 100
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-correlated-metric-goroutines-query": "go_goroutines"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-correlated-metric-goroutines-query": "go_goroutines", "cpu-correlated-metric-goroutines-coefficient": "0"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
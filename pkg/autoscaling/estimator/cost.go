@@ -0,0 +1,108 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const (
+	// MetadataCostDeltaPerHour is the metadata key of the projected cost delta per hour of applying the recommendation
+	MetadataCostDeltaPerHour = "cost-delta-per-hour"
+	// MetadataCostDeltaPerMonth is the metadata key of the projected cost delta per month of applying the recommendation
+	MetadataCostDeltaPerMonth = "cost-delta-per-month"
+
+	hoursPerMonth = 730
+	gibibyte      = 1024 * 1024 * 1024
+)
+
+// costConfig prices the requests of the workload, the prices are in any currency per unit per hour
+type costConfig struct {
+	cpuPricePerCoreHour float64
+	memPricePerGiBHour  float64
+	// replicas is the replica count to project, zero means the running pods of the target
+	replicas int
+}
+
+// getCostConfig returns nil if neither 'cost-cpu-price-per-core-hour' nor 'cost-mem-price-per-gib-hour' is set
+func getCostConfig(config map[string]string) (*costConfig, error) {
+	cpuPrice, cpuExists := config["cost-cpu-price-per-core-hour"]
+	memPrice, memExists := config["cost-mem-price-per-gib-hour"]
+	if !cpuExists && !memExists {
+		return nil, nil
+	}
+	cfg := &costConfig{}
+	var err error
+	if cfg.cpuPricePerCoreHour, err = utils.ParseFloat(cpuPrice, 0); err != nil {
+		return nil, fmt.Errorf("parse cost-cpu-price-per-core-hour failed: %v", err)
+	}
+	if cfg.memPricePerGiBHour, err = utils.ParseFloat(memPrice, 0); err != nil {
+		return nil, fmt.Errorf("parse cost-mem-price-per-gib-hour failed: %v", err)
+	}
+	if cfg.cpuPricePerCoreHour < 0 || cfg.memPricePerGiBHour < 0 {
+		return nil, fmt.Errorf("cost prices must not be negative")
+	}
+	if replicas, exists := config["cost-replicas"]; exists {
+		if cfg.replicas, err = strconv.Atoi(replicas); err != nil {
+			return nil, fmt.Errorf("parse cost-replicas failed: %v", err)
+		}
+		if cfg.replicas <= 0 {
+			return nil, fmt.Errorf("cost-replicas must be positive, got %d", cfg.replicas)
+		}
+	}
+	return cfg, nil
+}
+
+// costDeltaPerHour returns the cost delta per hour of a pod moving from the current requests to the recommended,
+// negative if the recommendation saves. A resource missing in the current requests is priced from zero.
+func costDeltaPerHour(currRes *corev1.ResourceRequirements, resources corev1.ResourceList, cfg *costConfig) float64 {
+	var current corev1.ResourceList
+	if currRes != nil {
+		current = currRes.Requests
+	}
+	delta := 0.0
+	for resourceName, price := range map[corev1.ResourceName]float64{
+		corev1.ResourceCPU:    cfg.cpuPricePerCoreHour,
+		corev1.ResourceMemory: cfg.memPricePerGiBHour / gibibyte,
+	} {
+		recommended, exists := resources[resourceName]
+		if !exists {
+			continue
+		}
+		currentValue := 0.0
+		if quantity, exists := current[resourceName]; exists {
+			currentValue = quantityValue(resourceName, quantity)
+		}
+		delta += (quantityValue(resourceName, recommended) - currentValue) * price
+	}
+	return delta
+}
+
+// setCostDelta attaches the projected cost delta of all the replicas, it is skipped if the replicas are unknown
+func (e *PercentileResourceEstimator) setCostDelta(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, currRes *corev1.ResourceRequirements, estimation *ResourceEstimation, cfg *costConfig) error {
+	replicas := cfg.replicas
+	if replicas == 0 {
+		if e.Client == nil {
+			return nil
+		}
+		var err error
+		if replicas, err = e.countRunningPods(ctx, evpa); err != nil {
+			return err
+		}
+	}
+
+	perHour := costDeltaPerHour(currRes, estimation.Resources, cfg) * float64(replicas)
+	estimation.Metadata[MetadataCostDeltaPerHour] = formatCost(perHour)
+	estimation.Metadata[MetadataCostDeltaPerMonth] = formatCost(perHour * hoursPerMonth)
+	return nil
+}
+
+func formatCost(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', 4, 64)
+}
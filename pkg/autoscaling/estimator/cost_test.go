@@ -0,0 +1,89 @@
+package estimator
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func assertCostMetadata(t *testing.T, estimation *ResourceEstimation, perHour float64) {
+	value, err := strconv.ParseFloat(estimation.Metadata[MetadataCostDeltaPerHour], 64)
+	assert.NoError(t, err)
+	assert.InDelta(t, perHour, value, 1e-4)
+	value, err = strconv.ParseFloat(estimation.Metadata[MetadataCostDeltaPerMonth], 64)
+	assert.NoError(t, err)
+	assert.InDelta(t, perHour*hoursPerMonth, value, 1e-4)
+}
+
+func TestEstimateResourcesCostDelta(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	config := map[string]string{
+		"cost-cpu-price-per-core-hour": "0.04",
+		"cost-mem-price-per-gib-hour":  "0.005",
+		"cost-replicas":                "3",
+	}
+
+	// a decrease from 1 core and 1Gi to 250m and 256Mi
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assertCostMetadata(t, estimation, ((0.25-1)*0.04+(0.25-1)*0.005)*3)
+
+	// an increase from 100m and 128Mi
+	currRes.Requests[corev1.ResourceCPU] = resource.MustParse("100m")
+	currRes.Requests[corev1.ResourceMemory] = resource.MustParse("128Mi")
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assertCostMetadata(t, estimation, ((0.25-0.1)*0.04+(0.25-0.125)*0.005)*3)
+
+	// the replicas are the running pods of the target
+	delete(config, "cost-replicas")
+	e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		newPhasePod("nginx-a", corev1.PodRunning), newPhasePod("nginx-b", corev1.PodRunning), newPhasePod("nginx-c", corev1.PodPending), newTestDeployment(3)).Build()
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assertCostMetadata(t, estimation, ((0.25-0.1)*0.04+(0.25-0.125)*0.005)*2)
+
+	// deferred, nothing changes
+	e.Clock = clock.NewFakeClock(time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC))
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{
+		"cost-cpu-price-per-core-hour": "0.04",
+		"maintenance-window":           "02:00-04:00",
+	}, "nginx", currRes)
+	assert.NoError(t, err)
+	assertCostMetadata(t, estimation, 0)
+
+	// not attached by default
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.NotContains(t, estimation.Metadata, MetadataCostDeltaPerHour)
+}
+
+func TestGetCostConfig(t *testing.T) {
+	for _, config := range []map[string]string{
+		{"cost-cpu-price-per-core-hour": "free"},
+		{"cost-cpu-price-per-core-hour": "-1"},
+		{"cost-cpu-price-per-core-hour": "0.04", "cost-replicas": "0"},
+	} {
+		_, err := getCostConfig(config)
+		assert.Error(t, err, config)
+	}
+}
@@ -0,0 +1,95 @@
+package estimator
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/gocrane/crane/pkg/metricnaming"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/providers"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const defaultMaxHistoryLength = "168h"
+
+// countHistorySamples returns the samples count of the densest series in the window
+func countHistorySamples(history providers.History, namer metricnaming.MetricNamer, start time.Time, end time.Time, step time.Duration) (int, error) {
+	tsList, err := history.QueryTimeSeries(namer, start, end, step)
+	if err != nil {
+		return 0, err
+	}
+
+	samples := 0
+	for _, ts := range tsList {
+		if len(ts.Samples) > samples {
+			samples = len(ts.Samples)
+		}
+	}
+	return samples, nil
+}
+
+// extendHistoryLength doubles the history length of the config until the history coverage reaches the
+// '<prefix>-min-history-coverage', up to '<prefix>-max-history-length'. The coverage is the ratio of the samples
+// in the window to the samples expected in the configured history length, so a sparse resource can look back
+// further to collect enough samples. Each resource is extended independently.
+func (e *PercentileResourceEstimator) extendHistoryLength(namer metricnaming.MetricNamer, cfg *predictionconfig.Config, config map[string]string, prefix string) error {
+	minCoverageStr, exists := config[prefix+"-min-history-coverage"]
+	if !exists || e.History == nil || cfg.Percentile == nil {
+		return nil
+	}
+
+	minCoverage, err := utils.ParseFloat(minCoverageStr, 0)
+	if err != nil {
+		return fmt.Errorf("parse %s-min-history-coverage failed: %v", prefix, err)
+	}
+	if minCoverage <= 0 || minCoverage > 1 {
+		return fmt.Errorf("%s-min-history-coverage must be in (0,1], got %v", prefix, minCoverage)
+	}
+
+	maxHistoryLengthStr, exists := config[prefix+"-max-history-length"]
+	if !exists {
+		maxHistoryLengthStr = defaultMaxHistoryLength
+	}
+	maxHistoryLength, err := utils.ParseDuration(maxHistoryLengthStr)
+	if err != nil {
+		return fmt.Errorf("parse %s-max-history-length failed: %v", prefix, err)
+	}
+	historyLength, err := utils.ParseDuration(cfg.Percentile.HistoryLength)
+	if err != nil {
+		return fmt.Errorf("parse %s history length failed: %v", prefix, err)
+	}
+	sampleInterval, err := utils.ParseDuration(cfg.Percentile.SampleInterval)
+	if err != nil {
+		return fmt.Errorf("parse %s sample interval failed: %v", prefix, err)
+	}
+
+	expected := float64(historyLength / sampleInterval)
+	if expected <= 0 {
+		return fmt.Errorf("%s history length %v is shorter than the sample interval %v", prefix, historyLength, sampleInterval)
+	}
+
+	now := e.now()
+	extended := false
+	for {
+		samples, err := countHistorySamples(e.History, namer, now.Add(-historyLength), now, sampleInterval)
+		if err != nil {
+			return err
+		}
+		if float64(samples)/expected >= minCoverage || historyLength >= maxHistoryLength {
+			break
+		}
+		historyLength *= 2
+		if historyLength > maxHistoryLength {
+			historyLength = maxHistoryLength
+		}
+		extended = true
+	}
+
+	if extended {
+		klog.V(4).InfoS("Extend history length for sparse samples.", "queryExpr", namer.BuildUniqueKey(), "historyLength", historyLength)
+		cfg.Percentile.HistoryLength = historyLength.String()
+	}
+	return nil
+}
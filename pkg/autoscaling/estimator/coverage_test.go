@@ -0,0 +1,68 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+)
+
+// fakeHistory returns one sample every n steps for the metric
+type fakeHistory struct {
+	every map[string]int
+}
+
+func (h *fakeHistory) QueryTimeSeries(namer metricnaming.MetricNamer, startTime time.Time, endTime time.Time, step time.Duration) ([]*common.TimeSeries, error) {
+	keys := seriesKeys(namer)
+	every := h.every[keys[len(keys)-1]]
+	ts := common.NewTimeSeries()
+	i := 0
+	for t := startTime; t.Before(endTime); t = t.Add(step) {
+		if every > 0 && i%every == 0 {
+			ts.AppendSample(t.Unix(), 1)
+		}
+		i++
+	}
+	return []*common.TimeSeries{ts}, nil
+}
+
+func TestExtendHistoryLength(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.5),
+		"memory": newSeries(1024),
+	})
+	e.Clock = clock.NewFakeClock(time.Now())
+	// cpu is sampled every step, memory is sparse and only has a sample every 2 steps
+	e.History = &fakeHistory{every: map[string]int{
+		"cpu":    1,
+		"memory": 2,
+	}}
+
+	config := map[string]string{
+		"cpu-min-history-coverage": "0.9",
+		"mem-min-history-coverage": "0.9",
+		"mem-model-history-length": "24h",
+		"mem-max-history-length":   "72h",
+	}
+	_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "24h", predictor.queries["nginx/cpu"].Percentile.HistoryLength)
+	assert.Equal(t, "48h0m0s", predictor.queries["nginx/memory"].Percentile.HistoryLength)
+
+	// extending is capped by the max history length
+	e.History = &fakeHistory{every: map[string]int{"cpu": 1, "memory": 10}}
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "72h0m0s", predictor.queries["nginx/memory"].Percentile.HistoryLength)
+
+	// no coverage config keeps the history length
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "48h", predictor.queries["nginx/memory"].Percentile.HistoryLength)
+}
@@ -0,0 +1,164 @@
+package estimator
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+	predictionapi "github.com/gocrane/api/prediction/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/metricnaming"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+)
+
+// reservedMetricPrefixes are the names of the builtin resources and their config prefixes, a custom metric can't
+// reuse them
+var reservedMetricPrefixes = sets.NewString("cpu", "mem", corev1.ResourceMemory.String(), ephemeralStoragePrefix)
+
+var defaultCustomMetricHistogram = predictionapi.HistogramConfig{
+	HalfLife:   "24h",
+	BucketSize: "1",
+	MaxValue:   "100000",
+}
+
+// deviceResources are the extended resources of the devices, they are estimated as custom metrics named by the device
+// metric, such as 'custom-metrics: nvidia.com/gpu'
+var deviceResources = sets.NewString("nvidia.com/gpu")
+
+// defaultDeviceHistogram buckets the device usage in the hundredth of a device
+var defaultDeviceHistogram = predictionapi.HistogramConfig{
+	HalfLife:   "24h",
+	BucketSize: "0.01",
+	MaxValue:   "64",
+}
+
+// customMetric is a container metric estimated as the cpu and memory, it is configured by the '<name>-' keys and
+// recommended under its name. The prometheus data sources query it by the '<name>-query-template' PromQL, the
+// template takes the namespace, the workload name and the container name, such as
+// 'sum(nginx_connections{namespace="%s",pod=~"^%s-.*$",container="%s"})'. A literal '%' is escaped as '%%'.
+type customMetric struct {
+	name          string
+	queryTemplate string
+	config        *predictionconfig.Config
+}
+
+// getCustomMetrics returns the custom metrics of the comma separated 'custom-metrics', such as 'connections,threads'
+func getCustomMetrics(config map[string]string) ([]customMetric, error) {
+	names, err := parseCustomMetricNames(config)
+	if err != nil {
+		return nil, err
+	}
+	var metrics []customMetric
+	for _, name := range names {
+		histogram := defaultCustomMetricHistogram
+		if isDeviceResource(corev1.ResourceName(name)) {
+			histogram = defaultDeviceHistogram
+		}
+		cfg, err := getPrefixedConfig(config, name, "24h", histogram)
+		if err != nil {
+			return nil, err
+		}
+		queryTemplate, err := getCustomMetricQueryTemplate(config, name)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, customMetric{name: name, queryTemplate: queryTemplate, config: cfg})
+	}
+	return metrics, nil
+}
+
+// getCustomMetricQueryTemplate returns the '<name>-query-template' of the custom metric, the devices are queried by
+// the builtin device metrics and can't have one
+func getCustomMetricQueryTemplate(config map[string]string, name string) (string, error) {
+	key := name + "-query-template"
+	queryTemplate, exists := config[key]
+	if !exists {
+		return "", nil
+	}
+	if isDeviceResource(corev1.ResourceName(name)) {
+		return "", fmt.Errorf("%s is not supported, the device %s is queried by the device metric", key, name)
+	}
+	queryTemplate = strings.TrimSpace(queryTemplate)
+	if queryTemplate == "" {
+		return "", fmt.Errorf("%s is empty", key)
+	}
+	if query := fmt.Sprintf(queryTemplate, "namespace", "workload", "container"); strings.Contains(query, "%!") {
+		return "", fmt.Errorf("invalid %s %q, it must take the namespace, the workload name and the container name by three %%s: %s", key, queryTemplate, query)
+	}
+	return queryTemplate, nil
+}
+
+func parseCustomMetricNames(config map[string]string) ([]string, error) {
+	value, exists := config["custom-metrics"]
+	if !exists {
+		return nil, nil
+	}
+	var names []string
+	seen := sets.NewString()
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid custom-metrics name %q: %s", name, strings.Join(errs, "; "))
+		}
+		if reservedMetricPrefixes.Has(name) {
+			return nil, fmt.Errorf("custom-metrics name %s is reserved", name)
+		}
+		if seen.Has(name) {
+			return nil, fmt.Errorf("duplicated custom-metrics name %s", name)
+		}
+		seen.Insert(name)
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// customMetricsOf returns the custom metrics configured by any estimator of the evpa, the invalid configs are ignored.
+// Only the names and the query templates are set, they are what the queries of the metrics are named by.
+func customMetricsOf(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) []customMetric {
+	var metrics []customMetric
+	seen := sets.NewString()
+	for _, estimator := range evpa.Spec.ResourceEstimators {
+		names, err := parseCustomMetricNames(estimator.Config)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			queryTemplate, err := getCustomMetricQueryTemplate(estimator.Config, name)
+			if err != nil {
+				continue
+			}
+			if key := name + "/" + queryTemplate; !seen.Has(key) {
+				seen.Insert(key)
+				metrics = append(metrics, customMetric{name: name, queryTemplate: queryTemplate})
+			}
+		}
+	}
+	return metrics
+}
+
+// newCustomMetricNamer builds the namer of the custom metric of the container, the query template is a part of the
+// unique key so the metrics queried differently don't share a query
+func newCustomMetricNamer(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, caller string, containerName string, metric customMetric, selector labels.Selector) *metricnaming.GeneralMetricNamer {
+	namer := newContainerMetricNamer(evpa, caller, containerName, corev1.ResourceName(metric.name), selector)
+	if namer.Metric.Container != nil {
+		namer.Metric.Container.QueryTemplate = metric.queryTemplate
+	}
+	return namer
+}
+
+// isByteResource returns whether the resource is measured in bytes, the others such as the cpu and the custom metrics
+// are measured in the milli precision
+func isByteResource(resourceName corev1.ResourceName) bool {
+	return resourceName == corev1.ResourceMemory || resourceName == corev1.ResourceEphemeralStorage
+}
+
+// isDeviceResource returns whether the resource is the extended resource of a device, its usage is queried by the
+// device metric instead of the container metric
+func isDeviceResource(resourceName corev1.ResourceName) bool {
+	return deviceResources.Has(resourceName.String())
+}
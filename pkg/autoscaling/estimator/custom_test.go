@@ -0,0 +1,148 @@
+package estimator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricquery"
+)
+
+func TestEstimateResourcesCustomMetrics(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":         newSeries(0.25),
+		"memory":      newSeries(256 * 1024 * 1024),
+		"connections": newSeries(120),
+		"threads":     newSeries(12.5),
+	})
+
+	config := map[string]string{
+		"custom-metrics":                      "connections, threads",
+		"connections-request-percentile":      "0.9",
+		"connections-request-margin-fraction": "0.2",
+	}
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, "256Mi", resources.Memory().String())
+	connections := resources[corev1.ResourceName("connections")]
+	assert.Equal(t, "120", connections.String())
+	threads := resources[corev1.ResourceName("threads")]
+	assert.Equal(t, "12500m", threads.String())
+
+	// the per metric settings
+	assert.Equal(t, "0.9", predictor.queries["nginx/connections"].Percentile.Percentile)
+	assert.Equal(t, "0.2", predictor.queries["nginx/connections"].Percentile.MarginFraction)
+	assert.Equal(t, "0.99", predictor.queries["nginx/threads"].Percentile.Percentile)
+	assert.Equal(t, defaultCustomMetricHistogram, predictor.queries["nginx/threads"].Percentile.Histogram)
+
+	// the cpu and memory defaults are intact
+	assert.Equal(t, "0.99", predictor.queries["nginx/cpu"].Percentile.Percentile)
+
+	// not configured
+	e, predictor = newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":         newSeries(0.25),
+		"memory":      newSeries(256 * 1024 * 1024),
+		"connections": newSeries(120),
+	})
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Len(t, resources, 2)
+	assert.NotContains(t, predictor.queries, "nginx/connections")
+
+	// a custom metric without samples doesn't fail the others
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"custom-metrics": "queue"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.NotContains(t, resources, corev1.ResourceName("queue"))
+	assert.Equal(t, "250m", resources.Cpu().String())
+}
+
+func TestEstimateResourcesDeviceMetrics(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":            newSeries(0.25),
+		"memory":         newSeries(256 * 1024 * 1024),
+		"nvidia.com/gpu": newSeries(0.42),
+	})
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"custom-metrics": "nvidia.com/gpu"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	gpu := resources[corev1.ResourceName("nvidia.com/gpu")]
+	assert.Equal(t, "420m", gpu.String())
+	assert.Equal(t, defaultDeviceHistogram, predictor.queries["nginx/nvidia.com/gpu"].Percentile.Histogram)
+
+	// the device is named by the device metric, the others by the container metric
+	namer := newContainerMetricNamer(newTestEVPA("nginx"), "caller", "nginx", "nvidia.com/gpu", nil)
+	assert.Equal(t, metricquery.DeviceMetricType, namer.Metric.Type)
+	assert.Equal(t, "nginx", namer.Metric.Device.ContainerName)
+	namer = newContainerMetricNamer(newTestEVPA("nginx"), "caller", "nginx", "connections", nil)
+	assert.Equal(t, metricquery.ContainerMetricType, namer.Metric.Type)
+}
+
+func TestGetCustomMetrics(t *testing.T) {
+	metrics, err := getCustomMetrics(map[string]string{})
+	assert.NoError(t, err)
+	assert.Empty(t, metrics)
+
+	metrics, err = getCustomMetrics(map[string]string{"custom-metrics": "connections,open_files", "open_files-sample-interval": "5m"})
+	assert.NoError(t, err)
+	if assert.Len(t, metrics, 2) {
+		assert.Equal(t, "connections", metrics[0].name)
+		assert.Equal(t, "open_files", metrics[1].name)
+		assert.Equal(t, "5m", metrics[1].config.Percentile.SampleInterval)
+	}
+
+	for _, value := range []string{"", "connections,", "cpu", "mem", "memory", "ephemeral-storage", "connections,connections", "bad name"} {
+		_, err := getCustomMetrics(map[string]string{"custom-metrics": value})
+		assert.Error(t, err, value)
+	}
+	_, err = getCustomMetrics(map[string]string{"custom-metrics": "connections", "connections-request-percentile": "90"})
+	assert.Error(t, err)
+}
+
+func TestGetCustomMetricsQueryTemplate(t *testing.T) {
+	queryTemplate := `sum(nginx_connections{namespace="%s",pod=~"^%s-.*$",container="%s"})`
+	metrics, err := getCustomMetrics(map[string]string{"custom-metrics": "connections", "connections-query-template": queryTemplate})
+	assert.NoError(t, err)
+	if assert.Len(t, metrics, 1) {
+		assert.Equal(t, queryTemplate, metrics[0].queryTemplate)
+	}
+
+	// the template is a part of the unique key of the query
+	namer := newCustomMetricNamer(newTestEVPA("nginx"), "caller", "nginx", metrics[0], nil)
+	assert.Equal(t, queryTemplate, namer.Metric.Container.QueryTemplate)
+	assert.Contains(t, namer.BuildUniqueKey(), queryTemplate)
+
+	for _, value := range []string{"", " ", `nginx_connections{namespace="%s"}`, `nginx_connections{namespace="%s",pod="%s",container="%s",id="%s"}`, `nginx_connections{namespace="%d",pod="%s",container="%s"}`} {
+		_, err := getCustomMetrics(map[string]string{"custom-metrics": "connections", "connections-query-template": value})
+		assert.Error(t, err, value)
+	}
+	_, err = getCustomMetrics(map[string]string{"custom-metrics": "nvidia.com/gpu", "nvidia.com/gpu-query-template": queryTemplate})
+	assert.Error(t, err)
+}
+
+func TestDeleteEstimationCustomMetrics(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{})
+	evpa := newTestEVPA("nginx")
+	evpa.Spec.ResourceEstimators = []autoscalingapi.ResourceEstimator{
+		{Type: "Percentile", Config: map[string]string{"custom-metrics": "connections"}},
+		{Type: "Percentile", Config: map[string]string{"custom-metrics": "threads", "threads-query-template": `sum(threads{namespace="%s",pod=~"^%s-.*$",container="%s"})`}},
+	}
+
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), evpa))
+	deleted := sets.NewString()
+	for _, key := range predictor.deleted {
+		for _, name := range []string{"connections", "threads"} {
+			if strings.Contains(key, name) {
+				deleted.Insert(name)
+			}
+		}
+	}
+	assert.Equal(t, []string{"connections", "threads"}, deleted.List())
+}
@@ -0,0 +1,47 @@
+package estimator
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ReasonDownscaleLocked means the recommendation is clamped to the current requests because down-scaling is locked
+	ReasonDownscaleLocked = "DownscaleLocked"
+)
+
+// getNoDownscale returns whether 'no-downscale' is set, the stability-critical containers are only scaled up
+func getNoDownscale(config map[string]string) (bool, error) {
+	value, exists := config["no-downscale"]
+	if !exists {
+		return false, nil
+	}
+	noDownscale, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parse no-downscale failed: %v", err)
+	}
+	return noDownscale, nil
+}
+
+// lockDownscale raises the resources lower than the current requests to the current requests, it returns the
+// names of the clamped resources
+func (r *ResourceEstimation) lockDownscale(currRes *corev1.ResourceRequirements) []corev1.ResourceName {
+	if currRes == nil {
+		return nil
+	}
+	var clamped []corev1.ResourceName
+	for resourceName, quantity := range r.Resources {
+		current, exists := currRes.Requests[resourceName]
+		if !exists || quantity.Cmp(current) >= 0 {
+			continue
+		}
+		r.Resources[resourceName] = current.DeepCopy()
+		clamped = append(clamped, resourceName)
+	}
+	if len(clamped) > 0 {
+		r.Reason = ReasonDownscaleLocked
+	}
+	return clamped
+}
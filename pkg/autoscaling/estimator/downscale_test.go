@@ -0,0 +1,52 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestNoDownscale(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(2 * 1024 * 1024 * 1024),
+	})
+	config := map[string]string{"no-downscale": "true"}
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+
+	// cpu would be lowered and is clamped, memory is scaled up
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonDownscaleLocked, estimation.Reason)
+	assert.Equal(t, "1", estimation.Resources.Cpu().String())
+	assert.Equal(t, "2Gi", estimation.Resources.Memory().String())
+	assert.Equal(t, "250m", estimation.Computed.Cpu().String())
+
+	// up-scales pass through
+	currRes.Requests[corev1.ResourceCPU] = resource.MustParse("100m")
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Empty(t, estimation.Reason)
+	assert.Equal(t, "250m", estimation.Resources.Cpu().String())
+	assert.Equal(t, "2Gi", estimation.Resources.Memory().String())
+
+	// not locked
+	currRes.Requests[corev1.ResourceCPU] = resource.MustParse("1")
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Empty(t, estimation.Reason)
+	assert.Equal(t, "250m", estimation.Resources.Cpu().String())
+
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"no-downscale": "yes"}, "nginx", currRes)
+	assert.Error(t, err)
+}
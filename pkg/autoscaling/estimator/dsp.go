@@ -0,0 +1,165 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/clock"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+	predictionapi "github.com/gocrane/api/prediction/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/prediction"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+	"github.com/gocrane/crane/pkg/utils/target"
+)
+
+const dspCallerFormat = "EVPADSPCaller-%s-%s"
+
+var _ ResourceEstimator = &DSPResourceEstimator{}
+
+// DSPResourceEstimator recommends the forecasted peak of the next window plus the margin, the forecast is the
+// time-series decomposition of the history. It is for the workloads with a strong daily or weekly seasonality, the
+// recommendation follows the coming peak instead of a pure historical percentile.
+type DSPResourceEstimator struct {
+	// Predictor is the dsp predictor
+	Predictor     prediction.Interface
+	TargetFetcher target.SelectorFetcher
+	Clock         clock.Clock
+}
+
+func (e *DSPResourceEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+	horizonStr, exists := config["forecast-horizon"]
+	if !exists {
+		horizonStr = "24h"
+	}
+	horizon, err := utils.ParseDuration(horizonStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse forecast-horizon failed: %v", err)
+	}
+	if horizon <= 0 {
+		return nil, fmt.Errorf("forecast-horizon must be positive, got %v", horizon)
+	}
+	cfg, err := getDSPConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+
+	clk := e.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	now := clk.Now()
+
+	caller := fmt.Sprintf(dspCallerFormat, klog.KObj(evpa), string(evpa.UID))
+	recommendResource := corev1.ResourceList{}
+	var errs []error
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		prefix := "cpu"
+		if resourceName == corev1.ResourceMemory {
+			prefix = "mem"
+		}
+		marginFraction, err := utils.ParseFloat(config[prefix+"-margin-fraction"], 0)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s-margin-fraction failed: %v", prefix, err)
+		}
+
+		metricNamer := newContainerMetricNamer(evpa, caller, containerName, resourceName, selector)
+		if err := e.Predictor.WithQuery(metricNamer, caller, *cfg); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tsList, err := e.Predictor.QueryPredictedTimeSeries(ctx, metricNamer, now, now.Add(horizon))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		peak, found := maxSampleValue(tsList)
+		if !found {
+			errs = append(errs, noValueError(ctx, e.Predictor, metricNamer))
+			continue
+		}
+
+		value := peak * (1 + marginFraction)
+		if resourceName == corev1.ResourceCPU {
+			recommendResource[resourceName] = *resource.NewMilliQuantity(int64(value*1000), resource.DecimalSI)
+		} else {
+			recommendResource[resourceName] = *resource.NewQuantity(int64(value), resource.BinarySI)
+		}
+	}
+
+	if len(recommendResource) == 0 {
+		return recommendResource, allFailedError(errs, nil)
+	}
+
+	return recommendResource, nil
+}
+
+func (e *DSPResourceEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	if evpa.Spec.ResourcePolicy == nil {
+		return nil
+	}
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+	caller := fmt.Sprintf(dspCallerFormat, klog.KObj(evpa), string(evpa.UID))
+	var errs []error
+	for _, containerPolicy := range evpa.Spec.ResourcePolicy.ContainerPolicies {
+		for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			metricNamer := newContainerMetricNamer(evpa, caller, containerPolicy.ContainerName, resourceName, selector)
+			if err := e.Predictor.DeleteQuery(metricNamer, caller); err != nil {
+				errs = append(errs, fmt.Errorf("delete query %s failed: %v", metricNamer.BuildUniqueKey(), err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// getDSPConfig returns the dsp config of 'dsp-sample-interval', 'dsp-history-length' and 'seasonality-period'. The
+// history is 15d sampled by 1m by default and the period is auto-detected unless the seasonality-period is set.
+func getDSPConfig(config map[string]string) (*predictionconfig.Config, error) {
+	sampleInterval, exists := config["dsp-sample-interval"]
+	if !exists {
+		sampleInterval = "1m"
+	}
+	if _, err := utils.ParseDuration(sampleInterval); err != nil {
+		return nil, fmt.Errorf("parse dsp-sample-interval failed: %v", err)
+	}
+	historyLength, exists := config["dsp-history-length"]
+	if !exists {
+		historyLength = "15d"
+	}
+	if _, err := utils.ParseDuration(historyLength); err != nil {
+		return nil, fmt.Errorf("parse dsp-history-length failed: %v", err)
+	}
+	var seasonalityPeriod time.Duration
+	if seasonalityPeriodStr, exists := config["seasonality-period"]; exists {
+		var err error
+		seasonalityPeriod, err = utils.ParseDuration(seasonalityPeriodStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse seasonality-period failed: %v", err)
+		}
+		if seasonalityPeriod <= 0 {
+			return nil, fmt.Errorf("seasonality-period must be positive, got %v", seasonalityPeriod)
+		}
+	}
+	return &predictionconfig.Config{
+		DSP: &predictionapi.DSP{
+			SampleInterval: sampleInterval,
+			HistoryLength:  historyLength,
+		},
+		SeasonalityPeriod: seasonalityPeriod,
+	}, nil
+}
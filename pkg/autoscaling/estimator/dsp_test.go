@@ -0,0 +1,63 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestDSPResourceEstimation(t *testing.T) {
+	predictor := newFakePredictor(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.2, 1.0, 0.4),
+		"memory": newSeries(100*mebibyte, 400*mebibyte, 200*mebibyte),
+	})
+	e := &DSPResourceEstimator{
+		Predictor:     predictor,
+		TargetFetcher: &fakeFetcher{},
+		Clock:         clock.NewFakeClock(time.Now()),
+	}
+
+	// the forecasted peak of the window
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+	assert.Equal(t, "400Mi", resources.Memory().String())
+	assert.Equal(t, "1m", predictor.queries["nginx/cpu"].DSP.SampleInterval)
+	assert.Equal(t, "15d", predictor.queries["nginx/cpu"].DSP.HistoryLength)
+	assert.Equal(t, time.Duration(0), predictor.queries["nginx/cpu"].SeasonalityPeriod)
+
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{
+		"cpu-margin-fraction": "0.2",
+		"dsp-history-length":  "7d",
+		"seasonality-period":  "24h",
+	}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1200m", resources.Cpu().String())
+	assert.Equal(t, "7d", predictor.queries["nginx/memory"].DSP.HistoryLength)
+	assert.Equal(t, 24*time.Hour, predictor.queries["nginx/memory"].SeasonalityPeriod)
+
+	for _, config := range []map[string]string{
+		{"forecast-horizon": "0s"},
+		{"dsp-sample-interval": "x"},
+		{"dsp-history-length": "x"},
+		{"seasonality-period": "-1h"},
+		{"mem-margin-fraction": "x"},
+	} {
+		_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, config)
+	}
+
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), newTestEVPA("nginx")))
+	assert.Len(t, predictor.deleted, 2)
+
+	// no forecast yet
+	e.Predictor = newFakePredictor(map[string][]*common.TimeSeries{})
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const (
+	// EnsembleTieBreakMax picks the most conservative member output
+	EnsembleTieBreakMax = "max"
+	// EnsembleTieBreakMin picks the least member output
+	EnsembleTieBreakMin = "min"
+)
+
+// EnsembleResourceEstimator blends the outputs of the member estimators. The members are configured by
+// 'ensemble-members', such as "Percentile,OOM", and receive the same config as the ensemble.
+// When the member outputs of a resource are within 'ensemble-tolerance', relative to the largest output, they
+// agree and a deterministic representative is picked by 'ensemble-tie-break' rather than blending the noise.
+type EnsembleResourceEstimator struct {
+	Members map[string]ResourceEstimator
+}
+
+type ensembleConfig struct {
+	members   []string
+	tolerance float64
+	tieBreak  string
+}
+
+func getEnsembleConfig(config map[string]string) (*ensembleConfig, error) {
+	cfg := &ensembleConfig{}
+	for _, member := range strings.Split(config["ensemble-members"], ",") {
+		if member = strings.TrimSpace(member); member != "" {
+			cfg.members = append(cfg.members, member)
+		}
+	}
+	if len(cfg.members) == 0 {
+		return nil, fmt.Errorf("ensemble-members is required")
+	}
+
+	tolerance, err := utils.ParseFloat(config["ensemble-tolerance"], 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse ensemble-tolerance failed: %v", err)
+	}
+	if tolerance < 0 || tolerance >= 1 {
+		return nil, fmt.Errorf("ensemble-tolerance must be in [0, 1), got %v", tolerance)
+	}
+	cfg.tolerance = tolerance
+
+	cfg.tieBreak = EnsembleTieBreakMax
+	if tieBreak, exists := config["ensemble-tie-break"]; exists {
+		switch tieBreak {
+		case EnsembleTieBreakMax, EnsembleTieBreakMin:
+			cfg.tieBreak = tieBreak
+		default:
+			return nil, fmt.Errorf("unknown ensemble-tie-break %s", tieBreak)
+		}
+	}
+	return cfg, nil
+}
+
+func (e *EnsembleResourceEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+	cfg, err := getEnsembleConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := map[corev1.ResourceName][]float64{}
+	var errs []string
+	for _, member := range cfg.members {
+		estimator, exists := e.Members[member]
+		if !exists {
+			return nil, fmt.Errorf("unknown ensemble member %s", member)
+		}
+		resources, err := estimator.GetResourceEstimation(ctx, evpa, config, containerName, currRes)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("ensemble member %s interrupted: %w", member, ctx.Err())
+			}
+			klog.V(4).InfoS("Ensemble member failed to estimate.", "member", member, "evpa", klog.KObj(evpa), "container", containerName, "error", err)
+			errs = append(errs, fmt.Sprintf("%s: %v", member, err))
+			continue
+		}
+		for resourceName, quantity := range resources {
+			outputs[resourceName] = append(outputs[resourceName], quantityValue(resourceName, quantity))
+		}
+	}
+	if len(outputs) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("all ensemble members failed: %s", strings.Join(errs, "; "))
+	}
+
+	recommendResource := corev1.ResourceList{}
+	for resourceName, values := range outputs {
+		value := cfg.combine(values)
+		if resourceName == corev1.ResourceCPU {
+			recommendResource[resourceName] = *resource.NewMilliQuantity(int64(math.Ceil(value*1000)), resource.DecimalSI)
+		} else {
+			recommendResource[resourceName] = *resource.NewQuantity(int64(math.Ceil(value)), resource.BinarySI)
+		}
+	}
+	return recommendResource, nil
+}
+
+// combine picks the representative by the tie-break rule if the values agree within the tolerance, otherwise
+// it blends the values by the mean
+func (c *ensembleConfig) combine(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	min, max := sorted[0], sorted[len(sorted)-1]
+
+	if max-min <= max*c.tolerance {
+		if c.tieBreak == EnsembleTieBreakMin {
+			return min
+		}
+		return max
+	}
+
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+	return sum / float64(len(sorted))
+}
+
+func (e *EnsembleResourceEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	var errs []error
+	deleted := map[string]struct{}{}
+	for _, estimatorSpec := range evpa.Spec.ResourceEstimators {
+		cfg, err := getEnsembleConfig(estimatorSpec.Config)
+		if err != nil {
+			continue
+		}
+		for _, member := range cfg.members {
+			if _, exists := deleted[member]; exists {
+				continue
+			}
+			if estimator, exists := e.Members[member]; exists {
+				if err := estimator.DeleteEstimation(ctx, evpa); err != nil {
+					errs = append(errs, fmt.Errorf("ensemble member %s: %v", member, err))
+				}
+				deleted[member] = struct{}{}
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,92 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+type fakeEstimator struct {
+	resources corev1.ResourceList
+	err       error
+	deleted   int
+	deleteErr error
+}
+
+func (f *fakeEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+	return f.resources, f.err
+}
+
+func (f *fakeEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	f.deleted++
+	return f.deleteErr
+}
+
+func newFakeEstimator(cpu, memory string) *fakeEstimator {
+	return &fakeEstimator{resources: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}}
+}
+
+func TestEnsembleTieBreak(t *testing.T) {
+	e := &EnsembleResourceEstimator{Members: map[string]ResourceEstimator{
+		"a": newFakeEstimator("1", "1000Mi"),
+		"b": newFakeEstimator("1.04", "1990Mi"),
+	}}
+	config := map[string]string{"ensemble-members": "a,b", "ensemble-tolerance": "0.05"}
+
+	// cpu agrees within the tolerance, the most conservative is picked; memory diverges and is blended
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1040m", resources.Cpu().String())
+	assert.Equal(t, "1495Mi", resources.Memory().String())
+
+	config["ensemble-tie-break"] = EnsembleTieBreakMin
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+	assert.Equal(t, "1495Mi", resources.Memory().String())
+
+	// no tolerance, always blend
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"ensemble-members": "a,b"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1020m", resources.Cpu().String())
+}
+
+func TestEnsembleMemberFailure(t *testing.T) {
+	failed := &fakeEstimator{err: fmt.Errorf("no data")}
+	e := &EnsembleResourceEstimator{Members: map[string]ResourceEstimator{
+		"a":      newFakeEstimator("1", "1Gi"),
+		"failed": failed,
+	}}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"ensemble-members": "a,failed"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"ensemble-members": "failed"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"ensemble-members": "a,unknown"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"ensemble-members": "a", "ensemble-tie-break": "median"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+
+	evpa := newTestEVPA("nginx")
+	evpa.Spec.ResourceEstimators = []autoscalingapi.ResourceEstimator{{Type: "Ensemble", Config: map[string]string{"ensemble-members": "a,failed"}}}
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), evpa))
+	assert.Equal(t, 1, failed.deleted)
+
+	// the errors of the members are aggregated
+	failed.deleteErr = fmt.Errorf("delete failed")
+	err = e.DeleteEstimation(context.TODO(), evpa)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "delete failed")
+	assert.Equal(t, 2, failed.deleted)
+}
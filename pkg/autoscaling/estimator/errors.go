@@ -0,0 +1,40 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/prediction"
+)
+
+var (
+	// ErrModelNotReady means the prediction model is still warming up, the caller should back off quietly
+	ErrModelNotReady = errors.New("prediction model not ready")
+	// ErrNoSamples means the prediction is ready but no sample is returned
+	ErrNoSamples = errors.New("no samples")
+	// ErrInvalidSample means the predicted value is NaN or infinite, it is not applied
+	ErrInvalidSample = errors.New("invalid sample")
+)
+
+// noValueError tells whether the query returned nothing because the model is not ready yet
+func noValueError(ctx context.Context, predictor prediction.Interface, namer metricnaming.MetricNamer) error {
+	status, err := predictor.QueryPredictionStatus(ctx, namer)
+	if err == nil && status != prediction.StatusReady {
+		return fmt.Errorf("%w, status %s for queryExpr: %s", ErrModelNotReady, status, namer.BuildUniqueKey())
+	}
+	return fmt.Errorf("%w returned for queryExpr: %s", ErrNoSamples, namer.BuildUniqueKey())
+}
+
+// allFailedError wraps the not ready first, then the no samples, so the callers can tell why all failed
+func allFailedError(predictErrs []error, noValueErrs []error) error {
+	for _, sentinel := range []error{ErrModelNotReady, ErrNoSamples} {
+		for _, err := range append(append([]error{}, predictErrs...), noValueErrs...) {
+			if errors.Is(err, sentinel) {
+				return fmt.Errorf("all resource predicted failed: %w, predictErrs: %v, noValueErrs: %v", sentinel, predictErrs, noValueErrs)
+			}
+		}
+	}
+	return fmt.Errorf("all resource predicted failed, predictErrs: %v, noValueErrs: %v", predictErrs, noValueErrs)
+}
@@ -0,0 +1,70 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/prediction"
+)
+
+func TestGetResourceEstimationErrors(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		series   map[string][]*common.TimeSeries
+		statuses map[string]prediction.Status
+		errs     map[string]error
+		sentinel error
+	}{
+		{
+			desc:     "model not ready",
+			series:   map[string][]*common.TimeSeries{},
+			statuses: map[string]prediction.Status{"cpu": prediction.StatusInitializing, "memory": prediction.StatusNotStarted},
+			sentinel: ErrModelNotReady,
+		},
+		{
+			// the not ready wins, so the caller backs off
+			desc:     "partially not ready",
+			series:   map[string][]*common.TimeSeries{},
+			statuses: map[string]prediction.Status{"memory": prediction.StatusInitializing},
+			sentinel: ErrModelNotReady,
+		},
+		{
+			desc:     "no samples",
+			series:   map[string][]*common.TimeSeries{"cpu": newSeries(), "memory": {}},
+			sentinel: ErrNoSamples,
+		},
+		{
+			desc:   "failed",
+			series: map[string][]*common.TimeSeries{},
+			errs:   map[string]error{"cpu": assert.AnError, "memory": assert.AnError},
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			e, predictor := newTestEstimator(test.series)
+			if test.statuses != nil {
+				predictor.statuses = test.statuses
+			}
+			if test.errs != nil {
+				predictor.errs = test.errs
+			}
+			_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+			assert.Error(t, err)
+			for _, sentinel := range []error{ErrModelNotReady, ErrNoSamples} {
+				assert.Equal(t, sentinel == test.sentinel, errors.Is(err, sentinel), err.Error())
+			}
+		})
+	}
+}
+
+func TestMaxResourceEstimationModelNotReady(t *testing.T) {
+	predictor := newFakePredictor(map[string][]*common.TimeSeries{})
+	predictor.statuses["cpu"] = prediction.StatusInitializing
+	e := &MaxResourceEstimator{Predictor: predictor, TargetFetcher: &fakeFetcher{}}
+	_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.True(t, errors.Is(err, ErrModelNotReady), err)
+}
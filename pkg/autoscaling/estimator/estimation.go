@@ -0,0 +1,76 @@
+package estimator
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ReasonOutsideMaintenanceWindow means the recommendation is deferred until the maintenance window
+	ReasonOutsideMaintenanceWindow = "OutsideMaintenanceWindow"
+)
+
+const (
+	// MetadataCpuMilliCores is the metadata key of the recommended cpu in millicores
+	MetadataCpuMilliCores = "cpu-millicores"
+	// MetadataMemoryBytes is the metadata key of the recommended memory in bytes
+	MetadataMemoryBytes = "memory-bytes"
+)
+
+// ResourceEstimation is the detailed result of an estimation for a container
+type ResourceEstimation struct {
+	// Resources is the recommended resources that should be emitted
+	Resources corev1.ResourceList
+	// Computed is the resources computed by the estimator, it differs from Resources when the
+	// emission is deferred, so it can still be used as a shadow result for metrics
+	Computed corev1.ResourceList
+	// Limits is the recommended limits that should be emitted with Resources, empty if not limited
+	Limits corev1.ResourceList
+	// Reason explains why Resources differs from Computed, empty if they are the same
+	Reason string
+	// Metadata contains extra information about the recommendation
+	Metadata map[string]string
+}
+
+func newResourceEstimation(computed corev1.ResourceList) *ResourceEstimation {
+	return &ResourceEstimation{
+		Resources: computed.DeepCopy(),
+		Computed:  computed,
+		Metadata:  map[string]string{},
+	}
+}
+
+// deferToCurrent makes the estimation emit the current requests and limits instead of the computed resources
+func (r *ResourceEstimation) deferToCurrent(currRes *corev1.ResourceRequirements, reason string) {
+	resources := corev1.ResourceList{}
+	limits := corev1.ResourceList{}
+	if currRes != nil {
+		for resourceName := range r.Computed {
+			if quantity, exists := currRes.Requests[resourceName]; exists {
+				resources[resourceName] = quantity.DeepCopy()
+			}
+			if quantity, exists := currRes.Limits[resourceName]; exists {
+				limits[resourceName] = quantity.DeepCopy()
+			}
+		}
+	}
+	r.Resources = resources
+	r.Limits = limits
+	r.Reason = reason
+}
+
+// setNumericMetadata records the recommended resources in the canonical numeric form, so consumers don't re-parse
+// the quantities
+func (r *ResourceEstimation) setNumericMetadata() {
+	if cpu, exists := r.Resources[corev1.ResourceCPU]; exists {
+		r.Metadata[MetadataCpuMilliCores] = strconv.FormatInt(cpu.MilliValue(), 10)
+	} else {
+		delete(r.Metadata, MetadataCpuMilliCores)
+	}
+	if memory, exists := r.Resources[corev1.ResourceMemory]; exists {
+		r.Metadata[MetadataMemoryBytes] = strconv.FormatInt(memory.Value(), 10)
+	} else {
+		delete(r.Metadata, MetadataMemoryBytes)
+	}
+}
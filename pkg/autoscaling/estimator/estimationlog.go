@@ -0,0 +1,53 @@
+package estimator
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+// estimationLogLevel is the verbosity of the estimation steps, they are too many for the default level
+const estimationLogLevel klog.Level = 4
+
+// logEstimationStep logs a step of the estimation of the container resource with the fields to tell the estimations
+// apart, the values are not built unless the verbosity is enabled
+func logEstimationStep(msg string, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resourceName corev1.ResourceName, keysAndValues ...interface{}) {
+	if !klog.V(estimationLogLevel).Enabled() {
+		return
+	}
+	workload := ""
+	if evpa.Spec.TargetRef != nil {
+		workload = evpa.Spec.TargetRef.Name
+	}
+	fields := append([]interface{}{"namespace", evpa.Namespace, "workload", workload, "container", containerName, "resource", resourceName}, keysAndValues...)
+	klog.V(estimationLogLevel).InfoS(msg, fields...)
+}
+
+// logPredicted logs the predicted series of the resource and the selected value, the raw percentile is the value
+// without the margin
+func logPredicted(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resourceName corev1.ResourceName, namer metricnaming.MetricNamer, cfg *predictionconfig.Config, tsList []*common.TimeSeries, sample common.Sample, selected bool) {
+	if !klog.V(estimationLogLevel).Enabled() {
+		return
+	}
+	samples := 0
+	for _, ts := range tsList {
+		samples += len(ts.Samples)
+	}
+	logEstimationStep("Queried the predicted series.", evpa, containerName, resourceName, "queryExpr", namer.BuildUniqueKey(), "series", len(tsList), "samples", samples)
+	if !selected {
+		return
+	}
+	marginFraction := 0.0
+	if cfg != nil && cfg.Percentile != nil {
+		if parsed, err := utils.ParseFloat(cfg.Percentile.MarginFraction, 0); err == nil {
+			marginFraction = parsed
+		}
+	}
+	logEstimationStep("Selected the predicted value.", evpa, containerName, resourceName, "percentileValue", sample.Value/(1+marginFraction), "marginFraction", marginFraction, "value", sample.Value)
+}
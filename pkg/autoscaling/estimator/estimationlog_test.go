@@ -0,0 +1,54 @@
+package estimator
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestEstimationStepLogs(t *testing.T) {
+	var logs bytes.Buffer
+	klog.LogToStderr(false)
+	klog.SetOutput(&logs)
+	defer klog.LogToStderr(true)
+
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.5, 0.25),
+		"memory": newSeries(256 * mebibyte),
+	})
+	config := map[string]string{"cpu-request-margin-fraction": "0.25"}
+
+	// quiet by default
+	_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	klog.Flush()
+	assert.NotContains(t, logs.String(), "Selected the predicted value")
+
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	assert.NoError(t, flags.Set("v", "4"))
+	defer func() { _ = flags.Set("v", "0") }()
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	klog.Flush()
+	output := logs.String()
+	for _, expected := range []string{
+		`"Built the estimation query." namespace="default" workload="nginx" container="nginx" resource="cpu" caller="EVPACaller-default/evpa-uid"`,
+		`"Queried the predicted series." namespace="default" workload="nginx" container="nginx" resource="cpu"`,
+		`series=1 samples=2`,
+		`"Selected the predicted value." namespace="default" workload="nginx" container="nginx" resource="cpu" percentileValue=0.4 marginFraction=0.25 value=0.5`,
+		`"Selected the predicted value." namespace="default" workload="nginx" container="nginx" resource="memory"`,
+		`"Estimated the resource." namespace="default" workload="nginx" container="nginx" resource="cpu" computed="500m" quantity="500m"`,
+		`"Estimated the resource." namespace="default" workload="nginx" container="nginx" resource="memory" computed="256Mi" quantity="256Mi"`,
+	} {
+		assert.Contains(t, output, expected)
+	}
+}
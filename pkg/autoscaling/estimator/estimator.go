@@ -1,9 +1,14 @@
 package estimator
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"sync"
 
 	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -11,6 +16,7 @@ import (
 
 	"github.com/gocrane/crane/pkg/oom"
 	"github.com/gocrane/crane/pkg/prediction"
+	"github.com/gocrane/crane/pkg/providers"
 	"github.com/gocrane/crane/pkg/utils/target"
 )
 
@@ -21,7 +27,13 @@ type ResourceEstimatorManager interface {
 	GetEstimators(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) []ResourceEstimatorInstance
 
 	// DeleteEstimators release estimator resources based on EffectiveVPA spec
-	DeleteEstimators(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler)
+	DeleteEstimators(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error
+
+	// Ready returns nil if the backends of the estimators, such as the predictor, can serve
+	Ready(ctx context.Context) error
+
+	// Close releases the resources held by the estimators, such as the connections to the estimation services
+	Close() error
 }
 
 type estimatorManager struct {
@@ -29,27 +41,68 @@ type estimatorManager struct {
 
 	// estimatorMap save build-in estimators, type -> estimator
 	estimatorMap map[string]ResourceEstimator
+
+	// predictor and client are shared with the registered estimators
+	predictor prediction.Interface
+	client    client.Client
 }
 
-func NewResourceEstimatorManager(client client.Client, fetcher target.SelectorFetcher, oomRecorder oom.Recorder, predictor prediction.Interface) ResourceEstimatorManager {
+func NewResourceEstimatorManager(client client.Client, fetcher target.SelectorFetcher, oomRecorder oom.Recorder, predictor prediction.Interface, forecastPredictor prediction.Interface, history providers.History, killSwitch *KillSwitch, callerPrefix string, recorder record.EventRecorder) ResourceEstimatorManager {
 	resourceEstimatorManager := &estimatorManager{
 		estimatorMap: make(map[string]ResourceEstimator),
+		predictor:    predictor,
+		client:       client,
 	}
-	resourceEstimatorManager.buildEstimators(client, fetcher, oomRecorder, predictor)
+	resourceEstimatorManager.buildEstimators(client, fetcher, oomRecorder, predictor, forecastPredictor, history, killSwitch, callerPrefix, recorder)
 	return resourceEstimatorManager
 }
 
-func (m *estimatorManager) buildEstimators(client client.Client, fetcher target.SelectorFetcher, oomRecorder oom.Recorder, predictor prediction.Interface) {
+func (m *estimatorManager) buildEstimators(client client.Client, fetcher target.SelectorFetcher, oomRecorder oom.Recorder, predictor prediction.Interface, forecastPredictor prediction.Interface, history providers.History, killSwitch *KillSwitch, callerPrefix string, recorder record.EventRecorder) {
 	percentileEstimator := &PercentileResourceEstimator{
 		Predictor:     predictor,
 		Client:        client,
 		TargetFetcher: fetcher,
+		History:       history,
+		Registry:      NewQueryRegistry(predictor, callerPrefix),
+		KillSwitch:    killSwitch,
+		Cache:         NewPredictionCache(),
+		CallerPrefix:  callerPrefix,
+		Recorder:      recorder,
+		OOMRecorder:   oomRecorder,
 	}
 	m.registerEstimator("Percentile", percentileEstimator)
 	oomEstimator := &OOMResourceEstimator{
 		OOMRecorder: oomRecorder,
 	}
 	m.registerEstimator("OOM", oomEstimator)
+	m.registerEstimator("Max", &MaxResourceEstimator{
+		Predictor:     predictor,
+		TargetFetcher: fetcher,
+	})
+	m.registerEstimator("MovingWindow", &MovingWindowResourceEstimator{
+		Predictor:     predictor,
+		TargetFetcher: fetcher,
+	})
+	m.registerEstimator(grpcEstimatorType, &GrpcResourceEstimator{})
+	// the forecasting estimators are served by the dsp predictor, it is optional
+	if forecastPredictor != nil {
+		m.registerEstimator("DSP", &DSPResourceEstimator{
+			Predictor:     forecastPredictor,
+			TargetFetcher: fetcher,
+		})
+		m.registerEstimator("Peak", &PeakResourceEstimator{
+			Predictor:         predictor,
+			ForecastPredictor: forecastPredictor,
+			TargetFetcher:     fetcher,
+		})
+	}
+	ensembleEstimator := &EnsembleResourceEstimator{
+		Members: map[string]ResourceEstimator{
+			"Percentile": percentileEstimator,
+			"OOM":        oomEstimator,
+		},
+	}
+	m.registerEstimator("Ensemble", ensembleEstimator)
 }
 
 func (m *estimatorManager) GetEstimators(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) []ResourceEstimatorInstance {
@@ -62,7 +115,13 @@ func (m *estimatorManager) GetEstimators(evpa *autoscalingapi.EffectiveVerticalP
 		estimator := m.estimatorMap[estimatorSpec.Type]
 		var estimatorInstance resourceEstimatorInstance
 		if estimator == nil {
-			// can't found in estimatorMap, create a external estimator and register it
+			if registered, err := New(estimatorSpec.Type, m.predictor, m.client); err == nil {
+				estimator = registered
+				m.registerEstimator(estimatorSpec.Type, estimator)
+			}
+		}
+		if estimator == nil {
+			// can't found in estimatorMap or the registered estimators, create a external estimator and register it
 			estimatorInstance = resourceEstimatorInstance{
 				ResourceEstimator: NewExternalResourceEstimator(estimatorSpec),
 				Spec:              estimatorSpec,
@@ -81,15 +140,36 @@ func (m *estimatorManager) GetEstimators(evpa *autoscalingapi.EffectiveVerticalP
 	return resourceEstimatorInstances
 }
 
-func (m *estimatorManager) DeleteEstimators(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) {
+// DeleteEstimators deletes the estimations of all the estimators of the evpa, the errors are aggregated
+func (m *estimatorManager) DeleteEstimators(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	var errs []error
 	for _, estimatorSpec := range evpa.Spec.ResourceEstimators {
 		estimator := m.estimatorMap[estimatorSpec.Type]
 		if estimator == nil {
-			klog.Warning("Delete estimators failed, type %s not found. ", estimatorSpec.Type)
-			return
+			klog.Warningf("Delete estimators failed, type %s not found. ", estimatorSpec.Type)
+			continue
+		}
+		if err := estimator.DeleteEstimation(ctx, evpa); err != nil {
+			errs = append(errs, fmt.Errorf("estimator %s: %v", estimatorSpec.Type, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Close closes the estimators holding the resources, the errors are aggregated
+func (m *estimatorManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	for estimatorType, estimator := range m.estimatorMap {
+		if closer, ok := estimator.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("estimator %s: %v", estimatorType, err))
+			}
 		}
-		estimator.DeleteEstimation(evpa)
 	}
+	return utilerrors.NewAggregate(errs)
 }
 
 // registerEstimator register a estimator in estimatorMap
@@ -103,10 +183,10 @@ func (m *estimatorManager) registerEstimator(estimatorType string, estimator Res
 type ResourceEstimator interface {
 
 	// GetResourceEstimation get estimated resource result for an EffectiveVPA and related configs
-	GetResourceEstimation(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error)
+	GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error)
 
-	// DeleteEstimation delete related resource from an EffectiveVPA
-	DeleteEstimation(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler)
+	// DeleteEstimation delete related resource from an EffectiveVPA, it attempts all the deletions and aggregates the errors
+	DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error
 }
 
 // ResourceEstimatorInstance is the instance that used for container scaling
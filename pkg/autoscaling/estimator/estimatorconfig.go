@@ -0,0 +1,213 @@
+package estimator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	predictionapi "github.com/gocrane/api/prediction/v1alpha1"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+// ModelConfig is the percentile model config of a resource, the values are kept in the string form of the config map
+type ModelConfig struct {
+	SampleInterval string
+	Percentile     string
+	MarginFraction string
+	InitMode       predictionconfig.ModelInitMode
+	Aggregated     bool
+	HistoryLength  string
+	Histogram      predictionapi.HistogramConfig
+}
+
+// EstimatorConfig is the typed form of the model keys of the percentile estimator config map, such as
+// 'cpu-request-percentile' and 'mem-model-history-length'
+type EstimatorConfig struct {
+	CPU    ModelConfig
+	Memory ModelConfig
+	// Extra are the keys not modeled by the struct, such as the config of the transforms, they are passed through
+	Extra map[string]string
+}
+
+// DefaultEstimatorConfig returns the config the estimator uses if no key is set
+func DefaultEstimatorConfig() *EstimatorConfig {
+	return &EstimatorConfig{
+		CPU: ModelConfig{
+			SampleInterval: "1m",
+			Percentile:     "0.99",
+			MarginFraction: "0.15",
+			InitMode:       predictionconfig.ModelInitModeLazyTraining,
+			Aggregated:     true,
+			HistoryLength:  "24h",
+			Histogram:      defaultCpuHistogram,
+		},
+		Memory: ModelConfig{
+			SampleInterval: "1m",
+			Percentile:     "0.99",
+			MarginFraction: "0.15",
+			InitMode:       predictionconfig.ModelInitModeLazyTraining,
+			Aggregated:     true,
+			HistoryLength:  "48h",
+			Histogram:      defaultMemHistogram,
+		},
+		Extra: map[string]string{},
+	}
+}
+
+// modelKeys are the keys of the model config by the resource prefix
+func modelKeys(prefix string) []string {
+	return []string{
+		prefix + "-sample-interval",
+		prefix + "-request-percentile",
+		prefix + "-request-margin-fraction",
+		prefix + "-model-init-mode",
+		prefix + "-aggregated",
+		prefix + "-model-history-length",
+		prefix + "-histogram-halflife",
+		prefix + "-histogram-bucket-size",
+		prefix + "-histogram-max-value",
+	}
+}
+
+// FromConfigMap parses the config map, the unset keys are the defaults of the 'workload-profile'. It returns the
+// sorted unknown keys too, they are the keys in the namespaces of the model keys, such as 'cpu-request-' and
+// 'mem-histogram-', but none of them, which are likely typos. All the keys not modeled are kept in Extra.
+func FromConfigMap(config map[string]string) (*EstimatorConfig, []string, error) {
+	defaults, err := getProfileEstimatorConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	cpu, err := parseModelConfig(config, "cpu", defaults.CPU)
+	if err != nil {
+		return nil, nil, err
+	}
+	memory, err := parseModelConfig(config, "mem", defaults.Memory)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	modeled := map[string]bool{}
+	for _, prefix := range []string{"cpu", "mem"} {
+		for _, key := range modelKeys(prefix) {
+			modeled[key] = true
+		}
+	}
+	extra := map[string]string{}
+	var unknown []string
+	for key, value := range config {
+		if modeled[key] {
+			continue
+		}
+		extra[key] = value
+		for _, namespace := range []string{"-request-", "-model-", "-histogram-"} {
+			if strings.HasPrefix(key, "cpu"+namespace) || strings.HasPrefix(key, "mem"+namespace) {
+				unknown = append(unknown, key)
+				break
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return &EstimatorConfig{CPU: cpu, Memory: memory, Extra: extra}, unknown, nil
+}
+
+// ToConfigMap returns the config map of all the model keys and the extra keys
+func (c *EstimatorConfig) ToConfigMap() map[string]string {
+	config := map[string]string{}
+	for key, value := range c.Extra {
+		config[key] = value
+	}
+	for prefix, model := range map[string]ModelConfig{"cpu": c.CPU, "mem": c.Memory} {
+		keys := modelKeys(prefix)
+		for i, value := range []string{
+			model.SampleInterval,
+			model.Percentile,
+			model.MarginFraction,
+			string(model.InitMode),
+			strconv.FormatBool(model.Aggregated),
+			model.HistoryLength,
+			model.Histogram.HalfLife,
+			model.Histogram.BucketSize,
+			model.Histogram.MaxValue,
+		} {
+			config[keys[i]] = value
+		}
+	}
+	return config
+}
+
+// parseModelConfig parses the model keys of the prefix, the unset ones fall back to the defaults
+func parseModelConfig(config map[string]string, prefix string, defaults ModelConfig) (ModelConfig, error) {
+	model := defaults
+	var err error
+	if sampleInterval, exists := config[prefix+"-sample-interval"]; exists {
+		model.SampleInterval = sampleInterval
+	}
+	if model.Percentile, err = getPercentile(config, prefix+"-request-percentile", defaults.Percentile); err != nil {
+		return model, err
+	}
+	if model.MarginFraction, err = getMarginFraction(config, prefix+"-request-margin-fraction", defaults.MarginFraction); err != nil {
+		return model, err
+	}
+	if _, exists := config[prefix+"-model-init-mode"]; exists {
+		if model.InitMode, err = getModelInitMode(config, prefix+"-model-init-mode"); err != nil {
+			return model, err
+		}
+	}
+	if _, exists := config[prefix+"-aggregated"]; exists {
+		if model.Aggregated, err = getAggregated(config, prefix+"-aggregated"); err != nil {
+			return model, err
+		}
+	}
+	if model.Histogram, err = getHistogramConfig(config, prefix, defaults.Histogram); err != nil {
+		return model, err
+	}
+	if historyLength, exists := config[prefix+"-model-history-length"]; exists {
+		model.HistoryLength = historyLength
+	}
+	if err := validateModelDurations(model, prefix); err != nil {
+		return model, err
+	}
+	return model, nil
+}
+
+// validateModelDurations checks the sample interval and the history length are positive durations and the history
+// covers at least one sample, so a malformed value like '1min' is reported by its key rather than by the predictor
+func validateModelDurations(model ModelConfig, prefix string) error {
+	sampleInterval, err := utils.ParseDuration(model.SampleInterval)
+	if err != nil {
+		return fmt.Errorf("parse %s-sample-interval failed: %v", prefix, err)
+	}
+	if sampleInterval <= 0 {
+		return fmt.Errorf("%s-sample-interval must be positive, got %v", prefix, model.SampleInterval)
+	}
+	historyLength, err := utils.ParseDuration(model.HistoryLength)
+	if err != nil {
+		return fmt.Errorf("parse %s-model-history-length failed: %v", prefix, err)
+	}
+	if historyLength <= 0 {
+		return fmt.Errorf("%s-model-history-length must be positive, got %v", prefix, model.HistoryLength)
+	}
+	if historyLength < sampleInterval {
+		return fmt.Errorf("%s-model-history-length must not be shorter than the %s-sample-interval %v, got %v", prefix, prefix, model.SampleInterval, model.HistoryLength)
+	}
+	return nil
+}
+
+// predictionConfig returns the prediction config of the percentile model
+func (m ModelConfig) predictionConfig() *predictionconfig.Config {
+	initMode := m.InitMode
+	return &predictionconfig.Config{
+		InitMode: &initMode,
+		Percentile: &predictionapi.Percentile{
+			Aggregated:     m.Aggregated,
+			HistoryLength:  m.HistoryLength,
+			SampleInterval: m.SampleInterval,
+			MarginFraction: m.MarginFraction,
+			Percentile:     m.Percentile,
+			Histogram:      m.Histogram,
+		},
+	}
+}
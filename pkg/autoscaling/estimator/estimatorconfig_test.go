@@ -0,0 +1,110 @@
+package estimator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+)
+
+func TestEstimatorConfigRoundTrip(t *testing.T) {
+	config := map[string]string{
+		"cpu-request-percentile":      "0.95",
+		"cpu-request-margin-fraction": "0.2",
+		"cpu-model-init-mode":         string(predictionconfig.ModelInitModeHistory),
+		"mem-model-history-length":    "72h",
+		"mem-aggregated":              "false",
+		"mem-histogram-halflife":      "24h",
+		"tshirt-sizes":                "small=500m/512Mi",
+	}
+	typed, unknown, err := FromConfigMap(config)
+	assert.NoError(t, err)
+	assert.Empty(t, unknown)
+	assert.Equal(t, "0.95", typed.CPU.Percentile)
+	assert.Equal(t, "0.2", typed.CPU.MarginFraction)
+	assert.Equal(t, predictionconfig.ModelInitModeHistory, typed.CPU.InitMode)
+	assert.Equal(t, "24h", typed.CPU.HistoryLength)
+	assert.Equal(t, "72h", typed.Memory.HistoryLength)
+	assert.False(t, typed.Memory.Aggregated)
+	assert.Equal(t, "24h", typed.Memory.Histogram.HalfLife)
+	assert.Equal(t, map[string]string{"tshirt-sizes": "small=500m/512Mi"}, typed.Extra)
+
+	// the set keys are kept and the unset ones are the defaults
+	roundTrip := typed.ToConfigMap()
+	for key, value := range config {
+		assert.Equal(t, value, roundTrip[key], key)
+	}
+	assert.Equal(t, "0.99", roundTrip["mem-request-percentile"])
+	again, _, err := FromConfigMap(roundTrip)
+	assert.NoError(t, err)
+	assert.Equal(t, typed, again)
+
+	// the typed config builds the same prediction config as the map
+	for _, getConfig := range []struct {
+		get   func(map[string]string) (*predictionconfig.Config, error)
+		model ModelConfig
+	}{{getCpuConfig, typed.CPU}, {getMemConfig, typed.Memory}} {
+		fromMap, err := getConfig.get(config)
+		assert.NoError(t, err)
+		assert.Equal(t, getConfig.model.predictionConfig(), fromMap)
+	}
+}
+
+func TestDefaultEstimatorConfig(t *testing.T) {
+	typed, unknown, err := FromConfigMap(map[string]string{})
+	assert.NoError(t, err)
+	assert.Empty(t, unknown)
+	assert.Equal(t, DefaultEstimatorConfig(), typed)
+
+	cpuConfig, err := getCpuConfig(DefaultEstimatorConfig().ToConfigMap())
+	assert.NoError(t, err)
+	assert.Equal(t, "0.99", cpuConfig.Percentile.Percentile)
+	assert.Equal(t, "0.15", cpuConfig.Percentile.MarginFraction)
+	assert.Equal(t, "24h", cpuConfig.Percentile.HistoryLength)
+}
+
+func TestFromConfigMapUnknownKeys(t *testing.T) {
+	typed, unknown, err := FromConfigMap(map[string]string{
+		"cpu-request-percentil":    "0.9",
+		"mem-model-history-lenght": "72h",
+		"cpu-histogram-half-life":  "12h",
+		"cpu-round-to":             "50m",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cpu-histogram-half-life", "cpu-request-percentil", "mem-model-history-lenght"}, unknown)
+	// the typos fall back to the defaults, they are passed through
+	assert.Equal(t, "0.99", typed.CPU.Percentile)
+	assert.Equal(t, "0.9", typed.Extra["cpu-request-percentil"])
+
+	_, _, err = FromConfigMap(map[string]string{"mem-request-percentile": "99"})
+	assert.Error(t, err)
+}
+
+func TestParseModelConfigDurations(t *testing.T) {
+	for _, test := range []struct {
+		config map[string]string
+		err    string
+	}{
+		{map[string]string{"cpu-sample-interval": "1min"}, "parse cpu-sample-interval failed"},
+		{map[string]string{"mem-model-history-length": "24hr"}, "parse mem-model-history-length failed"},
+		{map[string]string{"cpu-sample-interval": "0s"}, "cpu-sample-interval must be positive"},
+		{map[string]string{"mem-model-history-length": "-1h"}, "mem-model-history-length must be positive"},
+		{map[string]string{"cpu-sample-interval": "2h", "cpu-model-history-length": "1h"}, "cpu-model-history-length must not be shorter than the cpu-sample-interval 2h, got 1h"},
+	} {
+		_, _, err := FromConfigMap(test.config)
+		assert.Error(t, err, test.config)
+		assert.Contains(t, err.Error(), test.err)
+	}
+
+	// the valid strings are kept as is
+	typed, _, err := FromConfigMap(map[string]string{"cpu-sample-interval": "30s", "cpu-model-history-length": "7d"})
+	assert.NoError(t, err)
+	assert.Equal(t, "30s", typed.CPU.SampleInterval)
+	assert.Equal(t, "7d", typed.CPU.HistoryLength)
+	cpuConfig, err := getCpuConfig(map[string]string{"cpu-model-history-length": "1m"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1m", cpuConfig.Percentile.HistoryLength)
+	_, err = getMemConfig(map[string]string{"mem-sample-interval": "1min"})
+	assert.Error(t, err)
+}
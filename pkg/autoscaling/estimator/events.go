@@ -0,0 +1,81 @@
+package estimator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+const (
+	// EventReasonRecommendationClamped is the event reason of an estimation clamped to the allowed range
+	EventReasonRecommendationClamped = "RecommendationClamped"
+	// EventReasonRecommendationFellBack is the event reason of an estimation fell back to the current request
+	EventReasonRecommendationFellBack = "RecommendationFellBack"
+)
+
+// recordClamped emits the events of the resources clamped to the allowed range, the overridden ones are skipped
+func (e *PercentileResourceEstimator) recordClamped(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, unclamped corev1.ResourceList, clamped corev1.ResourceList, override corev1.ResourceList) {
+	resourceNames := make([]string, 0, len(unclamped))
+	for resourceName := range unclamped {
+		resourceNames = append(resourceNames, string(resourceName))
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		resourceName := corev1.ResourceName(name)
+		estimated, result := unclamped[resourceName], clamped[resourceName]
+		eventType, message := corev1.EventTypeNormal, ""
+		if _, overridden := override[resourceName]; !overridden && estimated.Cmp(result) != 0 {
+			bound := "MinAllowed"
+			if estimated.Cmp(result) > 0 {
+				bound, eventType = "MaxAllowed", corev1.EventTypeWarning
+			}
+			message = fmt.Sprintf("%s estimate %s clamped to %s %s", resourceName, estimated.String(), bound, result.String())
+		}
+		e.recordAdjustment(evpa, containerName, resourceName, eventType, EventReasonRecommendationClamped, message)
+	}
+}
+
+// recordFellBack emits the events of the resources fell back to the current requests
+func (e *PercentileResourceEstimator) recordFellBack(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, fellBack map[corev1.ResourceName]bool, current corev1.ResourceList) {
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		message := ""
+		if quantity, exists := current[resourceName]; exists && fellBack[resourceName] {
+			message = fmt.Sprintf("%s estimate fell back to the current request %s without the prediction samples", resourceName, quantity.String())
+		}
+		e.recordAdjustment(evpa, containerName, resourceName, corev1.EventTypeWarning, EventReasonRecommendationFellBack, message)
+	}
+}
+
+// recordAdjustment emits the event of the adjustment of the resource if the recorder is set. The message is emitted
+// once until it changes, so the reconciles of an unchanged adjustment don't spam the api server. An empty message
+// means the resource is not adjusted, the next adjustment is emitted again.
+func (e *PercentileResourceEstimator) recordAdjustment(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resourceName corev1.ResourceName, eventType string, reason string, message string) {
+	if e.Recorder == nil {
+		return
+	}
+	key := lastGoodKey(evpa, containerName) + "/" + string(resourceName) + "/" + reason
+	if message == "" {
+		e.adjustments.Delete(key)
+		return
+	}
+	if last, exists := e.adjustments.Load(key); exists && last.(string) == message {
+		return
+	}
+	e.adjustments.Store(key, message)
+	e.Recorder.Event(evpa, eventType, reason, fmt.Sprintf("Container %s: %s", containerName, message))
+}
+
+// forgetAdjustments deletes the emitted adjustments of the evpa
+func (e *PercentileResourceEstimator) forgetAdjustments(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) {
+	prefix := evpaReferent(evpa) + "/"
+	e.adjustments.Range(func(key, value interface{}) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			e.adjustments.Delete(key)
+		}
+		return true
+	})
+}
@@ -0,0 +1,107 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestRecordClampedEvents(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.62),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	recorder := record.NewFakeRecorder(10)
+	e.Recorder = recorder
+	evpa := newTestEVPA("nginx")
+	evpa.Spec.ResourcePolicy.ContainerPolicies[0].MaxAllowed = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}
+	evpa.Spec.ResourcePolicy.ContainerPolicies[0].MinAllowed = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")}
+
+	_, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"Warning RecommendationClamped Container nginx: cpu estimate 620m clamped to MaxAllowed 500m",
+		"Normal RecommendationClamped Container nginx: memory estimate 256Mi clamped to MinAllowed 512Mi",
+	}, drainEvents(recorder))
+
+	// an unchanged adjustment is not emitted again
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Empty(t, drainEvents(recorder))
+
+	// a changed value is emitted
+	predictor.series["cpu"] = newSeries(0.7)
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Warning RecommendationClamped Container nginx: cpu estimate 700m clamped to MaxAllowed 500m"}, drainEvents(recorder))
+
+	// the adjustment is emitted again after it is gone
+	predictor.series["cpu"] = newSeries(0.25)
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Empty(t, drainEvents(recorder))
+	predictor.series["cpu"] = newSeries(0.7)
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Len(t, drainEvents(recorder), 1)
+
+	// the deletion forgets the emitted adjustments
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), evpa))
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Len(t, drainEvents(recorder), 2)
+}
+
+func TestRecordFellBackEvents(t *testing.T) {
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	config := map[string]string{"fallback-to-current": "true"}
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{"cpu": newSeries(0.25)})
+	recorder := record.NewFakeRecorder(10)
+	e.Recorder = recorder
+
+	_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Warning RecommendationFellBack Container nginx: memory estimate fell back to the current request 1Gi without the prediction samples"}, drainEvents(recorder))
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Empty(t, drainEvents(recorder))
+
+	// the pinned resources don't fall back, the fallback is emitted again after it is unpinned
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"fallback-to-current": "true", "static-mem": "2Gi"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Empty(t, drainEvents(recorder))
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Len(t, drainEvents(recorder), 1)
+
+	// no events without the recorder
+	e.Recorder = nil
+	predictor.series = map[string][]*common.TimeSeries{}
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Empty(t, drainEvents(recorder))
+}
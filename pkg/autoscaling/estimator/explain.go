@@ -0,0 +1,62 @@
+package estimator
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+// ResourceExplanation is how a recommended resource is derived, such as "p99 was 210m, +15% margin = 241m, clamped
+// to max 500m". The values are cpu in cores and memory in bytes, they are empty if the resource is not predicted.
+type ResourceExplanation struct {
+	// Samples are the raw samples predicted by the model
+	Samples []common.Sample
+	// Percentile is the applied percentile, PercentileValue is the value at it before the margin
+	Percentile      float64
+	PercentileValue float64
+	// MarginFraction is the applied margin, MarginValue is the predicted value with the margin
+	MarginFraction float64
+	MarginValue    float64
+	// Adjustments are the transforms applied after the margin in order, such as the clamping to the allowed range
+	Adjustments []ExplanationNode
+	// Final is the recommended quantity
+	Final resource.Quantity
+}
+
+// EstimationExplanation is the result of the explain mode, the estimation with the explanation of each recommended
+// resource
+type EstimationExplanation struct {
+	Estimation *ResourceEstimation
+	Resources  map[corev1.ResourceName]*ResourceExplanation
+}
+
+// ExplainResourceEstimation estimates the resources as EstimateResources does and explains the derivation of each
+// recommended resource. It is read-only, the queries are left registered as by a regular estimation.
+func (e *PercentileResourceEstimator) ExplainResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (*EstimationExplanation, error) {
+	graph := newExplanationGraph()
+	estimation, err := e.estimateResources(ctx, evpa, config, containerName, currRes, graph)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &EstimationExplanation{Estimation: estimation, Resources: map[corev1.ResourceName]*ResourceExplanation{}}
+	for resourceName, quantity := range estimation.Resources {
+		resourceExplanation, exists := graph.predictions[resourceName]
+		if !exists {
+			resourceExplanation = &ResourceExplanation{}
+		}
+		for _, node := range graph.Nodes {
+			if node.Resource == resourceName && node.Type == ExplanationNodeTransform {
+				resourceExplanation.Adjustments = append(resourceExplanation.Adjustments, node)
+			}
+		}
+		resourceExplanation.Final = quantity.DeepCopy()
+		explanation.Resources[resourceName] = resourceExplanation
+	}
+	return explanation, nil
+}
@@ -0,0 +1,161 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+type ExplanationNodeType string
+
+const (
+	ExplanationNodeInput     ExplanationNodeType = "Input"
+	ExplanationNodeReducer   ExplanationNodeType = "Reducer"
+	ExplanationNodeMargin    ExplanationNodeType = "Margin"
+	ExplanationNodeTransform ExplanationNodeType = "Transform"
+	ExplanationNodeFinal     ExplanationNodeType = "Final"
+)
+
+// ExplanationNode is a step of the derivation of a recommended resource
+type ExplanationNode struct {
+	// ID is unique in the graph, such as cpu/margin
+	ID       string
+	Type     ExplanationNodeType
+	Resource corev1.ResourceName
+	// Value is the output of the step, cpu in cores and memory in bytes
+	Value float64
+	// Detail describes the step, such as the query or the config
+	Detail string
+	// Inputs are the ids of the nodes the value is derived from
+	Inputs []string
+}
+
+// ExplanationGraph is the DAG of the decision: inputs (series, config) -> reducer -> margin -> transforms -> final.
+// Each resource is a separated chain in the graph.
+type ExplanationGraph struct {
+	Nodes []ExplanationNode
+
+	pendingInputs map[corev1.ResourceName][]string
+	heads         map[corev1.ResourceName]string
+	predictions   map[corev1.ResourceName]*ResourceExplanation
+}
+
+func newExplanationGraph() *ExplanationGraph {
+	return &ExplanationGraph{
+		pendingInputs: map[corev1.ResourceName][]string{},
+		heads:         map[corev1.ResourceName]string{},
+		predictions:   map[corev1.ResourceName]*ResourceExplanation{},
+	}
+}
+
+// addInput adds an input node, it is consumed by the next step of the resource. It is a no-op on a nil graph so
+// the estimation can record the steps unconditionally.
+func (g *ExplanationGraph) addInput(resourceName corev1.ResourceName, name string, value float64, detail string) {
+	if g == nil {
+		return
+	}
+	id := resourceName.String() + "/" + name
+	g.Nodes = append(g.Nodes, ExplanationNode{ID: id, Type: ExplanationNodeInput, Resource: resourceName, Value: value, Detail: detail})
+	g.pendingInputs[resourceName] = append(g.pendingInputs[resourceName], id)
+}
+
+// addStep adds a step derived from the previous step and the pending inputs of the resource
+func (g *ExplanationGraph) addStep(resourceName corev1.ResourceName, nodeType ExplanationNodeType, name string, value float64, detail string) {
+	if g == nil {
+		return
+	}
+	var inputs []string
+	if head, exists := g.heads[resourceName]; exists {
+		inputs = append(inputs, head)
+	}
+	inputs = append(inputs, g.pendingInputs[resourceName]...)
+	delete(g.pendingInputs, resourceName)
+
+	id := resourceName.String() + "/" + name
+	g.Nodes = append(g.Nodes, ExplanationNode{ID: id, Type: nodeType, Resource: resourceName, Value: value, Detail: detail, Inputs: inputs})
+	g.heads[resourceName] = id
+}
+
+// Node returns the node by id
+func (g *ExplanationGraph) Node(id string) (*ExplanationNode, bool) {
+	for i := range g.Nodes {
+		if g.Nodes[i].ID == id {
+			return &g.Nodes[i], true
+		}
+	}
+	return nil, false
+}
+
+// Final returns the final node of the resource
+func (g *ExplanationGraph) Final(resourceName corev1.ResourceName) (*ExplanationNode, bool) {
+	for i := range g.Nodes {
+		if g.Nodes[i].Type == ExplanationNodeFinal && g.Nodes[i].Resource == resourceName {
+			return &g.Nodes[i], true
+		}
+	}
+	return nil, false
+}
+
+// explainPredicted records the predicted value of the percentile predictor, the predictor applies the margin
+// internally, so the reduced percentile is derived back from the margin fraction.
+func (g *ExplanationGraph) explainPredicted(resourceName corev1.ResourceName, namer metricnaming.MetricNamer, cfg *predictionconfig.Config, samples []common.Sample, value float64) {
+	if g == nil || cfg.Percentile == nil {
+		return
+	}
+	marginFraction, err := utils.ParseFloat(cfg.Percentile.MarginFraction, 0)
+	if err != nil {
+		marginFraction = 0
+	}
+	percentile, err := utils.ParseFloat(cfg.Percentile.Percentile, 0)
+	if err != nil {
+		percentile = 0
+	}
+
+	g.addInput(resourceName, "series", value, namer.BuildUniqueKey())
+	g.addInput(resourceName, "config", percentile, fmt.Sprintf("percentile=%s, margin-fraction=%s, history-length=%s, sample-interval=%s",
+		cfg.Percentile.Percentile, cfg.Percentile.MarginFraction, cfg.Percentile.HistoryLength, cfg.Percentile.SampleInterval))
+	g.addStep(resourceName, ExplanationNodeReducer, "percentile", value/(1+marginFraction), fmt.Sprintf("percentile %s", cfg.Percentile.Percentile))
+	g.addStep(resourceName, ExplanationNodeMargin, "margin", value, fmt.Sprintf("margin-fraction %s", cfg.Percentile.MarginFraction))
+	g.predictions[resourceName] = &ResourceExplanation{
+		Samples:         append([]common.Sample(nil), samples...),
+		Percentile:      percentile,
+		PercentileValue: value / (1 + marginFraction),
+		MarginFraction:  marginFraction,
+		MarginValue:     value,
+	}
+}
+
+func quantityValue(resourceName corev1.ResourceName, quantity resource.Quantity) float64 {
+	if !isByteResource(resourceName) {
+		return float64(quantity.MilliValue()) / 1000
+	}
+	return float64(quantity.Value())
+}
+
+// ExplainGraph returns the full derivation of the recommendation as a graph, so tools can render how each
+// recommended resource is computed. The final nodes are the emitted resources.
+func (e *PercentileResourceEstimator) ExplainGraph(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (*ExplanationGraph, error) {
+	graph := newExplanationGraph()
+	estimation, err := e.estimateResources(ctx, evpa, config, containerName, currRes, graph)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		quantity, exists := estimation.Resources[resourceName]
+		if !exists {
+			continue
+		}
+		graph.addStep(resourceName, ExplanationNodeFinal, "final", quantityValue(resourceName, quantity), quantity.String())
+	}
+	return graph, nil
+}
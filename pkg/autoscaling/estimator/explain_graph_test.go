@@ -0,0 +1,55 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestExplainGraph(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(1.15),
+		"memory": newSeries(1150 * 1024 * 1024),
+	})
+
+	graph, err := e.ExplainGraph(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	for resourceName, quantity := range resources {
+		final, found := graph.Final(resourceName)
+		assert.True(t, found)
+		assert.Equal(t, quantityValue(resourceName, quantity), final.Value)
+		assert.Equal(t, []string{resourceName.String() + "/margin"}, final.Inputs)
+
+		margin, found := graph.Node(resourceName.String() + "/margin")
+		assert.True(t, found)
+		assert.Equal(t, final.Value, margin.Value)
+		reducer, found := graph.Node(margin.Inputs[0])
+		assert.True(t, found)
+		assert.Equal(t, ExplanationNodeReducer, reducer.Type)
+		// the default margin fraction is 0.15
+		assert.InDelta(t, margin.Value/1.15, reducer.Value, 1e-6)
+		assert.Equal(t, []string{resourceName.String() + "/series", resourceName.String() + "/config"}, reducer.Inputs)
+	}
+
+	// the transforms are chained before the final
+	e.Clock = clock.NewFakeClock(time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC))
+	currRes := &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}}
+	graph, err = e.ExplainGraph(context.TODO(), newTestEVPA("nginx"), map[string]string{"maintenance-window": "02:00-04:00"}, "nginx", currRes)
+	assert.NoError(t, err)
+	final, found := graph.Final(corev1.ResourceCPU)
+	assert.True(t, found)
+	assert.Equal(t, 2.0, final.Value)
+	assert.Equal(t, []string{"cpu/maintenance-window"}, final.Inputs)
+	_, found = graph.Final(corev1.ResourceMemory)
+	assert.False(t, found)
+}
@@ -0,0 +1,58 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestExplainResourceEstimation(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		// p99 was 210m, +15% margin = 241m
+		"cpu":    newSeries(0.2415, 0.1),
+		"memory": newSeries(1024 * 1024 * 1024),
+	})
+	evpa := newTestEVPA("nginx")
+	evpa.Spec.ResourcePolicy.ContainerPolicies[0].MaxAllowed = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}
+
+	explanation, err := e.ExplainResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	resources, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, resources, explanation.Estimation.Resources)
+
+	cpu := explanation.Resources[corev1.ResourceCPU]
+	assert.Equal(t, []common.Sample{{Timestamp: 0, Value: 0.2415}, {Timestamp: 60, Value: 0.1}}, cpu.Samples)
+	assert.Equal(t, 0.99, cpu.Percentile)
+	assert.InDelta(t, 0.21, cpu.PercentileValue, 1e-9)
+	assert.Equal(t, 0.15, cpu.MarginFraction)
+	assert.Equal(t, 0.2415, cpu.MarginValue)
+	assert.Len(t, cpu.Adjustments, 1)
+	assert.Equal(t, "cpu/allowed", cpu.Adjustments[0].ID)
+	assert.Equal(t, 0.2, cpu.Adjustments[0].Value)
+	assert.Equal(t, "200m", cpu.Final.String())
+
+	memory := explanation.Resources[corev1.ResourceMemory]
+	assert.Equal(t, float64(1024*1024*1024), memory.MarginValue)
+	assert.Empty(t, memory.Adjustments)
+	assert.Equal(t, "1Gi", memory.Final.String())
+
+	// the explanation is read-only
+	assert.Empty(t, predictor.deleted)
+
+	// the adjustments are in order
+	explanation, err = e.ExplainResourceEstimation(context.TODO(), evpa, map[string]string{"cpu-round-to": "100m"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	cpu = explanation.Resources[corev1.ResourceCPU]
+	var adjustments []string
+	for _, adjustment := range cpu.Adjustments {
+		adjustments = append(adjustments, adjustment.ID)
+	}
+	assert.Equal(t, []string{"cpu/round-to", "cpu/allowed"}, adjustments)
+	assert.Equal(t, "200m", cpu.Final.String())
+}
@@ -1,6 +1,8 @@
 package estimator
 
 import (
+	"context"
+
 	corev1 "k8s.io/api/core/v1"
 
 	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
@@ -18,7 +20,7 @@ func NewExternalResourceEstimator(spec autoscalingapi.ResourceEstimator) *Extern
 	}
 }
 
-func (e *ExternalResourceEstimator) GetResourceEstimation(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+func (e *ExternalResourceEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
 	for _, currentEstimator := range evpa.Status.CurrentEstimators {
 		if currentEstimator.Type == e.Type {
 			for _, containerRecommendation := range currentEstimator.Recommendation.ContainerRecommendations {
@@ -32,7 +34,7 @@ func (e *ExternalResourceEstimator) GetResourceEstimation(evpa *autoscalingapi.E
 	return nil, nil
 }
 
-func (e *ExternalResourceEstimator) DeleteEstimation(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) {
+func (e *ExternalResourceEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
 	// do nothing
-	return
+	return nil
 }
@@ -0,0 +1,39 @@
+package estimator
+
+import (
+	"fmt"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gocrane/crane/pkg/prediction"
+)
+
+// Factory builds a resource estimator on the shared predictor and client
+type Factory func(predictor prediction.Interface, client client.Client) ResourceEstimator
+
+var (
+	estimatorFactories = make(map[string]Factory)
+	factoryLock        sync.Mutex
+)
+
+// Register registers the factory of the estimator type, so the estimator can be selected by the type of the
+// evpa resource estimators without changing the controller. The build-in estimators take precedence.
+func Register(estimatorType string, factory Factory) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+
+	estimatorFactories[estimatorType] = factory
+}
+
+// New builds the estimator of the registered type
+func New(estimatorType string, predictor prediction.Interface, client client.Client) (ResourceEstimator, error) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+
+	factory, ok := estimatorFactories[estimatorType]
+	if !ok {
+		return nil, fmt.Errorf("not registered estimator type %v", estimatorType)
+	}
+	return factory(predictor, client), nil
+}
@@ -0,0 +1,58 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/prediction"
+)
+
+// maxOfWindowEstimator is a plugged estimator built on the shared predictor
+type maxOfWindowEstimator struct {
+	predictor prediction.Interface
+}
+
+func (e *maxOfWindowEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+	return corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}, nil
+}
+
+func (e *maxOfWindowEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	predictor := newFakePredictor(map[string][]*common.TimeSeries{})
+	Register("MaxOfWindow", func(predictor prediction.Interface, client client.Client) ResourceEstimator {
+		return &maxOfWindowEstimator{predictor: predictor}
+	})
+
+	estimator, err := New("MaxOfWindow", predictor, nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &maxOfWindowEstimator{}, estimator)
+	assert.Equal(t, predictor, estimator.(*maxOfWindowEstimator).predictor)
+
+	_, err = New("Unknown", predictor, nil)
+	assert.Error(t, err)
+
+	// selected by the type of the evpa resource estimators, the unknown type is an external estimator
+	manager := NewResourceEstimatorManager(nil, nil, nil, predictor, nil, nil, nil, "", nil)
+	evpa := newTestEVPA("nginx")
+	evpa.Spec.ResourceEstimators = []autoscalingapi.ResourceEstimator{{Type: "MaxOfWindow"}, {Type: "Percentile"}, {Type: "Unknown"}}
+	instances := manager.GetEstimators(evpa)
+	assert.Len(t, instances, 3)
+	assert.IsType(t, &maxOfWindowEstimator{}, instances[0].(resourceEstimatorInstance).ResourceEstimator)
+	assert.IsType(t, &PercentileResourceEstimator{}, instances[1].(resourceEstimatorInstance).ResourceEstimator)
+	assert.IsType(t, &ExternalResourceEstimator{}, instances[2].(resourceEstimatorInstance).ResourceEstimator)
+
+	resources, err := instances[0].GetResourceEstimation(context.TODO(), evpa, nil, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+}
@@ -0,0 +1,30 @@
+package estimator
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// getFallbackToCurrent returns whether 'fallback-to-current' is set, the resources without prediction samples fall
+// back to the current requests instead of failing, such as of a freshly created workload without history
+func getFallbackToCurrent(config map[string]string) (bool, error) {
+	value, exists := config["fallback-to-current"]
+	if !exists {
+		return false, nil
+	}
+	fallback, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parse fallback-to-current failed: %v", err)
+	}
+	return fallback, nil
+}
+
+// currentFallback returns the current requests to fall back to, nil if the fallback is disabled
+func currentFallback(currRes *corev1.ResourceRequirements, enabled bool) corev1.ResourceList {
+	if !enabled || currRes == nil {
+		return nil
+	}
+	return currRes.Requests
+}
@@ -0,0 +1,68 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestFallbackToCurrent(t *testing.T) {
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	config := map[string]string{"fallback-to-current": "true"}
+
+	// fallback hit, no samples of any resource
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{})
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+	assert.Equal(t, "1Gi", resources.Memory().String())
+
+	// disabled by default, out of the warmup
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"warmup-duration": "0"}, "nginx", currRes)
+	assert.Error(t, err)
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"fallback-to-current": "false", "warmup-duration": "0"}, "nginx", currRes)
+	assert.Error(t, err)
+
+	// only the resource without samples falls back
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{"cpu": newSeries(0.25)})
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, "1Gi", resources.Memory().String())
+
+	// fallback miss, the current requests lack the memory
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{})
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+	assert.NotContains(t, resources, corev1.ResourceMemory)
+
+	// fallback miss of all resources
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrNoSamples)
+
+	// a query error is not a missing sample, it doesn't fall back
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{})
+	predictor.errs["cpu"] = fmt.Errorf("query failed")
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.NotContains(t, resources, corev1.ResourceCPU)
+	assert.Equal(t, "1Gi", resources.Memory().String())
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"fallback-to-current": "maybe"}, "nginx", currRes)
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package estimator
+
+import (
+	"fmt"
+
+	"k8s.io/klog/v2"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+// Each resource aggregates its samples at its own granularity, the '<prefix>-sample-interval'. Cpu moves fast and
+// should be aggregated finely to catch the brief bursts, memory moves slowly and can be aggregated coarsely to
+// keep the histogram cheap over a longer history. The recommended pairings are:
+//
+//	cpu-sample-interval: 1m, cpu-model-history-length: 24h
+//	mem-sample-interval: 5m, mem-model-history-length: 48h
+//
+// A cpu granularity coarser than the memory one is allowed but logged, since the bursts are averaged out.
+const minGranularitySamples = 60
+
+// granularityOf validates the sample interval of the resource against its history length, the history must hold
+// at least minGranularitySamples samples at the granularity
+func granularityOf(cfg *predictionconfig.Config, prefix string) (int64, error) {
+	sampleInterval, err := utils.ParseDuration(cfg.Percentile.SampleInterval)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s-sample-interval failed: %v", prefix, err)
+	}
+	if sampleInterval <= 0 {
+		return 0, fmt.Errorf("%s-sample-interval must be positive, got %s", prefix, cfg.Percentile.SampleInterval)
+	}
+	historyLength, err := utils.ParseDuration(cfg.Percentile.HistoryLength)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s history length failed: %v", prefix, err)
+	}
+	if sampleInterval*minGranularitySamples > historyLength {
+		return 0, fmt.Errorf("%s-sample-interval %s is too coarse for the history length %s, at least %d samples are needed",
+			prefix, cfg.Percentile.SampleInterval, cfg.Percentile.HistoryLength, minGranularitySamples)
+	}
+	return int64(sampleInterval), nil
+}
+
+// validateGranularity validates the granularity of cpu and memory
+func validateGranularity(cpuConfig, memConfig *predictionconfig.Config, callerName string) error {
+	cpuGranularity, err := granularityOf(cpuConfig, "cpu")
+	if err != nil {
+		return err
+	}
+	memGranularity, err := granularityOf(memConfig, "mem")
+	if err != nil {
+		return err
+	}
+	if cpuGranularity > memGranularity {
+		klog.V(4).InfoS("Cpu is aggregated coarser than memory, the brief bursts may be missed.", "caller", callerName,
+			"cpuSampleInterval", cpuConfig.Percentile.SampleInterval, "memSampleInterval", memConfig.Percentile.SampleInterval)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+)
+
+// stepHistory samples the usage function of a metric at the queried step
+type stepHistory struct {
+	usage map[string]func(time.Time) float64
+}
+
+func (h *stepHistory) QueryTimeSeries(namer metricnaming.MetricNamer, startTime time.Time, endTime time.Time, step time.Duration) ([]*common.TimeSeries, error) {
+	keys := seriesKeys(namer)
+	usage := h.usage[keys[len(keys)-1]]
+	ts := common.NewTimeSeries()
+	for t := startTime; !t.After(endTime); t = t.Add(step) {
+		ts.AppendSample(t.Unix(), usage(t))
+	}
+	return []*common.TimeSeries{ts}, nil
+}
+
+func TestEstimateResourcesGranularity(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	e.Clock = clock.NewFakeClock(now)
+	burst := now.Add(-7 * time.Minute)
+	// both burst for a minute only
+	e.History = &stepHistory{usage: map[string]func(time.Time) float64{
+		"cpu": func(t time.Time) float64 {
+			if t.Equal(burst) {
+				return 2
+			}
+			return 0.1
+		},
+		"memory": func(t time.Time) float64 {
+			if t.Equal(burst) {
+				return 1024 * 1024 * 1024
+			}
+			return 100 * 1024 * 1024
+		},
+	}}
+
+	config := map[string]string{
+		"cpu-sample-interval":         "1m",
+		"mem-sample-interval":         "10m",
+		"cpu-winsorize-bounds":        "0,1",
+		"mem-winsorize-bounds":        "0,1",
+		"cpu-request-percentile":      "1.0",
+		"cpu-request-margin-fraction": "0",
+		"mem-request-percentile":      "1.0",
+		"mem-request-margin-fraction": "0",
+	}
+	// the fine cpu granularity catches the burst, the coarse memory granularity doesn't
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resources.Cpu().String())
+	assert.Equal(t, "100Mi", resources.Memory().String())
+
+	config["mem-sample-interval"] = "1m"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1Gi", resources.Memory().String())
+
+	// too coarse for the history length
+	config["cpu-sample-interval"] = "1h"
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	config["cpu-sample-interval"] = "0s"
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
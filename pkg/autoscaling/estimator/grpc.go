@@ -0,0 +1,168 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+const grpcEstimatorType = "Grpc"
+
+// grpcEstimateMethod is the method the estimation service serves, both the request and the response are a
+// google.protobuf.Struct so the service is implemented without any crane protos:
+//
+//	service Estimator {
+//	  rpc Estimate(google.protobuf.Struct) returns (google.protobuf.Struct) {}
+//	}
+//
+// The request is {"namespace", "name", "targetRef": {"apiVersion", "kind", "name"}, "container",
+// "requests": {<resource>: <quantity>}, "config": {<key>: <value>}}, the response is
+// {"resources": {<resource>: <quantity>}}, such as {"resources": {"cpu": "250m", "memory": "256Mi"}}.
+const grpcEstimateMethod = "/crane.estimator.Estimator/Estimate"
+
+const defaultGrpcEstimatorTimeout = 10 * time.Second
+
+var _ ResourceEstimator = &GrpcResourceEstimator{}
+
+// GrpcResourceEstimator delegates the estimation to an external estimation service, it is configured by the
+// 'grpc-address' and 'grpc-timeout' keys of the evpa resource estimator, so users can plug in their own estimation
+// without forking the controller.
+type GrpcResourceEstimator struct {
+	// DialOptions are used to connect the service, the insecure transport by default
+	DialOptions []grpc.DialOption
+
+	mu sync.Mutex
+	// conns is the connection of each address, they are reused across the estimations
+	conns map[string]*grpc.ClientConn
+}
+
+func (e *GrpcResourceEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+	address, exists := config["grpc-address"]
+	if !exists || address == "" {
+		return nil, fmt.Errorf("grpc-address is required by the %s estimator", grpcEstimatorType)
+	}
+	timeout := defaultGrpcEstimatorTimeout
+	if timeoutStr, exists := config["grpc-timeout"]; exists {
+		var err error
+		timeout, err = time.ParseDuration(timeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse grpc-timeout failed: %v", err)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("grpc-timeout must be positive, got %v", timeout)
+		}
+	}
+
+	req, err := grpcEstimateRequest(evpa, config, containerName, currRes)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := e.conn(address)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp := &structpb.Struct{}
+	if err := conn.Invoke(ctx, grpcEstimateMethod, req, resp); err != nil {
+		return nil, fmt.Errorf("failed to estimate by %s: %v", address, err)
+	}
+	return grpcEstimatedResources(resp)
+}
+
+// conn returns the connection of the address, it is dialed on the first use
+func (e *GrpcResourceEstimator) conn(address string) (*grpc.ClientConn, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if conn, exists := e.conns[address]; exists {
+		return conn, nil
+	}
+	dialOptions := e.DialOptions
+	if len(dialOptions) == 0 {
+		dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	conn, err := grpc.Dial(address, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if e.conns == nil {
+		e.conns = make(map[string]*grpc.ClientConn)
+	}
+	e.conns[address] = conn
+	return conn, nil
+}
+
+// Close closes the connections of all addresses
+func (e *GrpcResourceEstimator) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var errs []error
+	for address, conn := range e.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close connection to %s failed: %v", address, err))
+		}
+	}
+	e.conns = nil
+	return utilerrors.NewAggregate(errs)
+}
+
+func (e *GrpcResourceEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	// do nothing, the service owns its state
+	return nil
+}
+
+// grpcEstimateRequest builds the request of the target container, the config and the current requests are passed as is
+func grpcEstimateRequest(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (*structpb.Struct, error) {
+	configValues := map[string]interface{}{}
+	for key, value := range config {
+		configValues[key] = value
+	}
+	requests := map[string]interface{}{}
+	if currRes != nil {
+		for resourceName, quantity := range currRes.Requests {
+			requests[resourceName.String()] = quantity.String()
+		}
+	}
+	return structpb.NewStruct(map[string]interface{}{
+		"namespace": evpa.Namespace,
+		"name":      evpa.Name,
+		"targetRef": map[string]interface{}{
+			"apiVersion": evpa.Spec.TargetRef.APIVersion,
+			"kind":       evpa.Spec.TargetRef.Kind,
+			"name":       evpa.Spec.TargetRef.Name,
+		},
+		"container": containerName,
+		"requests":  requests,
+		"config":    configValues,
+	})
+}
+
+// grpcEstimatedResources parses the 'resources' of the response, such as {"resources": {"cpu": "250m"}}
+func grpcEstimatedResources(resp *structpb.Struct) (corev1.ResourceList, error) {
+	resources := resp.GetFields()["resources"].GetStructValue()
+	if resources == nil {
+		return nil, fmt.Errorf("no resources in the %s estimation", grpcEstimatorType)
+	}
+	recommendResource := corev1.ResourceList{}
+	for name, value := range resources.GetFields() {
+		quantity, err := resource.ParseQuantity(value.GetStringValue())
+		if err != nil {
+			return nil, fmt.Errorf("parse the estimated %s failed: %v", name, err)
+		}
+		recommendResource[corev1.ResourceName(name)] = quantity
+	}
+	return recommendResource, nil
+}
@@ -0,0 +1,97 @@
+package estimator
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// startEstimateServer serves the Estimate method by the handler on an in-memory listener
+func startEstimateServer(t *testing.T, handler func(req *structpb.Struct) (*structpb.Struct, error)) *bufconn.Listener {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "crane.estimator.Estimator",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Estimate",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := &structpb.Struct{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return handler(req)
+			},
+		}},
+	}, struct{}{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener
+}
+
+func TestGrpcResourceEstimation(t *testing.T) {
+	var received *structpb.Struct
+	listener := startEstimateServer(t, func(req *structpb.Struct) (*structpb.Struct, error) {
+		received = req
+		return structpb.NewStruct(map[string]interface{}{
+			"resources": map[string]interface{}{"cpu": "250m", "memory": "256Mi"},
+		})
+	})
+	e := &GrpcResourceEstimator{DialOptions: []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	}}
+	config := map[string]string{"grpc-address": "bufnet", "grpc-timeout": "5s", "model": "v2"}
+	currRes := &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, "256Mi", resources.Memory().String())
+
+	// the request carries the target, the config and the current requests
+	if assert.NotNil(t, received) {
+		fields := received.AsMap()
+		assert.Equal(t, "nginx", fields["container"])
+		assert.Equal(t, "nginx", fields["targetRef"].(map[string]interface{})["name"])
+		assert.Equal(t, "v2", fields["config"].(map[string]interface{})["model"])
+		assert.Equal(t, "1", fields["requests"].(map[string]interface{})["cpu"])
+	}
+
+	// the connection is reused across the estimations and closed on shutdown
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Len(t, e.conns, 1)
+	assert.NoError(t, e.Close())
+	assert.Empty(t, e.conns)
+
+	// the invalid configs
+	for _, config := range []map[string]string{
+		{},
+		{"grpc-address": "bufnet", "grpc-timeout": "-1s"},
+		{"grpc-address": "bufnet", "grpc-timeout": "1d"},
+	} {
+		_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+		assert.Error(t, err, config)
+	}
+}
+
+func TestGrpcEstimatedResources(t *testing.T) {
+	resp, _ := structpb.NewStruct(map[string]interface{}{})
+	_, err := grpcEstimatedResources(resp)
+	assert.Error(t, err)
+
+	resp, _ = structpb.NewStruct(map[string]interface{}{"resources": map[string]interface{}{"cpu": "a lot"}})
+	_, err = grpcEstimatedResources(resp)
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
+	"github.com/gocrane/crane/pkg/prediction"
+)
+
+// readyChecker is implemented by the estimators depending on a backend that must be functioning before reconciling
+type readyChecker interface {
+	Ready(ctx context.Context) error
+}
+
+// Ready returns nil only if the predictor can serve. The predictor implementing prediction.HealthChecker is asked
+// directly, otherwise a status query of a probe metric is issued, it registers nothing.
+func (e *PercentileResourceEstimator) Ready(ctx context.Context) error {
+	if e.Predictor == nil {
+		return fmt.Errorf("no predictor")
+	}
+	if checker, ok := e.Predictor.(prediction.HealthChecker); ok {
+		return checker.Healthy(ctx)
+	}
+	probe := &metricnaming.GeneralMetricNamer{
+		CallerName: "readiness",
+		Metric: &metricquery.Metric{
+			Type:       metricquery.ContainerMetricType,
+			MetricName: "cpu",
+			Container:  &metricquery.ContainerNamerInfo{},
+		},
+	}
+	if _, err := e.Predictor.QueryPredictionStatus(ctx, probe); err != nil {
+		return fmt.Errorf("predictor %s is not ready: %v", e.Predictor.Name(), err)
+	}
+	return nil
+}
+
+// Ready checks the estimators depending on a backend, the errors are aggregated. The result of each estimator is
+// reported by the crane_estimator_ready gauge.
+func (m *estimatorManager) Ready(ctx context.Context) error {
+	registerMetrics()
+	// the checks may be slow, don't hold the lock during them
+	m.mu.Lock()
+	checkers := map[string]readyChecker{}
+	for estimatorType, estimator := range m.estimatorMap {
+		if checker, ok := estimator.(readyChecker); ok {
+			checkers[estimatorType] = checker
+		}
+	}
+	m.mu.Unlock()
+
+	estimatorTypes := make([]string, 0, len(checkers))
+	for estimatorType := range checkers {
+		estimatorTypes = append(estimatorTypes, estimatorType)
+	}
+	sort.Strings(estimatorTypes)
+	var errs []error
+	for _, estimatorType := range estimatorTypes {
+		if err := checkers[estimatorType].Ready(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("estimator %s: %v", estimatorType, err))
+			estimatorReady.WithLabelValues(estimatorType).Set(0)
+			continue
+		}
+		estimatorReady.WithLabelValues(estimatorType).Set(1)
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,48 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+// healthCheckingPredictor is a fake predictor reporting its health
+type healthCheckingPredictor struct {
+	*fakePredictor
+	unhealthy error
+}
+
+func (p *healthCheckingPredictor) Healthy(_ context.Context) error {
+	return p.unhealthy
+}
+
+func TestReady(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{})
+	assert.NoError(t, e.Ready(context.TODO()))
+
+	predictor := &healthCheckingPredictor{fakePredictor: newFakePredictor(map[string][]*common.TimeSeries{})}
+	e.Predictor = predictor
+	assert.NoError(t, e.Ready(context.TODO()))
+
+	predictor.unhealthy = fmt.Errorf("predictor fake is not running")
+	assert.EqualError(t, e.Ready(context.TODO()), "predictor fake is not running")
+
+	// the probe registers nothing
+	assert.Empty(t, predictor.registered)
+
+	e.Predictor = nil
+	assert.Error(t, e.Ready(context.TODO()))
+
+	// the manager consults the estimators depending on the predictor
+	manager := NewResourceEstimatorManager(nil, nil, nil, predictor, nil, nil, nil, "", nil)
+	assert.EqualError(t, manager.Ready(context.TODO()), "estimator Percentile: predictor fake is not running")
+	assert.Equal(t, float64(0), testutil.ToFloat64(estimatorReady.WithLabelValues("Percentile")))
+	predictor.unhealthy = nil
+	assert.NoError(t, manager.Ready(context.TODO()))
+	assert.Equal(t, float64(1), testutil.ToFloat64(estimatorReady.WithLabelValues("Percentile")))
+}
@@ -0,0 +1,99 @@
+package estimator
+
+import (
+	"fmt"
+	"strconv"
+
+	predictionapi "github.com/gocrane/api/prediction/v1alpha1"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const (
+	histogramScaleLinear = "linear"
+	histogramScaleLog    = "log"
+
+	// logHistogramGrowthRatio is the growth of each bucket over the previous one, so the relative resolution is
+	// about 5% at any value
+	logHistogramGrowthRatio = "0.05"
+)
+
+// applyHistogramScale sets the bucketing of the histograms by the 'histogram-scale'. The default linear buckets
+// are 0.1 core and 100Mi wide, which is too coarse for the tail of a workload clustered at low usage, the log
+// buckets start at 10m and 1Mi and grow exponentially.
+func applyHistogramScale(config map[string]string, cpuConfig *predictionconfig.Config, memConfig *predictionconfig.Config) error {
+	scale, exists := config["histogram-scale"]
+	if !exists || scale == histogramScaleLinear {
+		return nil
+	}
+	if scale != histogramScaleLog {
+		return fmt.Errorf("unknown histogram-scale %s", scale)
+	}
+
+	for cfg, firstBucketSize := range map[*predictionconfig.Config]string{
+		cpuConfig: "0.01",
+		memConfig: "1048576",
+	} {
+		cfg.Percentile.Histogram.BucketSize = ""
+		cfg.Percentile.Histogram.FirstBucketSize = firstBucketSize
+		cfg.Percentile.Histogram.BucketSizeGrowthRatio = logHistogramGrowthRatio
+	}
+	return nil
+}
+
+var (
+	defaultCpuHistogram = predictionapi.HistogramConfig{
+		HalfLife:   "24h",
+		BucketSize: "0.1",
+		MaxValue:   "100",
+	}
+	defaultMemHistogram = predictionapi.HistogramConfig{
+		HalfLife:   "48h",
+		BucketSize: "104857600",
+		MaxValue:   "104857600000",
+	}
+	defaultEphemeralStorageHistogram = predictionapi.HistogramConfig{
+		HalfLife:   "48h",
+		BucketSize: "104857600",
+		MaxValue:   "1048576000000",
+	}
+)
+
+// getHistogramConfig returns the histogram of the '<prefix>-histogram-halflife', '<prefix>-histogram-bucket-size' and
+// '<prefix>-histogram-max-value' keys, the unset ones fall back to the defaults
+func getHistogramConfig(config map[string]string, prefix string, defaults predictionapi.HistogramConfig) (predictionapi.HistogramConfig, error) {
+	histogram := defaults
+	if value, exists := config[prefix+"-histogram-halflife"]; exists {
+		halfLife, err := utils.ParseDuration(value)
+		if err != nil {
+			return histogram, fmt.Errorf("parse %s-histogram-halflife failed: %v", prefix, err)
+		}
+		if halfLife <= 0 {
+			return histogram, fmt.Errorf("%s-histogram-halflife %s must be positive", prefix, value)
+		}
+		histogram.HalfLife = value
+	}
+	if value, exists := config[prefix+"-histogram-bucket-size"]; exists {
+		histogram.BucketSize = value
+	}
+	if value, exists := config[prefix+"-histogram-max-value"]; exists {
+		histogram.MaxValue = value
+	}
+
+	bucketSize, err := strconv.ParseFloat(histogram.BucketSize, 64)
+	if err != nil {
+		return histogram, fmt.Errorf("parse %s-histogram-bucket-size failed: %v", prefix, err)
+	}
+	if bucketSize <= 0 {
+		return histogram, fmt.Errorf("%s-histogram-bucket-size %s must be positive", prefix, histogram.BucketSize)
+	}
+	maxValue, err := strconv.ParseFloat(histogram.MaxValue, 64)
+	if err != nil {
+		return histogram, fmt.Errorf("parse %s-histogram-max-value failed: %v", prefix, err)
+	}
+	if maxValue <= bucketSize {
+		return histogram, fmt.Errorf("%s-histogram-max-value %s must exceed the bucket size %s", prefix, histogram.MaxValue, histogram.BucketSize)
+	}
+	return histogram, nil
+}
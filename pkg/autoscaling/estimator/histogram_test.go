@@ -0,0 +1,140 @@
+package estimator
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	vpa "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/util"
+
+	predictionapi "github.com/gocrane/api/prediction/v1alpha1"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+// histogramOptionsOf builds the histogram options the same way as the percentile predictor
+func histogramOptionsOf(t *testing.T, cfg *predictionconfig.Config) vpa.HistogramOptions {
+	h := cfg.Percentile.Histogram
+	maxValue, err := utils.ParseFloat(h.MaxValue, 0)
+	assert.NoError(t, err)
+	var options vpa.HistogramOptions
+	if h.BucketSizeGrowthRatio != "" {
+		firstBucketSize, err := utils.ParseFloat(h.FirstBucketSize, 0)
+		assert.NoError(t, err)
+		ratio, err := utils.ParseFloat(h.BucketSizeGrowthRatio, 0)
+		assert.NoError(t, err)
+		options, err = vpa.NewExponentialHistogramOptions(maxValue, firstBucketSize, 1+ratio, 1e-10)
+		assert.NoError(t, err)
+	} else {
+		bucketSize, err := utils.ParseFloat(h.BucketSize, 0)
+		assert.NoError(t, err)
+		options, err = vpa.NewLinearHistogramOptions(maxValue, bucketSize, 1e-10)
+		assert.NoError(t, err)
+	}
+	return options
+}
+
+func TestApplyHistogramScale(t *testing.T) {
+	cpuConfig, memConfig := cpuConfigOf(t, map[string]string{}), memConfigOf(t, map[string]string{})
+	assert.NoError(t, applyHistogramScale(map[string]string{}, cpuConfig, memConfig))
+	assert.Equal(t, "0.1", cpuConfig.Percentile.Histogram.BucketSize)
+
+	assert.NoError(t, applyHistogramScale(map[string]string{"histogram-scale": "log"}, cpuConfig, memConfig))
+	assert.Equal(t, "", cpuConfig.Percentile.Histogram.BucketSize)
+	assert.Equal(t, "0.01", cpuConfig.Percentile.Histogram.FirstBucketSize)
+	assert.Equal(t, "1048576", memConfig.Percentile.Histogram.FirstBucketSize)
+	assert.Equal(t, "100", cpuConfig.Percentile.Histogram.MaxValue)
+
+	assert.Error(t, applyHistogramScale(map[string]string{"histogram-scale": "sqrt"}, cpuConfig, memConfig))
+}
+
+func TestHistogramScaleTailAccuracy(t *testing.T) {
+	// most of the usage is idle, the tail is clustered in a narrow band of 210m to 240m
+	var values []float64
+	for i := 0; i < 900; i++ {
+		values = append(values, 0.02+float64(i%10)*0.001)
+	}
+	for i := 0; i < 100; i++ {
+		values = append(values, 0.21+float64(i)*0.0003)
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	linearConfig, logConfig := cpuConfigOf(t, map[string]string{}), cpuConfigOf(t, map[string]string{})
+	assert.NoError(t, applyHistogramScale(map[string]string{"histogram-scale": "log"}, logConfig, memConfigOf(t, map[string]string{})))
+
+	now := time.Now()
+	linear := vpa.NewHistogram(histogramOptionsOf(t, linearConfig))
+	log := vpa.NewHistogram(histogramOptionsOf(t, logConfig))
+	for _, value := range values {
+		linear.AddSample(value, 1, now)
+		log.AddSample(value, 1, now)
+	}
+
+	for _, percentile := range []float64{0.99, 0.999} {
+		exact := sorted[int(math.Ceil(percentile*float64(len(sorted))))-1]
+		linearErr := math.Abs(linear.Percentile(percentile) - exact)
+		logErr := math.Abs(log.Percentile(percentile) - exact)
+		assert.Less(t, logErr, linearErr, "p%v", percentile*100)
+		assert.Less(t, logErr/exact, 0.1, "p%v", percentile*100)
+	}
+}
+
+func TestHistogramScaleChangesConfigHash(t *testing.T) {
+	linear, err := ConfigHash(cpuConfigOf(t, map[string]string{}), memConfigOf(t, map[string]string{}))
+	assert.NoError(t, err)
+	cpuConfig, memConfig := cpuConfigOf(t, map[string]string{}), memConfigOf(t, map[string]string{})
+	assert.NoError(t, applyHistogramScale(map[string]string{"histogram-scale": "log"}, cpuConfig, memConfig))
+	log, err := ConfigHash(cpuConfig, memConfig)
+	assert.NoError(t, err)
+	assert.NotEqual(t, linear, log)
+}
+
+func TestGetHistogramConfig(t *testing.T) {
+	assert.Equal(t, defaultCpuHistogram, cpuConfigOf(t, map[string]string{}).Percentile.Histogram)
+	assert.Equal(t, defaultMemHistogram, memConfigOf(t, map[string]string{}).Percentile.Histogram)
+
+	// the default cpu histogram saturates at 100 cores
+	options := histogramOptionsOf(t, cpuConfigOf(t, map[string]string{}))
+	assert.Equal(t, options.NumBuckets()-1, options.FindBucket(500))
+
+	cpuConfig := cpuConfigOf(t, map[string]string{
+		"cpu-histogram-halflife":    "12h",
+		"cpu-histogram-bucket-size": "0.5",
+		"cpu-histogram-max-value":   "1000",
+	})
+	assert.Equal(t, predictionapi.HistogramConfig{HalfLife: "12h", BucketSize: "0.5", MaxValue: "1000"}, cpuConfig.Percentile.Histogram)
+	options = histogramOptionsOf(t, cpuConfig)
+	assert.Less(t, options.FindBucket(500), options.NumBuckets()-1)
+
+	// unset keys fall back to the defaults, the memory histogram is independent
+	memConfig := memConfigOf(t, map[string]string{"cpu-histogram-max-value": "1000", "mem-histogram-max-value": "1048576000000"})
+	assert.Equal(t, "1048576000000", memConfig.Percentile.Histogram.MaxValue)
+	assert.Equal(t, defaultMemHistogram.BucketSize, memConfig.Percentile.Histogram.BucketSize)
+	assert.Equal(t, defaultMemHistogram.HalfLife, memConfig.Percentile.Histogram.HalfLife)
+
+	storageConfig, err := getEphemeralStorageConfig(map[string]string{"ephemeral-storage-histogram-max-value": "2097152000000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2097152000000", storageConfig.Percentile.Histogram.MaxValue)
+
+	for _, props := range []map[string]string{
+		{"cpu-histogram-halflife": "soon"},
+		{"cpu-histogram-halflife": "0s"},
+		{"cpu-histogram-bucket-size": "small"},
+		{"cpu-histogram-bucket-size": "0"},
+		{"cpu-histogram-bucket-size": "-0.1"},
+		{"cpu-histogram-max-value": "many"},
+		{"cpu-histogram-max-value": "0.1"},
+		{"cpu-histogram-bucket-size": "10", "cpu-histogram-max-value": "5"},
+	} {
+		_, err := getCpuConfig(props)
+		assert.Error(t, err, props)
+	}
+
+	_, err = getMemConfig(map[string]string{"mem-histogram-bucket-size": "0"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "mem-histogram-bucket-size")
+}
@@ -0,0 +1,256 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+// historyEstimationConfig enables computing the percentile from the raw per pod history instead of the predictor,
+// which is needed when the samples must be attributed to the pods they come from
+type historyEstimationConfig struct {
+	readiness *readinessWeightingConfig
+	blueGreen *blueGreenConfig
+	// winsorize is keyed by the resource prefix
+	winsorize map[string]*winsorizeConfig
+	// businessHours keeps only the samples in the business hours, so the off-hours idle doesn't drag down the percentile
+	businessHours *dailyWindows
+	// activeWindows is keyed by the resource prefix, it takes the place of the business hours for the resource
+	activeWindows map[string]*dailyWindows
+	scaledToZero  *scaledToZeroConfig
+	perPod        *perPodNormalizationConfig
+	// counterResets is keyed by the resource prefix, only the cpu usage is derived from a counter
+	counterResets map[string]*counterResetConfig
+}
+
+// getHistoryEstimationConfig returns nil if no handling on the raw history is enabled
+func getHistoryEstimationConfig(config map[string]string) (*historyEstimationConfig, error) {
+	readiness, err := getReadinessWeightingConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	blueGreen, err := getBlueGreenConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	winsorize := map[string]*winsorizeConfig{}
+	for _, prefix := range []string{"cpu", "mem"} {
+		winsorizeConfig, err := getWinsorizeConfig(config, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if winsorizeConfig != nil {
+			winsorize[prefix] = winsorizeConfig
+		}
+	}
+	var businessHours *dailyWindows
+	if windowsStr, exists := config["business-hours"]; exists {
+		businessHours, err = parseDailyWindows(windowsStr, config["business-hours-timezone"])
+		if err != nil {
+			return nil, fmt.Errorf("parse business-hours failed: %v", err)
+		}
+	}
+	activeWindows := map[string]*dailyWindows{}
+	for _, prefix := range []string{"cpu", "mem"} {
+		windowsStr, exists := config[prefix+"-active-window"]
+		if !exists {
+			continue
+		}
+		activeWindows[prefix], err = parseDailyWindows(windowsStr, config["active-window-timezone"])
+		if err != nil {
+			return nil, fmt.Errorf("parse %s-active-window failed: %v", prefix, err)
+		}
+	}
+	scaledToZero, err := getScaledToZeroConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	perPod := getPerPodNormalizationConfig(config)
+	counterResets := map[string]*counterResetConfig{}
+	cpuCounterReset, err := getCounterResetConfig(config, "cpu")
+	if err != nil {
+		return nil, err
+	}
+	if cpuCounterReset != nil {
+		counterResets["cpu"] = cpuCounterReset
+	}
+	if readiness == nil && blueGreen == nil && len(winsorize) == 0 && businessHours == nil && len(activeWindows) == 0 && scaledToZero == nil && perPod == nil && len(counterResets) == 0 {
+		return nil, nil
+	}
+	return &historyEstimationConfig{readiness: readiness, blueGreen: blueGreen, winsorize: winsorize, businessHours: businessHours, activeWindows: activeWindows, scaledToZero: scaledToZero, perPod: perPod, counterResets: counterResets}, nil
+}
+
+// needsPods tells whether the pods are needed to attribute the samples
+func (c *historyEstimationConfig) needsPods() bool {
+	return c.readiness != nil || c.blueGreen != nil
+}
+
+// appliesTo tells whether the resource of the prefix is estimated from the raw history
+func (c *historyEstimationConfig) appliesTo(prefix string) bool {
+	return c.needsPods() || c.winsorize[prefix] != nil || c.windowsOf(prefix) != nil || c.scaledToZero != nil || c.perPod.appliesTo(prefix) || c.counterResets[prefix] != nil
+}
+
+// windowsOf returns the recurring daily windows the samples of the resource are restricted to, nil if not restricted
+func (c *historyEstimationConfig) windowsOf(prefix string) *dailyWindows {
+	if windows, exists := c.activeWindows[prefix]; exists {
+		return windows
+	}
+	return c.businessHours
+}
+
+func (c *historyEstimationConfig) String() string {
+	var handlings []string
+	if c.perPod != nil {
+		handlings = append(handlings, "normalized per pod by the time-weighted replicas")
+	}
+	if c.businessHours != nil {
+		handlings = append(handlings, "within business hours")
+	}
+	if len(c.activeWindows) > 0 {
+		handlings = append(handlings, "within the active windows")
+	}
+	if c.scaledToZero != nil {
+		handlings = append(handlings, "excluding the scaled-to-zero periods")
+	}
+	if len(c.counterResets) > 0 {
+		handlings = append(handlings, "excluding the counter resets")
+	}
+	if len(c.winsorize) > 0 {
+		handlings = append(handlings, "winsorized")
+	}
+	if c.blueGreen != nil {
+		handlings = append(handlings, "prefer the stable color")
+	}
+	if c.readiness != nil {
+		handlings = append(handlings, "weighted by pod readiness")
+	}
+	return "recomputed from the history " + strings.Join(handlings, ", ")
+}
+
+func listTargetPods(ctx context.Context, kubeClient client.Client, namespace string, selector labels.Selector) ([]corev1.Pod, error) {
+	opts := []client.ListOption{client.InNamespace(namespace)}
+	if selector != nil {
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	}
+	podList := &corev1.PodList{}
+	if err := kubeClient.List(ctx, podList, opts...); err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+// estimateFromHistory computes the percentile with margin from the raw history of each pod. It is not found if no
+// sample is left, such as none is in the active window, then the predicted value of the whole history is kept. The
+// outliers are dropped only if opted in.
+func (e *PercentileResourceEstimator) estimateFromHistory(namer metricnaming.MetricNamer, cfg *predictionconfig.Config, prefix string, outliers *outlierConfig, pods []corev1.Pod, historyConfig *historyEstimationConfig) (float64, bool, error) {
+	if !historyConfig.appliesTo(prefix) {
+		return 0, false, nil
+	}
+	historyLength, err := utils.ParseDuration(cfg.Percentile.HistoryLength)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse history length failed: %v", err)
+	}
+	sampleInterval, err := utils.ParseDuration(cfg.Percentile.SampleInterval)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse sample interval failed: %v", err)
+	}
+	percentile, err := utils.ParseFloat(cfg.Percentile.Percentile, 0.99)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse percentile failed: %v", err)
+	}
+	marginFraction, err := utils.ParseFloat(cfg.Percentile.MarginFraction, 0)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse margin fraction failed: %v", err)
+	}
+
+	now := e.now()
+	var tsList []*common.TimeSeries
+	if historyConfig.perPod.appliesTo(prefix) {
+		var found bool
+		tsList, found, err = e.queryPerPodHistory(historyConfig.perPod, prefix, now.Add(-historyLength), now, sampleInterval)
+		if err != nil || !found {
+			return 0, false, err
+		}
+	} else {
+		tsList, err = e.History.QueryTimeSeries(namer, now.Add(-historyLength), now, sampleInterval)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	var scaledToZero map[int64]struct{}
+	if historyConfig.scaledToZero != nil && historyConfig.scaledToZero.namer != nil {
+		replicasList, err := e.History.QueryTimeSeries(historyConfig.scaledToZero.namer, now.Add(-historyLength), now, sampleInterval)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to query replicas: %v", err)
+		}
+		scaledToZero = zeroReplicasTimestamps(replicasList)
+	}
+	var resets map[string][]int64
+	if counterReset := historyConfig.counterResets[prefix]; counterReset != nil && counterReset.namer != nil {
+		counterList, err := e.History.QueryTimeSeries(counterReset.namer, now.Add(-historyLength), now, sampleInterval)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to query the counter: %v", err)
+		}
+		resets = counterResets(counterList)
+	}
+	for _, ts := range tsList {
+		ts.Samples = discardCounterResets(ts.Samples, resetsOf(ts, resets), sampleInterval)
+		ts.Samples = discardOutliers(ts.Samples, outliers)
+		if windows := historyConfig.windowsOf(prefix); windows != nil {
+			ts.Samples = samplesWithin(ts.Samples, windows)
+		}
+		if len(scaledToZero) > 0 {
+			ts.Samples = excludeTimestamps(ts.Samples, scaledToZero)
+		}
+	}
+	winsorize(tsList, historyConfig.winsorize[prefix])
+
+	if historyConfig.blueGreen != nil {
+		tsList = historyConfig.blueGreen.selectSeries(tsList, pods)
+	}
+
+	var samples []weightedSample
+	if historyConfig.readiness != nil {
+		samples = weightSamplesByReadiness(tsList, podReadinessOf(pods), historyConfig.readiness)
+	} else {
+		samples = unweightedSamples(tsList)
+	}
+
+	value, found := weightedPercentile(samples, math.Min(percentile, 1))
+	if !found {
+		return 0, false, nil
+	}
+	return value * (1 + marginFraction), true, nil
+}
+
+// samplesWithin keeps the samples in the windows
+func samplesWithin(samples []common.Sample, windows *dailyWindows) []common.Sample {
+	var result []common.Sample
+	for _, sample := range samples {
+		if windows.Contains(time.Unix(sample.Timestamp, 0)) {
+			result = append(result, sample)
+		}
+	}
+	return result
+}
+
+func unweightedSamples(tsList []*common.TimeSeries) []weightedSample {
+	var result []weightedSample
+	for _, ts := range tsList {
+		for _, sample := range ts.Samples {
+			result = append(result, weightedSample{value: sample.Value, weight: 1})
+		}
+	}
+	return result
+}
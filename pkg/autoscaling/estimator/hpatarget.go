@@ -0,0 +1,107 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const (
+	// MetadataSuggestedHPATargetUtilization is the metadata key of the suggested cpu utilization target of HPA
+	// in percentage, it is implied by the recommended cpu request
+	MetadataSuggestedHPATargetUtilization = "suggested-hpa-target-utilization"
+
+	defaultHPATargetUsageWindow = time.Hour
+)
+
+// hpaTargetConfig enables suggesting the HPA cpu utilization target as the recent usage / recommended request,
+// so EVPA and EHPA are kept coherent when both are in play
+type hpaTargetConfig struct {
+	usageWindow time.Duration
+}
+
+// getHPATargetConfig returns nil if 'suggest-hpa-target' is not set
+func getHPATargetConfig(config map[string]string) (*hpaTargetConfig, error) {
+	value, exists := config["suggest-hpa-target"]
+	if !exists {
+		return nil, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("parse suggest-hpa-target failed: %v", err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+	usageWindow, err := utils.ParseDuration(config["hpa-target-usage-window"])
+	if err != nil {
+		return nil, fmt.Errorf("parse hpa-target-usage-window failed: %v", err)
+	}
+	if usageWindow == 0 {
+		usageWindow = defaultHPATargetUsageWindow
+	}
+	if usageWindow < 0 {
+		return nil, fmt.Errorf("hpa-target-usage-window must be positive, got %s", config["hpa-target-usage-window"])
+	}
+	return &hpaTargetConfig{usageWindow: usageWindow}, nil
+}
+
+// suggestHPATargetUtilization returns the suggested utilization in percentage of the recommended cpu request
+func (e *PercentileResourceEstimator) suggestHPATargetUtilization(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, cpuRequest float64, cfg *hpaTargetConfig) (int32, bool, error) {
+	if e.History == nil || cpuRequest <= 0 {
+		return 0, false, nil
+	}
+
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+	cpuMetricNamer := newContainerMetricNamer(evpa, e.caller(evpa), containerName, corev1.ResourceCPU, selector)
+
+	now := e.now()
+	tsList, err := e.History.QueryTimeSeries(cpuMetricNamer, now.Add(-cfg.usageWindow), now, time.Minute)
+	if err != nil {
+		return 0, false, err
+	}
+	usage := averageByTimestamp(tsList)
+	if len(usage) == 0 {
+		return 0, false, nil
+	}
+	sum := 0.0
+	for _, value := range usage {
+		sum += value
+	}
+	recentUsage := sum / float64(len(usage))
+
+	utilization := int32(math.Round(recentUsage / cpuRequest * 100))
+	if utilization < 1 {
+		utilization = 1
+	}
+	if utilization > 100 {
+		utilization = 100
+	}
+	return utilization, true, nil
+}
+
+// SuggestedHPATargetUtilization returns the suggested HPA cpu utilization target of the estimation, the EHPA
+// controller can use it as the target to keep coherent with the recommended requests
+func SuggestedHPATargetUtilization(estimation *ResourceEstimation) (int32, bool) {
+	value, exists := estimation.Metadata[MetadataSuggestedHPATargetUtilization]
+	if !exists {
+		return 0, false
+	}
+	utilization, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(utilization), true
+}
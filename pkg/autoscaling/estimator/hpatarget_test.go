@@ -0,0 +1,48 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestSuggestHPATargetUtilization(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(2),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	e.Clock = clock.NewFakeClock(now)
+	// recent usage 1.2 cores on average
+	e.History = &stepHistory{usage: map[string]func(time.Time) float64{
+		"cpu": func(t time.Time) float64 {
+			if t.Before(now.Add(-4 * time.Minute)) {
+				return 1.0
+			}
+			return 1.4
+		},
+	}}
+
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"suggest-hpa-target": "true", "hpa-target-usage-window": "9m"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", estimation.Resources.Cpu().String())
+	utilization, found := SuggestedHPATargetUtilization(estimation)
+	assert.True(t, found)
+	// 1.2 / 2
+	assert.Equal(t, int32(60), utilization)
+
+	// not suggested by default
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	_, found = SuggestedHPATargetUtilization(estimation)
+	assert.False(t, found)
+
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"suggest-hpa-target": "on"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
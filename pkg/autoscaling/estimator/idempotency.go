@@ -0,0 +1,46 @@
+package estimator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// MetadataIdempotencyKey is the metadata key of the idempotency key of the recommendation
+	MetadataIdempotencyKey = "idempotency-key"
+)
+
+// IdempotencyKey is the stable key of a recommended resource value, it is the same for the identical recommendation
+// so a re-apply of an already applied value can be skipped
+func IdempotencyKey(namespace string, workload string, container string, resourceName corev1.ResourceName, quantity resource.Quantity) string {
+	return hashKey(fmt.Sprintf("%s/%s/%s/%s=%s", namespace, workload, container, resourceName, canonicalValue(resourceName, quantity)))
+}
+
+// RecommendationIdempotencyKey is the stable key of all the recommended resources of a container
+func RecommendationIdempotencyKey(namespace string, workload string, container string, resources corev1.ResourceList) string {
+	var keys []string
+	for resourceName, quantity := range resources {
+		keys = append(keys, IdempotencyKey(namespace, workload, container, resourceName, quantity))
+	}
+	sort.Strings(keys)
+	return hashKey(strings.Join(keys, ","))
+}
+
+// canonicalValue formats the value independent of the quantity format, such as 1 and 1000m
+func canonicalValue(resourceName corev1.ResourceName, quantity resource.Quantity) string {
+	if resourceName == corev1.ResourceCPU {
+		return fmt.Sprintf("%dm", quantity.MilliValue())
+	}
+	return fmt.Sprintf("%d", quantity.Value())
+}
+
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,51 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	key := IdempotencyKey("default", "nginx", "nginx", corev1.ResourceCPU, resource.MustParse("1"))
+	assert.Equal(t, key, IdempotencyKey("default", "nginx", "nginx", corev1.ResourceCPU, resource.MustParse("1000m")))
+	assert.NotEqual(t, key, IdempotencyKey("default", "nginx", "nginx", corev1.ResourceCPU, resource.MustParse("1100m")))
+	assert.NotEqual(t, key, IdempotencyKey("default", "nginx", "sidecar", corev1.ResourceCPU, resource.MustParse("1")))
+
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	key = RecommendationIdempotencyKey("default", "nginx", "nginx", resources)
+	assert.Equal(t, key, RecommendationIdempotencyKey("default", "nginx", "nginx", resources.DeepCopy()))
+	resources[corev1.ResourceMemory] = resource.MustParse("2Gi")
+	assert.NotEqual(t, key, RecommendationIdempotencyKey("default", "nginx", "nginx", resources))
+}
+
+func TestEstimateResourcesIdempotencyKey(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	again, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, estimation.Metadata[MetadataIdempotencyKey])
+	assert.Equal(t, estimation.Metadata[MetadataIdempotencyKey], again.Metadata[MetadataIdempotencyKey])
+
+	// the recommended cpu changes
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.5),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	changed, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, estimation.Metadata[MetadataIdempotencyKey], changed.Metadata[MetadataIdempotencyKey])
+}
@@ -0,0 +1,61 @@
+package estimator
+
+import (
+	"context"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ReasonGloballyDisabled means all the recommendation changes are stopped by the global kill switch
+	ReasonGloballyDisabled = "GloballyDisabled"
+
+	// KillSwitchConfigMapKey is the key of the kill switch ConfigMap, the switch is active if it is "true"
+	KillSwitchConfigMapKey = "disabled"
+)
+
+// KillSwitch stops all the recommendation changes cluster-wide during incidents, without editing every EVPA.
+// It is active if Disabled is set, or the watched ConfigMap says so. The estimations are still computed.
+type KillSwitch struct {
+	Disabled bool
+	// Client reads the ConfigMap, it is optional if the ConfigMap is not used
+	Client    client.Reader
+	Namespace string
+	Name      string
+}
+
+// Active tells whether the kill switch is on, it is off for a nil KillSwitch
+func (k *KillSwitch) Active(ctx context.Context) bool {
+	if k == nil {
+		return false
+	}
+	if k.Disabled {
+		return true
+	}
+	if k.Client == nil || k.Name == "" {
+		return false
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := k.Client.Get(ctx, types.NamespacedName{Namespace: k.Namespace, Name: k.Name}, configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to get kill switch configmap.", "configmap", klog.KRef(k.Namespace, k.Name))
+		}
+		return false
+	}
+	value, exists := configMap.Data[KillSwitchConfigMapKey]
+	if !exists {
+		return false
+	}
+	active, err := strconv.ParseBool(value)
+	if err != nil {
+		klog.ErrorS(err, "Failed to parse kill switch.", "configmap", klog.KRef(k.Namespace, k.Name), "value", value)
+		return false
+	}
+	return active
+}
@@ -0,0 +1,60 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestKillSwitch(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "crane-kill-switch", Namespace: "crane-system"},
+		Data:       map[string]string{KillSwitchConfigMapKey: "true"},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(configMap).Build()
+	killSwitch := &KillSwitch{Client: kubeClient, Namespace: "crane-system", Name: "crane-kill-switch"}
+
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	e.KillSwitch = killSwitch
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+
+	// active, the current requests are emitted and the computed is kept
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonGloballyDisabled, estimation.Reason)
+	assert.Equal(t, "1", estimation.Resources.Cpu().String())
+	assert.Equal(t, "250m", estimation.Computed.Cpu().String())
+
+	// switched off, the emission resumes
+	configMap.Data[KillSwitchConfigMapKey] = "false"
+	assert.NoError(t, kubeClient.Update(context.TODO(), configMap))
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Empty(t, estimation.Reason)
+	assert.Equal(t, "250m", estimation.Resources.Cpu().String())
+
+	// the flag overrides the configmap
+	killSwitch.Disabled = true
+	assert.True(t, killSwitch.Active(context.TODO()))
+
+	// the configmap is absent
+	assert.False(t, (&KillSwitch{Client: kubeClient, Namespace: "crane-system", Name: "absent"}).Active(context.TODO()))
+	var nilSwitch *KillSwitch
+	assert.False(t, nilSwitch.Active(context.TODO()))
+}
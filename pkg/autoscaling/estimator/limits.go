@@ -0,0 +1,279 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const (
+	// LimitBelowRequestRaiseLimit raises the limit proportionally to the current limit to request ratio
+	LimitBelowRequestRaiseLimit = "raise-limit"
+	// LimitBelowRequestCapRequest caps the recommended request to the current limit
+	LimitBelowRequestCapRequest = "cap-request"
+	// LimitBelowRequestError fails the estimation
+	LimitBelowRequestError = "error"
+)
+
+// memLimitHeadroomConfig is the minimum gap between the memory request and limit, the greater of the absolute
+// and the fractional gap is enforced
+type memLimitHeadroomConfig struct {
+	absolute resource.Quantity
+	fraction float64
+}
+
+// getMemLimitHeadroomConfig returns nil if neither 'mem-limit-min-headroom' nor 'mem-limit-min-headroom-fraction' is set
+func getMemLimitHeadroomConfig(config map[string]string) (*memLimitHeadroomConfig, error) {
+	absoluteStr, absoluteExists := config["mem-limit-min-headroom"]
+	fractionStr, fractionExists := config["mem-limit-min-headroom-fraction"]
+	if !absoluteExists && !fractionExists {
+		return nil, nil
+	}
+
+	cfg := &memLimitHeadroomConfig{}
+	if absoluteExists {
+		absolute, err := resource.ParseQuantity(absoluteStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse mem-limit-min-headroom failed: %v", err)
+		}
+		if absolute.Sign() < 0 {
+			return nil, fmt.Errorf("mem-limit-min-headroom must not be negative, got %s", absoluteStr)
+		}
+		cfg.absolute = absolute
+	}
+	fraction, err := utils.ParseFloat(fractionStr, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse mem-limit-min-headroom-fraction failed: %v", err)
+	}
+	if fraction < 0 {
+		return nil, fmt.Errorf("mem-limit-min-headroom-fraction must not be negative, got %v", fraction)
+	}
+	cfg.fraction = fraction
+
+	return cfg, nil
+}
+
+// enforceMinHeadroom raises the limit to the request plus the minimum headroom if it is too close to the request
+func (c *memLimitHeadroomConfig) enforceMinHeadroom(request resource.Quantity, limit resource.Quantity) resource.Quantity {
+	headroom := int64(float64(request.Value()) * c.fraction)
+	if c.absolute.Value() > headroom {
+		headroom = c.absolute.Value()
+	}
+	minLimit := request.Value() + headroom
+	if limit.Value() >= minLimit {
+		return limit
+	}
+	return *resource.NewQuantity(minLimit, resource.BinarySI)
+}
+
+// getLimitRatios returns the ratios of the 'cpu-limit-ratio' and 'mem-limit-ratio' by the resource, the limit is the
+// recommended request times the ratio. A ratio of 1 makes the limit equal to the request, the unset ones keep the
+// current limits.
+func getLimitRatios(config map[string]string) (map[corev1.ResourceName]float64, error) {
+	ratios := map[corev1.ResourceName]float64{}
+	for key, resourceName := range map[string]corev1.ResourceName{
+		"cpu-limit-ratio": corev1.ResourceCPU,
+		"mem-limit-ratio": corev1.ResourceMemory,
+	} {
+		ratioStr, exists := config[key]
+		if !exists {
+			continue
+		}
+		ratio, err := utils.ParseFloat(ratioStr, 0)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s failed: %v", key, err)
+		}
+		if ratio < 1 {
+			return nil, fmt.Errorf("%s must be at least 1, got %v", key, ratio)
+		}
+		ratios[resourceName] = ratio
+	}
+	return ratios, nil
+}
+
+func getLimitBelowRequestPolicy(config map[string]string) (string, error) {
+	policy, exists := config["limit-below-request-policy"]
+	if !exists {
+		return LimitBelowRequestRaiseLimit, nil
+	}
+	switch policy {
+	case LimitBelowRequestRaiseLimit, LimitBelowRequestCapRequest, LimitBelowRequestError:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown limit-below-request-policy %q", policy)
+	}
+}
+
+// recommendLimits returns the recommended limits for the recommended requests. The limits of the resources with a
+// limit ratio are derived from the requests. The targets, such as the limits estimated by the limit percentiles, are
+// used for the others, they are at least the requests. The others are kept as the current ones unless they are below the
+// recommended requests, which is handled by the 'limit-below-request-policy' so request <= limit holds. Then the memory limit is raised to keep the minimum headroom above the request, so brief
+// spikes don't OOM. Resources without a current limit stay unlimited. The requests may be capped by the policy.
+func recommendLimits(currRes *corev1.ResourceRequirements, requests corev1.ResourceList, targets corev1.ResourceList, config map[string]string) (corev1.ResourceList, error) {
+	policy, err := getLimitBelowRequestPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+	headroomConfig, err := getMemLimitHeadroomConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	ratios, err := getLimitRatios(config)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := corev1.ResourceList{}
+	for resourceName, request := range requests {
+		if ratio, exists := ratios[resourceName]; exists {
+			limits[resourceName] = scaleQuantity(resourceName, request, ratio)
+			continue
+		}
+		if target, exists := targets[resourceName]; exists {
+			if target.Cmp(request) < 0 {
+				target = request
+			}
+			limits[resourceName] = target.DeepCopy()
+			continue
+		}
+		if currRes == nil {
+			continue
+		}
+		limit, exists := currRes.Limits[resourceName]
+		if !exists {
+			continue
+		}
+		if limit.Cmp(request) >= 0 {
+			limits[resourceName] = limit.DeepCopy()
+			continue
+		}
+
+		switch policy {
+		case LimitBelowRequestError:
+			return nil, fmt.Errorf("current %s limit %s is below the recommended request %s", resourceName, limit.String(), request.String())
+		case LimitBelowRequestCapRequest:
+			requests[resourceName] = limit.DeepCopy()
+			limits[resourceName] = limit.DeepCopy()
+		default:
+			limits[resourceName] = raiseLimit(resourceName, request, limit, currRes.Requests[resourceName])
+		}
+	}
+
+	memRequest, requestExists := requests[corev1.ResourceMemory]
+	memLimit, limitExists := limits[corev1.ResourceMemory]
+	if headroomConfig != nil && requestExists && limitExists {
+		limits[corev1.ResourceMemory] = headroomConfig.enforceMinHeadroom(memRequest, memLimit)
+	}
+	return limits, nil
+}
+
+// getLimitPercentiles returns the 'cpu-limit-percentile' and 'mem-limit-percentile' by the resource if 'target-limits'
+// is set, the limits are estimated by the percentiles, which are 0.999 by default. Nil if the limits are not targeted.
+func getLimitPercentiles(config map[string]string) (map[corev1.ResourceName]string, error) {
+	enabledStr, exists := config["target-limits"]
+	if !exists {
+		return nil, nil
+	}
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse target-limits failed: %v", err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+	percentiles := map[corev1.ResourceName]string{}
+	for key, resourceName := range map[string]corev1.ResourceName{
+		"cpu-limit-percentile": corev1.ResourceCPU,
+		"mem-limit-percentile": corev1.ResourceMemory,
+	} {
+		percentile, err := getPercentile(config, key, "0.999")
+		if err != nil {
+			return nil, err
+		}
+		percentiles[resourceName] = percentile
+	}
+	return percentiles, nil
+}
+
+// estimateLimits returns the limits of the container at the limit percentiles, the history and the sample configs are
+// the same as the requests
+func (e *PercentileResourceEstimator) estimateLimits(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, percentiles map[corev1.ResourceName]string) (corev1.ResourceList, error) {
+	cpuConfig, err := getCpuConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	memConfig, err := getMemConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyHistogramScale(config, cpuConfig, memConfig); err != nil {
+		return nil, err
+	}
+	configs := map[corev1.ResourceName]*predictionconfig.Config{corev1.ResourceCPU: cpuConfig, corev1.ResourceMemory: memConfig}
+	sampleSelection, err := getSampleSelection(config)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+	caller := e.caller(evpa)
+	controlled := controlledResourcesOf(evpa, containerName)
+
+	limits := corev1.ResourceList{}
+	var predictErrs []error
+	var noValueErrs []error
+	for resourceName, percentile := range percentiles {
+		if !controlled.controls(resourceName) {
+			continue
+		}
+		// only the percentile differs from the request
+		cfg := *configs[resourceName]
+		percentileConfig := *cfg.Percentile
+		percentileConfig.Percentile = percentile
+		cfg.Percentile = &percentileConfig
+
+		metricNamer := newContainerMetricNamer(evpa, caller, containerName, resourceName, selector)
+		tsList, err := e.Predictor.QueryRealtimePredictedValuesOnce(ctx, metricNamer, cfg)
+		if err != nil {
+			predictErrs = append(predictErrs, err)
+			continue
+		}
+		sample, selected := selectSample(seriesSamples(largestSeries(tsList, sampleSelection)), sampleSelection)
+		if !selected {
+			noValueErrs = append(noValueErrs, noValueError(ctx, e.Predictor, metricNamer))
+			continue
+		}
+		limits[resourceName] = boundQuantity(resourceName, sample.Value)
+	}
+	if len(limits) == 0 {
+		return nil, allFailedError(predictErrs, noValueErrs)
+	}
+	return limits, nil
+}
+
+// raiseLimit keeps the current limit to request ratio for the recommended request, the limit is at least the request
+func raiseLimit(resourceName corev1.ResourceName, request resource.Quantity, currLimit resource.Quantity, currRequest resource.Quantity) resource.Quantity {
+	if currRequest.IsZero() || currLimit.Cmp(currRequest) <= 0 {
+		return request.DeepCopy()
+	}
+	ratio := float64(currLimit.MilliValue()) / float64(currRequest.MilliValue())
+	return scaleQuantity(resourceName, request, ratio)
+}
+
+func scaleQuantity(resourceName corev1.ResourceName, quantity resource.Quantity, ratio float64) resource.Quantity {
+	if resourceName == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(int64(float64(quantity.MilliValue())*ratio), quantity.Format)
+	}
+	return *resource.NewQuantity(int64(float64(quantity.Value())*ratio), quantity.Format)
+}
@@ -0,0 +1,189 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestMemLimitMinHeadroom(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.5),
+		"memory": newSeries(1024 * 1024 * 1024),
+	})
+	currRes := &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("1100Mi"),
+		},
+	}
+
+	// computed limit is too close to the 1Gi request, raised by the fractional gap
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-limit-min-headroom-fraction": "0.25"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "1280Mi", estimation.Limits.Memory().String())
+	assert.Equal(t, "2", estimation.Limits.Cpu().String())
+
+	// the greater of the absolute and fractional gap
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-limit-min-headroom-fraction": "0.25", "mem-limit-min-headroom": "512Mi"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "1536Mi", estimation.Limits.Memory().String())
+
+	// enough headroom, the limit is kept
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-limit-min-headroom": "64Mi"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "1100Mi", estimation.Limits.Memory().String())
+
+	// unlimited stays unlimited
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-limit-min-headroom": "64Mi"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Empty(t, estimation.Limits)
+
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-limit-min-headroom": "-1Mi"}, "nginx", currRes)
+	assert.Error(t, err)
+}
+
+func TestLimitBelowRequestPolicy(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(2),
+		"memory": newSeries(1024 * 1024 * 1024),
+	})
+	// the recommended 2 cores exceeds the current limit, memory limit is above the recommendation
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("2Gi"),
+		},
+	}
+
+	// default raises the limit proportionally to the current limit to request ratio
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", estimation.Resources.Cpu().String())
+	assert.Equal(t, "4", estimation.Limits.Cpu().String())
+	assert.Equal(t, "2Gi", estimation.Limits.Memory().String())
+
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"limit-below-request-policy": LimitBelowRequestRaiseLimit}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "4", estimation.Limits.Cpu().String())
+
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"limit-below-request-policy": LimitBelowRequestCapRequest}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", estimation.Resources.Cpu().String())
+	assert.Equal(t, "1", estimation.Limits.Cpu().String())
+	assert.Equal(t, "1Gi", estimation.Resources.Memory().String())
+
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"limit-below-request-policy": LimitBelowRequestError}, "nginx", currRes)
+	assert.Error(t, err)
+
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"limit-below-request-policy": "ignore"}, "nginx", currRes)
+	assert.Error(t, err)
+}
+
+func TestGetResourceEstimationWithLimits(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.5),
+		"memory": newSeries(1024 * 1024 * 1024),
+	})
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse("1"),
+			corev1.ResourceMemory:           resource.MustParse("2Gi"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse("4"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+		},
+	}
+
+	// ratio > 1
+	requirements, err := e.GetResourceEstimationWithLimits(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-limit-ratio": "2", "mem-limit-ratio": "1.5"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", requirements.Requests.Cpu().String())
+	assert.Equal(t, "1Gi", requirements.Requests.Memory().String())
+	assert.Equal(t, "1", requirements.Limits.Cpu().String())
+	assert.Equal(t, "1536Mi", requirements.Limits.Memory().String())
+	// the resources not estimated fall back to the current ones
+	assert.Equal(t, "1Gi", requirements.Requests.StorageEphemeral().String())
+	assert.Equal(t, "2Gi", requirements.Limits.StorageEphemeral().String())
+
+	// ratio = 1, the limits equal the requests
+	requirements, err = e.GetResourceEstimationWithLimits(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-limit-ratio": "1", "mem-limit-ratio": "1"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", requirements.Limits.Cpu().String())
+	assert.Equal(t, "1Gi", requirements.Limits.Memory().String())
+
+	// missing config, the current cpu limit is kept and the memory stays unlimited
+	requirements, err = e.GetResourceEstimationWithLimits(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "4", requirements.Limits.Cpu().String())
+	assert.NotContains(t, requirements.Limits, corev1.ResourceMemory)
+
+	// the ratio limits the unlimited memory, the min headroom still applies
+	requirements, err = e.GetResourceEstimationWithLimits(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-limit-ratio": "1", "mem-limit-min-headroom": "256Mi"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1280Mi", requirements.Limits.Memory().String())
+	assert.NotContains(t, requirements.Limits, corev1.ResourceCPU)
+
+	for _, ratio := range []string{"0.5", "0", "double"} {
+		_, err = e.GetResourceEstimationWithLimits(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-limit-ratio": ratio}, "nginx", currRes)
+		assert.Error(t, err, ratio)
+	}
+}
+
+func TestEstimateResourcesTargetLimits(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":          newSeries(0.25),
+		"cpu@0.999":    newSeries(0.8),
+		"cpu@0.9999":   newSeries(1.2),
+		"memory":       newSeries(256 * 1024 * 1024),
+		"memory@0.999": newSeries(128 * 1024 * 1024),
+	})
+	currRes := &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"target-limits": "true"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", estimation.Resources.Cpu().String())
+	assert.Equal(t, "800m", estimation.Limits.Cpu().String())
+	// the limit is at least the request
+	assert.Equal(t, "256Mi", estimation.Limits.Memory().String())
+	if assert.Len(t, predictor.onceQueries["nginx/cpu"], 1) {
+		assert.Equal(t, "0.999", predictor.onceQueries["nginx/cpu"][0].Percentile.Percentile)
+	}
+
+	// the configured percentile, the limit ratio wins over it
+	config := map[string]string{"target-limits": "true", "cpu-limit-percentile": "0.9999", "mem-limit-ratio": "2"}
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "1200m", estimation.Limits.Cpu().String())
+	assert.Equal(t, "512Mi", estimation.Limits.Memory().String())
+
+	// not targeted, the current limits are kept
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"target-limits": "false"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", estimation.Limits.Cpu().String())
+
+	for _, config := range []map[string]string{
+		{"target-limits": "yes"},
+		{"target-limits": "true", "cpu-limit-percentile": "1.5"},
+		{"target-limits": "true", "mem-limit-percentile": "0"},
+	} {
+		_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+		assert.Error(t, err, config)
+	}
+}
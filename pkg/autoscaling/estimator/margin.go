@@ -0,0 +1,60 @@
+package estimator
+
+import (
+	"fmt"
+	"math"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+// getAbsoluteMargins returns the absolute margins of 'cpu-margin-absolute' and 'mem-margin-absolute', such as 100m
+// and 128Mi. The resources without the config have no absolute margin.
+func getAbsoluteMargins(config map[string]string) (map[corev1.ResourceName]resource.Quantity, error) {
+	margins := map[corev1.ResourceName]resource.Quantity{}
+	for resourceName, prefix := range map[corev1.ResourceName]string{corev1.ResourceCPU: "cpu", corev1.ResourceMemory: "mem"} {
+		marginStr, exists := config[prefix+"-margin-absolute"]
+		if !exists {
+			continue
+		}
+		margin, err := resource.ParseQuantity(marginStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s-margin-absolute failed: %v", prefix, err)
+		}
+		if margin.Sign() < 0 {
+			return nil, fmt.Errorf("%s-margin-absolute must not be negative, got %s", prefix, marginStr)
+		}
+		margins[resourceName] = margin
+	}
+	return margins, nil
+}
+
+// applyAbsoluteMargins raises the estimated resources so the effective margin is max(fraction*value, absolute). The
+// estimated values already include the fractional margin, the value without margin is derived back from the fraction.
+// The resources fell back to the current requests are skipped, or the margin would ratchet them up on every round.
+func applyAbsoluteMargins(resources corev1.ResourceList, margins map[corev1.ResourceName]resource.Quantity, configs map[corev1.ResourceName]*predictionconfig.Config, fellBack map[corev1.ResourceName]bool, graph *ExplanationGraph) {
+	for resourceName, margin := range margins {
+		quantity, exists := resources[resourceName]
+		if !exists || fellBack[resourceName] || configs[resourceName] == nil {
+			continue
+		}
+		marginFraction, err := utils.ParseFloat(configs[resourceName].Percentile.MarginFraction, 0)
+		if err != nil {
+			marginFraction = 0
+		}
+		value := quantityValue(resourceName, quantity)
+		withAbsolute := value/(1+marginFraction) + quantityValue(resourceName, margin)
+		if withAbsolute <= value {
+			continue
+		}
+		if resourceName == corev1.ResourceCPU {
+			resources[resourceName] = *resource.NewMilliQuantity(int64(math.Round(withAbsolute*1000)), resource.DecimalSI)
+		} else {
+			resources[resourceName] = *resource.NewQuantity(int64(math.Round(withAbsolute)), resource.BinarySI)
+		}
+		graph.addStep(resourceName, ExplanationNodeMargin, "margin-absolute", quantityValue(resourceName, resources[resourceName]), fmt.Sprintf("absolute margin %s dominates the margin-fraction %s", margin.String(), configs[resourceName].Percentile.MarginFraction))
+	}
+}
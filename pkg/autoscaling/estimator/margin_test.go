@@ -0,0 +1,62 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestEstimateResourcesAbsoluteMargin(t *testing.T) {
+	config := map[string]string{
+		"cpu-margin-absolute": "100m",
+		"mem-margin-absolute": "128Mi",
+	}
+
+	// the absolute margin wins for the small values, the predicted values include the default 0.15 margin fraction
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.0115),
+		"memory": newSeries(1.15 * 100 * 1024 * 1024),
+	})
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "110m", resources.Cpu().String())
+	assert.Equal(t, "228Mi", resources.Memory().String())
+
+	// the fractional margin wins for the large values
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(2.3),
+		"memory": newSeries(4 * 1024 * 1024 * 1024),
+	})
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2300m", resources.Cpu().String())
+	assert.Equal(t, "4Gi", resources.Memory().String())
+
+	// no absolute margin by default
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.0115),
+		"memory": newSeries(1.15 * 100 * 1024 * 1024),
+	})
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "11m", resources.Cpu().String())
+
+	// the fallback to the current requests has no margin
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{})
+	config["fallback-to-current"] = "true"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "10m", resources.Cpu().String())
+
+	for _, invalid := range []map[string]string{{"cpu-margin-absolute": "-100m"}, {"mem-margin-absolute": "lots"}} {
+		_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), invalid, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, invalid)
+	}
+}
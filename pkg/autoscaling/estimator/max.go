@@ -0,0 +1,137 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/clock"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/prediction"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+	"github.com/gocrane/crane/pkg/utils/target"
+)
+
+const maxCallerFormat = "EVPAMaxCaller-%s-%s"
+
+var _ ResourceEstimator = &MaxResourceEstimator{}
+
+// MaxResourceEstimator recommends the max of the predicted window plus the margin, it is for the latency sensitive
+// services that can't afford to be sized to a percentile
+type MaxResourceEstimator struct {
+	Predictor     prediction.Interface
+	TargetFetcher target.SelectorFetcher
+	Clock         clock.Clock
+}
+
+func (e *MaxResourceEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+	windowStr, exists := config["max-window"]
+	if !exists {
+		windowStr = "24h"
+	}
+	window, err := utils.ParseDuration(windowStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse max-window failed: %v", err)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("max-window must be positive, got %v", window)
+	}
+
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+
+	clk := e.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	now := clk.Now()
+
+	caller := fmt.Sprintf(maxCallerFormat, klog.KObj(evpa), string(evpa.UID))
+	recommendResource := corev1.ResourceList{}
+	var errs []error
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		prefix, cfg, err := getMaxConfig(config, resourceName)
+		if err != nil {
+			return nil, err
+		}
+		marginFraction, err := utils.ParseFloat(config[prefix+"-margin-fraction"], 0)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s-margin-fraction failed: %v", prefix, err)
+		}
+
+		metricNamer := newContainerMetricNamer(evpa, caller, containerName, resourceName, selector)
+		if err := e.Predictor.WithQuery(metricNamer, caller, *cfg); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tsList, err := e.Predictor.QueryPredictedTimeSeries(ctx, metricNamer, now, now.Add(window))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		peak, found := maxSampleValue(tsList)
+		if !found {
+			errs = append(errs, noValueError(ctx, e.Predictor, metricNamer))
+			continue
+		}
+
+		value := peak * (1 + marginFraction)
+		if resourceName == corev1.ResourceCPU {
+			recommendResource[resourceName] = *resource.NewMilliQuantity(int64(value*1000), resource.DecimalSI)
+		} else {
+			recommendResource[resourceName] = *resource.NewQuantity(int64(value), resource.BinarySI)
+		}
+	}
+
+	if len(recommendResource) == 0 {
+		return recommendResource, allFailedError(errs, nil)
+	}
+
+	return recommendResource, nil
+}
+
+func (e *MaxResourceEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	if evpa.Spec.ResourcePolicy == nil {
+		return nil
+	}
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+	caller := fmt.Sprintf(maxCallerFormat, klog.KObj(evpa), string(evpa.UID))
+	var errs []error
+	for _, containerPolicy := range evpa.Spec.ResourcePolicy.ContainerPolicies {
+		for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			metricNamer := newContainerMetricNamer(evpa, caller, containerPolicy.ContainerName, resourceName, selector)
+			if err := e.Predictor.DeleteQuery(metricNamer, caller); err != nil {
+				errs = append(errs, fmt.Errorf("delete query %s failed: %v", metricNamer.BuildUniqueKey(), err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// getMaxConfig returns the config prefix and the prediction config of the resource, the margin is applied to the
+// max by the estimator instead of the predictor
+func getMaxConfig(config map[string]string, resourceName corev1.ResourceName) (string, *predictionconfig.Config, error) {
+	prefix := "cpu"
+	getConfig := getCpuConfig
+	if resourceName == corev1.ResourceMemory {
+		prefix = "mem"
+		getConfig = getMemConfig
+	}
+	cfg, err := getConfig(config)
+	if err != nil {
+		return "", nil, err
+	}
+	cfg.Percentile.MarginFraction = "0"
+	return prefix, cfg, nil
+}
@@ -0,0 +1,59 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestMaxResourceEstimation(t *testing.T) {
+	predictor := newFakePredictor(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.2, 0.5, 0.8, 1.0),
+		"memory": newSeries(100*mebibyte, 200*mebibyte, 400*mebibyte),
+	})
+	e := &MaxResourceEstimator{
+		Predictor:     predictor,
+		TargetFetcher: &fakeFetcher{},
+		Clock:         clock.NewFakeClock(time.Now()),
+	}
+
+	// the peak instead of the first sample
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+	assert.Equal(t, "400Mi", resources.Memory().String())
+	// the margin is applied by the estimator only
+	assert.Equal(t, "0", predictor.queries["nginx/cpu"].Percentile.MarginFraction)
+	assert.Equal(t, "0", predictor.queries["nginx/memory"].Percentile.MarginFraction)
+
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{
+		"cpu-margin-fraction": "0.2",
+		"mem-margin-fraction": "0.5",
+	}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1200m", resources.Cpu().String())
+	assert.Equal(t, "600Mi", resources.Memory().String())
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-margin-fraction": "x"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"max-window": "0s"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), newTestEVPA("nginx")))
+	assert.Len(t, predictor.deleted, 2)
+}
+
+func TestMaxResourceEstimationNoValue(t *testing.T) {
+	e := &MaxResourceEstimator{
+		Predictor:     newFakePredictor(map[string][]*common.TimeSeries{}),
+		TargetFetcher: &fakeFetcher{},
+	}
+	_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
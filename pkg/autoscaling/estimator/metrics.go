@@ -0,0 +1,111 @@
+package estimator
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+const (
+	errorReasonNoSamples     = "no_samples"
+	errorReasonQueryError    = "query_error"
+	errorReasonModelNotReady = "model_not_ready"
+)
+
+var (
+	estimationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "crane",
+			Subsystem: "estimator",
+			Name:      "duration_seconds",
+			Help:      "The duration of querying the predicted values of a resource",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"resource", "estimator"},
+	)
+	estimationErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "crane",
+			Subsystem: "estimator",
+			Name:      "errors_total",
+			Help:      "The count of the resources failed to be estimated",
+		},
+		[]string{"reason"},
+	)
+	servedQueries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "crane",
+			Subsystem: "estimator",
+			Name:      "served_queries_total",
+			Help:      "The count of the predicted values queries by the predictor served them, primary or secondary",
+		},
+		[]string{"resource", "predictor"},
+	)
+	recommendedValue = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "crane",
+			Subsystem: "estimator",
+			Name:      "recommended_value",
+			Help:      "The recommended value of a container resource, cpu in cores and the others in bytes",
+		},
+		[]string{"namespace", "workload", "container", "resource"},
+	)
+	estimatorReady = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "crane",
+			Subsystem: "estimator",
+			Name:      "ready",
+			Help:      "Whether the backends of an estimator, such as the predictor, can serve, 1 if ready and 0 if not",
+		},
+		[]string{"estimator"},
+	)
+)
+
+var registerMetricsOnce sync.Once
+
+// registerMetrics registers the estimator metrics with the global registry, it is safe to be called many times
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		metrics.Registry.MustRegister(estimationDuration, estimationErrors, servedQueries, recommendedValue, estimatorReady)
+	})
+}
+
+func observeEstimationDuration(resourceName corev1.ResourceName, estimatorType string, start time.Time) {
+	estimationDuration.WithLabelValues(string(resourceName), estimatorType).Observe(time.Since(start).Seconds())
+}
+
+// countEstimationErrors counts the errors by the reason
+func countEstimationErrors(errs ...[]error) {
+	for _, list := range errs {
+		for _, err := range list {
+			estimationErrors.WithLabelValues(errorReasonOf(err)).Inc()
+		}
+	}
+}
+
+func errorReasonOf(err error) string {
+	switch {
+	case errors.Is(err, ErrModelNotReady):
+		return errorReasonModelNotReady
+	case errors.Is(err, ErrNoSamples):
+		return errorReasonNoSamples
+	default:
+		return errorReasonQueryError
+	}
+}
+
+func setRecommendedValues(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resources corev1.ResourceList) {
+	var workload string
+	if evpa.Spec.TargetRef != nil {
+		workload = evpa.Spec.TargetRef.Name
+	}
+	for resourceName, quantity := range resources {
+		recommendedValue.WithLabelValues(evpa.Namespace, workload, containerName, string(resourceName)).Set(quantity.AsApproximateFloat64())
+	}
+}
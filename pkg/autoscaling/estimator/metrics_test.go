@@ -0,0 +1,91 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+// gatherMetric scrapes the family of the name from the global registry
+func gatherMetric(t *testing.T, name string) *dto.MetricFamily {
+	families, err := metrics.Registry.Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == name {
+			return family
+		}
+	}
+	return nil
+}
+
+func labelsOf(metric *dto.Metric) map[string]string {
+	labels := map[string]string{}
+	for _, pair := range metric.GetLabel() {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return labels
+}
+
+func TestEstimationMetrics(t *testing.T) {
+	// the registration is idempotent
+	registerMetrics()
+	registerMetrics()
+
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+
+	family := gatherMetric(t, "crane_estimator_recommended_value")
+	if assert.NotNil(t, family) {
+		values := map[string]float64{}
+		for _, metric := range family.GetMetric() {
+			labels := labelsOf(metric)
+			if labels["namespace"] == "default" && labels["workload"] == "nginx" && labels["container"] == "nginx" {
+				values[labels["resource"]] = metric.GetGauge().GetValue()
+			}
+		}
+		// the gauges of the other resources may be set by other tests of the container
+		assert.Equal(t, 0.25, values["cpu"])
+		assert.Equal(t, float64(256*1024*1024), values["memory"])
+	}
+
+	family = gatherMetric(t, "crane_estimator_duration_seconds")
+	if assert.NotNil(t, family) {
+		counts := map[string]uint64{}
+		for _, metric := range family.GetMetric() {
+			labels := labelsOf(metric)
+			assert.Equal(t, percentileEstimatorType, labels["estimator"])
+			counts[labels["resource"]] += metric.GetHistogram().GetSampleCount()
+		}
+		assert.NotZero(t, counts["cpu"])
+		assert.NotZero(t, counts["memory"])
+	}
+
+	// the errors are counted by the reason
+	noSamples := testutil.ToFloat64(estimationErrors.WithLabelValues(errorReasonNoSamples))
+	queryErrors := testutil.ToFloat64(estimationErrors.WithLabelValues(errorReasonQueryError))
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{})
+	predictor.errs = map[string]error{"cpu": fmt.Errorf("query failed")}
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	assert.Equal(t, noSamples+1, testutil.ToFloat64(estimationErrors.WithLabelValues(errorReasonNoSamples)))
+	assert.Equal(t, queryErrors+1, testutil.ToFloat64(estimationErrors.WithLabelValues(errorReasonQueryError)))
+	assert.NotNil(t, gatherMetric(t, "crane_estimator_errors_total"))
+}
+
+func TestErrorReasonOf(t *testing.T) {
+	assert.Equal(t, errorReasonModelNotReady, errorReasonOf(fmt.Errorf("%w, status NotReady", ErrModelNotReady)))
+	assert.Equal(t, errorReasonNoSamples, errorReasonOf(fmt.Errorf("%w returned", ErrNoSamples)))
+	assert.Equal(t, errorReasonQueryError, errorReasonOf(fmt.Errorf("connection refused")))
+}
@@ -0,0 +1,188 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/clock"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/prediction"
+	"github.com/gocrane/crane/pkg/utils"
+	"github.com/gocrane/crane/pkg/utils/target"
+)
+
+const movingWindowCallerFormat = "EVPAMovingWindowCaller-%s-%s"
+
+var _ ResourceEstimator = &MovingWindowResourceEstimator{}
+
+// MovingWindowResourceEstimator recommends the time-weighted average of the predicted window plus the margin, it is
+// for the bursty workloads the percentile overreacts to the short transients of
+type MovingWindowResourceEstimator struct {
+	Predictor     prediction.Interface
+	TargetFetcher target.SelectorFetcher
+	Clock         clock.Clock
+}
+
+func (e *MovingWindowResourceEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+
+	clk := e.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	now := clk.Now()
+
+	caller := fmt.Sprintf(movingWindowCallerFormat, klog.KObj(evpa), string(evpa.UID))
+	recommendResource := corev1.ResourceList{}
+	var errs []error
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		prefix, cfg, err := getMaxConfig(config, resourceName)
+		if err != nil {
+			return nil, err
+		}
+		window, decay, err := getMovingWindowConfig(config, prefix)
+		if err != nil {
+			return nil, err
+		}
+		marginFraction, err := utils.ParseFloat(config[prefix+"-margin-fraction"], 0)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s-margin-fraction failed: %v", prefix, err)
+		}
+
+		metricNamer := newContainerMetricNamer(evpa, caller, containerName, resourceName, selector)
+		if err := e.Predictor.WithQuery(metricNamer, caller, *cfg); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tsList, err := e.Predictor.QueryPredictedTimeSeries(ctx, metricNamer, now, now.Add(window))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// the series are per pod if not aggregated, the largest average fits every pod
+		average, found := 0.0, false
+		for _, ts := range tsList {
+			if value, averaged := timeWeightedAverage(ts.Samples, decay); averaged && (!found || value > average) {
+				average, found = value, true
+			}
+		}
+		if !found {
+			errs = append(errs, noValueError(ctx, e.Predictor, metricNamer))
+			continue
+		}
+
+		value := average * (1 + marginFraction)
+		if resourceName == corev1.ResourceCPU {
+			recommendResource[resourceName] = *resource.NewMilliQuantity(int64(math.Ceil(value*1000)), resource.DecimalSI)
+		} else {
+			recommendResource[resourceName] = *resource.NewQuantity(int64(math.Ceil(value)), resource.BinarySI)
+		}
+	}
+
+	if len(recommendResource) == 0 {
+		return recommendResource, allFailedError(errs, nil)
+	}
+
+	return recommendResource, nil
+}
+
+func (e *MovingWindowResourceEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	if evpa.Spec.ResourcePolicy == nil {
+		return nil
+	}
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+	caller := fmt.Sprintf(movingWindowCallerFormat, klog.KObj(evpa), string(evpa.UID))
+	var errs []error
+	for _, containerPolicy := range evpa.Spec.ResourcePolicy.ContainerPolicies {
+		for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			metricNamer := newContainerMetricNamer(evpa, caller, containerPolicy.ContainerName, resourceName, selector)
+			if err := e.Predictor.DeleteQuery(metricNamer, caller); err != nil {
+				errs = append(errs, fmt.Errorf("delete query %s failed: %v", metricNamer.BuildUniqueKey(), err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// getMovingWindowConfig returns the '<prefix>-window', 24h by default, and the half-life of '<prefix>-decay', the
+// samples are not decayed if it is not set
+func getMovingWindowConfig(config map[string]string, prefix string) (time.Duration, time.Duration, error) {
+	windowStr, exists := config[prefix+"-window"]
+	if !exists {
+		windowStr = "24h"
+	}
+	window, err := utils.ParseDuration(windowStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse %s-window failed: %v", prefix, err)
+	}
+	if window <= 0 {
+		return 0, 0, fmt.Errorf("%s-window must be positive, got %v", prefix, window)
+	}
+	decayStr, exists := config[prefix+"-decay"]
+	if !exists {
+		return window, 0, nil
+	}
+	decay, err := utils.ParseDuration(decayStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse %s-decay failed: %v", prefix, err)
+	}
+	if decay < 0 {
+		return 0, 0, fmt.Errorf("%s-decay must not be negative, got %v", prefix, decay)
+	}
+	return window, decay, nil
+}
+
+// timeWeightedAverage averages the samples weighted by the time each of them lasts until the next one, the last one
+// lasts as long as the previous one. The weights are halved every decay from the start of the window, so the near
+// future counts more.
+func timeWeightedAverage(samples []common.Sample, decay time.Duration) (float64, bool) {
+	if len(samples) == 0 {
+		return 0, false
+	}
+	sorted := append([]common.Sample(nil), samples...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+	if len(sorted) == 1 {
+		return sorted[0].Value, true
+	}
+
+	start := sorted[0].Timestamp
+	var sum, weights float64
+	for i, sample := range sorted {
+		var duration int64
+		if i+1 < len(sorted) {
+			duration = sorted[i+1].Timestamp - sample.Timestamp
+		} else {
+			duration = sample.Timestamp - sorted[i-1].Timestamp
+		}
+		weight := float64(duration)
+		if decay > 0 {
+			weight *= math.Pow(0.5, float64(sample.Timestamp-start)/decay.Seconds())
+		}
+		sum += sample.Value * weight
+		weights += weight
+	}
+	// the samples are all at the same timestamp
+	if weights == 0 {
+		for _, sample := range sorted {
+			sum += sample.Value
+		}
+		return sum / float64(len(sorted)), true
+	}
+	return sum / weights, true
+}
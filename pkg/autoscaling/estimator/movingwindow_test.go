@@ -0,0 +1,90 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestMovingWindowResourceEstimation(t *testing.T) {
+	// a steady usage with one outlier sample
+	cpuValues := make([]float64, 60)
+	for i := range cpuValues {
+		cpuValues[i] = 0.1
+	}
+	cpuValues[30] = 2
+	predictor := newFakePredictor(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(cpuValues...),
+		"memory": newSeries(256*mebibyte, 256*mebibyte, 512*mebibyte, 256*mebibyte),
+	})
+	e := &MovingWindowResourceEstimator{
+		Predictor:     predictor,
+		TargetFetcher: &fakeFetcher{},
+		Clock:         clock.NewFakeClock(time.Now()),
+	}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "132m", resources.Cpu().String())
+	assert.Equal(t, "320Mi", resources.Memory().String())
+	// the margin is applied by the estimator only
+	assert.Equal(t, "0", predictor.queries["nginx/cpu"].Percentile.MarginFraction)
+
+	// the p99 of the same series is the outlier
+	p99, err := (&MaxResourceEstimator{Predictor: predictor, TargetFetcher: &fakeFetcher{}}).GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", p99.Cpu().String())
+
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-margin-fraction": "0.5"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "198m", resources.Cpu().String())
+
+	for _, config := range []map[string]string{
+		{"cpu-window": "0s"},
+		{"mem-window": "x"},
+		{"cpu-decay": "-1m"},
+		{"cpu-margin-fraction": "x"},
+	} {
+		_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, config)
+	}
+
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), newTestEVPA("nginx")))
+	assert.Len(t, predictor.deleted, 2)
+
+	// selected by the type of the evpa resource estimators
+	manager := NewResourceEstimatorManager(nil, nil, nil, predictor, nil, nil, nil, "", nil)
+	evpa := newTestEVPA("nginx")
+	evpa.Spec.ResourceEstimators = []autoscalingapi.ResourceEstimator{{Type: "MovingWindow"}}
+	instances := manager.GetEstimators(evpa)
+	assert.Len(t, instances, 1)
+	assert.IsType(t, &MovingWindowResourceEstimator{}, instances[0].(resourceEstimatorInstance).ResourceEstimator)
+}
+
+func TestTimeWeightedAverage(t *testing.T) {
+	_, averaged := timeWeightedAverage(nil, 0)
+	assert.False(t, averaged)
+
+	average, averaged := timeWeightedAverage(newSeries(3)[0].Samples, 0)
+	assert.True(t, averaged)
+	assert.Equal(t, 3.0, average)
+
+	// weighted by the time the samples last, not ordered
+	samples := []common.Sample{{Timestamp: 180, Value: 1}, {Timestamp: 0, Value: 1}, {Timestamp: 60, Value: 4}}
+	average, _ = timeWeightedAverage(samples, 0)
+	assert.InDelta(t, (60*1+120*4+120*1)/300.0, average, 1e-9)
+
+	// the weights are halved every decay
+	average, _ = timeWeightedAverage(newSeries(1, 1, 3)[0].Samples, time.Minute)
+	assert.InDelta(t, (1+0.5+0.75)/1.75, average, 1e-9)
+	average, _ = timeWeightedAverage(newSeries(1, 1, 3)[0].Samples, 0)
+	assert.InDelta(t, 5.0/3, average, 1e-9)
+}
@@ -0,0 +1,51 @@
+package estimator
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+)
+
+// getNegativeValueFloor returns the floor the negative values of the resource are clamped to, zero by default
+func getNegativeValueFloor(config map[string]string, prefix string) (resource.Quantity, error) {
+	floorStr, exists := config[prefix+"-negative-value-floor"]
+	if !exists {
+		return resource.Quantity{}, nil
+	}
+	floor, err := resource.ParseQuantity(floorStr)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("parse %s-negative-value-floor failed: %v", prefix, err)
+	}
+	if floor.Sign() < 0 {
+		return resource.Quantity{}, fmt.Errorf("%s-negative-value-floor must not be negative, got %s", prefix, floorStr)
+	}
+	return floor, nil
+}
+
+// clampNegativeResources clamps the negative resources to the floor rather than emitting an invalid quantity,
+// and warns with the query key so the data source can be fixed
+func clampNegativeResources(resources corev1.ResourceList, config map[string]string, queryKeys map[corev1.ResourceName]string, graph *ExplanationGraph) error {
+	prefixes := map[corev1.ResourceName]string{corev1.ResourceCPU: "cpu", corev1.ResourceMemory: "mem", corev1.ResourceEphemeralStorage: ephemeralStoragePrefix}
+	// the custom metrics are prefixed by their names
+	for resourceName := range queryKeys {
+		if _, exists := prefixes[resourceName]; !exists {
+			prefixes[resourceName] = string(resourceName)
+		}
+	}
+	for resourceName, prefix := range prefixes {
+		floor, err := getNegativeValueFloor(config, prefix)
+		if err != nil {
+			return err
+		}
+		quantity, exists := resources[resourceName]
+		if !exists || quantity.Sign() >= 0 {
+			continue
+		}
+		klog.Warningf("Negative %s %s is clamped to %s, queryExpr: %s", resourceName, quantity.String(), floor.String(), queryKeys[resourceName])
+		resources[resourceName] = floor
+		graph.addStep(resourceName, ExplanationNodeTransform, "negative-clamp", quantityValue(resourceName, floor), "clamp the negative value")
+	}
+	return nil
+}
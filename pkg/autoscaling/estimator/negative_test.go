@@ -0,0 +1,41 @@
+package estimator
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestClampNegativePredictedValue(t *testing.T) {
+	var logs bytes.Buffer
+	klog.LogToStderr(false)
+	klog.SetOutput(&logs)
+	defer klog.LogToStderr(true)
+
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(-0.5),
+		"memory": newSeries(-1024),
+	})
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0", resources.Cpu().String())
+	assert.Equal(t, "0", resources.Memory().String())
+	klog.Flush()
+	assert.Contains(t, logs.String(), "Negative cpu -500m is clamped to 0")
+	assert.Contains(t, logs.String(), "container_cpu_default_nginx_nginx")
+
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-negative-value-floor": "100m", "mem-negative-value-floor": "64Mi"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "100m", resources.Cpu().String())
+	assert.Equal(t, "64Mi", resources.Memory().String())
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-negative-value-floor": "-1"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
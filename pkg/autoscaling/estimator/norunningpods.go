@@ -0,0 +1,100 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+const (
+	// ReasonNoRunningPods means the workload has no running pods, so there is no fresh data to estimate from
+	ReasonNoRunningPods = "NoRunningPods"
+
+	// NoRunningPodsFallbackLastGood returns the last good recommendation, or the current requests if there is none
+	NoRunningPodsFallbackLastGood = "last-good"
+	// NoRunningPodsFallbackCurrent returns the current requests
+	NoRunningPodsFallbackCurrent = "current"
+)
+
+// getNoRunningPodsFallback returns the 'no-running-pods-fallback', empty means the running pods are not checked
+func getNoRunningPodsFallback(config map[string]string) (string, error) {
+	fallback, exists := config["no-running-pods-fallback"]
+	if !exists {
+		return "", nil
+	}
+	switch fallback {
+	case NoRunningPodsFallbackLastGood, NoRunningPodsFallbackCurrent:
+		return fallback, nil
+	default:
+		return "", fmt.Errorf("unknown no-running-pods-fallback %s", fallback)
+	}
+}
+
+func (e *PercentileResourceEstimator) hasRunningPods(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) (bool, error) {
+	running, err := e.countRunningPods(ctx, evpa)
+	return running > 0, err
+}
+
+// countRunningPods returns the running pods of the evpa target
+func (e *PercentileResourceEstimator) countRunningPods(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) (int, error) {
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch target workload selector: %v", err)
+	}
+	pods, err := listTargetPods(ctx, e.Client, evpa.Namespace, selector)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list target pods: %v", err)
+	}
+	running := 0
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			running++
+		}
+	}
+	return running, nil
+}
+
+func lastGoodKey(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string) string {
+	return evpaReferent(evpa) + "/" + containerName
+}
+
+// storeLastGood saves the recommendation as the last good one of the container
+func (e *PercentileResourceEstimator) storeLastGood(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resources corev1.ResourceList) {
+	e.lastGood.Store(lastGoodKey(evpa, containerName), resources.DeepCopy())
+}
+
+// deleteLastGood deletes the last good recommendations of all containers of the evpa
+func (e *PercentileResourceEstimator) deleteLastGood(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) {
+	prefix := evpaReferent(evpa) + "/"
+	e.lastGood.Range(func(key, value interface{}) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			e.lastGood.Delete(key)
+		}
+		return true
+	})
+}
+
+// noRunningPodsEstimation returns the fallback recommendation without estimating from the stale data
+func (e *PercentileResourceEstimator) noRunningPodsEstimation(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, currRes *corev1.ResourceRequirements, fallback string) *ResourceEstimation {
+	if fallback == NoRunningPodsFallbackLastGood {
+		if value, exists := e.lastGood.Load(lastGoodKey(evpa, containerName)); exists {
+			estimation := newResourceEstimation(value.(corev1.ResourceList).DeepCopy())
+			estimation.Reason = ReasonNoRunningPods
+			estimation.setNumericMetadata()
+			return estimation
+		}
+	}
+
+	current := corev1.ResourceList{}
+	if currRes != nil {
+		current = currRes.Requests.DeepCopy()
+	}
+	estimation := newResourceEstimation(current)
+	estimation.deferToCurrent(currRes, ReasonNoRunningPods)
+	estimation.setNumericMetadata()
+	return estimation
+}
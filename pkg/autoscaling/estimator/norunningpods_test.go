@@ -0,0 +1,87 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func newPhasePod(name string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestGetNoRunningPodsFallback(t *testing.T) {
+	fallback, err := getNoRunningPodsFallback(map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", fallback)
+
+	fallback, err = getNoRunningPodsFallback(map[string]string{"no-running-pods-fallback": "current"})
+	assert.NoError(t, err)
+	assert.Equal(t, NoRunningPodsFallbackCurrent, fallback)
+
+	_, err = getNoRunningPodsFallback(map[string]string{"no-running-pods-fallback": "latest"})
+	assert.Error(t, err)
+}
+
+func TestEstimateResourcesNoRunningPods(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	evpa := newTestEVPA("nginx")
+
+	// a good recommendation while the pods are running
+	e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(newPhasePod("nginx-a", corev1.PodRunning), newTestDeployment(1)).Build()
+	config := map[string]string{"no-running-pods-fallback": "last-good"}
+	estimation, err := e.EstimateResources(context.TODO(), evpa, config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "", estimation.Reason)
+	good := estimation.Resources.DeepCopy()
+
+	// no pod is running, the last good recommendation is returned
+	e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		newPhasePod("nginx-a", corev1.PodPending), newPhasePod("nginx-b", corev1.PodSucceeded), newTestDeployment(2)).Build()
+	estimation, err = e.EstimateResources(context.TODO(), evpa, config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonNoRunningPods, estimation.Reason)
+	assert.True(t, good.Cpu().Equal(*estimation.Resources.Cpu()))
+	assert.True(t, good.Memory().Equal(*estimation.Resources.Memory()))
+
+	// the current requests are returned when configured
+	config = map[string]string{"no-running-pods-fallback": "current"}
+	estimation, err = e.EstimateResources(context.TODO(), evpa, config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonNoRunningPods, estimation.Reason)
+	assert.Equal(t, "1", estimation.Resources.Cpu().String())
+	assert.Equal(t, "1Gi", estimation.Resources.Memory().String())
+
+	// no last good recommendation after the evpa is deleted, falls back to the current requests
+	e.DeleteEstimation(context.TODO(), evpa)
+	config = map[string]string{"no-running-pods-fallback": "last-good"}
+	estimation, err = e.EstimateResources(context.TODO(), evpa, config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonNoRunningPods, estimation.Reason)
+	assert.Equal(t, "1", estimation.Resources.Cpu().String())
+
+	// not checked by default
+	estimation, err = e.EstimateResources(context.TODO(), evpa, map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "", estimation.Reason)
+}
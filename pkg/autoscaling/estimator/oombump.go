@@ -0,0 +1,164 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+// oomBumpConfig bumps the memory above the limit of the recent OOMKilled containers, so the too-low recommendation
+// is not applied again
+type oomBumpConfig struct {
+	factor   float64
+	lookback time.Duration
+}
+
+// getOOMBumpConfig returns the config of 'mem-oom-bump', 'mem-oom-bump-factor' and 'mem-oom-bump-lookback', nil if
+// the bump is not enabled. The factor is 1.2 and the lookback is 24h by default.
+func getOOMBumpConfig(config map[string]string) (*oomBumpConfig, error) {
+	enabledStr, exists := config["mem-oom-bump"]
+	if !exists {
+		return nil, nil
+	}
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse mem-oom-bump failed: %v", err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+	bump := &oomBumpConfig{factor: 1.2, lookback: 24 * time.Hour}
+	if factorStr, exists := config["mem-oom-bump-factor"]; exists {
+		bump.factor, err = strconv.ParseFloat(factorStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse mem-oom-bump-factor failed: %v", err)
+		}
+		if math.IsNaN(bump.factor) || math.IsInf(bump.factor, 0) || bump.factor < 1 {
+			return nil, fmt.Errorf("mem-oom-bump-factor must be finite and at least 1, got %s", factorStr)
+		}
+	}
+	if lookbackStr, exists := config["mem-oom-bump-lookback"]; exists {
+		bump.lookback, err = time.ParseDuration(lookbackStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse mem-oom-bump-lookback failed: %v", err)
+		}
+		if bump.lookback <= 0 {
+			return nil, fmt.Errorf("mem-oom-bump-lookback must be positive, got %v", bump.lookback)
+		}
+	}
+	return bump, nil
+}
+
+// recentOOMLimit returns the largest memory limit of the container OOMKilled within the lookback in the target pods,
+// the request is used if the container has no limit. It is not found if no OOMKill is recent.
+func (e *PercentileResourceEstimator) recentOOMLimit(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, lookback time.Duration) (resource.Quantity, bool, error) {
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		return resource.Quantity{}, false, fmt.Errorf("failed to fetch target workload selector: %v", err)
+	}
+	pods, err := listTargetPods(ctx, e.Client, evpa.Namespace, selector)
+	if err != nil {
+		return resource.Quantity{}, false, fmt.Errorf("failed to list target pods: %v", err)
+	}
+	since := e.now().Add(-lookback)
+	var limit resource.Quantity
+	found := false
+	for _, pod := range pods {
+		if !recentlyOOMKilled(pod, containerName, since) {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			if container.Name != containerName {
+				continue
+			}
+			observed, exists := container.Resources.Limits[corev1.ResourceMemory]
+			if !exists {
+				observed, exists = container.Resources.Requests[corev1.ResourceMemory]
+			}
+			if exists && (!found || observed.Cmp(limit) > 0) {
+				limit, found = observed.DeepCopy(), true
+			}
+		}
+	}
+	return limit, found, nil
+}
+
+// recordedOOMMemory returns the largest memory recorded by the OOMRecorder of the container OOMKilled since the time in
+// the target pods, the records are kept after the pods are gone. It is not found if no record is recent.
+func (e *PercentileResourceEstimator) recordedOOMMemory(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, since time.Time) (resource.Quantity, bool, error) {
+	records, err := e.OOMRecorder.GetOOMRecord()
+	if err != nil {
+		return resource.Quantity{}, false, fmt.Errorf("failed to get the oom records: %v", err)
+	}
+	podPrefix := fmt.Sprintf("%s-", evpa.Spec.TargetRef.Name)
+	var memory resource.Quantity
+	found := false
+	for _, record := range records {
+		if record.Container != containerName || !strings.HasPrefix(record.Pod, podPrefix) || record.OOMAt.Before(since) {
+			continue
+		}
+		if !found || record.Memory.Cmp(memory) > 0 {
+			memory, found = record.Memory.DeepCopy(), true
+		}
+	}
+	return memory, found, nil
+}
+
+// recentlyOOMKilled tells whether the last termination of the container is an OOMKill finished since the time
+func recentlyOOMKilled(pod corev1.Pod, containerName string, since time.Time) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+		terminated := status.LastTerminationState.Terminated
+		if terminated != nil && terminated.Reason == "OOMKilled" && !terminated.FinishedAt.Time.Before(since) {
+			return true
+		}
+	}
+	return false
+}
+
+// bumpOOMKilledMemory raises the memory to the factor of the limit of the recent OOMKill, the OOMKills are observed in
+// the pod status and recorded by the OOMRecorder
+func (e *PercentileResourceEstimator) bumpOOMKilledMemory(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resources corev1.ResourceList, bump *oomBumpConfig, graph *ExplanationGraph) error {
+	memory, exists := resources[corev1.ResourceMemory]
+	if !exists {
+		return nil
+	}
+	var limit resource.Quantity
+	found := false
+	if e.Client != nil {
+		observed, exists, err := e.recentOOMLimit(ctx, evpa, containerName, bump.lookback)
+		if err != nil {
+			return err
+		}
+		limit, found = observed, exists
+	}
+	if e.OOMRecorder != nil {
+		recorded, exists, err := e.recordedOOMMemory(evpa, containerName, e.now().Add(-bump.lookback))
+		if err != nil {
+			return err
+		}
+		if exists && (!found || recorded.Cmp(limit) > 0) {
+			limit, found = recorded, true
+		}
+	}
+	if !found {
+		return nil
+	}
+	bumped := resource.NewQuantity(int64(math.Ceil(float64(limit.Value())*bump.factor)), resource.BinarySI)
+	if bumped.Cmp(memory) <= 0 {
+		return nil
+	}
+	resources[corev1.ResourceMemory] = *bumped
+	graph.addStep(corev1.ResourceMemory, ExplanationNodeTransform, "oom-bump", quantityValue(corev1.ResourceMemory, *bumped), fmt.Sprintf("OOMKilled at the limit %s within %v, bump by %g", limit.String(), bump.lookback, bump.factor))
+	return nil
+}
@@ -0,0 +1,139 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/oom"
+)
+
+func newOOMKilledPod(name string, memoryLimit string, finishedAt time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "nginx"}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "nginx",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memoryLimit)},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "nginx",
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(finishedAt)},
+				},
+			}},
+		},
+	}
+}
+
+func TestGetOOMBumpConfig(t *testing.T) {
+	bump, err := getOOMBumpConfig(map[string]string{})
+	assert.NoError(t, err)
+	assert.Nil(t, bump)
+
+	bump, err = getOOMBumpConfig(map[string]string{"mem-oom-bump": "false"})
+	assert.NoError(t, err)
+	assert.Nil(t, bump)
+
+	bump, err = getOOMBumpConfig(map[string]string{"mem-oom-bump": "true"})
+	assert.NoError(t, err)
+	assert.Equal(t, &oomBumpConfig{factor: 1.2, lookback: 24 * time.Hour}, bump)
+
+	bump, err = getOOMBumpConfig(map[string]string{"mem-oom-bump": "true", "mem-oom-bump-factor": "1.5", "mem-oom-bump-lookback": "6h"})
+	assert.NoError(t, err)
+	assert.Equal(t, &oomBumpConfig{factor: 1.5, lookback: 6 * time.Hour}, bump)
+
+	for _, config := range []map[string]string{
+		{"mem-oom-bump": "yes"},
+		{"mem-oom-bump": "true", "mem-oom-bump-factor": "0.9"},
+		{"mem-oom-bump": "true", "mem-oom-bump-factor": "NaN"},
+		{"mem-oom-bump": "true", "mem-oom-bump-lookback": "-1h"},
+		{"mem-oom-bump": "true", "mem-oom-bump-lookback": "1d"},
+	} {
+		_, err = getOOMBumpConfig(config)
+		assert.Error(t, err, config)
+	}
+}
+
+func TestEstimateResourcesOOMBump(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	e.Clock = clock.NewFakeClock(now)
+	e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		newOOMKilledPod("nginx-a", "400Mi", now.Add(-time.Hour)),
+		newOOMKilledPod("nginx-b", "500Mi", now.Add(-2*time.Hour)),
+		// out of the lookback
+		newOOMKilledPod("nginx-c", "1Gi", now.Add(-48*time.Hour)),
+		newTestDeployment(3),
+	).Build()
+	config := map[string]string{"mem-oom-bump": "true", "mem-oom-bump-factor": "1.2"}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, "600Mi", resources.Memory().String())
+
+	// only the OOMKills within the lookback are counted
+	config["mem-oom-bump-factor"] = "1"
+	config["mem-oom-bump-lookback"] = "90m"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "400Mi", resources.Memory().String())
+
+	// not bumped by default
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "256Mi", resources.Memory().String())
+}
+
+type fakeOOMRecorder struct {
+	records []oom.OOMRecord
+}
+
+func (r *fakeOOMRecorder) GetOOMRecord() ([]oom.OOMRecord, error) {
+	return r.records, nil
+}
+
+func TestEstimateResourcesOOMBumpRecorded(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	e.Clock = clock.NewFakeClock(now)
+	e.OOMRecorder = &fakeOOMRecorder{records: []oom.OOMRecord{
+		{Pod: "nginx-a", Container: "nginx", Memory: resource.MustParse("300Mi"), OOMAt: now.Add(-time.Hour)},
+		// another container, another workload and out of the lookback
+		{Pod: "nginx-a", Container: "sidecar", Memory: resource.MustParse("1Gi"), OOMAt: now.Add(-time.Hour)},
+		{Pod: "redis-a", Container: "nginx", Memory: resource.MustParse("1Gi"), OOMAt: now.Add(-time.Hour)},
+		{Pod: "nginx-b", Container: "nginx", Memory: resource.MustParse("1Gi"), OOMAt: now.Add(-48 * time.Hour)},
+	}}
+	config := map[string]string{"mem-oom-bump": "true", "mem-oom-bump-factor": "1.5"}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "450Mi", resources.Memory().String())
+
+	// the percentile is kept if it is above the bump
+	config["mem-oom-bump-factor"] = "1"
+	config["mem-oom-bump-lookback"] = "30m"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "256Mi", resources.Memory().String())
+}
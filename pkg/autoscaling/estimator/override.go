@@ -0,0 +1,80 @@
+package estimator
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/known"
+)
+
+const (
+	// ReasonOverridden means the recommendation is forced by the override annotation
+	ReasonOverridden = "Overridden"
+
+	// MetadataOverrideExpiry is the metadata key of the expiry of the override, in RFC3339
+	MetadataOverrideExpiry = "override-expiry"
+)
+
+// recommendationOverride is the value of the override annotation, such as
+// {"expiry":"2022-07-01T18:00:00Z","containers":{"nginx":{"cpu":"2","memory":"4Gi"}}}. The container "*"
+// applies to the containers not listed.
+type recommendationOverride struct {
+	Expiry     metav1.Time                    `json:"expiry"`
+	Containers map[string]corev1.ResourceList `json:"containers"`
+}
+
+// getRecommendationOverride returns the resources forced by the annotation for the container, nil if there is no
+// override or it is expired, so the estimation resumes the normal computation
+func getRecommendationOverride(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, now time.Time) (corev1.ResourceList, time.Time, error) {
+	value, exists := evpa.Annotations[known.EffectiveVerticalPodAutoscalerRecommendationOverrideAnnotation]
+	if !exists {
+		return nil, time.Time{}, nil
+	}
+	override := &recommendationOverride{}
+	if err := json.Unmarshal([]byte(value), override); err != nil {
+		return nil, time.Time{}, fmt.Errorf("parse annotation %s failed: %v", known.EffectiveVerticalPodAutoscalerRecommendationOverrideAnnotation, err)
+	}
+	if override.Expiry.IsZero() {
+		return nil, time.Time{}, fmt.Errorf("annotation %s requires the expiry", known.EffectiveVerticalPodAutoscalerRecommendationOverrideAnnotation)
+	}
+	if !now.Before(override.Expiry.Time) {
+		return nil, time.Time{}, nil
+	}
+
+	resources, exists := override.Containers[containerName]
+	if !exists {
+		resources = override.Containers["*"]
+	}
+	for resourceName, quantity := range resources {
+		if quantity.Sign() <= 0 {
+			return nil, time.Time{}, fmt.Errorf("override %s of container %s must be positive, got %s", resourceName, containerName, quantity.String())
+		}
+	}
+	if len(resources) == 0 {
+		return nil, time.Time{}, nil
+	}
+	return resources, override.Expiry.Time, nil
+}
+
+// coversAllResources tells whether cpu and memory are both in any of the resource lists
+func coversAllResources(lists ...corev1.ResourceList) bool {
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		covered := false
+		for _, list := range lists {
+			if _, exists := list[resourceName]; exists {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/known"
+)
+
+func TestEstimateResourcesOverride(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	fakeClock := clock.NewFakeClock(time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC))
+	e.Clock = fakeClock
+	evpa := newTestEVPA("nginx")
+	evpa.Annotations = map[string]string{
+		known.EffectiveVerticalPodAutoscalerRecommendationOverrideAnnotation: `{"expiry":"2022-07-01T18:00:00Z","containers":{"nginx":{"cpu":"2","memory":"4Gi"}}}`,
+	}
+
+	// unexpired, the override is returned
+	estimation, err := e.EstimateResources(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonOverridden, estimation.Reason)
+	assert.Equal(t, "2", estimation.Resources.Cpu().String())
+	assert.Equal(t, "4Gi", estimation.Resources.Memory().String())
+	assert.Equal(t, "2022-07-01T18:00:00Z", estimation.Metadata[MetadataOverrideExpiry])
+
+	// the override is not transformed
+	estimation, err = e.EstimateResources(context.TODO(), evpa, map[string]string{"significant-figures": "1", "mem-round-pow2": "true"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", estimation.Resources.Cpu().String())
+
+	// the other containers are computed normally
+	estimation, err = e.EstimateResources(context.TODO(), evpa, map[string]string{}, "sidecar", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", estimation.Reason)
+
+	// expired, the normal computation resumes
+	fakeClock.Step(6 * time.Hour)
+	estimation, err = e.EstimateResources(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", estimation.Reason)
+	assert.NotContains(t, estimation.Metadata, MetadataOverrideExpiry)
+	assert.NotEqual(t, "2", estimation.Resources.Cpu().String())
+}
+
+func TestEstimateResourcesPartialOverride(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	e.Clock = clock.NewFakeClock(time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC))
+	evpa := newTestEVPA("nginx")
+	evpa.Annotations = map[string]string{
+		known.EffectiveVerticalPodAutoscalerRecommendationOverrideAnnotation: `{"expiry":"2022-07-01T18:00:00Z","containers":{"*":{"cpu":"2"}}}`,
+	}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resources.Cpu().String())
+	assert.Equal(t, "256Mi", resources.Memory().String())
+}
+
+func TestGetRecommendationOverride(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	evpa := newTestEVPA("nginx")
+
+	override, _, err := getRecommendationOverride(evpa, "nginx", now)
+	assert.NoError(t, err)
+	assert.Nil(t, override)
+
+	for _, value := range []string{
+		`not json`,
+		`{"containers":{"nginx":{"cpu":"2"}}}`,
+		`{"expiry":"2022-07-01T18:00:00Z","containers":{"nginx":{"cpu":"-1"}}}`,
+	} {
+		evpa.Annotations = map[string]string{known.EffectiveVerticalPodAutoscalerRecommendationOverrideAnnotation: value}
+		_, _, err = getRecommendationOverride(evpa, "nginx", now)
+		assert.Error(t, err, value)
+	}
+}
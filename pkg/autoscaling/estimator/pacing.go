@@ -0,0 +1,135 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+const (
+	// MetadataPacingMaxUnavailable is the metadata key of the suggested maxUnavailable of the rolling restart
+	MetadataPacingMaxUnavailable = "pacing-max-unavailable"
+	// MetadataPacingMaxSurge is the metadata key of the suggested maxSurge of the rolling restart
+	MetadataPacingMaxSurge = "pacing-max-surge"
+)
+
+// pacingHint is the rollout pacing of the changes up to the magnitude
+type pacingHint struct {
+	maxChange      float64
+	maxUnavailable string
+	maxSurge       string
+}
+
+// pacingHints are ordered by the magnitude of change, the larger changes are rolled out more conservatively
+var pacingHints = []pacingHint{
+	{maxChange: 0.1, maxUnavailable: "25%", maxSurge: "25%"},
+	{maxChange: 0.5, maxUnavailable: "10%", maxSurge: "25%"},
+	{maxChange: 1, maxUnavailable: "5%", maxSurge: "10%"},
+	{maxChange: math.Inf(1), maxUnavailable: "0", maxSurge: "5%"},
+}
+
+// getPacingHintsEnabled returns whether 'pacing-hints' is set
+func getPacingHintsEnabled(config map[string]string) (bool, error) {
+	value, exists := config["pacing-hints"]
+	if !exists {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parse pacing-hints failed: %v", err)
+	}
+	return enabled, nil
+}
+
+// changeMagnitude is the max relative change of the requests, a resource without current request is a full change
+func changeMagnitude(currRes *corev1.ResourceRequirements, resources corev1.ResourceList) float64 {
+	magnitude := 0.0
+	for resourceName, quantity := range resources {
+		current := 0.0
+		if currRes != nil {
+			if q, exists := currRes.Requests[resourceName]; exists {
+				current = quantityValue(resourceName, q)
+			}
+		}
+		if current <= 0 {
+			magnitude = math.Max(magnitude, 1)
+			continue
+		}
+		magnitude = math.Max(magnitude, math.Abs(quantityValue(resourceName, quantity)-current)/current)
+	}
+	return magnitude
+}
+
+// pacingHintOf returns the pacing of the magnitude of change, no disruption is suggested if the PDB allows none
+func pacingHintOf(magnitude float64, disruptionsAllowed *int32) pacingHint {
+	hint := pacingHints[len(pacingHints)-1]
+	for _, h := range pacingHints {
+		if magnitude <= h.maxChange {
+			hint = h
+			break
+		}
+	}
+	if disruptionsAllowed != nil && *disruptionsAllowed <= 0 {
+		hint.maxUnavailable = "0"
+	}
+	return hint
+}
+
+// disruptionsAllowed returns the least disruptions allowed by the PDBs of the target pods, nil if no PDB matches
+func disruptionsAllowed(ctx context.Context, kubeClient client.Client, namespace string, pods []corev1.Pod) (*int32, error) {
+	pdbList := &policyv1beta1.PodDisruptionBudgetList{}
+	if err := kubeClient.List(ctx, pdbList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	var result *int32
+	for i := range pdbList.Items {
+		pdb := &pdbList.Items[i]
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		for _, pod := range pods {
+			if !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			if result == nil || pdb.Status.DisruptionsAllowed < *result {
+				allowed := pdb.Status.DisruptionsAllowed
+				result = &allowed
+			}
+			break
+		}
+	}
+	return result, nil
+}
+
+// setPacingHints attaches the rollout pacing hints of the changes to the metadata
+func (e *PercentileResourceEstimator) setPacingHints(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, currRes *corev1.ResourceRequirements, estimation *ResourceEstimation) {
+	var allowed *int32
+	if e.Client != nil {
+		selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+		if err != nil {
+			klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+		}
+		pods, err := listTargetPods(ctx, e.Client, evpa.Namespace, selector)
+		if err == nil {
+			allowed, err = disruptionsAllowed(ctx, e.Client, evpa.Namespace, pods)
+		}
+		if err != nil {
+			klog.ErrorS(err, "Failed to get the disruption budget, pacing without it.", "evpa", klog.KObj(evpa))
+		}
+	}
+
+	hint := pacingHintOf(changeMagnitude(currRes, estimation.Resources), allowed)
+	estimation.Metadata[MetadataPacingMaxUnavailable] = hint.maxUnavailable
+	estimation.Metadata[MetadataPacingMaxSurge] = hint.maxSurge
+}
@@ -0,0 +1,76 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestPacingHintOf(t *testing.T) {
+	small := pacingHintOf(0.05, nil)
+	medium := pacingHintOf(0.3, nil)
+	large := pacingHintOf(3, nil)
+	assert.Equal(t, "25%", small.maxUnavailable)
+	assert.Equal(t, "10%", medium.maxUnavailable)
+	assert.Equal(t, "0", large.maxUnavailable)
+	assert.Equal(t, "5%", large.maxSurge)
+
+	// no disruption allowed by the PDB
+	var none int32
+	assert.Equal(t, "0", pacingHintOf(0.05, &none).maxUnavailable)
+}
+
+func TestEstimateResourcesPacingHints(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(1.05),
+		"memory": newSeries(1024 * 1024 * 1024),
+	})
+	config := map[string]string{"pacing-hints": "true"}
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "25%", estimation.Metadata[MetadataPacingMaxUnavailable])
+	assert.Equal(t, "25%", estimation.Metadata[MetadataPacingMaxSurge])
+
+	// a larger change is paced more conservatively
+	currRes.Requests[corev1.ResourceCPU] = resource.MustParse("250m")
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", estimation.Metadata[MetadataPacingMaxUnavailable])
+	assert.Equal(t, "5%", estimation.Metadata[MetadataPacingMaxSurge])
+
+	// the PDB has no headroom
+	pod := newReadyPod("nginx-a", corev1.ConditionTrue, metav1.Now().Time)
+	pod.Labels = map[string]string{"app": "nginx"}
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+		Spec:       policyv1beta1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}}},
+		Status:     policyv1beta1.PodDisruptionBudgetStatus{DisruptionsAllowed: 0},
+	}
+	e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(pod, pdb, newTestDeployment(1)).Build()
+	currRes.Requests[corev1.ResourceCPU] = resource.MustParse("1")
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", estimation.Metadata[MetadataPacingMaxUnavailable])
+	assert.Equal(t, "25%", estimation.Metadata[MetadataPacingMaxSurge])
+
+	// not attached by default
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.NotContains(t, estimation.Metadata, MetadataPacingMaxUnavailable)
+}
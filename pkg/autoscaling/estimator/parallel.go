@@ -0,0 +1,66 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+)
+
+// predictedValues is the result of the realtime predicted values query of a resource
+type predictedValues struct {
+	tsList []*common.TimeSeries
+	err    error
+	// predictor is the role of the predictor served the values, primary, secondary or cache
+	predictor string
+}
+
+// queryRealtimePredictedValues queries the predicted values of the resources concurrently, each query may take
+// hundreds of milliseconds against a remote data source. The transient errors are retried, then the secondary predictor
+// serves the query of the secondary namer if any. It returns promptly once the context is done, the result is discarded
+// then, and the queries still running are cancelled.
+func (e *PercentileResourceEstimator) queryRealtimePredictedValues(ctx context.Context, namers map[corev1.ResourceName]metricnaming.MetricNamer, secondaryNamers map[corev1.ResourceName]metricnaming.MetricNamer, retry queryRetry) (map[corev1.ResourceName]predictedValues, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	result := make(map[corev1.ResourceName]predictedValues, len(namers))
+	for resourceName, namer := range namers {
+		wg.Add(1)
+		go func(resourceName corev1.ResourceName, namer metricnaming.MetricNamer) {
+			defer runtime.HandleCrash()
+			defer wg.Done()
+			start := time.Now()
+			values := predictedValues{predictor: primaryPredictor}
+			values.tsList, values.err = retry.queryRealtimePredictedValues(ctx, e.Predictor, namer)
+			if secondaryNamer, exists := secondaryNamers[resourceName]; exists && e.servedBySecondary(ctx, namer, values.err) {
+				values = e.querySecondary(ctx, secondaryNamer, values.err, retry)
+			}
+			observeEstimationDuration(resourceName, percentileEstimatorType, start)
+			if values.err == nil {
+				servedQueries.WithLabelValues(string(resourceName), values.predictor).Inc()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			result[resourceName] = values
+		}(resourceName, namer)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return result, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("query predicted values interrupted: %w", ctx.Err())
+	}
+}
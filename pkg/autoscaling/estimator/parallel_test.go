@@ -0,0 +1,100 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+)
+
+// delayedPredictor delays the predicted values like a remote data source
+type delayedPredictor struct {
+	*fakePredictor
+	delay time.Duration
+}
+
+func (p *delayedPredictor) QueryRealtimePredictedValues(ctx context.Context, namer metricnaming.MetricNamer) ([]*common.TimeSeries, error) {
+	time.Sleep(p.delay)
+	return p.fakePredictor.QueryRealtimePredictedValues(ctx, namer)
+}
+
+func TestGetResourceEstimationConcurrentQueries(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":               newSeries(0.25),
+		"memory":            newSeries(256 * 1024 * 1024),
+		"ephemeral-storage": newSeries(1024 * 1024 * 1024),
+	})
+	delay := 200 * time.Millisecond
+	e.Predictor = &delayedPredictor{fakePredictor: predictor, delay: delay}
+
+	start := time.Now()
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"ephemeral-storage-request-percentile": "0.99"}, "nginx", &corev1.ResourceRequirements{})
+	elapsed := time.Since(start)
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, "256Mi", resources.Memory().String())
+	assert.Equal(t, "1Gi", resources.StorageEphemeral().String())
+	// the three queries are not serialized
+	assert.Less(t, int64(elapsed), int64(2*delay), elapsed.String())
+}
+
+func TestGetResourceEstimationConcurrentQueriesErrors(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+
+	// the failed query doesn't fail the other
+	predictor.errs["memory"] = assert.AnError
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.NotContains(t, resources, corev1.ResourceMemory)
+
+	// all failed
+	predictor.errs["cpu"] = assert.AnError
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), assert.AnError.Error())
+}
+
+func TestGetResourceEstimationCancelled(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	// the predictor hangs and ignores the context
+	e.Predictor = &delayedPredictor{fakePredictor: predictor, delay: 5 * time.Second}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := e.GetResourceEstimation(ctx, newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.True(t, errors.Is(err, context.Canceled), err)
+
+	// the deadline is honored as well
+	ctx, cancel = context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	_, err = e.GetResourceEstimation(ctx, newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
+
+func TestDeleteEstimationCancelled(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{})
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	err := e.DeleteEstimation(ctx, newTestEVPA("nginx"))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, predictor.deleted)
+
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), newTestEVPA("nginx")))
+	assert.NotEmpty(t, predictor.deleted)
+}
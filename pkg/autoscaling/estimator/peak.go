@@ -0,0 +1,195 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/clock"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+	predictionapi "github.com/gocrane/api/prediction/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/prediction"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+	"github.com/gocrane/crane/pkg/utils/target"
+)
+
+const peakCallerFormat = "EVPAPeakCaller-%s-%s"
+
+// PeakResourceEstimator recommends the greater of the historical peak and the forecasted peak, then decays
+// toward the forecasted peak as the forecast confidence grows. Low confidence forecasts defer to the history
+// for safety, high confidence forecasts lead for responsiveness.
+type PeakResourceEstimator struct {
+	// Predictor is used to get the historical peak, it is a percentile predictor
+	Predictor prediction.Interface
+	// ForecastPredictor is used to get the forecasted time series, such as a dsp predictor
+	ForecastPredictor prediction.Interface
+	TargetFetcher     target.SelectorFetcher
+	Clock             clock.Clock
+}
+
+func (e *PeakResourceEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+	confidence, err := utils.ParseFloat(config["forecast-confidence"], 0.5)
+	if err != nil {
+		return nil, fmt.Errorf("parse forecast-confidence failed: %v", err)
+	}
+	if confidence < 0 || confidence > 1 {
+		return nil, fmt.Errorf("forecast-confidence must be in [0,1], got %v", confidence)
+	}
+	horizonStr, exists := config["forecast-horizon"]
+	if !exists {
+		horizonStr = "24h"
+	}
+	horizon, err := utils.ParseDuration(horizonStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse forecast-horizon failed: %v", err)
+	}
+	// the forecast period is auto-detected unless the seasonality-period is set
+	var seasonalityPeriod time.Duration
+	if seasonalityPeriodStr, exists := config["seasonality-period"]; exists {
+		seasonalityPeriod, err = utils.ParseDuration(seasonalityPeriodStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse seasonality-period failed: %v", err)
+		}
+		if seasonalityPeriod <= 0 {
+			return nil, fmt.Errorf("seasonality-period must be positive, got %v", seasonalityPeriod)
+		}
+	}
+
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+
+	clk := e.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	now := clk.Now()
+
+	caller := fmt.Sprintf(peakCallerFormat, klog.KObj(evpa), string(evpa.UID))
+	recommendResource := corev1.ResourceList{}
+	var errs []error
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		metricNamer := newContainerMetricNamer(evpa, caller, containerName, resourceName, selector)
+
+		historicalPeak, forecastPeak, err := e.queryPeaks(ctx, metricNamer, caller, resourceName, now, horizon, seasonalityPeriod)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		value := blendPeaks(historicalPeak, forecastPeak, confidence)
+		if resourceName == corev1.ResourceCPU {
+			recommendResource[resourceName] = *resource.NewMilliQuantity(int64(value*1000), resource.DecimalSI)
+		} else {
+			recommendResource[resourceName] = *resource.NewQuantity(int64(value), resource.BinarySI)
+		}
+	}
+
+	if len(recommendResource) == 0 {
+		return recommendResource, fmt.Errorf("all resource predicted failed: %v", errs)
+	}
+
+	return recommendResource, nil
+}
+
+func (e *PeakResourceEstimator) queryPeaks(ctx context.Context, metricNamer metricnaming.MetricNamer, caller string, resourceName corev1.ResourceName, now time.Time, horizon time.Duration, seasonalityPeriod time.Duration) (float64, float64, error) {
+	historyConfig := getPeakHistoryConfig(resourceName)
+	err := e.Predictor.WithQuery(metricNamer, caller, *historyConfig)
+	if err != nil {
+		return 0, 0, err
+	}
+	tsList, err := e.Predictor.QueryRealtimePredictedValues(ctx, metricNamer)
+	if err != nil {
+		return 0, 0, err
+	}
+	historicalPeak, found := maxSampleValue(tsList)
+	if !found {
+		return 0, 0, fmt.Errorf("no historical value retured for queryExpr: %s", metricNamer.BuildUniqueKey())
+	}
+
+	forecastConfig := &predictionconfig.Config{DSP: &predictionapi.DSP{}, SeasonalityPeriod: seasonalityPeriod}
+	err = e.ForecastPredictor.WithQuery(metricNamer, caller, *forecastConfig)
+	if err != nil {
+		return 0, 0, err
+	}
+	tsList, err = e.ForecastPredictor.QueryPredictedTimeSeries(ctx, metricNamer, now, now.Add(horizon))
+	if err != nil {
+		return 0, 0, err
+	}
+	forecastPeak, found := maxSampleValue(tsList)
+	if !found {
+		// no forecast yet, defer to the history
+		return historicalPeak, historicalPeak, nil
+	}
+
+	return historicalPeak, forecastPeak, nil
+}
+
+func (e *PeakResourceEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	if evpa.Spec.ResourcePolicy == nil {
+		return nil
+	}
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+	caller := fmt.Sprintf(peakCallerFormat, klog.KObj(evpa), string(evpa.UID))
+	var errs []error
+	for _, containerPolicy := range evpa.Spec.ResourcePolicy.ContainerPolicies {
+		for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			metricNamer := newContainerMetricNamer(evpa, caller, containerPolicy.ContainerName, resourceName, selector)
+			for _, predictor := range []prediction.Interface{e.Predictor, e.ForecastPredictor} {
+				if err := predictor.DeleteQuery(metricNamer, caller); err != nil {
+					errs = append(errs, fmt.Errorf("delete query %s failed: %v", metricNamer.BuildUniqueKey(), err))
+				}
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// blendPeaks starts from the greater of the two peaks and decays toward the forecast as the confidence grows,
+// confidence 0 returns max(historical, forecast), confidence 1 returns the forecast.
+func blendPeaks(historicalPeak, forecastPeak, confidence float64) float64 {
+	safePeak := math.Max(historicalPeak, forecastPeak)
+	return forecastPeak + (1-confidence)*(safePeak-forecastPeak)
+}
+
+func maxSampleValue(tsList []*common.TimeSeries) (float64, bool) {
+	found := false
+	peak := 0.0
+	for _, ts := range tsList {
+		for _, sample := range ts.Samples {
+			if !found || sample.Value > peak {
+				peak = sample.Value
+				found = true
+			}
+		}
+	}
+	return peak, found
+}
+
+// getPeakHistoryConfig use the 100th percentile without margin, that is the historical peak
+func getPeakHistoryConfig(resourceName corev1.ResourceName) *predictionconfig.Config {
+	// the default configs are always valid
+	var cfg *predictionconfig.Config
+	if resourceName == corev1.ResourceCPU {
+		cfg, _ = getCpuConfig(map[string]string{})
+	} else {
+		cfg, _ = getMemConfig(map[string]string{})
+	}
+	cfg.Percentile.Percentile = "1.0"
+	cfg.Percentile.MarginFraction = "0"
+	return cfg
+}
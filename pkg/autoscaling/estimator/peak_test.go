@@ -0,0 +1,74 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestBlendPeaks(t *testing.T) {
+	tests := []struct {
+		description string
+		historical  float64
+		forecast    float64
+		confidence  float64
+		expect      float64
+	}{
+		{description: "zero confidence uses the greater peak", historical: 2, forecast: 1, confidence: 0, expect: 2},
+		{description: "full confidence uses the forecast", historical: 2, forecast: 1, confidence: 1, expect: 1},
+		{description: "forecast above history is always used", historical: 1, forecast: 2, confidence: 0.3, expect: 2},
+		{description: "half confidence", historical: 3, forecast: 1, confidence: 0.5, expect: 2},
+	}
+
+	for _, test := range tests {
+		assert.InDelta(t, test.expect, blendPeaks(test.historical, test.forecast, test.confidence), 1e-9, test.description)
+	}
+}
+
+func TestPeakResourceEstimation(t *testing.T) {
+	history := newFakePredictor(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(2.0),
+		"memory": newSeries(2048),
+	})
+	forecast := newFakePredictor(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.8, 1.0, 0.9),
+		"memory": newSeries(800, 1024, 900),
+	})
+	e := &PeakResourceEstimator{
+		Predictor:         history,
+		ForecastPredictor: forecast,
+		TargetFetcher:     &fakeFetcher{},
+		Clock:             clock.NewFakeClock(time.Now()),
+	}
+
+	// low confidence forecast defers to the history
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"forecast-confidence": "0.1"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1900), resources.Cpu().MilliValue())
+	assert.Equal(t, int64(1945), resources.Memory().Value())
+
+	// high confidence forecast leads
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"forecast-confidence": "0.9"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1100), resources.Cpu().MilliValue())
+	assert.Equal(t, int64(1126), resources.Memory().Value())
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"forecast-confidence": "2"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+
+	// the forecast period is auto-detected by default, and forced by the seasonality-period
+	assert.Equal(t, time.Duration(0), forecast.queries["nginx/cpu"].SeasonalityPeriod)
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"seasonality-period": "24h"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, forecast.queries["nginx/cpu"].SeasonalityPeriod)
+	assert.Equal(t, 24*time.Hour, forecast.queries["nginx/memory"].SeasonalityPeriod)
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"seasonality-period": "0s"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
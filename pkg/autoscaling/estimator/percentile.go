@@ -3,253 +3,1126 @@ package estimator
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
-	predictionapi "github.com/gocrane/api/prediction/v1alpha1"
 
+	"github.com/gocrane/crane/pkg/common"
 	"github.com/gocrane/crane/pkg/metricnaming"
 	"github.com/gocrane/crane/pkg/metricquery"
+	"github.com/gocrane/crane/pkg/oom"
 	"github.com/gocrane/crane/pkg/prediction"
 	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/providers"
 	"github.com/gocrane/crane/pkg/utils/target"
 )
 
-const callerFormat = "EVPACaller-%s-%s"
+// defaultCallerPrefix prefixes the predictor callers of the evpas
+const defaultCallerPrefix = "EVPACaller"
+
+const percentileEstimatorType = "Percentile"
+
+var _ ResourceEstimator = &PercentileResourceEstimator{}
 
 type PercentileResourceEstimator struct {
 	Predictor     prediction.Interface
 	Client        client.Client
 	TargetFetcher target.SelectorFetcher
+	// History is used to check the history coverage, it is optional
+	History providers.History
+	Clock   clock.Clock
+	// Registry shares the identical queries across evpas, it is optional
+	Registry *QueryRegistry
+	// KillSwitch defers all the estimations to the current requests when active, it is optional
+	KillSwitch *KillSwitch
+	// Cache memoizes the realtime predicted values, it is optional
+	Cache *PredictionCache
+	// CallerPrefix prefixes the predictor callers, such as the tenant of a shared predictor, EVPACaller by default
+	CallerPrefix string
+	// Secondary serves the queries the Predictor fails transiently or is not ready for, it is optional
+	Secondary prediction.Interface
+	// Recorder emits the events of the clamped and fell back estimations on the evpa, it is optional
+	Recorder record.EventRecorder
+	// OOMRecorder serves the recorded OOMKills, they are bumped along with the ones in the pod status, it is optional
+	OOMRecorder oom.Recorder
+
+	// lastGood saves the last good recommendation by evpa and container
+	lastGood sync.Map
+	// firstSeen saves the time each model is first estimated by evpa and the unique key of the query
+	firstSeen sync.Map
+	// adjustments saves the message of the last emitted adjustment event by evpa, container, resource and reason
+	adjustments sync.Map
+	// stabilizer saves the applied recommendation by evpa, container and resource
+	stabilizer stabilizer
+	// confidences saves the confidence of the last estimation by evpa and container
+	confidences sync.Map
 }
 
-func (e *PercentileResourceEstimator) GetResourceEstimation(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
-	recommendResource := corev1.ResourceList{}
+// caller returns the predictor caller of the evpa, the queries are created and deleted by the same caller
+func (e *PercentileResourceEstimator) caller(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) string {
+	return fmt.Sprintf("%s-%s-%s", callerPrefixOrDefault(e.CallerPrefix), klog.KObj(evpa), string(evpa.UID))
+}
 
-	selector, err := e.TargetFetcher.Fetch(&corev1.ObjectReference{
-		APIVersion: evpa.Spec.TargetRef.APIVersion,
-		Kind:       evpa.Spec.TargetRef.Kind,
-		Name:       evpa.Spec.TargetRef.Name,
-		Namespace:  evpa.Namespace,
-	})
-	if err != nil {
-		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+// callerPrefixOrDefault returns the caller prefix, EVPACaller if it is not set. The evpa callers and the shared callers
+// of the registry are both prefixed by it, so the queries of the estimator are told apart from other subsystems.
+func callerPrefixOrDefault(prefix string) string {
+	if prefix == "" {
+		return defaultCallerPrefix
 	}
-	caller := fmt.Sprintf(callerFormat, klog.KObj(evpa), string(evpa.UID))
-	cpuMetricNamer := &metricnaming.GeneralMetricNamer{
+	return prefix
+}
+
+func (e *PercentileResourceEstimator) now() time.Time {
+	if e.Clock == nil {
+		return time.Now()
+	}
+	return e.Clock.Now()
+}
+
+// newContainerMetricNamer builds the namer of the resource usage of the container of the evpa target, the estimations
+// and the deletions share it so the queries they register and delete have the same unique keys. The device resources,
+// such as nvidia.com/gpu, are named by the device metric.
+func newContainerMetricNamer(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, caller string, containerName string, resourceName corev1.ResourceName, selector labels.Selector) *metricnaming.GeneralMetricNamer {
+	if isDeviceResource(resourceName) {
+		return &metricnaming.GeneralMetricNamer{
+			CallerName: caller,
+			Metric: &metricquery.Metric{
+				Type:       metricquery.DeviceMetricType,
+				MetricName: resourceName.String(),
+				Device: &metricquery.DeviceNamerInfo{
+					Namespace:     evpa.Namespace,
+					WorkloadName:  evpa.Spec.TargetRef.Name,
+					WorkloadKind:  evpa.Spec.TargetRef.Kind,
+					APIVersion:    evpa.Spec.TargetRef.APIVersion,
+					ContainerName: containerName,
+					Selector:      selector,
+				},
+			},
+		}
+	}
+	return &metricnaming.GeneralMetricNamer{
 		CallerName: caller,
 		Metric: &metricquery.Metric{
 			Type:       metricquery.ContainerMetricType,
-			MetricName: corev1.ResourceCPU.String(),
+			MetricName: resourceName.String(),
 			Container: &metricquery.ContainerNamerInfo{
 				Namespace:    evpa.Namespace,
 				WorkloadName: evpa.Spec.TargetRef.Name,
+				WorkloadKind: evpa.Spec.TargetRef.Kind,
+				APIVersion:   evpa.Spec.TargetRef.APIVersion,
 				Name:         containerName,
 				Selector:     selector,
 			},
 		},
 	}
+}
 
-	cpuConfig := getCpuConfig(config)
+// GetResourceEstimation returns the recommended resources of the container. If the samples are not aggregated, the
+// percentile is estimated per pod and the recommendation is the one of the largest pod. The keys scoped to the
+// container, such as 'sidecar.cpu-request-percentile', override the global ones.
+func (e *PercentileResourceEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+	registerMetrics()
+	estimation, err := e.EstimateResources(ctx, evpa, config, containerName, currRes)
+	if err != nil {
+		return nil, err
+	}
+	setRecommendedValues(evpa, containerName, estimation.Resources)
+	return estimation.Resources, nil
+}
 
-	memoryMetricNamer := &metricnaming.GeneralMetricNamer{
-		CallerName: caller,
-		Metric: &metricquery.Metric{
-			Type:       metricquery.ContainerMetricType,
-			MetricName: corev1.ResourceMemory.String(),
-			Container: &metricquery.ContainerNamerInfo{
-				Namespace:    evpa.Namespace,
-				WorkloadName: evpa.Spec.TargetRef.Name,
-				Name:         containerName,
-				Selector:     selector,
-			},
+// GetResourceEstimationWithLimits returns the recommended requests and limits of the container, the current ones are
+// kept for the resources not estimated
+func (e *PercentileResourceEstimator) GetResourceEstimationWithLimits(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (*corev1.ResourceRequirements, error) {
+	registerMetrics()
+	estimation, err := e.EstimateResources(ctx, evpa, config, containerName, currRes)
+	if err != nil {
+		return nil, err
+	}
+	setRecommendedValues(evpa, containerName, estimation.Resources)
+
+	requirements := &corev1.ResourceRequirements{
+		Requests: estimation.Resources.DeepCopy(),
+		Limits:   estimation.Limits.DeepCopy(),
+	}
+	if currRes != nil {
+		requirements.Requests = withFallback(requirements.Requests, currRes.Requests)
+		requirements.Limits = withFallback(requirements.Limits, currRes.Limits)
+	}
+	return requirements, nil
+}
+
+// withFallback adds the fallback quantities of the resources missing in the list
+func withFallback(list corev1.ResourceList, fallback corev1.ResourceList) corev1.ResourceList {
+	for resourceName, quantity := range fallback {
+		if _, exists := list[resourceName]; exists {
+			continue
+		}
+		if list == nil {
+			list = corev1.ResourceList{}
+		}
+		list[resourceName] = quantity.DeepCopy()
+	}
+	return list
+}
+
+// EstimateResources returns the detailed estimation, includes the computed resources and the reason if the emission is deferred
+func (e *PercentileResourceEstimator) EstimateResources(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (*ResourceEstimation, error) {
+	return e.estimateResources(ctx, evpa, config, containerName, currRes, nil)
+}
+
+// estimationStep is a post-processing step of the estimation, the steps run in order and each may defer the estimation
+// to the current requests or add its metadata
+type estimationStep func(e *PercentileResourceEstimator, ctx context.Context, req *estimationRequest, estimation *ResourceEstimation) error
+
+// estimationSteps post-process the estimation. The last good recommendation is stored before the maintenance window and
+// the kill switch defer it, so the deferred estimations are still the shadows of the next ones.
+var estimationSteps = []estimationStep{
+	(*PercentileResourceEstimator).applyNoDownscale,
+	(*PercentileResourceEstimator).applyStabilization,
+	(*PercentileResourceEstimator).applyLimits,
+	(*PercentileResourceEstimator).applyPacingHints,
+	(*PercentileResourceEstimator).applyHPATarget,
+	(*PercentileResourceEstimator).applyQueryBudget,
+	(*PercentileResourceEstimator).applyUnitMismatch,
+	(*PercentileResourceEstimator).applyConfidence,
+	(*PercentileResourceEstimator).applyLastGood,
+	(*PercentileResourceEstimator).applyMaintenanceWindow,
+	(*PercentileResourceEstimator).applyKillSwitch,
+	(*PercentileResourceEstimator).applyCostDelta,
+	(*PercentileResourceEstimator).logEstimation,
+	(*PercentileResourceEstimator).applyIdempotencyKey,
+}
+
+// estimateResources records the steps to the graph if it is not nil
+func (e *PercentileResourceEstimator) estimateResources(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements, graph *ExplanationGraph) (*ResourceEstimation, error) {
+	// the estimation fetches the target workload selector once
+	ctx = WithSelectorCache(ctx)
+	config = containerConfig(config, containerName)
+	opts, err := parseEstimationOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	override, overrideExpiry, err := getRecommendationOverride(evpa, containerName, e.now())
+	if err != nil {
+		return nil, err
+	}
+	req := &estimationRequest{
+		evpa:           evpa,
+		config:         config,
+		containerName:  containerName,
+		currRes:        currRes,
+		opts:           opts,
+		override:       override,
+		overrideExpiry: overrideExpiry,
+		graph:          graph,
+	}
+	if opts.noRunningPodsFallback != "" && e.Client != nil && !req.overridden() {
+		running, err := e.hasRunningPods(ctx, evpa)
+		if err != nil {
+			return nil, err
+		}
+		if !running {
+			return e.noRunningPodsEstimation(evpa, containerName, currRes, opts.noRunningPodsFallback), nil
+		}
+	}
+
+	computed, fellBack, configHash, err := e.computeResources(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	tshirtSize, err := roundResources(req, computed)
+	if err != nil {
+		return nil, err
+	}
+	e.clampResources(req, computed, fellBack)
+
+	estimation := newResourceEstimation(computed)
+	if len(opts.static) > 0 {
+		estimation.Reason = ReasonStatic
+	}
+	if req.overridden() {
+		estimation.Reason = ReasonOverridden
+		estimation.Metadata[MetadataOverrideExpiry] = overrideExpiry.Format(time.RFC3339)
+	}
+	if tshirtSize != "" {
+		estimation.Metadata[MetadataTShirtSize] = tshirtSize
+	}
+	if configHash != "" {
+		estimation.Metadata[MetadataConfigHash] = configHash
+	}
+	for _, step := range estimationSteps {
+		if err := step(e, ctx, req, estimation); err != nil {
+			return nil, err
+		}
+	}
+	return estimation, nil
+}
+
+// computeResources returns the computed resources with the static ones, the ones of them fell back and the hash of the
+// resolved prediction configs. The estimation is bypassed if all resources are pinned or overridden.
+func (e *PercentileResourceEstimator) computeResources(ctx context.Context, req *estimationRequest) (corev1.ResourceList, map[corev1.ResourceName]bool, string, error) {
+	opts := req.opts
+	computed := corev1.ResourceList{}
+	var fellBack map[corev1.ResourceName]bool
+	configHash := ""
+	if !coversAllResources(opts.static, req.override) {
+		var err error
+		computed, fellBack, configHash, err = e.estimate(ctx, req, currentFallback(req.currRes, opts.fallbackToCurrent), currentFallback(req.currRes, true))
+		if err != nil {
+			return nil, nil, "", err
+		}
+		if opts.oomBump != nil && (e.Client != nil || e.OOMRecorder != nil) {
+			if err := e.bumpOOMKilledMemory(ctx, req.evpa, req.containerName, computed, opts.oomBump, req.graph); err != nil {
+				return nil, nil, "", err
+			}
+		}
+	}
+	for resourceName, quantity := range opts.static {
+		computed[resourceName] = quantity
+		req.graph.addInput(resourceName, "static", quantityValue(resourceName, quantity), "pinned by the static config")
+		req.graph.addStep(resourceName, ExplanationNodeTransform, "static", quantityValue(resourceName, quantity), "pinned by the static config")
+	}
+	if opts.cpuMemRatio > 0 {
+		if resourceName, scaled := applyCpuMemRatio(computed, opts.cpuMemRatio, opts.static); scaled {
+			req.graph.addStep(resourceName, ExplanationNodeTransform, "cpu-mem-ratio", quantityValue(resourceName, computed[resourceName]), fmt.Sprintf("scale up to the cpu-mem-ratio %s", req.config["cpu-mem-ratio"]))
+		}
+	}
+	return computed, fellBack, configHash, nil
+}
+
+// roundResources rounds the computed resources up in place and returns the tshirt size they are snapped to. The pinned
+// resources are not rounded, the tshirt sizes are sized for the whole container, so they are off if any resource is
+// pinned or overridden.
+func roundResources(req *estimationRequest, computed corev1.ResourceList) (string, error) {
+	opts := req.opts
+	if memory, exists := computed[corev1.ResourceMemory]; exists && opts.memRoundPow2 && !req.pinned(corev1.ResourceMemory) {
+		computed[corev1.ResourceMemory] = roundUpPow2(memory, maxAllowedOf(req.evpa, req.containerName, corev1.ResourceMemory))
+		req.graph.addStep(corev1.ResourceMemory, ExplanationNodeTransform, "round-pow2", quantityValue(corev1.ResourceMemory, computed[corev1.ResourceMemory]), "round up to the next power of two")
+	}
+	tshirtSize := ""
+	if opts.tshirtSize != nil && len(opts.static) == 0 && !req.overridden() {
+		var err error
+		tshirtSize, err = snapToTShirtSize(computed, opts.tshirtSize)
+		if err != nil {
+			return "", err
+		}
+		for resourceName, quantity := range computed {
+			req.graph.addStep(resourceName, ExplanationNodeTransform, "tshirt-size", quantityValue(resourceName, quantity), fmt.Sprintf("snap to tshirt size %s", tshirtSize))
+		}
+	}
+	if opts.significantFigures > 0 {
+		for resourceName, quantity := range computed {
+			computed[resourceName] = roundUpSignificant(resourceName, quantity, opts.significantFigures)
+			req.graph.addStep(resourceName, ExplanationNodeTransform, "significant-figures", quantityValue(resourceName, computed[resourceName]), fmt.Sprintf("round up to %d significant figures", opts.significantFigures))
+		}
+	}
+	for resourceName, step := range opts.roundTo {
+		quantity, exists := computed[resourceName]
+		if !exists || req.pinned(resourceName) {
+			continue
+		}
+		computed[resourceName] = roundUpTo(resourceName, quantity, step)
+		req.graph.addStep(resourceName, ExplanationNodeTransform, "round-to", quantityValue(resourceName, computed[resourceName]), fmt.Sprintf("round up to the multiple of %s", step.String()))
+	}
+	if memory, exists := computed[corev1.ResourceMemory]; exists && opts.memUnit != nil && !req.pinned(corev1.ResourceMemory) {
+		computed[corev1.ResourceMemory] = roundUpToMemUnit(memory, opts.memUnit)
+		req.graph.addStep(corev1.ResourceMemory, ExplanationNodeTransform, "mem-unit", quantityValue(corev1.ResourceMemory, computed[corev1.ResourceMemory]), opts.memUnit.String())
+	}
+	return tshirtSize, nil
+}
+
+// clampResources clamps the computed resources to the allowed range of the container policy and applies the override,
+// the pinned resources are clamped as the estimated ones, the override is the exact value forced by the operator and
+// is not clamped
+func (e *PercentileResourceEstimator) clampResources(req *estimationRequest, computed corev1.ResourceList, fellBack map[corev1.ResourceName]bool) {
+	estimated := computed.DeepCopy()
+	unclamped := computed.DeepCopy()
+	for _, resourceName := range clampToAllowed(req.evpa, req.containerName, estimated) {
+		computed[resourceName] = estimated[resourceName]
+		req.graph.addStep(resourceName, ExplanationNodeTransform, "allowed", quantityValue(resourceName, computed[resourceName]), "clamp to the allowed range of the container policy")
+	}
+	e.recordClamped(req.evpa, req.containerName, unclamped, estimated, req.override)
+	for resourceName := range req.opts.static {
+		delete(fellBack, resourceName)
+	}
+	for resourceName := range req.override {
+		delete(fellBack, resourceName)
+	}
+	e.recordFellBack(req.evpa, req.containerName, fellBack, currentFallback(req.currRes, true))
+	for resourceName, quantity := range req.override {
+		computed[resourceName] = quantity.DeepCopy()
+		req.graph.addInput(resourceName, "override", quantityValue(resourceName, quantity), "forced by the override annotation")
+		req.graph.addStep(resourceName, ExplanationNodeTransform, "override", quantityValue(resourceName, quantity), fmt.Sprintf("overridden until %s", req.overrideExpiry.Format(time.RFC3339)))
+	}
+}
+
+func (e *PercentileResourceEstimator) applyNoDownscale(_ context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	if !req.opts.noDownscale || req.overridden() {
+		return nil
+	}
+	for _, resourceName := range estimation.lockDownscale(req.currRes) {
+		req.graph.addStep(resourceName, ExplanationNodeTransform, "no-downscale", quantityValue(resourceName, estimation.Resources[resourceName]), "down-scaling is locked, clamp to the current requests")
+	}
+	return nil
+}
+
+// applyStabilization holds the small changes of the sliding window at the applied recommendation to avoid the churn
+func (e *PercentileResourceEstimator) applyStabilization(_ context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	thresholds := req.opts.stabilizationThresholds
+	if len(thresholds) == 0 || req.overridden() {
+		return nil
+	}
+	for _, resourceName := range estimation.stabilize(&e.stabilizer, lastGoodKey(req.evpa, req.containerName), thresholds, e.now()) {
+		req.graph.addStep(resourceName, ExplanationNodeTransform, "stabilization", quantityValue(resourceName, estimation.Resources[resourceName]), fmt.Sprintf("within the stabilization threshold %g, hold the applied recommendation", thresholds[resourceName]))
+	}
+	return nil
+}
+
+// applyLimits recommends the limits, they fall back to the current ones if the limit percentiles can't be estimated.
+// The requests may be capped to the current limits.
+func (e *PercentileResourceEstimator) applyLimits(ctx context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	var targetLimits corev1.ResourceList
+	if req.opts.limitPercentiles != nil && !req.overridden() {
+		var err error
+		targetLimits, err = e.estimateLimits(ctx, req.evpa, req.config, req.containerName, req.opts.limitPercentiles)
+		if err != nil {
+			klog.ErrorS(err, "Failed to estimate the limits by the limit percentiles.", "evpa", klog.KObj(req.evpa), "container", req.containerName)
+		}
+	}
+	limits, err := recommendLimits(req.currRes, estimation.Resources, targetLimits, req.config)
+	if err != nil {
+		return err
+	}
+	estimation.Limits = limits
+	return nil
+}
+
+func (e *PercentileResourceEstimator) applyPacingHints(ctx context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	if req.opts.pacingHints {
+		e.setPacingHints(ctx, req.evpa, req.currRes, estimation)
+	}
+	return nil
+}
+
+func (e *PercentileResourceEstimator) applyHPATarget(ctx context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	cpu, exists := estimation.Resources[corev1.ResourceCPU]
+	if !exists || req.opts.hpaTarget == nil || !req.opts.budget.take(1) {
+		return nil
+	}
+	utilization, found, err := e.suggestHPATargetUtilization(ctx, req.evpa, req.containerName, quantityValue(corev1.ResourceCPU, cpu), req.opts.hpaTarget)
+	if err != nil {
+		return err
+	}
+	if found {
+		estimation.Metadata[MetadataSuggestedHPATargetUtilization] = strconv.FormatInt(int64(utilization), 10)
+	}
+	return nil
+}
+
+func (e *PercentileResourceEstimator) applyQueryBudget(_ context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	if !req.opts.budget.isReduced() {
+		return nil
+	}
+	klog.V(4).InfoS("Skipped some queries to stay in the query budget.", "evpa", klog.KObj(req.evpa), "container", req.containerName, "maxQueries", req.opts.budget.max)
+	if estimation.Reason == "" {
+		estimation.Reason = ReasonQueryBudgetReduced
+	}
+	return nil
+}
+
+func (e *PercentileResourceEstimator) applyUnitMismatch(_ context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	suspected := unitMismatchSuspected(req.currRes, estimation.Computed, req.opts.unitMismatchRatio)
+	if len(suspected) == 0 || req.overridden() {
+		return nil
+	}
+	klog.Warningf("Unit mismatch suspected for evpa %s container %s, resources %v of %v differ wildly from the current requests", klog.KObj(req.evpa), req.containerName, suspected, estimation.Computed)
+	estimation.deferToCurrent(req.currRes, ReasonUnitMismatchSuspected)
+	for resourceName, quantity := range estimation.Resources {
+		req.graph.addStep(resourceName, ExplanationNodeTransform, "unit-mismatch", quantityValue(resourceName, quantity), "unit mismatch suspected, defer to the current requests")
+	}
+	return nil
+}
+
+// applyConfidence defers the recommendation of a too short or too sparse history until enough samples are collected
+func (e *PercentileResourceEstimator) applyConfidence(ctx context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	if req.opts.confidence == nil || e.History == nil || req.overridden() || !req.opts.budget.take(2) {
+		return nil
+	}
+	confidence, err := e.estimateConfidence(ctx, req.evpa, req.config, req.containerName, req.opts.confidence)
+	if err != nil {
+		return err
+	}
+	e.storeConfidence(req.evpa, req.containerName, confidence)
+	estimation.Metadata[MetadataConfidence] = strconv.FormatFloat(confidence.Score, 'f', 2, 64)
+	if !confidence.Confident {
+		estimation.deferToCurrent(req.currRes, ReasonLowConfidence)
+		for resourceName, quantity := range estimation.Resources {
+			req.graph.addStep(resourceName, ExplanationNodeTransform, "low-confidence", quantityValue(resourceName, quantity), fmt.Sprintf("only %d samples, confidence %.2f, defer to the current requests", confidence.Samples, confidence.Score))
+		}
+	}
+	return nil
+}
+
+func (e *PercentileResourceEstimator) applyLastGood(_ context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	if estimation.Reason != ReasonUnitMismatchSuspected && estimation.Reason != ReasonOverridden && estimation.Reason != ReasonLowConfidence {
+		e.storeLastGood(req.evpa, req.containerName, estimation.Resources)
+	}
+	return nil
+}
+
+// applyMaintenanceWindow still computes outside the maintenance window so the result can be used as a shadow, but
+// defers the change
+func (e *PercentileResourceEstimator) applyMaintenanceWindow(_ context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	if req.opts.maintenanceWindows == nil || req.opts.maintenanceWindows.Contains(e.now()) {
+		return nil
+	}
+	estimation.deferToCurrent(req.currRes, ReasonOutsideMaintenanceWindow)
+	for resourceName, quantity := range estimation.Resources {
+		req.graph.addStep(resourceName, ExplanationNodeTransform, "maintenance-window", quantityValue(resourceName, quantity), "outside the maintenance window, defer to the current requests")
+	}
+	return nil
+}
+
+func (e *PercentileResourceEstimator) applyKillSwitch(ctx context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	if !e.KillSwitch.Active(ctx) {
+		return nil
+	}
+	estimation.deferToCurrent(req.currRes, ReasonGloballyDisabled)
+	for resourceName, quantity := range estimation.Resources {
+		req.graph.addStep(resourceName, ExplanationNodeTransform, "kill-switch", quantityValue(resourceName, quantity), "globally disabled, defer to the current requests")
+	}
+	return nil
+}
+
+// applyCostDelta sets the cost delta of what is emitted, zero if the recommendation is deferred
+func (e *PercentileResourceEstimator) applyCostDelta(ctx context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	if req.opts.cost == nil {
+		return nil
+	}
+	return e.setCostDelta(ctx, req.evpa, req.currRes, estimation, req.opts.cost)
+}
+
+func (e *PercentileResourceEstimator) logEstimation(_ context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	if !klog.V(estimationLogLevel).Enabled() {
+		return nil
+	}
+	for resourceName, quantity := range estimation.Resources {
+		computed := estimation.Computed[resourceName]
+		logEstimationStep("Estimated the resource.", req.evpa, req.containerName, resourceName, "computed", computed.String(), "quantity", quantity.String(), "reason", estimation.Reason)
+	}
+	return nil
+}
+
+func (e *PercentileResourceEstimator) applyIdempotencyKey(_ context.Context, req *estimationRequest, estimation *ResourceEstimation) error {
+	estimation.setNumericMetadata()
+	estimation.Metadata[MetadataIdempotencyKey] = RecommendationIdempotencyKey(req.evpa.Namespace, req.evpa.Spec.TargetRef.Name, req.containerName, estimation.Resources)
+	return nil
+}
+
+// estimationQueries are the prediction queries of the estimated resources of a container
+type estimationQueries struct {
+	caller   string
+	selector labels.Selector
+	// resourceNames are the estimated resources in the order they are registered, the cpu and memory are registered
+	// first, or the memory will be not registered before the cpu prediction succeed
+	resourceNames []corev1.ResourceName
+	// metricNamers are the namers of the evpa, the secondary predictor is called by them
+	metricNamers map[corev1.ResourceName]*metricnaming.GeneralMetricNamer
+	// queryNamers are the namers registered in the predictor, they are shared across evpas if the registry is set.
+	// The resources not controlled by the evpa are not queried.
+	queryNamers map[corev1.ResourceName]metricnaming.MetricNamer
+	configs     map[corev1.ResourceName]*predictionconfig.Config
+	// prefixes are the config prefixes of the resources
+	prefixes   map[corev1.ResourceName]string
+	controlled controlledResources
+	configHash string
+}
+
+// newEstimationQueries resolves the prediction configs and builds the namers of the container. The ephemeral storage is
+// opt-in by its own config, the controlled resources only gate the cpu and memory.
+func (e *PercentileResourceEstimator) newEstimationQueries(req *estimationRequest, selector labels.Selector) (*estimationQueries, error) {
+	evpa, config, predictionOpts := req.evpa, req.config, req.opts.prediction
+	caller := e.caller(evpa)
+	cpuMetricNamer := newContainerMetricNamer(evpa, caller, req.containerName, corev1.ResourceCPU, selector)
+	cpuConfig, err := getCpuConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyBurstableCpuConfig(evpa, cpuConfig, config); err != nil {
+		return nil, err
+	}
+	if err := e.extendHistoryLength(cpuMetricNamer, cpuConfig, config, "cpu"); err != nil {
+		return nil, err
+	}
+	memoryMetricNamer := newContainerMetricNamer(evpa, caller, req.containerName, corev1.ResourceMemory, selector)
+	memConfig, err := getMemConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.extendHistoryLength(memoryMetricNamer, memConfig, config, "mem"); err != nil {
+		return nil, err
+	}
+	if err := applyHistogramScale(config, cpuConfig, memConfig); err != nil {
+		return nil, err
+	}
+	if err := validateGranularity(cpuConfig, memConfig, caller); err != nil {
+		return nil, err
+	}
+	configHash, err := ConfigHash(cpuConfig, memConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &estimationQueries{
+		caller:        caller,
+		selector:      selector,
+		resourceNames: []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory},
+		metricNamers: map[corev1.ResourceName]*metricnaming.GeneralMetricNamer{
+			corev1.ResourceCPU:    cpuMetricNamer,
+			corev1.ResourceMemory: memoryMetricNamer,
+		},
+		queryNamers: map[corev1.ResourceName]metricnaming.MetricNamer{},
+		configs: map[corev1.ResourceName]*predictionconfig.Config{
+			corev1.ResourceCPU:              cpuConfig,
+			corev1.ResourceMemory:           memConfig,
+			corev1.ResourceEphemeralStorage: predictionOpts.storage,
+		},
+		prefixes: map[corev1.ResourceName]string{
+			corev1.ResourceCPU:    "cpu",
+			corev1.ResourceMemory: "mem",
 		},
+		controlled: controlledResourcesOf(evpa, req.containerName),
+		configHash: configHash,
 	}
-	memConfig := getMemConfig(config)
+	if predictionOpts.storage != nil {
+		q.resourceNames = append(q.resourceNames, corev1.ResourceEphemeralStorage)
+		q.metricNamers[corev1.ResourceEphemeralStorage] = newEphemeralStorageMetricNamer(evpa, caller, req.containerName, selector)
+		q.prefixes[corev1.ResourceEphemeralStorage] = ephemeralStoragePrefix
+	}
+	for _, metric := range predictionOpts.customMetrics {
+		resourceName := corev1.ResourceName(metric.name)
+		q.resourceNames = append(q.resourceNames, resourceName)
+		q.metricNamers[resourceName] = newCustomMetricNamer(evpa, caller, req.containerName, metric, selector)
+		q.configs[resourceName] = metric.config
+		q.prefixes[resourceName] = metric.name
+	}
+	for _, resourceName := range q.resourceNames {
+		if q.queried(resourceName) {
+			q.queryNamers[resourceName] = q.metricNamers[resourceName]
+		}
+	}
+	return q, nil
+}
 
-	var errs []error
-	// first register cpu & memory, or the memory will be not registered before the cpu prediction succeed
-	err1 := e.Predictor.WithQuery(cpuMetricNamer, caller, *cpuConfig)
-	if err1 != nil {
-		errs = append(errs, err1)
+// queried tells whether the resource is queried, the cpu and memory are queried only if they are controlled
+func (q *estimationQueries) queried(resourceName corev1.ResourceName) bool {
+	switch resourceName {
+	case corev1.ResourceCPU, corev1.ResourceMemory:
+		return q.controlled.controls(resourceName)
+	default:
+		_, exists := q.metricNamers[resourceName]
+		return exists
 	}
-	err2 := e.Predictor.WithQuery(memoryMetricNamer, caller, *memConfig)
-	if err2 != nil {
-		errs = append(errs, err2)
+}
+
+// queryKeys returns the unique keys of the metric namers by the resource
+func (q *estimationQueries) queryKeys() map[corev1.ResourceName]string {
+	queryKeys := map[corev1.ResourceName]string{}
+	for resourceName, metricNamer := range q.metricNamers {
+		queryKeys[resourceName] = metricNamer.BuildUniqueKey()
+	}
+	return queryKeys
+}
+
+// register registers the queries in the predictor, by the registry if it is set so the identical queries are shared
+func (e *PercentileResourceEstimator) register(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, q *estimationQueries) error {
+	var errs []error
+	for _, resourceName := range q.resourceNames {
+		if !q.queried(resourceName) {
+			continue
+		}
+		metricNamer, cfg := q.metricNamers[resourceName], q.configs[resourceName]
+		if e.Registry == nil {
+			if err := e.Predictor.WithQuery(metricNamer, q.caller, *cfg); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		queryNamer, err := e.Registry.Register(evpaReferent(evpa), metricNamer, *cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		q.queryNamers[resourceName] = queryNamer
 	}
 	if len(errs) > 0 {
-		return nil, fmt.Errorf("failed to register metricNamer: %v", errs)
+		return fmt.Errorf("failed to register metricNamer: %v", errs)
+	}
+	return nil
+}
+
+// predictedEstimation accumulates the estimated resources of the predicted values and the history
+type predictedEstimation struct {
+	resources corev1.ResourceList
+	// fellBack are the resources fell back to the current requests, they are not estimated
+	fellBack    map[corev1.ResourceName]bool
+	predictErrs []error
+	noValueErrs []error
+}
+
+// set sets the estimated value of the resource, it is not fell back anymore
+func (p *predictedEstimation) set(resourceName corev1.ResourceName, value float64) {
+	p.resources[resourceName] = valueQuantity(resourceName, value)
+	delete(p.fellBack, resourceName)
+}
+
+// valueQuantity converts the estimated value to the quantity of the resource. The custom metrics are recommended in
+// the milli precision and rounded up, they may be fractional.
+func valueQuantity(resourceName corev1.ResourceName, value float64) resource.Quantity {
+	switch resourceName {
+	case corev1.ResourceCPU:
+		return *resource.NewMilliQuantity(int64(value*1000), resource.DecimalSI)
+	case corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+		return *resource.NewQuantity(int64(value), resource.BinarySI)
+	default:
+		return *resource.NewMilliQuantity(int64(math.Ceil(value*1000)), resource.DecimalSI)
 	}
+}
 
-	var predictErrs []error
-	var noValueErrs []error
-	tsList, err := e.Predictor.QueryRealtimePredictedValues(context.TODO(), cpuMetricNamer)
+// estimate returns the estimated resources, the ones of them fell back and the hash of the resolved prediction configs.
+// The resources without prediction samples fall back to the quantities of the fallback if it has them.
+func (e *PercentileResourceEstimator) estimate(ctx context.Context, req *estimationRequest, fallback corev1.ResourceList, current corev1.ResourceList) (corev1.ResourceList, map[corev1.ResourceName]bool, string, error) {
+	evpa, opts := req.evpa, req.opts.prediction
+	if err := resolveTargetWorkload(ctx, e.Client, evpa); err != nil {
+		return nil, nil, "", err
+	}
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
 	if err != nil {
-		predictErrs = append(predictErrs, err)
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+	q, err := e.newEstimationQueries(req, selector)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if err := e.register(evpa, q); err != nil {
+		return nil, nil, "", err
+	}
+	if klog.V(estimationLogLevel).Enabled() {
+		for resourceName, namer := range q.queryNamers {
+			logEstimationStep("Built the estimation query.", evpa, req.containerName, resourceName, "caller", q.caller, "queryExpr", namer.BuildUniqueKey())
+		}
+	}
+	// the secondary predictor is called by the evpa itself, not shared by the registry
+	metricNamers := map[corev1.ResourceName]metricnaming.MetricNamer{}
+	for resourceName := range q.queryNamers {
+		metricNamers[resourceName] = q.metricNamers[resourceName]
+	}
+	secondaryNamers := e.registerSecondary(metricNamers, q.caller, q.configs)
+	fallback = e.warmupFallback(evpa, fallback, current, opts.warmup, q.queryNamers)
+	predicted, err := e.queryCachedPredictedValues(ctx, req.config, q.queryNamers, secondaryNamers, q.configs, req.opts.budget)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if klog.V(estimationLogLevel).Enabled() {
+		for resourceName, values := range predicted {
+			logEstimationStep("Queried the predicted values.", evpa, req.containerName, resourceName, "predictor", values.predictor, "series", len(values.tsList), "err", values.err)
+		}
 	}
 
-	if len(tsList) > 0 && len(tsList[0].Samples) > 0 {
-		cpuValue := int64(tsList[0].Samples[0].Value * 1000)
-		recommendResource[corev1.ResourceCPU] = *resource.NewMilliQuantity(cpuValue, resource.DecimalSI)
-	} else {
-		noValueErrs = append(noValueErrs, fmt.Errorf("no value retured for queryExpr: %s", cpuMetricNamer.BuildUniqueKey()))
+	result := &predictedEstimation{resources: corev1.ResourceList{}, fellBack: map[corev1.ResourceName]bool{}}
+	for _, resourceName := range q.resourceNames {
+		if _, queried := q.queryNamers[resourceName]; !queried {
+			continue
+		}
+		if err := e.recommendPredicted(ctx, req, q, resourceName, predicted[resourceName], fallback, result); err != nil {
+			return nil, nil, "", err
+		}
 	}
+	countEstimationErrors(result.predictErrs, result.noValueErrs)
 
-	tsList, err = e.Predictor.QueryRealtimePredictedValues(context.TODO(), memoryMetricNamer)
-	if err != nil {
-		predictErrs = append(predictErrs, err)
+	// the aggregated prediction mixes the samples of all the replicas, it is normalized to a pod by the live replicas
+	if opts.perReplicaNormalization && e.Client != nil {
+		replicas, found, err := targetReplicas(ctx, e.Client, evpa)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to get the target replicas: %v", err)
+		}
+		// the workload scaled to zero has no pod to normalize to
+		if found && replicas > 0 {
+			normalizePerReplica(result.resources, replicas, q.configs, result.fellBack, req.graph)
+		}
 	}
 
-	if len(tsList) > 0 && len(tsList[0].Samples) > 0 {
-		memValue := int64(tsList[0].Samples[0].Value)
-		recommendResource[corev1.ResourceMemory] = *resource.NewQuantity(memValue, resource.BinarySI)
-	} else {
-		noValueErrs = append(noValueErrs, fmt.Errorf("no value retured for queryExpr: %s", memoryMetricNamer.BuildUniqueKey()))
+	// the history queries below are not context aware, don't start them if the context is already done
+	if err := ctx.Err(); err != nil {
+		return nil, nil, "", fmt.Errorf("estimation interrupted: %w", err)
+	}
+	if err := e.estimateHistory(ctx, req, q, result); err != nil {
+		return nil, nil, "", err
+	}
+	if err := e.estimateRps(req, q, result); err != nil {
+		return nil, nil, "", err
+	}
+	if err := e.estimateCorrelated(req, q, result); err != nil {
+		return nil, nil, "", err
 	}
 
+	// a buggy data source or query may yield negative usage
+	if err := clampNegativeResources(result.resources, req.config, q.queryKeys(), req.graph); err != nil {
+		return nil, nil, "", err
+	}
+	// the absolute margin cushions the small estimations, it is applied before the transforms and the clamping
+	applyAbsoluteMargins(result.resources, opts.absoluteMargins, q.configs, result.fellBack, req.graph)
+
 	// all failed
-	if len(recommendResource) == 0 {
-		return recommendResource, fmt.Errorf("all resource predicted failed, predictErrs: %v, noValueErrs: %v", predictErrs, noValueErrs)
+	if len(result.resources) == 0 {
+		return result.resources, nil, "", allFailedError(result.predictErrs, result.noValueErrs)
 	}
 
 	// at least one succeed
-	return recommendResource, nil
+	return result.resources, result.fellBack, q.configHash, nil
 }
 
-func (e *PercentileResourceEstimator) DeleteEstimation(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) {
-	selector, err := e.TargetFetcher.Fetch(&corev1.ObjectReference{
-		APIVersion: evpa.Spec.TargetRef.APIVersion,
-		Kind:       evpa.Spec.TargetRef.Kind,
-		Name:       evpa.Spec.TargetRef.Name,
-		Namespace:  evpa.Namespace,
-	})
+// recommendPredicted recommends the resource by its predicted values. If there are no prediction samples, it falls
+// back to the quantity of the fallback, only the fell back cpu and memory are reported.
+func (e *PercentileResourceEstimator) recommendPredicted(ctx context.Context, req *estimationRequest, q *estimationQueries, resourceName corev1.ResourceName, values predictedValues, fallback corev1.ResourceList, result *predictedEstimation) error {
+	opts := req.opts.prediction
+	metricNamer, queryNamer, cfg := q.metricNamers[resourceName], q.queryNamers[resourceName], q.configs[resourceName]
+	tsList, err := largestSeries(values.tsList, opts.sampleSelection), values.err
 	if err != nil {
-		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+		result.predictErrs = append(result.predictErrs, err)
 	}
-	for _, containerPolicy := range evpa.Spec.ResourcePolicy.ContainerPolicies {
-		caller := fmt.Sprintf(callerFormat, klog.KObj(evpa), string(evpa.UID))
-		cpuMetricNamer := &metricnaming.GeneralMetricNamer{
-			CallerName: caller,
-			Metric: &metricquery.Metric{
-				Type:       metricquery.ContainerMetricType,
-				MetricName: corev1.ResourceCPU.String(),
-				Container: &metricquery.ContainerNamerInfo{
-					Namespace:    evpa.Namespace,
-					WorkloadName: evpa.Spec.TargetRef.Name,
-					Name:         containerPolicy.ContainerName,
-					Selector:     selector,
-				},
-			},
+
+	samples := seriesSamples(tsList)
+	sample, selected := selectSample(samples, opts.sampleSelection)
+	logPredicted(req.evpa, req.containerName, resourceName, queryNamer, cfg, tsList, sample, selected)
+	if selected {
+		req.graph.explainPredicted(resourceName, metricNamer, cfg, tsList[0].Samples, sample.Value)
+	}
+	// the outliers are dropped only if opted in, a burst of the usage looks the same as a glitch
+	if outliers, exists := opts.outliers[resourceName]; exists {
+		samples = discardOutliers(samples, outliers)
+		if sample, selected = selectSample(samples, opts.sampleSelection); selected {
+			req.graph.addStep(resourceName, ExplanationNodeTransform, "outlier", sample.Value, "discard the samples far above the median")
+		}
+	}
+
+	if selected {
+		value := sample.Value
+		if alpha, exists := opts.blendAlphas[resourceName]; exists {
+			value = blendWithMax(resourceName, value, samples, alpha, req.graph)
 		}
-		err := e.Predictor.DeleteQuery(cpuMetricNamer, caller)
+		value, err := validSampleValue(req.config, q.prefixes[resourceName], resourceName, value, metricNamer.BuildUniqueKey(), req.graph)
 		if err != nil {
-			klog.ErrorS(err, "Failed to delete query.", "queryExpr", cpuMetricNamer.BuildUniqueKey())
+			return err
 		}
-		memoryMetricNamer := &metricnaming.GeneralMetricNamer{
-			CallerName: caller,
-			Metric: &metricquery.Metric{
-				Type:       metricquery.ContainerMetricType,
-				MetricName: corev1.ResourceMemory.String(),
-				Container: &metricquery.ContainerNamerInfo{
-					Namespace:    evpa.Namespace,
-					WorkloadName: evpa.Spec.TargetRef.Name,
-					Name:         containerPolicy.ContainerName,
-					Selector:     selector,
-				},
-			},
+		result.resources[resourceName] = valueQuantity(resourceName, value)
+	} else if quantity, exists := fallback[resourceName]; exists && err == nil {
+		result.resources[resourceName] = quantity.DeepCopy()
+		if resourceName == corev1.ResourceCPU || resourceName == corev1.ResourceMemory {
+			result.fellBack[resourceName] = true
 		}
-		err = e.Predictor.DeleteQuery(memoryMetricNamer, caller)
+		req.graph.addInput(resourceName, "current", quantityValue(resourceName, quantity), "no prediction samples, fall back to the current request")
+	} else if err == nil {
+		result.noValueErrs = append(result.noValueErrs, noValueError(ctx, e.Predictor, queryNamer))
+	}
+	return nil
+}
+
+// estimateHistory overrides the predicted value by the raw history, it is needed to preprocess the samples or
+// attribute them to the pods
+func (e *PercentileResourceEstimator) estimateHistory(ctx context.Context, req *estimationRequest, q *estimationQueries, result *predictedEstimation) error {
+	historyConfig := req.opts.prediction.history
+	if historyConfig == nil || e.History == nil || (e.Client == nil && historyConfig.needsPods()) {
+		return nil
+	}
+	if historyConfig.scaledToZero != nil {
+		historyConfig.scaledToZero.bind(req.evpa, q.caller)
+	}
+	if historyConfig.perPod != nil {
+		historyConfig.perPod.bind(req.evpa, q.caller)
+	}
+	for _, counterReset := range historyConfig.counterResets {
+		counterReset.bind(req.evpa, q.caller, req.containerName)
+	}
+	var pods []corev1.Pod
+	if historyConfig.needsPods() {
+		var err error
+		pods, err = listTargetPods(ctx, e.Client, req.evpa.Namespace, q.selector)
+		if err != nil {
+			return fmt.Errorf("failed to list target pods: %v", err)
+		}
+	}
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		prefix := q.prefixes[resourceName]
+		if !q.controlled.controls(resourceName) || !req.opts.budget.take(historyConfig.queriesOf(prefix)) {
+			continue
+		}
+		value, found, err := e.estimateFromHistory(q.metricNamers[resourceName], q.configs[resourceName], prefix, req.opts.prediction.outliers[resourceName], pods, historyConfig)
 		if err != nil {
-			klog.ErrorS(err, "Failed to delete query.", "queryExpr", memoryMetricNamer.BuildUniqueKey())
+			return err
+		}
+		if found {
+			req.graph.addStep(resourceName, ExplanationNodeTransform, "history", value, historyConfig.String())
+			result.set(resourceName, value)
 		}
 	}
-	return
+	return nil
 }
 
-func getCpuConfig(config map[string]string) *predictionconfig.Config {
-	sampleInterval, exists := config["cpu-sample-interval"]
-	if !exists {
-		sampleInterval = "1m"
+// estimateRps overrides the predicted value by the resource-per-request model when the usage is driven by the requests
+func (e *PercentileResourceEstimator) estimateRps(req *estimationRequest, q *estimationQueries, result *predictedEstimation) error {
+	rpsConfig := req.opts.prediction.rps
+	if rpsConfig == nil || e.History == nil {
+		return nil
 	}
-	percentile, exists := config["cpu-request-percentile"]
-	if !exists {
-		percentile = "0.99"
+	rpsNamer := newRpsMetricNamer(req.evpa, q.caller, rpsConfig.queryExpr, q.selector)
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		// each resource queries its usage and the rps
+		if !q.controlled.controls(resourceName) || !req.opts.budget.take(2) {
+			continue
+		}
+		value, detail, found, err := e.estimateFromRps(q.metricNamers[resourceName], rpsNamer, q.configs[resourceName], rpsConfig)
+		if err != nil {
+			return err
+		}
+		if found {
+			req.graph.addStep(resourceName, ExplanationNodeTransform, "rps-model", value, detail)
+			result.set(resourceName, value)
+		}
 	}
-	marginFraction, exists := config["cpu-request-margin-fraction"]
-	if !exists {
-		marginFraction = "0.15"
+	return nil
+}
+
+// estimateCorrelated sizes the cpu to the max of the cpu usage and the correlated metrics
+func (e *PercentileResourceEstimator) estimateCorrelated(req *estimationRequest, q *estimationQueries, result *predictedEstimation) error {
+	if len(req.opts.prediction.correlatedMetrics) == 0 || e.History == nil || !q.controlled.controls(corev1.ResourceCPU) {
+		return nil
+	}
+	for _, metric := range req.opts.prediction.correlatedMetrics {
+		if !req.opts.budget.take(1) {
+			break
+		}
+		value, found, err := e.estimateFromCorrelatedMetric(newCorrelatedMetricNamer(req.evpa, q.caller, metric), q.configs[corev1.ResourceCPU], metric)
+		if err != nil {
+			return err
+		}
+		cpu, exists := result.resources[corev1.ResourceCPU]
+		if !found || (exists && quantityValue(corev1.ResourceCPU, cpu) >= value) {
+			continue
+		}
+		req.graph.addStep(corev1.ResourceCPU, ExplanationNodeTransform, "correlated-"+metric.name, value, fmt.Sprintf("correlated metric %s normalized by %g dominates", metric.name, metric.coefficient))
+		result.set(corev1.ResourceCPU, value)
 	}
+	return nil
+}
 
-	initModeStr, exists := config["cpu-model-init-mode"]
-	initMode := predictionconfig.ModelInitModeLazyTraining
-	if !exists {
-		initMode = predictionconfig.ModelInitMode(initModeStr)
+func (e *PercentileResourceEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	e.deleteLastGood(evpa)
+	e.forgetFirstSeen(evpa)
+	e.forgetAdjustments(evpa)
+	e.forgetConfidences(evpa)
+	e.stabilizer.forget(evpaReferent(evpa) + "/")
+	// the shared queries of the primary are deleted by the registry once no evpa refers them
+	predictors := map[string]prediction.Interface{}
+	if e.Registry != nil {
+		e.Registry.Release(evpaReferent(evpa))
+	} else {
+		predictors[primaryPredictor] = e.Predictor
+	}
+	if e.Secondary != nil {
+		predictors[secondaryPredictor] = e.Secondary
+	}
+	// an evpa mid-deletion or partially applied may have no resource policy, nothing is registered then
+	if len(predictors) == 0 || evpa.Spec.ResourcePolicy == nil {
+		return nil
 	}
 
-	historyLength, exists := config["cpu-model-history-length"]
-	if !exists {
-		historyLength = "24h"
-	}
-
-	return &predictionconfig.Config{
-		InitMode: &initMode,
-		Percentile: &predictionapi.Percentile{
-			Aggregated:     true,
-			HistoryLength:  historyLength,
-			SampleInterval: sampleInterval,
-			MarginFraction: marginFraction,
-			Percentile:     percentile,
-			Histogram: predictionapi.HistogramConfig{
-				HalfLife:   "24h",
-				BucketSize: "0.1",
-				MaxValue:   "100",
-			},
-		},
+	selector, err := fetchSelector(ctx, e.TargetFetcher, evpa)
+	if err != nil {
+		klog.ErrorS(err, "Failed to fetch evpa target workload selector.", "evpa", klog.KObj(evpa))
+	}
+	caller := e.caller(evpa)
+	// the ephemeral storage and the custom metrics may not be estimated, deleting an unknown query is a no-op
+	resourceNames := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage}
+	customMetrics := customMetricsOf(evpa)
+	var errs []error
+	for _, containerPolicy := range evpa.Spec.ResourcePolicy.ContainerPolicies {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("stopped deleting the queries: %w", err))
+			break
+		}
+		var metricNamers []*metricnaming.GeneralMetricNamer
+		for _, resourceName := range resourceNames {
+			metricNamers = append(metricNamers, newContainerMetricNamer(evpa, caller, containerPolicy.ContainerName, resourceName, selector))
+		}
+		for _, metric := range customMetrics {
+			metricNamers = append(metricNamers, newCustomMetricNamer(evpa, caller, containerPolicy.ContainerName, metric, selector))
+		}
+		for _, metricNamer := range metricNamers {
+			for _, role := range []string{primaryPredictor, secondaryPredictor} {
+				predictor, exists := predictors[role]
+				if !exists {
+					continue
+				}
+				if err := predictor.DeleteQuery(metricNamer, caller); err != nil {
+					errs = append(errs, fmt.Errorf("delete query %s of the %s predictor failed: %v", metricNamer.BuildUniqueKey(), role, err))
+				}
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// evpaReferent identifies the evpa referring the shared queries
+func evpaReferent(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) string {
+	return fmt.Sprintf("%s/%s", klog.KObj(evpa), evpa.UID)
+}
+
+func getCpuConfig(config map[string]string) (*predictionconfig.Config, error) {
+	defaults, err := getProfileEstimatorConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	model, err := parseModelConfig(config, "cpu", defaults.CPU)
+	if err != nil {
+		return nil, err
+	}
+	interpolation, err := getPercentileInterpolation(config)
+	if err != nil {
+		return nil, err
+	}
+	cfg := model.predictionConfig()
+	cfg.PercentileInterpolation = interpolation
+	return cfg, nil
+}
+
+func getMemConfig(props map[string]string) (*predictionconfig.Config, error) {
+	defaults, err := getProfileEstimatorConfig(props)
+	if err != nil {
+		return nil, err
+	}
+	model, err := parseModelConfig(props, "mem", defaults.Memory)
+	if err != nil {
+		return nil, err
+	}
+	interpolation, err := getPercentileInterpolation(props)
+	if err != nil {
+		return nil, err
 	}
+	cfg := model.predictionConfig()
+	cfg.PercentileInterpolation = interpolation
+	return cfg, nil
 }
 
-func getMemConfig(props map[string]string) *predictionconfig.Config {
-	sampleInterval, exists := props["mem-sample-interval"]
+// getAggregated returns whether the samples of all pods are aggregated to one series, true by default. If not, the
+// prediction is per pod.
+func getAggregated(config map[string]string, key string) (bool, error) {
+	value, exists := config[key]
 	if !exists {
-		sampleInterval = "1m"
+		return true, nil
+	}
+	aggregated, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parse %s failed: %v", key, err)
+	}
+	return aggregated, nil
+}
+
+// largestSeries returns the series of the largest selected value. The prediction is per pod if it is not aggregated,
+// the recommendation is then the one of the largest pod, so it fits every pod of the workload. The ties are resolved
+// to the earlier series, so the selection is deterministic.
+func largestSeries(tsList []*common.TimeSeries, selection string) []*common.TimeSeries {
+	if len(tsList) <= 1 {
+		return tsList
 	}
-	percentile, exists := props["mem-request-percentile"]
+	var largest *common.TimeSeries
+	var largestValue float64
+	for _, ts := range tsList {
+		sample, selected := selectSample(ts.Samples, selection)
+		if !selected {
+			continue
+		}
+		if largest == nil || sample.Value > largestValue {
+			largest, largestValue = ts, sample.Value
+		}
+	}
+	if largest == nil {
+		return tsList[:1]
+	}
+	return []*common.TimeSeries{largest}
+}
+
+// getPercentile returns the percentile of the key, it must be a fraction in (0,1] rather than a percentage
+func getPercentile(config map[string]string, key string, defaultValue string) (string, error) {
+	value, exists := config[key]
 	if !exists {
-		percentile = "0.99"
+		return defaultValue, nil
+	}
+	percentile, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", fmt.Errorf("parse %s failed: %v", key, err)
 	}
-	marginFraction, exists := props["mem-request-margin-fraction"]
+	if math.IsNaN(percentile) || percentile <= 0 || percentile > 1 {
+		return "", fmt.Errorf("%s must be in (0,1], got %v", key, value)
+	}
+	return value, nil
+}
+
+// getMarginFraction returns the margin fraction of the key, it must be finite and not negative
+func getMarginFraction(config map[string]string, key string, defaultValue string) (string, error) {
+	value, exists := config[key]
 	if !exists {
-		marginFraction = "0.15"
+		return defaultValue, nil
+	}
+	marginFraction, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", fmt.Errorf("parse %s failed: %v", key, err)
 	}
+	if math.IsNaN(marginFraction) || math.IsInf(marginFraction, 0) || marginFraction < 0 {
+		return "", fmt.Errorf("%s must be a finite non-negative fraction, got %v", key, value)
+	}
+	return value, nil
+}
 
-	initModeStr, exists := props["mem-model-init-mode"]
-	initMode := predictionconfig.ModelInitModeLazyTraining
+// getModelInitMode returns the init mode of the key, the lazy training by default
+func getModelInitMode(config map[string]string, key string) (predictionconfig.ModelInitMode, error) {
+	value, exists := config[key]
 	if !exists {
-		initMode = predictionconfig.ModelInitMode(initModeStr)
+		return predictionconfig.ModelInitModeLazyTraining, nil
 	}
+	switch initMode := predictionconfig.ModelInitMode(value); initMode {
+	case predictionconfig.ModelInitModeHistory, predictionconfig.ModelInitModeLazyTraining, predictionconfig.ModelInitModeCheckpoint:
+		return initMode, nil
+	default:
+		return "", fmt.Errorf("unknown %s %s", key, value)
+	}
+}
 
-	historyLength, exists := props["mem-model-history-length"]
+// getPercentileInterpolation returns the 'percentile-interpolation' of both the cpu and memory, it is empty by default
+// which is the bucket boundary, so the config of the existing users is unchanged
+func getPercentileInterpolation(config map[string]string) (predictionconfig.PercentileInterpolation, error) {
+	value, exists := config["percentile-interpolation"]
 	if !exists {
-		historyLength = "48h"
-	}
-
-	return &predictionconfig.Config{
-		InitMode: &initMode,
-		Percentile: &predictionapi.Percentile{
-			Aggregated:     true,
-			HistoryLength:  historyLength,
-			SampleInterval: sampleInterval,
-			MarginFraction: marginFraction,
-			Percentile:     percentile,
-			Histogram: predictionapi.HistogramConfig{
-				HalfLife:   "48h",
-				BucketSize: "104857600",
-				MaxValue:   "104857600000",
-			},
-		},
+		return "", nil
+	}
+	switch interpolation := predictionconfig.PercentileInterpolation(value); interpolation {
+	case predictionconfig.PercentileInterpolationBoundary:
+		return "", nil
+	case predictionconfig.PercentileInterpolationLinear:
+		return interpolation, nil
+	default:
+		return "", fmt.Errorf("unknown percentile-interpolation %s", value)
 	}
 }
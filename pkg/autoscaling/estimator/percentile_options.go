@@ -0,0 +1,196 @@
+package estimator
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+)
+
+// estimationOptions is the config of an estimation parsed up front, so an invalid config fails the estimation before
+// any query and the steps of the estimation don't parse the raw config again
+type estimationOptions struct {
+	maintenanceWindows      *dailyWindows
+	tshirtSize              *tshirtSizeConfig
+	noDownscale             bool
+	stabilizationThresholds map[corev1.ResourceName]float64
+	memRoundPow2            bool
+	hpaTarget               *hpaTargetConfig
+	limitPercentiles        map[corev1.ResourceName]string
+	confidence              *confidenceConfig
+	significantFigures      int
+	roundTo                 map[corev1.ResourceName]resource.Quantity
+	memUnit                 *memUnitConfig
+	fallbackToCurrent       bool
+	pacingHints             bool
+	unitMismatchRatio       float64
+	noRunningPodsFallback   string
+	budget                  *queryBudget
+	cost                    *costConfig
+	oomBump                 *oomBumpConfig
+	cpuMemRatio             float64
+	static                  corev1.ResourceList
+	prediction              *predictionOptions
+}
+
+// predictionOptions is the config of estimating the resources from the predicted values and the history
+type predictionOptions struct {
+	// outliers is keyed by the resource, only the cpu drops the outliers
+	outliers                map[corev1.ResourceName]*outlierConfig
+	storage                 *predictionconfig.Config
+	customMetrics           []customMetric
+	history                 *historyEstimationConfig
+	rps                     *rpsModelConfig
+	correlatedMetrics       []correlatedMetric
+	absoluteMargins         map[corev1.ResourceName]resource.Quantity
+	sampleSelection         string
+	warmup                  time.Duration
+	blendAlphas             map[corev1.ResourceName]float64
+	perReplicaNormalization bool
+}
+
+// estimationRequest is an estimation of a container, it is passed through the steps of the estimation
+type estimationRequest struct {
+	evpa          *autoscalingapi.EffectiveVerticalPodAutoscaler
+	config        map[string]string
+	containerName string
+	currRes       *corev1.ResourceRequirements
+	opts          *estimationOptions
+	// override is forced by the operator until the overrideExpiry
+	override       corev1.ResourceList
+	overrideExpiry time.Time
+	graph          *ExplanationGraph
+}
+
+func (r *estimationRequest) overridden() bool {
+	return len(r.override) > 0
+}
+
+func (r *estimationRequest) pinned(resourceName corev1.ResourceName) bool {
+	_, pinned := r.opts.static[resourceName]
+	return pinned
+}
+
+func parseEstimationOptions(config map[string]string) (*estimationOptions, error) {
+	opts := &estimationOptions{}
+	var err error
+	if windowsStr, exists := config["maintenance-window"]; exists {
+		opts.maintenanceWindows, err = parseDailyWindows(windowsStr, config["maintenance-window-timezone"])
+		if err != nil {
+			return nil, fmt.Errorf("parse maintenance-window failed: %v", err)
+		}
+	}
+	if opts.tshirtSize, err = getTShirtSizeConfig(config); err != nil {
+		return nil, err
+	}
+	if opts.noDownscale, err = getNoDownscale(config); err != nil {
+		return nil, err
+	}
+	if opts.stabilizationThresholds, err = getStabilizationThresholds(config); err != nil {
+		return nil, err
+	}
+	if opts.memRoundPow2, err = getMemRoundPow2(config); err != nil {
+		return nil, err
+	}
+	if opts.hpaTarget, err = getHPATargetConfig(config); err != nil {
+		return nil, err
+	}
+	if opts.limitPercentiles, err = getLimitPercentiles(config); err != nil {
+		return nil, err
+	}
+	if opts.confidence, err = getConfidenceConfig(config); err != nil {
+		return nil, err
+	}
+	if opts.significantFigures, err = getSignificantFigures(config); err != nil {
+		return nil, err
+	}
+	if opts.roundTo, err = getRoundTo(config); err != nil {
+		return nil, err
+	}
+	if opts.memUnit, err = getMemUnitConfig(config); err != nil {
+		return nil, err
+	}
+	if opts.fallbackToCurrent, err = getFallbackToCurrent(config); err != nil {
+		return nil, err
+	}
+	if opts.pacingHints, err = getPacingHintsEnabled(config); err != nil {
+		return nil, err
+	}
+	if opts.unitMismatchRatio, err = getUnitMismatchRatio(config); err != nil {
+		return nil, err
+	}
+	if opts.noRunningPodsFallback, err = getNoRunningPodsFallback(config); err != nil {
+		return nil, err
+	}
+	if opts.budget, err = getQueryBudget(config); err != nil {
+		return nil, err
+	}
+	if opts.cost, err = getCostConfig(config); err != nil {
+		return nil, err
+	}
+	if opts.oomBump, err = getOOMBumpConfig(config); err != nil {
+		return nil, err
+	}
+	if opts.cpuMemRatio, err = getCpuMemRatio(config); err != nil {
+		return nil, err
+	}
+	if opts.static, err = getStaticResources(config); err != nil {
+		return nil, err
+	}
+	if opts.prediction, err = parsePredictionOptions(config); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+func parsePredictionOptions(config map[string]string) (*predictionOptions, error) {
+	opts := &predictionOptions{
+		outliers:    map[corev1.ResourceName]*outlierConfig{},
+		blendAlphas: map[corev1.ResourceName]float64{},
+	}
+	cpuOutlier, err := getOutlierConfig(config, "cpu")
+	if err != nil {
+		return nil, err
+	}
+	if cpuOutlier != nil {
+		opts.outliers[corev1.ResourceCPU] = cpuOutlier
+	}
+	if opts.storage, err = getEphemeralStorageConfig(config); err != nil {
+		return nil, err
+	}
+	if opts.customMetrics, err = getCustomMetrics(config); err != nil {
+		return nil, err
+	}
+	if opts.history, err = getHistoryEstimationConfig(config); err != nil {
+		return nil, err
+	}
+	if opts.rps, err = getRpsModelConfig(config); err != nil {
+		return nil, err
+	}
+	if opts.correlatedMetrics, err = getCorrelatedMetrics(config); err != nil {
+		return nil, err
+	}
+	if opts.absoluteMargins, err = getAbsoluteMargins(config); err != nil {
+		return nil, err
+	}
+	if opts.sampleSelection, err = getSampleSelection(config); err != nil {
+		return nil, err
+	}
+	if opts.warmup, err = getWarmupDuration(config); err != nil {
+		return nil, err
+	}
+	for resourceName, prefix := range map[corev1.ResourceName]string{corev1.ResourceCPU: "cpu", corev1.ResourceMemory: "mem"} {
+		if opts.blendAlphas[resourceName], err = getBlendAlpha(config, prefix); err != nil {
+			return nil, err
+		}
+	}
+	if opts.perReplicaNormalization, err = getPerReplicaNormalization(config); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
@@ -0,0 +1,531 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	autoscalingv2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/prediction"
+	"github.com/gocrane/crane/pkg/prediction/config"
+)
+
+// fakePredictor returns the time series set for a metric name, such as cpu or memory
+type fakePredictor struct {
+	mu sync.Mutex
+
+	series map[string][]*common.TimeSeries
+	errs   map[string]error
+	// failures limits the calls failed by the errs, the later calls succeed
+	failures map[string]int
+	// statuses overrides the ready status
+	statuses map[string]prediction.Status
+	// queries saves the registered config by container/metric
+	queries map[string]config.Config
+	// registered counts the registrations by the unique key
+	registered map[string]int
+	// callers saves the callers of the registrations
+	callers    []string
+	deleted    []string
+	deleteErrs map[string]error
+	called     map[string]int
+	// onceQueries saves the configs of the one-off queries by container/metric
+	onceQueries map[string][]config.Config
+}
+
+var _ prediction.Interface = &fakePredictor{}
+
+func newFakePredictor(series map[string][]*common.TimeSeries) *fakePredictor {
+	return &fakePredictor{
+		series:      series,
+		errs:        map[string]error{},
+		failures:    map[string]int{},
+		statuses:    map[string]prediction.Status{},
+		queries:     map[string]config.Config{},
+		registered:  map[string]int{},
+		deleteErrs:  map[string]error{},
+		called:      map[string]int{},
+		onceQueries: map[string][]config.Config{},
+	}
+}
+
+// seriesKeys return the keys to look up the fake results, container/metric first, then metric
+func seriesKeys(namer metricnaming.MetricNamer) []string {
+	gmn, ok := namer.(*metricnaming.GeneralMetricNamer)
+	if !ok || gmn.Metric == nil {
+		return []string{namer.BuildUniqueKey()}
+	}
+	if gmn.Metric.Container != nil {
+		return []string{gmn.Metric.Container.Name + "/" + gmn.Metric.MetricName, gmn.Metric.MetricName}
+	}
+	if gmn.Metric.Device != nil {
+		return []string{gmn.Metric.Device.ContainerName + "/" + gmn.Metric.MetricName, gmn.Metric.MetricName}
+	}
+	return []string{gmn.Metric.MetricName}
+}
+
+func (p *fakePredictor) Run(stopCh <-chan struct{}) {}
+
+func (p *fakePredictor) WithQuery(namer metricnaming.MetricNamer, caller string, cfg config.Config) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queries[seriesKeys(namer)[0]] = cfg
+	p.registered[namer.BuildUniqueKey()]++
+	p.callers = append(p.callers, caller)
+	return nil
+}
+
+func (p *fakePredictor) DeleteQuery(namer metricnaming.MetricNamer, caller string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.deleted = append(p.deleted, namer.BuildUniqueKey())
+	for _, key := range seriesKeys(namer) {
+		if err, exists := p.deleteErrs[key]; exists {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *fakePredictor) QueryPredictionStatus(ctx context.Context, namer metricnaming.MetricNamer) (prediction.Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, key := range seriesKeys(namer) {
+		if status, exists := p.statuses[key]; exists {
+			return status, nil
+		}
+	}
+	return prediction.StatusReady, nil
+}
+
+func (p *fakePredictor) QueryRealtimePredictedValues(ctx context.Context, namer metricnaming.MetricNamer) ([]*common.TimeSeries, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keys := seriesKeys(namer)
+	p.called[keys[0]]++
+	for _, key := range keys {
+		if err := p.errs[key]; err != nil {
+			if failures, limited := p.failures[key]; !limited || p.called[keys[0]] <= failures {
+				return nil, err
+			}
+		}
+		if series, exists := p.series[key]; exists {
+			return series, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *fakePredictor) QueryPredictedTimeSeries(ctx context.Context, namer metricnaming.MetricNamer, startTime time.Time, endTime time.Time) ([]*common.TimeSeries, error) {
+	return p.QueryRealtimePredictedValues(ctx, namer)
+}
+
+// QueryRealtimePredictedValuesOnce looks up the series of metric@percentile first, such as cpu@0.9
+func (p *fakePredictor) QueryRealtimePredictedValuesOnce(ctx context.Context, namer metricnaming.MetricNamer, cfg config.Config) ([]*common.TimeSeries, error) {
+	p.mu.Lock()
+	keys := seriesKeys(namer)
+	p.onceQueries[keys[0]] = append(p.onceQueries[keys[0]], cfg)
+	if cfg.Percentile != nil {
+		for _, key := range keys {
+			if series, exists := p.series[key+"@"+cfg.Percentile.Percentile]; exists {
+				p.mu.Unlock()
+				return series, nil
+			}
+		}
+	}
+	p.mu.Unlock()
+	return p.QueryRealtimePredictedValues(ctx, namer)
+}
+
+func (p *fakePredictor) Name() string {
+	return "fake"
+}
+
+type fakeFetcher struct {
+	selector labels.Selector
+	err      error
+}
+
+func (f *fakeFetcher) Fetch(targetRef *corev1.ObjectReference) (labels.Selector, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.selector == nil {
+		return labels.Everything(), nil
+	}
+	return f.selector, nil
+}
+
+func newTestEVPA(containerNames ...string) *autoscalingapi.EffectiveVerticalPodAutoscaler {
+	var containerPolicies []autoscalingapi.ContainerResourcePolicy
+	for _, name := range containerNames {
+		containerPolicies = append(containerPolicies, autoscalingapi.ContainerResourcePolicy{ContainerName: name})
+	}
+	return &autoscalingapi.EffectiveVerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "evpa",
+			Namespace: "default",
+			UID:       "uid",
+		},
+		Spec: autoscalingapi.EffectiveVerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "nginx",
+			},
+			ResourcePolicy: &autoscalingapi.PodResourcePolicy{
+				ContainerPolicies: containerPolicies,
+			},
+		},
+	}
+}
+
+func newSeries(values ...float64) []*common.TimeSeries {
+	ts := common.NewTimeSeries()
+	for i, value := range values {
+		ts.AppendSample(int64(i*60), value)
+	}
+	return []*common.TimeSeries{ts}
+}
+
+func newTestEstimator(series map[string][]*common.TimeSeries) (*PercentileResourceEstimator, *fakePredictor) {
+	predictor := newFakePredictor(series)
+	return &PercentileResourceEstimator{
+		Predictor:     predictor,
+		TargetFetcher: &fakeFetcher{},
+	}, predictor
+}
+
+func TestPercentileResourceEstimation(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, "256Mi", resources.Memory().String())
+
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{})
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
+
+func TestEstimateResourcesMaintenanceWindow(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	fakeClock := clock.NewFakeClock(time.Date(2022, 7, 1, 3, 0, 0, 0, time.UTC))
+	e.Clock = fakeClock
+	config := map[string]string{"maintenance-window": "02:00-04:00"}
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+
+	// inside the window
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Empty(t, estimation.Reason)
+	assert.Equal(t, "250m", estimation.Resources.Cpu().String())
+	assert.Equal(t, "256Mi", estimation.Resources.Memory().String())
+
+	// outside the window, returns current requests and keeps the computed as shadow
+	fakeClock.SetTime(time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC))
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonOutsideMaintenanceWindow, estimation.Reason)
+	assert.Equal(t, "1", estimation.Resources.Cpu().String())
+	assert.Equal(t, "1Gi", estimation.Resources.Memory().String())
+	assert.Equal(t, "250m", estimation.Computed.Cpu().String())
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"maintenance-window": "2am"}, "nginx", currRes)
+	assert.Error(t, err)
+}
+
+func TestEstimateResourcesNumericMetadata(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(1.5),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.FormatInt(estimation.Resources.Cpu().MilliValue(), 10), estimation.Metadata[MetadataCpuMilliCores])
+	assert.Equal(t, "1500", estimation.Metadata[MetadataCpuMilliCores])
+	assert.Equal(t, strconv.FormatInt(estimation.Resources.Memory().Value(), 10), estimation.Metadata[MetadataMemoryBytes])
+	assert.Equal(t, "268435456", estimation.Metadata[MetadataMemoryBytes])
+
+	// only cpu is recommended
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{"cpu": newSeries(0.25)})
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250", estimation.Metadata[MetadataCpuMilliCores])
+	assert.NotContains(t, estimation.Metadata, MetadataMemoryBytes)
+}
+
+func cpuConfigOf(t *testing.T, props map[string]string) *config.Config {
+	cfg, err := getCpuConfig(props)
+	assert.NoError(t, err)
+	return cfg
+}
+
+func memConfigOf(t *testing.T, props map[string]string) *config.Config {
+	cfg, err := getMemConfig(props)
+	assert.NoError(t, err)
+	return cfg
+}
+
+func TestGetConfigInitMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		props    map[string]string
+		expected config.ModelInitMode
+		wantErr  bool
+	}{
+		{name: "unset", props: map[string]string{}, expected: config.ModelInitModeLazyTraining},
+		{name: "checkpoint", props: map[string]string{"cpu-model-init-mode": "checkpoint", "mem-model-init-mode": "checkpoint"}, expected: config.ModelInitModeCheckpoint},
+		{name: "history", props: map[string]string{"cpu-model-init-mode": "history", "mem-model-init-mode": "history"}, expected: config.ModelInitModeHistory},
+		{name: "unknown", props: map[string]string{"cpu-model-init-mode": "garbage", "mem-model-init-mode": "garbage"}, wantErr: true},
+		{name: "empty", props: map[string]string{"cpu-model-init-mode": "", "mem-model-init-mode": ""}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for prefix, getConfig := range map[string]func(map[string]string) (*config.Config, error){
+				"cpu": getCpuConfig,
+				"mem": getMemConfig,
+			} {
+				cfg, err := getConfig(tt.props)
+				if tt.wantErr {
+					assert.Error(t, err, prefix)
+					continue
+				}
+				assert.NoError(t, err, prefix)
+				assert.Equal(t, tt.expected, *cfg.InitMode, prefix)
+			}
+		})
+	}
+
+	// the cpu and memory init modes are independent
+	cfg := cpuConfigOf(t, map[string]string{"mem-model-init-mode": "checkpoint"})
+	assert.Equal(t, config.ModelInitModeLazyTraining, *cfg.InitMode)
+}
+
+func TestGetConfigAggregated(t *testing.T) {
+	assert.True(t, cpuConfigOf(t, map[string]string{}).Percentile.Aggregated)
+	assert.True(t, memConfigOf(t, map[string]string{}).Percentile.Aggregated)
+	assert.False(t, cpuConfigOf(t, map[string]string{"cpu-aggregated": "false"}).Percentile.Aggregated)
+	assert.False(t, memConfigOf(t, map[string]string{"mem-aggregated": "false"}).Percentile.Aggregated)
+
+	// the cpu and memory aggregations are independent
+	assert.True(t, memConfigOf(t, map[string]string{"cpu-aggregated": "false"}).Percentile.Aggregated)
+
+	_, err := getCpuConfig(map[string]string{"cpu-aggregated": "maybe"})
+	assert.Error(t, err)
+	_, err = getMemConfig(map[string]string{"mem-aggregated": "maybe"})
+	assert.Error(t, err)
+}
+
+func TestGetConfigPercentileInterpolation(t *testing.T) {
+	// boundary is the default, it is left unset so the config hash is unchanged
+	assert.Empty(t, cpuConfigOf(t, map[string]string{}).PercentileInterpolation)
+	assert.Empty(t, memConfigOf(t, map[string]string{"percentile-interpolation": "boundary"}).PercentileInterpolation)
+	assert.Equal(t, config.PercentileInterpolationLinear, cpuConfigOf(t, map[string]string{"percentile-interpolation": "linear"}).PercentileInterpolation)
+	assert.Equal(t, config.PercentileInterpolationLinear, memConfigOf(t, map[string]string{"percentile-interpolation": "linear"}).PercentileInterpolation)
+
+	hash, err := ConfigHash(cpuConfigOf(t, map[string]string{}), memConfigOf(t, map[string]string{}))
+	assert.NoError(t, err)
+	boundaryHash, err := ConfigHash(cpuConfigOf(t, map[string]string{"percentile-interpolation": "boundary"}), memConfigOf(t, map[string]string{}))
+	assert.NoError(t, err)
+	linearHash, err := ConfigHash(cpuConfigOf(t, map[string]string{"percentile-interpolation": "linear"}), memConfigOf(t, map[string]string{}))
+	assert.NoError(t, err)
+	assert.Equal(t, hash, boundaryHash)
+	assert.NotEqual(t, hash, linearHash)
+
+	_, err = getCpuConfig(map[string]string{"percentile-interpolation": "cubic"})
+	assert.Error(t, err)
+}
+
+func TestEstimateResourcesPerPod(t *testing.T) {
+	perPod := func(values ...float64) []*common.TimeSeries {
+		var tsList []*common.TimeSeries
+		for _, value := range values {
+			tsList = append(tsList, newSeries(value)...)
+		}
+		return tsList
+	}
+
+	// aggregated, the predictor returns one series
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.True(t, predictor.queries["nginx/cpu"].Percentile.Aggregated)
+	assert.Equal(t, "250m", resources.Cpu().String())
+
+	// per pod, the recommendation is the one of the largest pod
+	e, predictor = newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    perPod(0.25, 0.75, 0.5),
+		"memory": perPod(512*1024*1024, 256*1024*1024),
+	})
+	config := map[string]string{"cpu-aggregated": "false", "mem-aggregated": "false"}
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.False(t, predictor.queries["nginx/cpu"].Percentile.Aggregated)
+	assert.False(t, predictor.queries["nginx/memory"].Percentile.Aggregated)
+	assert.Equal(t, "750m", resources.Cpu().String())
+	assert.Equal(t, "512Mi", resources.Memory().String())
+}
+
+func TestLargestSeries(t *testing.T) {
+	assert.Empty(t, largestSeries(nil, SampleSelectionFirst))
+
+	empty := common.NewTimeSeries()
+	tsList := append([]*common.TimeSeries{empty}, newSeries(1)...)
+	tsList = append(tsList, newSeries(3)...)
+	tsList = append(tsList, newSeries(2)...)
+	largest := largestSeries(tsList, SampleSelectionFirst)
+	assert.Len(t, largest, 1)
+	assert.Equal(t, float64(3), largest[0].Samples[0].Value)
+
+	// no samples at all, returns the first one
+	assert.Equal(t, []*common.TimeSeries{empty}, largestSeries([]*common.TimeSeries{empty, common.NewTimeSeries()}, SampleSelectionFirst))
+}
+
+func TestGetConfigPercentileAndMarginFraction(t *testing.T) {
+	cfg := cpuConfigOf(t, map[string]string{})
+	assert.Equal(t, "0.99", cfg.Percentile.Percentile)
+	assert.Equal(t, "0.15", cfg.Percentile.MarginFraction)
+	cfg = memConfigOf(t, map[string]string{"mem-request-percentile": "1", "mem-request-margin-fraction": "0"})
+	assert.Equal(t, "1", cfg.Percentile.Percentile)
+	assert.Equal(t, "0", cfg.Percentile.MarginFraction)
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{name: "percentage", key: "request-percentile", value: "99"},
+		{name: "zero percentile", key: "request-percentile", value: "0"},
+		{name: "negative percentile", key: "request-percentile", value: "-0.5"},
+		{name: "nan percentile", key: "request-percentile", value: "NaN"},
+		{name: "unparsable percentile", key: "request-percentile", value: "p99"},
+		{name: "negative margin fraction", key: "request-margin-fraction", value: "-0.1"},
+		{name: "infinite margin fraction", key: "request-margin-fraction", value: "+Inf"},
+		{name: "unparsable margin fraction", key: "request-margin-fraction", value: "15%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for prefix, getConfig := range map[string]func(map[string]string) (*config.Config, error){
+				"cpu":               getCpuConfig,
+				"mem":               getMemConfig,
+				"ephemeral-storage": getEphemeralStorageConfig,
+			} {
+				key := prefix + "-" + tt.key
+				_, err := getConfig(map[string]string{key: tt.value})
+				assert.Error(t, err, key)
+				if err != nil {
+					assert.Contains(t, err.Error(), key)
+				}
+			}
+		})
+	}
+
+	// the error is propagated by the estimation
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-request-percentile": "99"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cpu-request-percentile")
+}
+
+func TestDeleteEstimation(t *testing.T) {
+	// no resource policy, nothing to delete
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{})
+	evpa := newTestEVPA()
+	evpa.Spec.ResourcePolicy = nil
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), evpa))
+	assert.Empty(t, predictor.deleted)
+
+	// a failed deletion doesn't stop the others
+	predictor.deleteErrs["nginx/memory"] = fmt.Errorf("delete failed")
+	err := e.DeleteEstimation(context.TODO(), newTestEVPA("nginx", "sidecar"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "delete failed")
+	assert.Len(t, predictor.deleted, 6)
+}
+
+func TestCallerPrefix(t *testing.T) {
+	for _, prefix := range []string{"", "tenant-a"} {
+		e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+			"cpu":    newSeries(0.25),
+			"memory": newSeries(256 * 1024 * 1024),
+		})
+		e.CallerPrefix = prefix
+		evpa := newTestEVPA("nginx")
+		_, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+		assert.NoError(t, err)
+		assert.NoError(t, e.DeleteEstimation(context.TODO(), evpa))
+
+		expected := "EVPACaller-default/evpa-uid/"
+		if prefix != "" {
+			expected = prefix + "-default/evpa-uid/"
+		}
+		// the created queries are deleted by the same caller
+		assert.Len(t, predictor.registered, 2)
+		for key := range predictor.registered {
+			assert.True(t, strings.HasPrefix(key, expected), key)
+			assert.Contains(t, predictor.deleted, key)
+		}
+	}
+}
+
+func TestNewContainerMetricNamer(t *testing.T) {
+	evpa := newTestEVPA("nginx")
+	selector := labels.SelectorFromSet(labels.Set{"app": "nginx"})
+
+	namer := newContainerMetricNamer(evpa, "EVPACaller-default/evpa-uid", "nginx", corev1.ResourceCPU, selector)
+	assert.Equal(t, "EVPACaller-default/evpa-uid/container_cpu_default_nginx_nginx_app=nginx", namer.BuildUniqueKey())
+	namer = newContainerMetricNamer(evpa, "EVPACaller-default/evpa-uid", "sidecar", corev1.ResourceMemory, nil)
+	assert.Equal(t, "EVPACaller-default/evpa-uid/container_memory_default_nginx_sidecar_", namer.BuildUniqueKey())
+
+	// the estimation and the deletion build the same keys
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	_, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), evpa))
+	assert.Len(t, predictor.registered, 2)
+	for key := range predictor.registered {
+		assert.Contains(t, predictor.deleted, key)
+	}
+}
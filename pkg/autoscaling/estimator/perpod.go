@@ -0,0 +1,121 @@
+package estimator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
+)
+
+// perPodNormalizationConfig estimates the resource from the aggregated usage of the workload instead of the per pod
+// usage, normalized to a pod by the time-weighted average replicas over the window. The replicas fluctuate under
+// HPA, normalizing by the instantaneous replicas would skew the recommendation by a momentary replica spike.
+// The aggregated series are not attributed to the pods, so the readiness weighting and blue-green don't apply.
+type perPodNormalizationConfig struct {
+	// queries are the PromQL of the aggregated usage keyed by the resource prefix
+	queries map[string]string
+	// replicasQuery is the PromQL of the replicas of the workload, the kube-state-metrics replicas by default
+	replicasQuery string
+	// namers are bound to the target of the evpa before the estimation
+	namers        map[string]metricnaming.MetricNamer
+	replicasNamer metricnaming.MetricNamer
+}
+
+// getPerPodNormalizationConfig returns nil if no '<prefix>-aggregated-usage-query' is set
+func getPerPodNormalizationConfig(config map[string]string) *perPodNormalizationConfig {
+	queries := map[string]string{}
+	for _, prefix := range []string{"cpu", "mem"} {
+		if queryExpr, exists := config[prefix+"-aggregated-usage-query"]; exists && queryExpr != "" {
+			queries[prefix] = queryExpr
+		}
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+	return &perPodNormalizationConfig{queries: queries, replicasQuery: config["replicas-query"]}
+}
+
+// bind builds the namers of the aggregated usage and the replicas of the evpa target
+func (c *perPodNormalizationConfig) bind(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, caller string) {
+	c.namers = map[string]metricnaming.MetricNamer{}
+	for prefix, queryExpr := range c.queries {
+		c.namers[prefix] = &metricnaming.GeneralMetricNamer{
+			CallerName: caller,
+			Metric: &metricquery.Metric{
+				Type:       metricquery.PromQLMetricType,
+				MetricName: prefix + "-aggregated",
+				Prom: &metricquery.PromNamerInfo{
+					QueryExpr: queryExpr,
+					Namespace: evpa.Namespace,
+				},
+			},
+		}
+	}
+	c.replicasNamer = newReplicasMetricNamer(evpa, caller, c.replicasQuery)
+}
+
+func (c *perPodNormalizationConfig) appliesTo(prefix string) bool {
+	return c != nil && c.queries[prefix] != ""
+}
+
+// queryPerPodHistory returns the aggregated usage of the resource divided by the time-weighted average replicas,
+// it is not found if there are no replicas in the window
+func (e *PercentileResourceEstimator) queryPerPodHistory(c *perPodNormalizationConfig, prefix string, start time.Time, end time.Time, step time.Duration) ([]*common.TimeSeries, bool, error) {
+	tsList, err := e.History.QueryTimeSeries(c.namers[prefix], start, end, step)
+	if err != nil {
+		return nil, false, err
+	}
+	replicasList, err := e.History.QueryTimeSeries(c.replicasNamer, start, end, step)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query replicas: %v", err)
+	}
+	replicas, found := timeWeightedMean(replicasList, end)
+	if !found || replicas <= 0 {
+		return nil, false, nil
+	}
+	for _, ts := range tsList {
+		for i := range ts.Samples {
+			ts.Samples[i].Value /= replicas
+		}
+	}
+	return tsList, true, nil
+}
+
+// timeWeightedMean averages the series as a step function, each sample holds until the next one or the end. The
+// samples of the same timestamp in different series are averaged first.
+func timeWeightedMean(tsList []*common.TimeSeries, end time.Time) (float64, bool) {
+	values := averageByTimestamp(tsList)
+	if len(values) == 0 {
+		return 0, false
+	}
+	timestamps := make([]int64, 0, len(values))
+	for timestamp := range values {
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+
+	var sum, duration float64
+	for i, timestamp := range timestamps {
+		next := end.Unix()
+		if i+1 < len(timestamps) {
+			next = timestamps[i+1]
+		}
+		if next <= timestamp {
+			continue
+		}
+		sum += values[timestamp] * float64(next-timestamp)
+		duration += float64(next - timestamp)
+	}
+	if duration == 0 {
+		// a single sample at the end
+		return values[timestamps[len(timestamps)-1]], true
+	}
+	return sum / duration, true
+}
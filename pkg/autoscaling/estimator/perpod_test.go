@@ -0,0 +1,80 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestTimeWeightedMean(t *testing.T) {
+	start := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	ts := common.NewTimeSeries()
+	// 2 replicas for 30m, a spike to 10 for 1m, then 2 again for 29m
+	ts.AppendSample(start.Unix(), 2)
+	ts.AppendSample(start.Add(30*time.Minute).Unix(), 10)
+	ts.AppendSample(start.Add(31*time.Minute).Unix(), 2)
+	mean, found := timeWeightedMean([]*common.TimeSeries{ts}, start.Add(time.Hour))
+	assert.True(t, found)
+	assert.InDelta(t, (2*59+10*1)/60.0, mean, 1e-9)
+
+	// a single sample at the end
+	ts = common.NewTimeSeries()
+	ts.AppendSample(start.Unix(), 3)
+	mean, found = timeWeightedMean([]*common.TimeSeries{ts}, start)
+	assert.True(t, found)
+	assert.Equal(t, 3.0, mean)
+
+	_, found = timeWeightedMean(nil, start)
+	assert.False(t, found)
+}
+
+func TestEstimateResourcesPerPodNormalization(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	e.Clock = clock.NewFakeClock(now)
+	// the workload uses 1 core in total, the HPA scales from 2 to 10 replicas in the last 10 minutes
+	e.History = &stepHistory{usage: map[string]func(time.Time) float64{
+		"cpu-aggregated": func(t time.Time) float64 {
+			return 1
+		},
+		"replicas": func(t time.Time) float64 {
+			if t.Before(now.Add(-10 * time.Minute)) {
+				return 2
+			}
+			return 10
+		},
+	}}
+	config := map[string]string{
+		"cpu-aggregated-usage-query":  "sum(rate(container_cpu_usage_seconds_total{namespace=\"default\",container=\"nginx\"}[3m]))",
+		"cpu-request-margin-fraction": "0",
+	}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	// 1 core divided by the time-weighted replicas (1430m * 2 + 10m * 10) / 1440m, not by the instantaneous 10
+	assert.Equal(t, "486m", resources.Cpu().String())
+	// memory is still estimated per pod
+	assert.Equal(t, "256Mi", resources.Memory().String())
+
+	// no replicas in the window, fall back to the predicted value
+	e.History = &stepHistory{usage: map[string]func(time.Time) float64{
+		"cpu-aggregated": func(t time.Time) float64 {
+			return 1
+		},
+		"replicas": func(t time.Time) float64 {
+			return 0
+		},
+	}}
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+}
@@ -0,0 +1,69 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+)
+
+// getPerReplicaNormalization returns whether 'per-replica-normalization' is set, the aggregated estimations are then
+// divided by the current replicas of the target
+func getPerReplicaNormalization(config map[string]string) (bool, error) {
+	value, exists := config["per-replica-normalization"]
+	if !exists {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parse per-replica-normalization failed: %v", err)
+	}
+	return enabled, nil
+}
+
+// targetReplicas returns the replicas of the live spec of the target workload, it is not found if the workload has
+// no replicas, such as a DaemonSet
+func targetReplicas(ctx context.Context, kubeClient client.Client, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) (int64, bool, error) {
+	if evpa.Spec.TargetRef == nil {
+		return 0, false, nil
+	}
+	workload := &unstructured.Unstructured{}
+	workload.SetAPIVersion(evpa.Spec.TargetRef.APIVersion)
+	workload.SetKind(evpa.Spec.TargetRef.Kind)
+	if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: evpa.Namespace, Name: evpa.Spec.TargetRef.Name}, workload); err != nil {
+		return 0, false, err
+	}
+	replicas, found, err := unstructured.NestedInt64(workload.Object, "spec", "replicas")
+	if err != nil {
+		return 0, false, err
+	}
+	return replicas, found, nil
+}
+
+// normalizePerReplica divides the aggregated estimations by the replicas, so the request applied to each pod is its
+// share of the workload. The resources fell back to the current requests are per pod already, they are skipped.
+func normalizePerReplica(resources corev1.ResourceList, replicas int64, configs map[corev1.ResourceName]*predictionconfig.Config, fellBack map[corev1.ResourceName]bool, graph *ExplanationGraph) {
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		quantity, exists := resources[resourceName]
+		cfg := configs[resourceName]
+		if !exists || fellBack[resourceName] || cfg == nil || cfg.Percentile == nil || !cfg.Percentile.Aggregated {
+			continue
+		}
+		value := quantityValue(resourceName, quantity) / float64(replicas)
+		if resourceName == corev1.ResourceCPU {
+			resources[resourceName] = *resource.NewMilliQuantity(int64(math.Ceil(value*1000)), resource.DecimalSI)
+		} else {
+			resources[resourceName] = *resource.NewQuantity(int64(math.Ceil(value)), resource.BinarySI)
+		}
+		graph.addStep(resourceName, ExplanationNodeTransform, "per-replica", quantityValue(resourceName, resources[resourceName]), fmt.Sprintf("divided by %d replicas", replicas))
+	}
+}
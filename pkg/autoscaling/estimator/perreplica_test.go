@@ -0,0 +1,64 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func newTestDeployment(replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+}
+
+func TestEstimateResourcesPerReplicaNormalization(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(2),
+		"memory": newSeries(4 * 1024 * 1024 * 1024),
+	})
+	e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(newTestDeployment(4)).Build()
+	config := map[string]string{"per-replica-normalization": "true"}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+	assert.Equal(t, "1Gi", resources.Memory().String())
+
+	// the prediction per pod is not normalized
+	config["cpu-aggregated"] = "false"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resources.Cpu().String())
+	assert.Equal(t, "1Gi", resources.Memory().String())
+	delete(config, "cpu-aggregated")
+
+	// not normalized by default
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resources.Cpu().String())
+
+	// scaled to zero
+	e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(newTestDeployment(0)).Build()
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resources.Cpu().String())
+	assert.Equal(t, "4Gi", resources.Memory().String())
+
+	// the target is not found
+	e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"per-replica-normalization": "yes"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
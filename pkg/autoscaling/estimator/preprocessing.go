@@ -0,0 +1,246 @@
+package estimator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const (
+	// CounterResetHandlingNone keeps all samples as is
+	CounterResetHandlingNone = "none"
+	// CounterResetHandlingDiscard discards samples straddling a detected counter reset
+	CounterResetHandlingDiscard = "discard"
+
+	// cpuCounterExprTemplate is the raw cpu usage counter of the containers of the target
+	cpuCounterExprTemplate = `container_cpu_usage_seconds_total{container!="POD",namespace="%s",pod=~"^%s.*$",container="%s"}`
+	cpuCounterMetricName   = "cpu-counter"
+)
+
+// counterResetConfig controls how samples derived from a counter rate are handled when the counter resets. The resets
+// are detected from the raw counter, which is queried from the history, so the samples are recomputed from the history.
+type counterResetConfig struct {
+	handling string
+	// counterQuery is the PromQL of the raw counter, the cadvisor cpu usage counter of the container by default
+	counterQuery string
+	// namer is bound to the container of the evpa before the estimation
+	namer metricnaming.MetricNamer
+}
+
+// getCounterResetConfig returns nil if the counter resets are not discarded
+func getCounterResetConfig(config map[string]string, prefix string) (*counterResetConfig, error) {
+	handling, exists := config[prefix+"-counter-reset-handling"]
+	if !exists {
+		handling = CounterResetHandlingNone
+	}
+	if handling != CounterResetHandlingNone && handling != CounterResetHandlingDiscard {
+		return nil, fmt.Errorf("unknown %s-counter-reset-handling %q", prefix, handling)
+	}
+	if handling == CounterResetHandlingNone {
+		return nil, nil
+	}
+
+	return &counterResetConfig{
+		handling:     handling,
+		counterQuery: config[prefix+"-counter-query"],
+	}, nil
+}
+
+// bind builds the namer of the raw counter of the container
+func (c *counterResetConfig) bind(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, caller string, containerName string) {
+	queryExpr := c.counterQuery
+	if queryExpr == "" {
+		queryExpr = fmt.Sprintf(cpuCounterExprTemplate, evpa.Namespace, evpa.Spec.TargetRef.Name, containerName)
+	}
+	c.namer = &metricnaming.GeneralMetricNamer{
+		CallerName: caller,
+		Metric: &metricquery.Metric{
+			Type:       metricquery.PromQLMetricType,
+			MetricName: cpuCounterMetricName,
+			Prom: &metricquery.PromNamerInfo{
+				QueryExpr: queryExpr,
+				Namespace: evpa.Namespace,
+			},
+		},
+	}
+}
+
+// counterResets returns the timestamps the raw counter drops below its previous sample, keyed by the pod of the series
+func counterResets(counterList []*common.TimeSeries) map[string][]int64 {
+	resets := map[string][]int64{}
+	for _, ts := range counterList {
+		pod := seriesPodName(ts)
+		for i := 1; i < len(ts.Samples); i++ {
+			if ts.Samples[i].Value < ts.Samples[i-1].Value {
+				resets[pod] = append(resets[pod], ts.Samples[i].Timestamp)
+			}
+		}
+	}
+	return resets
+}
+
+// resetsOf returns the resets of the counter of the pod the rate series is computed from, all the resets if the rate
+// series is not attributed to a pod
+func resetsOf(ts *common.TimeSeries, resets map[string][]int64) []int64 {
+	if pod := seriesPodName(ts); pod != "" {
+		return resets[pod]
+	}
+	var all []int64
+	for _, podResets := range resets {
+		all = append(all, podResets...)
+	}
+	return all
+}
+
+// discardCounterResets drops the rate samples straddling a counter reset, the ones whose window of the rate covers
+// the reset. The window is the sample interval, the rate is computed from the counter samples in it.
+func discardCounterResets(samples []common.Sample, resets []int64, window time.Duration) []common.Sample {
+	if len(resets) == 0 || len(samples) == 0 {
+		return samples
+	}
+
+	windowSeconds := int64(window / time.Second)
+	var result []common.Sample
+	for _, sample := range samples {
+		straddling := false
+		for _, reset := range resets {
+			if sample.Timestamp >= reset && sample.Timestamp < reset+windowSeconds {
+				straddling = true
+				break
+			}
+		}
+		if !straddling {
+			result = append(result, sample)
+		}
+	}
+
+	return result
+}
+
+// outlierConfig drops the samples far above the typical value, it is opt-in since a real burst of the usage looks the
+// same as a glitch of the data source
+type outlierConfig struct {
+	// ratio is the ratio to the median value, samples above it are outliers
+	ratio float64
+}
+
+// getOutlierConfig returns nil if '<prefix>-outlier-ratio' is not set
+func getOutlierConfig(config map[string]string, prefix string) (*outlierConfig, error) {
+	ratioStr, exists := config[prefix+"-outlier-ratio"]
+	if !exists {
+		return nil, nil
+	}
+	ratio, err := utils.ParseFloat(ratioStr, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s-outlier-ratio failed: %v", prefix, err)
+	}
+	if ratio <= 1 {
+		return nil, fmt.Errorf("%s-outlier-ratio must be greater than 1, got %v", prefix, ratio)
+	}
+	return &outlierConfig{ratio: ratio}, nil
+}
+
+// discardOutliers drops the samples above the median by more than the ratio
+func discardOutliers(samples []common.Sample, cfg *outlierConfig) []common.Sample {
+	if cfg == nil || len(samples) == 0 {
+		return samples
+	}
+
+	median := medianValue(samples)
+	if median <= 0 {
+		return samples
+	}
+	var result []common.Sample
+	for _, sample := range samples {
+		if sample.Value > median*cfg.ratio {
+			continue
+		}
+		result = append(result, sample)
+	}
+
+	return result
+}
+
+func medianValue(samples []common.Sample) float64 {
+	values := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		values = append(values, sample.Value)
+	}
+	if len(values) == 0 {
+		return 0
+	}
+
+	sort.Float64s(values)
+	return values[len(values)/2]
+}
+
+// winsorizeConfig clamps the samples to the values at the lower and upper percentile bounds, the outliers retain
+// their direction without their full magnitude
+type winsorizeConfig struct {
+	lower float64
+	upper float64
+}
+
+// getWinsorizeConfig parses '<prefix>-winsorize-bounds' such as "0.01,0.99", returns nil if it is not set
+func getWinsorizeConfig(config map[string]string, prefix string) (*winsorizeConfig, error) {
+	boundsStr, exists := config[prefix+"-winsorize-bounds"]
+	if !exists || boundsStr == "" {
+		return nil, nil
+	}
+
+	bounds := strings.Split(boundsStr, ",")
+	if len(bounds) != 2 {
+		return nil, fmt.Errorf("%s-winsorize-bounds must be lower,upper, got %q", prefix, boundsStr)
+	}
+	lower, err := utils.ParseFloat(strings.TrimSpace(bounds[0]), 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s-winsorize-bounds failed: %v", prefix, err)
+	}
+	upper, err := utils.ParseFloat(strings.TrimSpace(bounds[1]), 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s-winsorize-bounds failed: %v", prefix, err)
+	}
+	if lower < 0 || upper > 1 || lower >= upper {
+		return nil, fmt.Errorf("%s-winsorize-bounds must satisfy 0 <= lower < upper <= 1, got %q", prefix, boundsStr)
+	}
+
+	return &winsorizeConfig{lower: lower, upper: upper}, nil
+}
+
+// winsorize clamps the samples of all the series to the values at the percentile bounds of the series set
+func winsorize(tsList []*common.TimeSeries, cfg *winsorizeConfig) {
+	if cfg == nil {
+		return
+	}
+
+	var values []float64
+	for _, ts := range tsList {
+		for _, sample := range ts.Samples {
+			values = append(values, sample.Value)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	sort.Float64s(values)
+	lowerValue := values[int(cfg.lower*float64(len(values)-1))]
+	upperValue := values[int(cfg.upper*float64(len(values)-1))]
+
+	for _, ts := range tsList {
+		for i := range ts.Samples {
+			if ts.Samples[i].Value < lowerValue {
+				ts.Samples[i].Value = lowerValue
+			} else if ts.Samples[i].Value > upperValue {
+				ts.Samples[i].Value = upperValue
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+)
+
+func sampleValues(samples []common.Sample) []float64 {
+	var values []float64
+	for _, sample := range samples {
+		values = append(values, sample.Value)
+	}
+	return values
+}
+
+func TestDiscardCounterResets(t *testing.T) {
+	start := time.Unix(0, 0)
+	// the counter of nginx-a resets at the fourth sample, nginx-b never resets
+	counterList := []*common.TimeSeries{
+		newPodSeries("nginx-a", start, 10, 20, 30, 2, 12, 22),
+		newPodSeries("nginx-b", start, 10, 20, 30, 40, 50, 60),
+	}
+	resets := counterResets(counterList)
+	assert.Equal(t, map[string][]int64{"nginx-a": {1800}}, resets)
+
+	// the rate sample straddling the reset is dropped, the spikes not at a reset are kept
+	rates := []*common.TimeSeries{
+		newPodSeries("nginx-a", start, 1.0, 1.1, 0.9, 42.0, 1.0, 1.2),
+		newPodSeries("nginx-b", start, 1.0, 1.1, 0.9, 42.0, 1.0, 1.2),
+	}
+	assert.Equal(t, []float64{1.0, 1.1, 0.9, 1.0, 1.2}, sampleValues(discardCounterResets(rates[0].Samples, resetsOf(rates[0], resets), time.Minute)))
+	assert.Equal(t, []float64{1.0, 1.1, 0.9, 42.0, 1.0, 1.2}, sampleValues(discardCounterResets(rates[1].Samples, resetsOf(rates[1], resets), time.Minute)))
+
+	// the window covers the following samples computed across the reset
+	assert.Equal(t, []float64{1.0, 1.1, 0.9, 1.2}, sampleValues(discardCounterResets(rates[0].Samples, resetsOf(rates[0], resets), 15*time.Minute)))
+
+	// the series not attributed to a pod is checked against the resets of all the pods
+	aggregated := newSeries(1.0, 1.1)[0]
+	assert.Equal(t, []int64{1800}, resetsOf(aggregated, resets))
+}
+
+func TestGetCounterResetConfig(t *testing.T) {
+	cfg, err := getCounterResetConfig(map[string]string{}, "cpu")
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+	cfg, err = getCounterResetConfig(map[string]string{"cpu-counter-reset-handling": "discard"}, "cpu")
+	assert.NoError(t, err)
+	cfg.bind(newTestEVPA("nginx"), "caller", "nginx")
+	assert.Equal(t, `container_cpu_usage_seconds_total{container!="POD",namespace="default",pod=~"^nginx.*$",container="nginx"}`, cfg.namer.(*metricnaming.GeneralMetricNamer).Metric.Prom.QueryExpr)
+
+	_, err = getCounterResetConfig(map[string]string{"cpu-counter-reset-handling": "drop"}, "cpu")
+	assert.Error(t, err)
+}
+
+func TestDiscardOutliers(t *testing.T) {
+	series := newSeries(1.0, 1.1, 0.9, 42.0, 1.0, 1.2)
+	cfg, err := getOutlierConfig(map[string]string{}, "cpu")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.0, 1.1, 0.9, 42.0, 1.0, 1.2}, sampleValues(discardOutliers(series[0].Samples, cfg)))
+
+	cfg, err = getOutlierConfig(map[string]string{"cpu-outlier-ratio": "10"}, "cpu")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.0, 1.1, 0.9, 1.0, 1.2}, sampleValues(discardOutliers(series[0].Samples, cfg)))
+
+	_, err = getOutlierConfig(map[string]string{"cpu-outlier-ratio": "0.5"}, "cpu")
+	assert.Error(t, err)
+}
+
+func TestEstimationDiscardCounterReset(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-2 * time.Hour)
+	config := map[string]string{
+		"cpu-counter-reset-handling":  "discard",
+		"cpu-request-percentile":      "1.0",
+		"cpu-request-margin-fraction": "0",
+	}
+	estimateCpu := func(counter *common.TimeSeries) string {
+		e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+			"cpu":    newSeries(2.0),
+			"memory": newSeries(1024),
+		})
+		e.Clock = clock.NewFakeClock(now)
+		e.History = &fakePodHistory{series: map[string][]*common.TimeSeries{
+			"cpu":         {newPodSeries("nginx-a", start, 0.5, 0.5, 0.5, 40, 0.5, 0.5)},
+			"cpu-counter": {counter},
+		}}
+		resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+		assert.NoError(t, err)
+		return resources.Cpu().String()
+	}
+
+	// recomputed from the history without the sample straddling the reset
+	assert.Equal(t, "500m", estimateCpu(newPodSeries("nginx-a", start, 300, 600, 900, 10, 310, 610)))
+	// no reset of the counter, the spike is kept
+	assert.Equal(t, "40", estimateCpu(newPodSeries("nginx-a", start, 300, 600, 900, 1200, 1500, 1800)))
+}
+
+func TestWinsorizeVsDrop(t *testing.T) {
+	// a glitchy series, mostly 1 core, a higher sample and a huge glitch
+	newGlitchySeries := func() []*common.TimeSeries {
+		return newSeries(1, 1, 1, 1, 1, 1, 1, 1, 2, 100)
+	}
+
+	dropped := newGlitchySeries()
+	dropped[0].Samples = discardOutliers(dropped[0].Samples, &outlierConfig{ratio: 10})
+	winsorized := newGlitchySeries()
+	winsorize(winsorized, &winsorizeConfig{lower: 0, upper: 0.9})
+
+	// the glitch is clamped to the upper bound instead of being dropped
+	assert.Equal(t, []float64{1, 1, 1, 1, 1, 1, 1, 1, 2}, sampleValues(dropped[0].Samples))
+	assert.Equal(t, []float64{1, 1, 1, 1, 1, 1, 1, 1, 2, 2}, sampleValues(winsorized[0].Samples))
+
+	// the clamped glitch still pulls the percentile up
+	droppedValue, _ := weightedPercentile(unweightedSamples(dropped), 0.85)
+	winsorizedValue, _ := weightedPercentile(unweightedSamples(winsorized), 0.85)
+	assert.Equal(t, 1.0, droppedValue)
+	assert.Equal(t, 2.0, winsorizedValue)
+}
+
+func TestEstimationWinsorize(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(100),
+		"memory": newSeries(1024),
+	})
+	e.Clock = clock.NewFakeClock(now)
+	e.History = &fakePodHistory{series: map[string][]*common.TimeSeries{
+		"cpu": {newPodSeries("nginx-a", now.Add(-2*time.Hour), 1, 1, 1, 1, 1, 1, 1, 1, 2, 100)},
+	}}
+
+	config := map[string]string{
+		"cpu-winsorize-bounds":        "0,0.9",
+		"cpu-request-percentile":      "1.0",
+		"cpu-request-margin-fraction": "0",
+	}
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resources.Cpu().String())
+	// memory is not winsorized, predicted by the predictor
+	assert.Equal(t, "1Ki", resources.Memory().String())
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-winsorize-bounds": "0.99,0.01"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package estimator
+
+import (
+	"fmt"
+)
+
+// WorkloadProfile is the criticality of the workload, it selects the defaults of the model keys
+type WorkloadProfile string
+
+const (
+	// WorkloadProfileBatch adapts quickly to the short history, with a small margin
+	WorkloadProfileBatch WorkloadProfile = "batch"
+	// WorkloadProfileStandard is the default profile
+	WorkloadProfileStandard WorkloadProfile = "standard"
+	// WorkloadProfileCritical is stable over the long history, with a large margin
+	WorkloadProfileCritical WorkloadProfile = "critical"
+)
+
+// profileDefaults are the model defaults selected by a workload profile, the sample interval and the margin fraction
+// are the same for the cpu and memory
+type profileDefaults struct {
+	CPUHistoryLength    string
+	MemoryHistoryLength string
+	SampleInterval      string
+	MarginFraction      string
+}
+
+var workloadProfiles = map[WorkloadProfile]profileDefaults{
+	WorkloadProfileBatch: {
+		CPUHistoryLength:    "6h",
+		MemoryHistoryLength: "12h",
+		SampleInterval:      "1m",
+		MarginFraction:      "0.1",
+	},
+	WorkloadProfileStandard: {
+		CPUHistoryLength:    "24h",
+		MemoryHistoryLength: "48h",
+		SampleInterval:      "1m",
+		MarginFraction:      "0.15",
+	},
+	WorkloadProfileCritical: {
+		CPUHistoryLength:    "168h",
+		MemoryHistoryLength: "336h",
+		SampleInterval:      "5m",
+		MarginFraction:      "0.25",
+	},
+}
+
+// ProfileEstimatorConfig returns the defaults of the workload profile, the standard one is DefaultEstimatorConfig
+func ProfileEstimatorConfig(profile WorkloadProfile) (*EstimatorConfig, error) {
+	defaults, exists := workloadProfiles[profile]
+	if !exists {
+		return nil, fmt.Errorf("unknown workload-profile %s", profile)
+	}
+	config := DefaultEstimatorConfig()
+	config.CPU.HistoryLength = defaults.CPUHistoryLength
+	config.Memory.HistoryLength = defaults.MemoryHistoryLength
+	for _, model := range []*ModelConfig{&config.CPU, &config.Memory} {
+		model.SampleInterval = defaults.SampleInterval
+		model.MarginFraction = defaults.MarginFraction
+	}
+	return config, nil
+}
+
+// getProfileEstimatorConfig returns the defaults of the 'workload-profile', the standard one if it is not set. The
+// model keys set explicitly override them.
+func getProfileEstimatorConfig(config map[string]string) (*EstimatorConfig, error) {
+	profile, exists := config["workload-profile"]
+	if !exists {
+		return DefaultEstimatorConfig(), nil
+	}
+	return ProfileEstimatorConfig(WorkloadProfile(profile))
+}
@@ -0,0 +1,67 @@
+package estimator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkloadProfile(t *testing.T) {
+	tests := []struct {
+		profile                        string
+		cpuHistory, memHistory         string
+		sampleInterval, marginFraction string
+	}{
+		{profile: "batch", cpuHistory: "6h", memHistory: "12h", sampleInterval: "1m", marginFraction: "0.1"},
+		{profile: "standard", cpuHistory: "24h", memHistory: "48h", sampleInterval: "1m", marginFraction: "0.15"},
+		{profile: "critical", cpuHistory: "168h", memHistory: "336h", sampleInterval: "5m", marginFraction: "0.25"},
+	}
+	for _, tt := range tests {
+		config := map[string]string{"workload-profile": tt.profile}
+		cpuConfig := cpuConfigOf(t, config)
+		memConfig := memConfigOf(t, config)
+		assert.Equal(t, tt.cpuHistory, cpuConfig.Percentile.HistoryLength, tt.profile)
+		assert.Equal(t, tt.memHistory, memConfig.Percentile.HistoryLength, tt.profile)
+		for _, sampleInterval := range []string{cpuConfig.Percentile.SampleInterval, memConfig.Percentile.SampleInterval} {
+			assert.Equal(t, tt.sampleInterval, sampleInterval, tt.profile)
+		}
+		assert.Equal(t, tt.marginFraction, cpuConfig.Percentile.MarginFraction, tt.profile)
+		assert.Equal(t, tt.marginFraction, memConfig.Percentile.MarginFraction, tt.profile)
+		// the other keys are the defaults
+		assert.Equal(t, "0.99", cpuConfig.Percentile.Percentile, tt.profile)
+
+		typed, unknown, err := FromConfigMap(config)
+		assert.NoError(t, err)
+		assert.Empty(t, unknown)
+		assert.Equal(t, tt.cpuHistory, typed.CPU.HistoryLength, tt.profile)
+		assert.Equal(t, tt.profile, typed.Extra["workload-profile"], tt.profile)
+	}
+
+	// the standard profile is the default
+	standard, err := ProfileEstimatorConfig(WorkloadProfileStandard)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultEstimatorConfig(), standard)
+	assert.Equal(t, cpuConfigOf(t, map[string]string{}), cpuConfigOf(t, map[string]string{"workload-profile": "standard"}))
+
+	_, err = getCpuConfig(map[string]string{"workload-profile": "interactive"})
+	assert.Error(t, err)
+	_, _, err = FromConfigMap(map[string]string{"workload-profile": "interactive"})
+	assert.Error(t, err)
+}
+
+func TestWorkloadProfileOverride(t *testing.T) {
+	config := map[string]string{
+		"workload-profile":            "critical",
+		"cpu-model-history-length":    "72h",
+		"mem-request-margin-fraction": "0.05",
+		"cpu-sample-interval":         "1m",
+	}
+	cpuConfig := cpuConfigOf(t, config)
+	memConfig := memConfigOf(t, config)
+	assert.Equal(t, "72h", cpuConfig.Percentile.HistoryLength)
+	assert.Equal(t, "336h", memConfig.Percentile.HistoryLength)
+	assert.Equal(t, "0.25", cpuConfig.Percentile.MarginFraction)
+	assert.Equal(t, "0.05", memConfig.Percentile.MarginFraction)
+	assert.Equal(t, "1m", cpuConfig.Percentile.SampleInterval)
+	assert.Equal(t, "5m", memConfig.Percentile.SampleInterval)
+}
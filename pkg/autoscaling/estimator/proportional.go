@@ -1,6 +1,8 @@
 package estimator
 
 import (
+	"context"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -17,7 +19,7 @@ type ProportionalResourceEstimator struct {
 	client.Client
 }
 
-func (e *ProportionalResourceEstimator) GetResourceEstimation(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
+func (e *ProportionalResourceEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *corev1.ResourceRequirements) (corev1.ResourceList, error) {
 	recommendResource := corev1.ResourceList{}
 
 	cpuQuantity := currRes.Requests[corev1.ResourceCPU]
@@ -39,7 +41,7 @@ func (e *ProportionalResourceEstimator) GetResourceEstimation(evpa *autoscalinga
 	return recommendResource, nil
 }
 
-func (e *ProportionalResourceEstimator) DeleteEstimation(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) {
+func (e *ProportionalResourceEstimator) DeleteEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
 	// do nothing
-	return
+	return nil
 }
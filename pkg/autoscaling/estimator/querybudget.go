@@ -0,0 +1,83 @@
+package estimator
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// ReasonQueryBudgetReduced means some optional queries were skipped to stay in the query budget, the
+	// recommendation is still valid but derived from fewer signals
+	ReasonQueryBudgetReduced = "QueryBudgetReduced"
+)
+
+// queryBudget caps the predictor and history queries issued by an estimation. The cpu and memory predictions are
+// always issued, the optional signals are skipped in the order of the estimation once the budget is exhausted.
+// A nil budget is unlimited.
+type queryBudget struct {
+	max     int
+	used    int
+	reduced bool
+}
+
+// getQueryBudget returns the budget of the 'max-queries-per-estimation', nil if not set or zero
+func getQueryBudget(config map[string]string) (*queryBudget, error) {
+	maxStr, exists := config["max-queries-per-estimation"]
+	if !exists {
+		return nil, nil
+	}
+	max, err := strconv.Atoi(maxStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse max-queries-per-estimation failed: %v", err)
+	}
+	if max < 0 {
+		return nil, fmt.Errorf("max-queries-per-estimation %d must not be negative", max)
+	}
+	if max == 0 {
+		return nil, nil
+	}
+	return &queryBudget{max: max}, nil
+}
+
+// spend counts the mandatory queries, they are issued even beyond the budget
+func (b *queryBudget) spend(n int) {
+	if b == nil {
+		return
+	}
+	b.used += n
+}
+
+// take reserves the optional queries, false and the budget is flagged as reduced if they do not fit
+func (b *queryBudget) take(n int) bool {
+	if b == nil || n == 0 {
+		return true
+	}
+	if b.used+n > b.max {
+		b.reduced = true
+		return false
+	}
+	b.used += n
+	return true
+}
+
+func (b *queryBudget) isReduced() bool {
+	return b != nil && b.reduced
+}
+
+// queriesOf returns the number of the history queries to estimate the resource
+func (c *historyEstimationConfig) queriesOf(prefix string) int {
+	if !c.appliesTo(prefix) {
+		return 0
+	}
+	queries := 1
+	if c.scaledToZero != nil && c.scaledToZero.namer != nil {
+		queries++
+	}
+	if c.perPod.appliesTo(prefix) {
+		queries++
+	}
+	if counterReset := c.counterResets[prefix]; counterReset != nil && counterReset.namer != nil {
+		queries++
+	}
+	return queries
+}
@@ -0,0 +1,101 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+)
+
+type countingHistory struct {
+	stepHistory
+	queries int
+}
+
+func (h *countingHistory) QueryTimeSeries(namer metricnaming.MetricNamer, startTime time.Time, endTime time.Time, step time.Duration) ([]*common.TimeSeries, error) {
+	h.queries++
+	return h.stepHistory.QueryTimeSeries(namer, startTime, endTime, step)
+}
+
+func TestQueryBudget(t *testing.T) {
+	budget, err := getQueryBudget(map[string]string{})
+	assert.NoError(t, err)
+	assert.Nil(t, budget)
+	assert.True(t, budget.take(10))
+	assert.False(t, budget.isReduced())
+
+	budget, err = getQueryBudget(map[string]string{"max-queries-per-estimation": "3"})
+	assert.NoError(t, err)
+	budget.spend(2)
+	assert.True(t, budget.take(1))
+	assert.False(t, budget.take(1))
+	assert.True(t, budget.isReduced())
+
+	_, err = getQueryBudget(map[string]string{"max-queries-per-estimation": "-1"})
+	assert.Error(t, err)
+	_, err = getQueryBudget(map[string]string{"max-queries-per-estimation": "many"})
+	assert.Error(t, err)
+}
+
+func TestEstimateResourcesQueryBudget(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	e.Clock = clock.NewFakeClock(time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC))
+	history := &countingHistory{stepHistory: stepHistory{usage: map[string]func(time.Time) float64{
+		"goroutines": func(t time.Time) float64 { return 500 },
+		"threads":    func(t time.Time) float64 { return 800 },
+	}}}
+	e.History = history
+	newConfig := func(maxQueries string) map[string]string {
+		config := map[string]string{
+			"cpu-correlated-metric-goroutines-query":       "sum(go_goroutines{job=\"nginx\"})",
+			"cpu-correlated-metric-goroutines-coefficient": "0.001",
+			"cpu-correlated-metric-threads-query":          "sum(go_threads{job=\"nginx\"})",
+			"cpu-correlated-metric-threads-coefficient":    "0.001",
+			"cpu-request-margin-fraction":                  "0",
+		}
+		if maxQueries != "" {
+			config["max-queries-per-estimation"] = maxQueries
+		}
+		return config
+	}
+
+	// unlimited, all the correlated metrics are queried
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), newConfig(""), "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", estimation.Reason)
+	assert.Equal(t, "800m", estimation.Resources.Cpu().String())
+	assert.Equal(t, 2, history.queries)
+
+	// room for one correlated metric besides the predictions
+	history.queries = 0
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), newConfig("3"), "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonQueryBudgetReduced, estimation.Reason)
+	assert.Equal(t, "500m", estimation.Resources.Cpu().String())
+	assert.Equal(t, "256Mi", estimation.Resources.Memory().String())
+	assert.Equal(t, 1, history.queries)
+
+	// only the predictions, still a valid recommendation
+	history.queries = 0
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), newConfig("2"), "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonQueryBudgetReduced, estimation.Reason)
+	assert.Equal(t, "250m", estimation.Resources.Cpu().String())
+	assert.Equal(t, "256Mi", estimation.Resources.Memory().String())
+	assert.Equal(t, 0, history.queries)
+
+	// the budget fits all the queries
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), newConfig("4"), "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", estimation.Reason)
+	assert.Equal(t, "800m", estimation.Resources.Cpu().String())
+}
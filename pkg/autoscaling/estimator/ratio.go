@@ -0,0 +1,57 @@
+package estimator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// getCpuMemRatio returns the memory bytes per cpu core of 'cpu-mem-ratio' such as "1:4", 1 core per 4Gi, zero if it
+// is not set
+func getCpuMemRatio(config map[string]string) (float64, error) {
+	ratioStr, exists := config["cpu-mem-ratio"]
+	if !exists {
+		return 0, nil
+	}
+	parts := strings.Split(ratioStr, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("cpu-mem-ratio must be <cores>:<gibibytes>, got %s", ratioStr)
+	}
+	var values [2]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse cpu-mem-ratio failed: %v", err)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) || value <= 0 {
+			return 0, fmt.Errorf("cpu-mem-ratio must be positive, got %s", ratioStr)
+		}
+		values[i] = value
+	}
+	return values[1] * (1 << 30) / values[0], nil
+}
+
+// applyCpuMemRatio scales up the resource below the ratio so the cpu and memory keep proportional, neither is
+// reduced. The pinned resources are kept as is, it returns the scaled resource.
+func applyCpuMemRatio(resources corev1.ResourceList, bytesPerCore float64, pinned corev1.ResourceList) (corev1.ResourceName, bool) {
+	cpu, cpuExists := resources[corev1.ResourceCPU]
+	memory, memExists := resources[corev1.ResourceMemory]
+	if !cpuExists || !memExists {
+		return "", false
+	}
+	cores := float64(cpu.MilliValue()) / 1000
+	bytes := float64(memory.Value())
+	if _, isPinned := pinned[corev1.ResourceMemory]; !isPinned && bytes < cores*bytesPerCore {
+		resources[corev1.ResourceMemory] = *resource.NewQuantity(int64(math.Ceil(cores*bytesPerCore)), memory.Format)
+		return corev1.ResourceMemory, true
+	}
+	if _, isPinned := pinned[corev1.ResourceCPU]; !isPinned && cores < bytes/bytesPerCore {
+		resources[corev1.ResourceCPU] = *resource.NewMilliQuantity(int64(math.Ceil(bytes/bytesPerCore*1000)), resource.DecimalSI)
+		return corev1.ResourceCPU, true
+	}
+	return "", false
+}
@@ -0,0 +1,99 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestGetCpuMemRatio(t *testing.T) {
+	bytesPerCore, err := getCpuMemRatio(map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, bytesPerCore)
+
+	bytesPerCore, err = getCpuMemRatio(map[string]string{"cpu-mem-ratio": "1:4"})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(4<<30), bytesPerCore)
+
+	bytesPerCore, err = getCpuMemRatio(map[string]string{"cpu-mem-ratio": "2:1"})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(512<<20), bytesPerCore)
+
+	for _, ratio := range []string{"4", "1:4:8", "a:4", "0:4", "1:-4", "1:Inf"} {
+		_, err = getCpuMemRatio(map[string]string{"cpu-mem-ratio": ratio})
+		assert.Error(t, err, ratio)
+	}
+}
+
+func TestEstimateResourcesCpuMemRatio(t *testing.T) {
+	for _, test := range []struct {
+		desc   string
+		cpu    float64
+		memory float64
+		ratio  string
+		// expected
+		expectedCpu    string
+		expectedMemory string
+	}{
+		{
+			desc:           "satisfied",
+			cpu:            1,
+			memory:         4 << 30,
+			ratio:          "1:4",
+			expectedCpu:    "1",
+			expectedMemory: "4Gi",
+		},
+		{
+			desc:           "cpu bound",
+			cpu:            2,
+			memory:         1 << 30,
+			ratio:          "1:4",
+			expectedCpu:    "2",
+			expectedMemory: "8Gi",
+		},
+		{
+			desc:           "memory bound",
+			cpu:            0.25,
+			memory:         2 << 30,
+			ratio:          "1:4",
+			expectedCpu:    "500m",
+			expectedMemory: "2Gi",
+		},
+		{
+			desc:           "not set",
+			cpu:            0.25,
+			memory:         2 << 30,
+			expectedCpu:    "250m",
+			expectedMemory: "2Gi",
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+				"cpu":    newSeries(test.cpu),
+				"memory": newSeries(test.memory),
+			})
+			config := map[string]string{}
+			if test.ratio != "" {
+				config["cpu-mem-ratio"] = test.ratio
+			}
+			resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedCpu, resources.Cpu().String())
+			assert.Equal(t, test.expectedMemory, resources.Memory().String())
+		})
+	}
+
+	// the pinned resource is not scaled
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(2),
+		"memory": newSeries(1 << 30),
+	})
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-mem-ratio": "1:4", "static-mem": "1Gi"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resources.Cpu().String())
+	assert.Equal(t, "1Gi", resources.Memory().String())
+}
@@ -0,0 +1,144 @@
+package estimator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const (
+	// podLabelName is the label of the pod name in the container metric series
+	podLabelName = "pod"
+
+	defaultNotReadySampleWeight = 0.0
+)
+
+// readinessWeightingConfig controls how samples of not-ready pods are weighted when computing the percentile.
+type readinessWeightingConfig struct {
+	// notReadyWeight is the weight of a sample taken when the pod is not ready, 0 excludes the sample
+	notReadyWeight float64
+}
+
+// getReadinessWeightingConfig returns nil if 'readiness-weighting' is not enabled
+func getReadinessWeightingConfig(config map[string]string) (*readinessWeightingConfig, error) {
+	enabledStr, exists := config["readiness-weighting"]
+	if !exists {
+		return nil, nil
+	}
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		return nil, fmt.Errorf("parse readiness-weighting failed: %v", err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	notReadyWeight, err := utils.ParseFloat(config["not-ready-sample-weight"], defaultNotReadySampleWeight)
+	if err != nil {
+		return nil, fmt.Errorf("parse not-ready-sample-weight failed: %v", err)
+	}
+	if notReadyWeight < 0 || notReadyWeight > 1 {
+		return nil, fmt.Errorf("not-ready-sample-weight must be in [0,1], got %v", notReadyWeight)
+	}
+
+	return &readinessWeightingConfig{notReadyWeight: notReadyWeight}, nil
+}
+
+// podReadiness is the latest transition of the pod ready condition
+type podReadiness struct {
+	ready bool
+	since time.Time
+}
+
+// readyAt tells whether the pod was ready at the time. Only the latest transition is known, so a pod ready since
+// T is treated as not ready before T, and a pod not ready since T is treated as ready before T.
+func (r podReadiness) readyAt(t time.Time) bool {
+	if t.Before(r.since) {
+		return !r.ready
+	}
+	return r.ready
+}
+
+// podReadinessOf returns the readiness of the pods by name
+func podReadinessOf(pods []corev1.Pod) map[string]podReadiness {
+	result := make(map[string]podReadiness, len(pods))
+	for _, pod := range pods {
+		readiness := podReadiness{since: pod.CreationTimestamp.Time}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady {
+				readiness.ready = condition.Status == corev1.ConditionTrue
+				if !condition.LastTransitionTime.IsZero() {
+					readiness.since = condition.LastTransitionTime.Time
+				}
+				break
+			}
+		}
+		result[pod.Name] = readiness
+	}
+	return result
+}
+
+type weightedSample struct {
+	value  float64
+	weight float64
+}
+
+// weightSamplesByReadiness weights the samples of each pod series by the pod readiness at the sample time.
+// Samples of pods unknown to the client, such as deleted pods, keep the full weight.
+func weightSamplesByReadiness(tsList []*common.TimeSeries, readiness map[string]podReadiness, cfg *readinessWeightingConfig) []weightedSample {
+	var result []weightedSample
+	for _, ts := range tsList {
+		podReadiness, known := readiness[seriesPodName(ts)]
+		for _, sample := range ts.Samples {
+			weight := 1.0
+			if known && !podReadiness.readyAt(time.Unix(sample.Timestamp, 0)) {
+				weight = cfg.notReadyWeight
+			}
+			result = append(result, weightedSample{value: sample.Value, weight: weight})
+		}
+	}
+	return result
+}
+
+func seriesPodName(ts *common.TimeSeries) string {
+	for _, label := range ts.Labels {
+		if label.Name == podLabelName {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+// weightedPercentile returns the smallest value whose cumulative weight reaches the percentile of the total weight
+func weightedPercentile(samples []weightedSample, percentile float64) (float64, bool) {
+	sorted := make([]weightedSample, 0, len(samples))
+	total := 0.0
+	for _, sample := range samples {
+		if sample.weight > 0 {
+			sorted = append(sorted, sample)
+			total += sample.weight
+		}
+	}
+	if len(sorted) == 0 {
+		return 0, false
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].value < sorted[j].value
+	})
+
+	threshold := total * percentile
+	cumulative := 0.0
+	for _, sample := range sorted {
+		cumulative += sample.weight
+		if cumulative >= threshold {
+			return sample.value, true
+		}
+	}
+	return sorted[len(sorted)-1].value, true
+}
@@ -0,0 +1,138 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+)
+
+// fakePodHistory returns the series set for a metric name
+type fakePodHistory struct {
+	series map[string][]*common.TimeSeries
+}
+
+func (h *fakePodHistory) QueryTimeSeries(namer metricnaming.MetricNamer, startTime time.Time, endTime time.Time, step time.Duration) ([]*common.TimeSeries, error) {
+	keys := seriesKeys(namer)
+	return h.series[keys[len(keys)-1]], nil
+}
+
+func newPodSeries(pod string, start time.Time, values ...float64) *common.TimeSeries {
+	ts := common.NewTimeSeries()
+	ts.SetLabels([]common.Label{{Name: podLabelName, Value: pod}})
+	for i, value := range values {
+		ts.AppendSample(start.Add(time.Duration(i)*10*time.Minute).Unix(), value)
+	}
+	return ts
+}
+
+func newReadyPod(name string, ready corev1.ConditionStatus, since time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             ready,
+				LastTransitionTime: metav1.NewTime(since),
+			}},
+		},
+	}
+}
+
+func TestEstimationWeightedByReadiness(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Hour)
+
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(4),
+		"memory": newSeries(1024),
+	})
+	e.Clock = clock.NewFakeClock(now)
+	// nginx-b became ready 30 minutes ago, it burns cpu while warming up
+	e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		newReadyPod("nginx-a", corev1.ConditionTrue, start.Add(-time.Hour)),
+		newReadyPod("nginx-b", corev1.ConditionTrue, now.Add(-30*time.Minute)),
+		newTestDeployment(2),
+	).Build()
+	e.History = &fakePodHistory{series: map[string][]*common.TimeSeries{
+		"cpu": {
+			newPodSeries("nginx-a", start, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5),
+			newPodSeries("nginx-b", start, 4, 4, 4, 1, 1, 1),
+		},
+		"memory": {
+			newPodSeries("nginx-a", start, 1024, 1024, 1024, 1024, 1024, 1024),
+			newPodSeries("nginx-b", start, 4096, 4096, 4096, 2048, 2048, 2048),
+		},
+	}}
+
+	config := map[string]string{
+		"readiness-weighting":         "true",
+		"cpu-request-percentile":      "1.0",
+		"cpu-request-margin-fraction": "0",
+		"mem-request-percentile":      "1.0",
+		"mem-request-margin-fraction": "0",
+	}
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+	assert.Equal(t, "2Ki", resources.Memory().String())
+
+	// disabled, the predicted value is used
+	config["readiness-weighting"] = "false"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "4", resources.Cpu().String())
+
+	config["readiness-weighting"] = "true"
+	config["not-ready-sample-weight"] = "2"
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
+
+func TestWeightSamplesByReadiness(t *testing.T) {
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Hour)
+	readiness := map[string]podReadiness{
+		"nginx-a": {ready: true, since: start.Add(-time.Hour)},
+		"nginx-b": {ready: true, since: now.Add(-30 * time.Minute)},
+	}
+	tsList := []*common.TimeSeries{
+		newPodSeries("nginx-a", start, 1, 1, 1, 1),
+		newPodSeries("nginx-b", start, 3, 3, 3, 2),
+		// the pod is gone, its samples keep the full weight
+		newPodSeries("nginx-c", start, 1),
+	}
+
+	samples := weightSamplesByReadiness(tsList, readiness, &readinessWeightingConfig{notReadyWeight: 0.25})
+	total := 0.0
+	for _, sample := range samples {
+		total += sample.weight
+		if sample.value == 3 {
+			assert.Equal(t, 0.25, sample.weight)
+		} else {
+			assert.Equal(t, 1.0, sample.weight)
+		}
+	}
+	assert.Equal(t, 6.75, total)
+
+	// down-weighted samples still count, but cover a smaller share of the distribution
+	value, found := weightedPercentile(samples, 0.85)
+	assert.True(t, found)
+	assert.Equal(t, 2.0, value)
+	value, _ = weightedPercentile(samples, 1.0)
+	assert.Equal(t, 3.0, value)
+
+	_, found = weightedPercentile(weightSamplesByReadiness(tsList[1:2], readiness, &readinessWeightingConfig{}), 0.5)
+	assert.True(t, found)
+	_, found = weightedPercentile(nil, 0.5)
+	assert.False(t, found)
+}
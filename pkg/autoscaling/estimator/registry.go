@@ -0,0 +1,123 @@
+package estimator
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/prediction"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+)
+
+// sharedCallerFormat is the caller of the shared queries, it is prefixed by the caller prefix of the estimator
+const sharedCallerFormat = "%s-shared-%x"
+
+// registeredQuery is a query registered in the predictor and the referents sharing it
+type registeredQuery struct {
+	namer     metricnaming.MetricNamer
+	referents map[string]struct{}
+}
+
+// QueryRegistry registers identical queries once across the callers, such as the evpas targeting overlapping
+// workloads. Queries of the same metric and config share a caller in the predictor, and the query is deleted
+// from the predictor when the last referent releases it.
+type QueryRegistry struct {
+	mu        sync.Mutex
+	Predictor prediction.Interface
+	// CallerPrefix prefixes the shared callers, EVPACaller by default
+	CallerPrefix string
+	// queries is keyed by the unique key of the shared namer
+	queries map[string]*registeredQuery
+	// referentQueries is the queries of each referent keyed by the metric
+	referentQueries map[string]map[string]string
+}
+
+func NewQueryRegistry(predictor prediction.Interface, callerPrefix string) *QueryRegistry {
+	return &QueryRegistry{
+		Predictor:       predictor,
+		CallerPrefix:    callerPrefix,
+		queries:         map[string]*registeredQuery{},
+		referentQueries: map[string]map[string]string{},
+	}
+}
+
+// Register returns the namer registered in the predictor for the metric, it should be used to query the predictor.
+// If the referent registered the metric with another config, the previous one is released.
+func (r *QueryRegistry) Register(referent string, namer *metricnaming.GeneralMetricNamer, cfg predictionconfig.Config) (metricnaming.MetricNamer, error) {
+	caller, err := r.sharedCaller(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sharedNamer := &metricnaming.GeneralMetricNamer{
+		CallerName: caller,
+		Metric:     namer.Metric,
+	}
+	key := sharedNamer.BuildUniqueKey()
+	metricKey := namer.Metric.BuildUniqueKey()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	query, exists := r.queries[key]
+	if !exists {
+		if err := r.Predictor.WithQuery(sharedNamer, caller, cfg); err != nil {
+			return nil, err
+		}
+		query = &registeredQuery{namer: sharedNamer, referents: map[string]struct{}{}}
+		r.queries[key] = query
+	}
+	query.referents[referent] = struct{}{}
+
+	if _, exists := r.referentQueries[referent]; !exists {
+		r.referentQueries[referent] = map[string]string{}
+	}
+	if previous, exists := r.referentQueries[referent][metricKey]; exists && previous != key {
+		r.release(referent, previous)
+	}
+	r.referentQueries[referent][metricKey] = key
+
+	return query.namer, nil
+}
+
+// Release releases all the queries of the referent
+func (r *QueryRegistry) Release(referent string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.referentQueries[referent] {
+		r.release(referent, key)
+	}
+	delete(r.referentQueries, referent)
+}
+
+// release deletes the query from the predictor if the referent is the last one
+func (r *QueryRegistry) release(referent string, key string) {
+	query, exists := r.queries[key]
+	if !exists {
+		return
+	}
+	delete(query.referents, referent)
+	if len(query.referents) > 0 {
+		return
+	}
+
+	if err := r.Predictor.DeleteQuery(query.namer, query.namer.Caller()); err != nil {
+		klog.ErrorS(err, "Failed to delete query.", "queryExpr", key)
+	}
+	delete(r.queries, key)
+}
+
+// sharedCaller returns the caller of the config, the queries with the same config share the caller
+func (r *QueryRegistry) sharedCaller(cfg predictionconfig.Config) (string, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal prediction config: %v", err)
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write(data)
+	return fmt.Sprintf(sharedCallerFormat, callerPrefixOrDefault(r.CallerPrefix), hash.Sum32()), nil
+}
@@ -0,0 +1,78 @@
+package estimator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestQueryRegistrySharedAcrossEVPAs(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.5),
+		"memory": newSeries(1024),
+	})
+	e.Registry = NewQueryRegistry(predictor, "")
+
+	// two evpas targeting the same workload
+	evpa1 := newTestEVPA("nginx")
+	evpa2 := newTestEVPA("nginx")
+	evpa2.Name = "evpa2"
+	evpa2.UID = "uid2"
+
+	for _, evpa := range []*autoscalingapi.EffectiveVerticalPodAutoscaler{evpa1, evpa2, evpa1} {
+		resources, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+		assert.NoError(t, err)
+		assert.Equal(t, "500m", resources.Cpu().String())
+	}
+	// cpu and memory are registered once
+	assert.Len(t, predictor.registered, 2)
+	for _, count := range predictor.registered {
+		assert.Equal(t, 1, count)
+	}
+
+	// still referred by evpa2
+	e.DeleteEstimation(context.TODO(), evpa1)
+	assert.Empty(t, predictor.deleted)
+
+	// delete on the last release
+	e.DeleteEstimation(context.TODO(), evpa2)
+	assert.Len(t, predictor.deleted, 2)
+
+	// a different config is not shared
+	_, err := e.GetResourceEstimation(context.TODO(), evpa1, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	_, err = e.GetResourceEstimation(context.TODO(), evpa2, map[string]string{"cpu-request-percentile": "0.9"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Len(t, predictor.registered, 3)
+
+	// evpa2 changes back to the default config, its previous query is released
+	_, err = e.GetResourceEstimation(context.TODO(), evpa2, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Len(t, predictor.deleted, 3)
+}
+
+func TestQueryRegistryCallerPrefix(t *testing.T) {
+	for prefix, expected := range map[string]string{"": "EVPACaller-shared-", "tenant-a": "tenant-a-shared-"} {
+		e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+			"cpu":    newSeries(0.5),
+			"memory": newSeries(1024),
+		})
+		e.CallerPrefix = prefix
+		e.Registry = NewQueryRegistry(predictor, prefix)
+
+		_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+		assert.NoError(t, err)
+		if assert.Len(t, predictor.callers, 2) {
+			for _, caller := range predictor.callers {
+				assert.True(t, strings.HasPrefix(caller, expected), caller)
+			}
+		}
+	}
+}
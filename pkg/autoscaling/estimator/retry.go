@@ -0,0 +1,75 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/prediction"
+)
+
+// queryRetry retries the failed realtime predicted values queries with the exponential backoff
+type queryRetry struct {
+	maxRetries int
+	backoff    time.Duration
+}
+
+// getQueryRetry returns the retry of 'query-max-retries' and 'query-retry-backoff', 2 retries from 200ms by default
+func getQueryRetry(config map[string]string) (queryRetry, error) {
+	retry := queryRetry{maxRetries: 2, backoff: 200 * time.Millisecond}
+	if maxRetriesStr, exists := config["query-max-retries"]; exists {
+		maxRetries, err := strconv.Atoi(maxRetriesStr)
+		if err != nil {
+			return retry, fmt.Errorf("parse query-max-retries failed: %v", err)
+		}
+		if maxRetries < 0 {
+			return retry, fmt.Errorf("query-max-retries must not be negative, got %d", maxRetries)
+		}
+		retry.maxRetries = maxRetries
+	}
+	if backoffStr, exists := config["query-retry-backoff"]; exists {
+		backoff, err := time.ParseDuration(backoffStr)
+		if err != nil {
+			return retry, fmt.Errorf("parse query-retry-backoff failed: %v", err)
+		}
+		if backoff <= 0 {
+			return retry, fmt.Errorf("query-retry-backoff must be positive, got %v", backoff)
+		}
+		retry.backoff = backoff
+	}
+	return retry, nil
+}
+
+// queryRealtimePredictedValues queries the predictor and retries the transient errors, the backoff is cut short once
+// the context is done
+func (r queryRetry) queryRealtimePredictedValues(ctx context.Context, predictor prediction.Interface, namer metricnaming.MetricNamer) ([]*common.TimeSeries, error) {
+	backoff := r.backoff
+	for retries := 0; ; retries++ {
+		tsList, err := predictor.QueryRealtimePredictedValues(ctx, namer)
+		if err == nil || retries >= r.maxRetries || !isTransientQueryError(ctx, predictor, namer, err) {
+			return tsList, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// isTransientQueryError tells whether the query may succeed on a retry, such as the data source is unavailable for a
+// while. The model warming up is not transient, it takes the history length to be ready.
+func isTransientQueryError(ctx context.Context, predictor prediction.Interface, namer metricnaming.MetricNamer, err error) bool {
+	if errors.Is(err, ErrModelNotReady) || errors.Is(err, ErrNoSamples) || ctx.Err() != nil {
+		return false
+	}
+	status, statusErr := predictor.QueryPredictionStatus(ctx, namer)
+	return statusErr != nil || status == prediction.StatusReady
+}
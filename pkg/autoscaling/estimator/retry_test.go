@@ -0,0 +1,79 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/prediction"
+)
+
+func TestQueryRetry(t *testing.T) {
+	config := map[string]string{"query-retry-backoff": "1ms"}
+	newRetriedEstimator := func(failures int, err error) (*PercentileResourceEstimator, *fakePredictor) {
+		e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+			"cpu":    newSeries(0.25),
+			"memory": newSeries(256 * 1024 * 1024),
+		})
+		predictor.errs["cpu"] = err
+		predictor.failures["cpu"] = failures
+		return e, predictor
+	}
+
+	// the transient errors are retried until success
+	e, predictor := newRetriedEstimator(2, fmt.Errorf("server returned HTTP status 503 Service Unavailable"))
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, 3, predictor.called["nginx/cpu"])
+	assert.Equal(t, 1, predictor.called["nginx/memory"])
+
+	// the retries are bounded
+	e, predictor = newRetriedEstimator(5, fmt.Errorf("server returned HTTP status 503 Service Unavailable"))
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.NotContains(t, resources, corev1.ResourceCPU)
+	assert.Equal(t, 3, predictor.called["nginx/cpu"])
+
+	e, predictor = newRetriedEstimator(5, fmt.Errorf("server returned HTTP status 503 Service Unavailable"))
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"query-max-retries": "4", "query-retry-backoff": "1ms"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, predictor.called["nginx/cpu"])
+
+	// the not ready model is not retried
+	e, predictor = newRetriedEstimator(5, fmt.Errorf("%w, status NotReady", ErrModelNotReady))
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, predictor.called["nginx/cpu"])
+
+	e, predictor = newRetriedEstimator(5, fmt.Errorf("metric cpu model status is Initializing, must be ready"))
+	predictor.statuses["cpu"] = prediction.StatusInitializing
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, predictor.called["nginx/cpu"])
+
+	// the backoff is cut short by the deadline
+	e, _ = newRetriedEstimator(5, fmt.Errorf("server returned HTTP status 503 Service Unavailable"))
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = e.GetResourceEstimation(ctx, newTestEVPA("nginx"), map[string]string{"query-retry-backoff": "1h"}, "nginx", &corev1.ResourceRequirements{})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	assert.Less(t, time.Since(start), time.Minute)
+
+	for _, invalid := range []map[string]string{
+		{"query-max-retries": "-1"},
+		{"query-max-retries": "twice"},
+		{"query-retry-backoff": "0s"},
+		{"query-retry-backoff": "soon"},
+	} {
+		_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), invalid, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,200 @@
+package estimator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const mebibyte = 1024 * 1024
+
+// getMemRoundPow2 returns whether 'mem-round-pow2' is set
+func getMemRoundPow2(config map[string]string) (bool, error) {
+	value, exists := config["mem-round-pow2"]
+	if !exists {
+		return false, nil
+	}
+	roundPow2, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parse mem-round-pow2 failed: %v", err)
+	}
+	return roundPow2, nil
+}
+
+// roundUpPow2 rounds the memory up to the next power of two mebibytes, such as 300Mi to 512Mi and 1.5Gi to 2Gi.
+// The rounded memory doesn't exceed the max allowed, unless the memory itself is greater than it.
+func roundUpPow2(memory resource.Quantity, maxAllowed *resource.Quantity) resource.Quantity {
+	bytes := memory.Value()
+	if bytes <= 0 {
+		return memory
+	}
+	mebibytes := (bytes + mebibyte - 1) / mebibyte
+	pow2 := int64(1)
+	for pow2 < mebibytes {
+		pow2 <<= 1
+	}
+	rounded := *resource.NewQuantity(pow2*mebibyte, resource.BinarySI)
+	if maxAllowed != nil && rounded.Cmp(*maxAllowed) > 0 {
+		if memory.Cmp(*maxAllowed) > 0 {
+			return memory
+		}
+		return maxAllowed.DeepCopy()
+	}
+	return rounded
+}
+
+// getSignificantFigures returns the 'significant-figures' of the recommended quantities, zero means not rounded
+func getSignificantFigures(config map[string]string) (int, error) {
+	value, exists := config["significant-figures"]
+	if !exists {
+		return 0, nil
+	}
+	figures, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse significant-figures failed: %v", err)
+	}
+	if figures <= 0 {
+		return 0, fmt.Errorf("significant-figures must be positive, got %d", figures)
+	}
+	return figures, nil
+}
+
+// roundUpSignificant rounds the quantity up to the significant figures for safety, cpu in millicores and memory
+// in mebibytes, the custom metrics as the cpu, such as 1373Mi to 1400Mi and 1234m to 1300m at 2 significant figures
+func roundUpSignificant(resourceName corev1.ResourceName, quantity resource.Quantity, figures int) resource.Quantity {
+	if !isByteResource(resourceName) {
+		return *resource.NewMilliQuantity(int64(ceilSignificant(float64(quantity.MilliValue()), figures)), resource.DecimalSI)
+	}
+	mebibytes := ceilSignificant(float64(quantity.Value())/mebibyte, figures)
+	return *resource.NewQuantity(int64(math.Ceil(mebibytes*mebibyte)), resource.BinarySI)
+}
+
+func ceilSignificant(value float64, figures int) float64 {
+	if value <= 0 {
+		return value
+	}
+	scale := math.Pow(10, math.Floor(math.Log10(value))-float64(figures-1))
+	// tolerate the float error of the values already at the significant figures
+	return math.Ceil(value/scale-1e-9) * scale
+}
+
+// getRoundTo returns the steps of the 'cpu-round-to' and 'mem-round-to' by the resource
+func getRoundTo(config map[string]string) (map[corev1.ResourceName]resource.Quantity, error) {
+	steps := map[corev1.ResourceName]resource.Quantity{}
+	for key, resourceName := range map[string]corev1.ResourceName{
+		"cpu-round-to": corev1.ResourceCPU,
+		"mem-round-to": corev1.ResourceMemory,
+	} {
+		stepStr, exists := config[key]
+		if !exists {
+			continue
+		}
+		step, err := resource.ParseQuantity(stepStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s failed: %v", key, err)
+		}
+		// the cpu is rounded in millicores and the memory in bytes
+		minStep := resource.NewQuantity(1, resource.BinarySI)
+		if resourceName == corev1.ResourceCPU {
+			minStep = resource.NewMilliQuantity(1, resource.DecimalSI)
+		}
+		if step.Cmp(*minStep) < 0 {
+			return nil, fmt.Errorf("%s must be at least %s, got %s", key, minStep.String(), stepStr)
+		}
+		steps[resourceName] = step
+	}
+	return steps, nil
+}
+
+// roundUpTo rounds the quantity up to the multiple of the step, cpu in millicores and memory in bytes, such as 237m
+// to 250m by 50m. The memory is formatted as the step, so 64Mi keeps the binary SI and 100M the decimal SI.
+func roundUpTo(resourceName corev1.ResourceName, quantity resource.Quantity, step resource.Quantity) resource.Quantity {
+	if resourceName == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(ceilMultiple(quantity.MilliValue(), step.MilliValue()), resource.DecimalSI)
+	}
+	return *resource.NewQuantity(ceilMultiple(quantity.Value(), step.Value()), step.Format)
+}
+
+// memUnits are the units of 'mem-unit' by the suffix
+var memUnits = map[string]resource.Format{
+	"Ki": resource.BinarySI,
+	"Mi": resource.BinarySI,
+	"Gi": resource.BinarySI,
+	"Ti": resource.BinarySI,
+	"k":  resource.DecimalSI,
+	"M":  resource.DecimalSI,
+	"G":  resource.DecimalSI,
+	"T":  resource.DecimalSI,
+}
+
+// memUnitConfig rounds the memory up to the whole 'mem-unit' and not below the 'mem-floor'
+type memUnitConfig struct {
+	unit  *resource.Quantity
+	floor *resource.Quantity
+}
+
+// getMemUnitConfig returns the 'mem-unit' and 'mem-floor', nil if neither is set
+func getMemUnitConfig(config map[string]string) (*memUnitConfig, error) {
+	unitStr, unitExists := config["mem-unit"]
+	floorStr, floorExists := config["mem-floor"]
+	if !unitExists && !floorExists {
+		return nil, nil
+	}
+	cfg := &memUnitConfig{}
+	if unitExists {
+		if _, known := memUnits[unitStr]; !known {
+			return nil, fmt.Errorf("unknown mem-unit %s", unitStr)
+		}
+		unit := resource.MustParse("1" + unitStr)
+		cfg.unit = &unit
+	}
+	if floorExists {
+		floor, err := resource.ParseQuantity(floorStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse mem-floor failed: %v", err)
+		}
+		if floor.Sign() < 0 {
+			return nil, fmt.Errorf("mem-floor must not be negative, got %s", floorStr)
+		}
+		cfg.floor = &floor
+	}
+	return cfg, nil
+}
+
+func (c *memUnitConfig) String() string {
+	var parts []string
+	if c.unit != nil {
+		parts = append(parts, "round up to the whole "+c.unit.String())
+	}
+	if c.floor != nil {
+		parts = append(parts, "not below "+c.floor.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// roundUpToMemUnit raises the memory to the floor, then rounds it up to the whole unit formatted as the unit, such
+// as 1476395008 to 1408Mi. The multiples of a larger unit are canonicalized to it, so 2048Mi is 2Gi.
+func roundUpToMemUnit(memory resource.Quantity, cfg *memUnitConfig) resource.Quantity {
+	bytes := memory.Value()
+	format := memory.Format
+	if cfg.floor != nil && bytes < cfg.floor.Value() {
+		bytes = cfg.floor.Value()
+		format = cfg.floor.Format
+	}
+	if cfg.unit != nil {
+		bytes = ceilMultiple(bytes, cfg.unit.Value())
+		format = cfg.unit.Format
+	}
+	return *resource.NewQuantity(bytes, format)
+}
+
+func ceilMultiple(value int64, step int64) int64 {
+	if value <= 0 {
+		return value
+	}
+	return (value + step - 1) / step * step
+}
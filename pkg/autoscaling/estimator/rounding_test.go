@@ -0,0 +1,215 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestRoundUpPow2(t *testing.T) {
+	for memory, expected := range map[string]string{
+		"300Mi":  "512Mi",
+		"512Mi":  "512Mi",
+		"513Mi":  "1Gi",
+		"1.5Gi":  "2Gi",
+		"3Gi":    "4Gi",
+		"100Ki":  "1Mi",
+		"1000Mi": "1Gi",
+	} {
+		rounded := roundUpPow2(resource.MustParse(memory), nil)
+		assert.Equal(t, expected, rounded.String(), memory)
+	}
+
+	maxAllowed := resource.MustParse("3Gi")
+	for memory, expected := range map[string]string{
+		"2.5Gi": "3Gi",
+		"1.5Gi": "2Gi",
+		"5Gi":   "5Gi",
+	} {
+		rounded := roundUpPow2(resource.MustParse(memory), &maxAllowed)
+		assert.Equal(t, expected, rounded.String(), memory)
+	}
+}
+
+func TestEstimateResourcesMemRoundPow2(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(300 * 1024 * 1024),
+	})
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-round-pow2": "true"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "512Mi", resources.Memory().String())
+	assert.Equal(t, "250m", resources.Cpu().String())
+
+	// disabled, the exact value is kept
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-round-pow2": "false"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "300Mi", resources.Memory().String())
+
+	// respects the max allowed of the policy
+	evpa := newTestEVPA("nginx")
+	evpa.Spec.ResourcePolicy.ContainerPolicies[0].MaxAllowed = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("400Mi")}
+	resources, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{"mem-round-pow2": "true"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "400Mi", resources.Memory().String())
+}
+
+func TestRoundUpSignificant(t *testing.T) {
+	for _, test := range []struct {
+		resourceName corev1.ResourceName
+		quantity     string
+		figures      int
+		expected     string
+	}{
+		{resourceName: corev1.ResourceMemory, quantity: "1373Mi", figures: 2, expected: "1400Mi"},
+		{resourceName: corev1.ResourceMemory, quantity: "1400Mi", figures: 2, expected: "1400Mi"},
+		{resourceName: corev1.ResourceMemory, quantity: "1373Mi", figures: 3, expected: "1380Mi"},
+		{resourceName: corev1.ResourceMemory, quantity: "1401Mi", figures: 1, expected: "2000Mi"},
+		{resourceName: corev1.ResourceCPU, quantity: "1234m", figures: 2, expected: "1300m"},
+		{resourceName: corev1.ResourceCPU, quantity: "87m", figures: 1, expected: "90m"},
+		{resourceName: corev1.ResourceCPU, quantity: "2", figures: 2, expected: "2"},
+	} {
+		rounded := roundUpSignificant(test.resourceName, resource.MustParse(test.quantity), test.figures)
+		assert.Equal(t, test.expected, rounded.String(), test.quantity)
+	}
+}
+
+func TestEstimateResourcesSignificantFigures(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(1.2345),
+		"memory": newSeries(1373.4921 * 1024 * 1024),
+	})
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"significant-figures": "2"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1300m", resources.Cpu().String())
+	assert.Equal(t, "1400Mi", resources.Memory().String())
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"significant-figures": "0"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
+
+func TestRoundUpTo(t *testing.T) {
+	tests := []struct {
+		resourceName corev1.ResourceName
+		quantity     string
+		step         string
+		expected     string
+	}{
+		{resourceName: corev1.ResourceCPU, quantity: "237m", step: "50m", expected: "250m"},
+		{resourceName: corev1.ResourceCPU, quantity: "250m", step: "50m", expected: "250m"},
+		{resourceName: corev1.ResourceCPU, quantity: "1001m", step: "0.5", expected: "1500m"},
+		{resourceName: corev1.ResourceMemory, quantity: "1476395008", step: "64Mi", expected: "1408Mi"},
+		{resourceName: corev1.ResourceMemory, quantity: "1Gi", step: "64Mi", expected: "1Gi"},
+		{resourceName: corev1.ResourceMemory, quantity: "1476395008", step: "100M", expected: "1500M"},
+	}
+	for _, tt := range tests {
+		rounded := roundUpTo(tt.resourceName, resource.MustParse(tt.quantity), resource.MustParse(tt.step))
+		assert.Equal(t, tt.expected, rounded.String(), "%s by %s", tt.quantity, tt.step)
+	}
+}
+
+func TestEstimateResourcesRoundTo(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.237),
+		"memory": newSeries(1476395008),
+	})
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-round-to": "50m", "mem-round-to": "64Mi"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, "1408Mi", resources.Memory().String())
+
+	// absent keys leave the values untouched
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "237m", resources.Cpu().String())
+	assert.Equal(t, int64(1476395008), resources.Memory().Value())
+
+	// only the cpu is rounded
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"cpu-round-to": "100m"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "300m", resources.Cpu().String())
+	assert.Equal(t, int64(1476395008), resources.Memory().Value())
+
+	for _, props := range []map[string]string{
+		{"cpu-round-to": "fifty"},
+		{"cpu-round-to": "0"},
+		{"cpu-round-to": "0.1m"},
+		{"mem-round-to": "-64Mi"},
+		{"mem-round-to": "0.5"},
+	} {
+		_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), props, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, props)
+	}
+}
+
+func TestRoundUpToMemUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity string
+		unit     string
+		floor    string
+		expected string
+	}{
+		{name: "below the floor", quantity: "10Mi", unit: "Mi", floor: "64Mi", expected: "64Mi"},
+		{name: "below the floor rounded to the unit", quantity: "10Mi", unit: "Gi", floor: "64Mi", expected: "1Gi"},
+		{name: "on the unit boundary", quantity: "1408Mi", unit: "Mi", floor: "64Mi", expected: "1408Mi"},
+		{name: "on the unit boundary of Gi", quantity: "2Gi", unit: "Gi", expected: "2Gi"},
+		{name: "mid unit", quantity: "1476395000", unit: "Mi", floor: "64Mi", expected: "1408Mi"},
+		{name: "mid unit of Gi", quantity: "1476395008", unit: "Gi", expected: "2Gi"},
+		{name: "mid unit of M", quantity: "1476395008", unit: "M", expected: "1477M"},
+		{name: "floor only", quantity: "1476395008", floor: "64Mi", expected: "1476395008"},
+	}
+	for _, tt := range tests {
+		config := map[string]string{}
+		if tt.unit != "" {
+			config["mem-unit"] = tt.unit
+		}
+		if tt.floor != "" {
+			config["mem-floor"] = tt.floor
+		}
+		cfg, err := getMemUnitConfig(config)
+		assert.NoError(t, err, tt.name)
+		rounded := roundUpToMemUnit(resource.MustParse(tt.quantity), cfg)
+		assert.Equal(t, tt.expected, rounded.String(), tt.name)
+	}
+}
+
+func TestEstimateResourcesMemUnit(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.237),
+		"memory": newSeries(1476395000),
+	})
+	config := map[string]string{"mem-unit": "Mi", "mem-floor": "64Mi"}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1408Mi", resources.Memory().String())
+	// the cpu is untouched
+	assert.Equal(t, "237m", resources.Cpu().String())
+
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.237),
+		"memory": newSeries(16 * mebibyte),
+	})
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "64Mi", resources.Memory().String())
+
+	for _, props := range []map[string]string{
+		{"mem-unit": "MiB"},
+		{"mem-unit": "64Mi"},
+		{"mem-floor": "a lot"},
+		{"mem-floor": "-64Mi"},
+	} {
+		_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), props, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, props)
+	}
+}
@@ -0,0 +1,159 @@
+package estimator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const (
+	rpsMetricName = "rps"
+)
+
+// rpsModelConfig sizes the requests from a resource-per-request model: resource ≈ a + b·RPS is fitted from the
+// history of the usage against the RPS, and projected at the target RPS. The RPS query should return the requests
+// per second served by a pod, such as the workload RPS divided by the replicas.
+type rpsModelConfig struct {
+	queryExpr string
+	// target is the RPS to project, zero means the forecasted RPS from the history
+	target float64
+	// percentile of the RPS history as the forecasted RPS
+	percentile float64
+}
+
+// getRpsModelConfig returns nil if 'rps-query' is not set
+func getRpsModelConfig(config map[string]string) (*rpsModelConfig, error) {
+	queryExpr, exists := config["rps-query"]
+	if !exists || queryExpr == "" {
+		return nil, nil
+	}
+	target, err := utils.ParseFloat(config["rps-target"], 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse rps-target failed: %v", err)
+	}
+	if target < 0 {
+		return nil, fmt.Errorf("rps-target must not be negative, got %v", target)
+	}
+	percentile, err := utils.ParseFloat(config["rps-percentile"], 0.99)
+	if err != nil {
+		return nil, fmt.Errorf("parse rps-percentile failed: %v", err)
+	}
+	if percentile <= 0 || percentile > 1 {
+		return nil, fmt.Errorf("rps-percentile must be in (0, 1], got %v", percentile)
+	}
+	return &rpsModelConfig{queryExpr: queryExpr, target: target, percentile: percentile}, nil
+}
+
+func newRpsMetricNamer(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, caller string, queryExpr string, selector labels.Selector) *metricnaming.GeneralMetricNamer {
+	return &metricnaming.GeneralMetricNamer{
+		CallerName: caller,
+		Metric: &metricquery.Metric{
+			Type:       metricquery.PromQLMetricType,
+			MetricName: rpsMetricName,
+			Prom: &metricquery.PromNamerInfo{
+				QueryExpr: queryExpr,
+				Namespace: evpa.Namespace,
+				Selector:  selector,
+			},
+		},
+	}
+}
+
+// estimateFromRps fits the usage of the namer against the RPS and returns the projected usage with margin
+func (e *PercentileResourceEstimator) estimateFromRps(namer metricnaming.MetricNamer, rpsNamer metricnaming.MetricNamer, cfg *predictionconfig.Config, rpsConfig *rpsModelConfig) (float64, string, bool, error) {
+	historyLength, err := utils.ParseDuration(cfg.Percentile.HistoryLength)
+	if err != nil {
+		return 0, "", false, fmt.Errorf("parse history length failed: %v", err)
+	}
+	sampleInterval, err := utils.ParseDuration(cfg.Percentile.SampleInterval)
+	if err != nil {
+		return 0, "", false, fmt.Errorf("parse sample interval failed: %v", err)
+	}
+	marginFraction, err := utils.ParseFloat(cfg.Percentile.MarginFraction, 0)
+	if err != nil {
+		return 0, "", false, fmt.Errorf("parse margin fraction failed: %v", err)
+	}
+
+	now := e.now()
+	usageList, err := e.History.QueryTimeSeries(namer, now.Add(-historyLength), now, sampleInterval)
+	if err != nil {
+		return 0, "", false, err
+	}
+	rpsList, err := e.History.QueryTimeSeries(rpsNamer, now.Add(-historyLength), now, sampleInterval)
+	if err != nil {
+		return 0, "", false, err
+	}
+
+	usage := averageByTimestamp(usageList)
+	rps := averageByTimestamp(rpsList)
+	var xs, ys []float64
+	for timestamp, value := range rps {
+		if usageValue, exists := usage[timestamp]; exists {
+			xs = append(xs, value)
+			ys = append(ys, usageValue)
+		}
+	}
+	a, b, ok := fitLinear(xs, ys)
+	if !ok {
+		return 0, "", false, nil
+	}
+
+	target := rpsConfig.target
+	if target == 0 {
+		sort.Float64s(xs)
+		target = xs[int(math.Ceil(rpsConfig.percentile*float64(len(xs))))-1]
+	}
+	value := math.Max(a+b*target, 0)
+	detail := fmt.Sprintf("%g + %g * rps at rps %g", a, b, target)
+	return value * (1 + marginFraction), detail, true, nil
+}
+
+// averageByTimestamp averages the samples of all series with the same timestamp
+func averageByTimestamp(tsList []*common.TimeSeries) map[int64]float64 {
+	sums := map[int64]float64{}
+	counts := map[int64]int{}
+	for _, ts := range tsList {
+		for _, sample := range ts.Samples {
+			sums[sample.Timestamp] += sample.Value
+			counts[sample.Timestamp]++
+		}
+	}
+	for timestamp := range sums {
+		sums[timestamp] /= float64(counts[timestamp])
+	}
+	return sums
+}
+
+// fitLinear fits y = a + b·x by the least squares, it is not ok if there are less than two distinct x
+func fitLinear(xs, ys []float64) (float64, float64, bool) {
+	n := float64(len(xs))
+	if len(xs) < 2 {
+		return 0, 0, false
+	}
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var covariance, variance float64
+	for i := range xs {
+		covariance += (xs[i] - meanX) * (ys[i] - meanY)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if variance == 0 {
+		return 0, 0, false
+	}
+	b := covariance / variance
+	return meanY - b*meanX, b, true
+}
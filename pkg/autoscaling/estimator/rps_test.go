@@ -0,0 +1,74 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestFitLinear(t *testing.T) {
+	a, b, ok := fitLinear([]float64{10, 20, 30, 40}, []float64{0.3, 0.5, 0.7, 0.9})
+	assert.True(t, ok)
+	assert.InDelta(t, 0.1, a, 1e-9)
+	assert.InDelta(t, 0.02, b, 1e-9)
+
+	_, _, ok = fitLinear([]float64{10, 10}, []float64{0.3, 0.5})
+	assert.False(t, ok)
+	_, _, ok = fitLinear([]float64{10}, []float64{0.3})
+	assert.False(t, ok)
+}
+
+func TestEstimateFromRpsModel(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	e.Clock = clock.NewFakeClock(now)
+	start := now.Add(-time.Hour)
+	// cpu = 0.1 + 0.01 * rps per pod, memory does not depend on the rps
+	e.History = &fakePodHistory{series: map[string][]*common.TimeSeries{
+		"cpu": {
+			newPodSeries("nginx-a", start, 0.2, 0.3, 0.4, 0.5),
+			newPodSeries("nginx-b", start, 0.2, 0.3, 0.4, 0.5),
+		},
+		"memory": {
+			newPodSeries("nginx-a", start, 1024, 1024, 1024, 1024),
+		},
+		"rps": {
+			newPodSeries("nginx", start, 10, 20, 30, 40),
+		},
+	}}
+
+	config := map[string]string{
+		"rps-query":                   "sum(rate(http_requests_total[1m])) / count(up)",
+		"rps-target":                  "100",
+		"cpu-request-margin-fraction": "0",
+		"mem-request-margin-fraction": "0",
+	}
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1100m", resources.Cpu().String())
+	// no distinct relationship, memory intercept is the constant usage
+	assert.Equal(t, "1Ki", resources.Memory().String())
+
+	// forecasted from the rps history
+	delete(config, "rps-target")
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+
+	// disabled, the predicted value is used
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"rps-query": "rps", "rps-target": "-1"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
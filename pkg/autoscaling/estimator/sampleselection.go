@@ -0,0 +1,65 @@
+package estimator
+
+import (
+	"fmt"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+const (
+	// SampleSelectionFirst selects the earliest sample of the predicted series, the percentile predictor returns a
+	// single sample so it is the current estimate
+	SampleSelectionFirst = "first"
+	// SampleSelectionLast selects the latest sample, for the predictors returning the series ordered in time
+	SampleSelectionLast = "last"
+	// SampleSelectionMax selects the largest sample
+	SampleSelectionMax = "max"
+)
+
+// getSampleSelection returns the 'sample-selection', first by default so the single sample predictors are unchanged
+func getSampleSelection(config map[string]string) (string, error) {
+	selection, exists := config["sample-selection"]
+	if !exists {
+		return SampleSelectionFirst, nil
+	}
+	switch selection {
+	case SampleSelectionFirst, SampleSelectionLast, SampleSelectionMax:
+		return selection, nil
+	default:
+		return "", fmt.Errorf("sample-selection must be one of %s, %s or %s, got %s", SampleSelectionFirst, SampleSelectionLast, SampleSelectionMax, selection)
+	}
+}
+
+// selectSample returns the sample of the selection, first and last are by timestamp rather than by index, the ties are
+// resolved to the earlier index
+func selectSample(samples []common.Sample, selection string) (common.Sample, bool) {
+	if len(samples) == 0 {
+		return common.Sample{}, false
+	}
+	selected := samples[0]
+	for _, sample := range samples[1:] {
+		switch selection {
+		case SampleSelectionLast:
+			if sample.Timestamp > selected.Timestamp {
+				selected = sample
+			}
+		case SampleSelectionMax:
+			if sample.Value > selected.Value {
+				selected = sample
+			}
+		default:
+			if sample.Timestamp < selected.Timestamp {
+				selected = sample
+			}
+		}
+	}
+	return selected, true
+}
+
+// seriesSamples returns the samples of the first series, nil if there is no series
+func seriesSamples(tsList []*common.TimeSeries) []common.Sample {
+	if len(tsList) == 0 {
+		return nil
+	}
+	return tsList[0].Samples
+}
@@ -0,0 +1,75 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestGetSampleSelection(t *testing.T) {
+	selection, err := getSampleSelection(map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, SampleSelectionFirst, selection)
+
+	for _, expected := range []string{SampleSelectionFirst, SampleSelectionLast, SampleSelectionMax} {
+		selection, err = getSampleSelection(map[string]string{"sample-selection": expected})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, selection)
+	}
+
+	_, err = getSampleSelection(map[string]string{"sample-selection": "latest"})
+	assert.Error(t, err)
+}
+
+func TestSelectSample(t *testing.T) {
+	_, selected := selectSample(nil, SampleSelectionLast)
+	assert.False(t, selected)
+
+	// not ordered by the timestamp
+	samples := []common.Sample{{Timestamp: 120, Value: 2}, {Timestamp: 0, Value: 1}, {Timestamp: 180, Value: 0.5}, {Timestamp: 60, Value: 3}}
+	for selection, expected := range map[string]common.Sample{
+		SampleSelectionFirst: {Timestamp: 0, Value: 1},
+		SampleSelectionLast:  {Timestamp: 180, Value: 0.5},
+		SampleSelectionMax:   {Timestamp: 60, Value: 3},
+	} {
+		sample, selected := selectSample(samples, selection)
+		assert.True(t, selected)
+		assert.Equal(t, expected, sample, selection)
+	}
+}
+
+func TestEstimateResourcesSampleSelection(t *testing.T) {
+	cpuSeries := []*common.TimeSeries{
+		{Samples: []common.Sample{{Timestamp: 0, Value: 0.5}, {Timestamp: 60, Value: 1.5}, {Timestamp: 120, Value: 0.25}}},
+		{Samples: []common.Sample{{Timestamp: 120, Value: 0.75}, {Timestamp: 0, Value: 0.25}, {Timestamp: 60, Value: 1}}},
+	}
+	memorySeries := []*common.TimeSeries{
+		{Samples: []common.Sample{{Timestamp: 0, Value: 256 * 1024 * 1024}, {Timestamp: 60, Value: 512 * 1024 * 1024}}},
+		{Samples: []common.Sample{{Timestamp: 60, Value: 128 * 1024 * 1024}, {Timestamp: 0, Value: 1024 * 1024 * 1024}}},
+	}
+	for _, test := range []struct {
+		selection      string
+		expectedCpu    string
+		expectedMemory string
+	}{
+		// the largest series by the selected sample
+		{SampleSelectionFirst, "500m", "1Gi"},
+		{SampleSelectionLast, "750m", "512Mi"},
+		{SampleSelectionMax, "1500m", "1Gi"},
+	} {
+		t.Run(test.selection, func(t *testing.T) {
+			e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+				"cpu":    cpuSeries,
+				"memory": memorySeries,
+			})
+			resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"sample-selection": test.selection}, "nginx", &corev1.ResourceRequirements{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedCpu, resources.Cpu().String())
+			assert.Equal(t, test.expectedMemory, resources.Memory().String())
+		})
+	}
+}
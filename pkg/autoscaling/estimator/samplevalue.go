@@ -0,0 +1,57 @@
+package estimator
+
+import (
+	"fmt"
+	"math"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// maxByteSampleValue is the largest byte value converted to a quantity, it is well below the int64 limit the
+	// float64 rounds up to
+	maxByteSampleValue = float64(1 << 62)
+	// maxMilliSampleValue is the largest value converted to a milli quantity
+	maxMilliSampleValue = maxByteSampleValue / 1000
+)
+
+// getMaxSampleValue returns the '<prefix>-max-sample-value' the predicted value of the resource saturates at, it is
+// the largest convertible value by default, and never above it
+func getMaxSampleValue(config map[string]string, prefix string, resourceName corev1.ResourceName) (float64, error) {
+	limit := maxMilliSampleValue
+	if isByteResource(resourceName) {
+		limit = maxByteSampleValue
+	}
+	maxStr, exists := config[prefix+"-max-sample-value"]
+	if !exists {
+		return limit, nil
+	}
+	max, err := resource.ParseQuantity(maxStr)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s-max-sample-value failed: %v", prefix, err)
+	}
+	if max.Sign() <= 0 {
+		return 0, fmt.Errorf("%s-max-sample-value must be positive, got %s", prefix, maxStr)
+	}
+	return math.Min(max.AsApproximateFloat64(), limit), nil
+}
+
+// validSampleValue rejects the NaN and infinite predicted values with ErrInvalidSample, and saturates the values above
+// the max rather than overflowing the quantity. The negative values are left to the negative value floor.
+func validSampleValue(config map[string]string, prefix string, resourceName corev1.ResourceName, value float64, queryKey string, graph *ExplanationGraph) (float64, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("%w %v of %s for queryExpr: %s", ErrInvalidSample, value, resourceName, queryKey)
+	}
+	max, err := getMaxSampleValue(config, prefix, resourceName)
+	if err != nil {
+		return 0, err
+	}
+	if value <= max {
+		return value, nil
+	}
+	klog.Warningf("Predicted %s %g saturates at %g, queryExpr: %s", resourceName, value, max, queryKey)
+	graph.addStep(resourceName, ExplanationNodeTransform, "saturate", max, fmt.Sprintf("saturate the predicted value %g", value))
+	return max, nil
+}
@@ -0,0 +1,65 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestEstimateResourcesInvalidSample(t *testing.T) {
+	for name, series := range map[string]map[string][]*common.TimeSeries{
+		"nan cpu":           {"cpu": newSeries(math.NaN()), "memory": newSeries(mebibyte)},
+		"infinite memory":   {"cpu": newSeries(1), "memory": newSeries(math.Inf(1))},
+		"negative infinity": {"cpu": newSeries(math.Inf(-1)), "memory": newSeries(mebibyte)},
+	} {
+		e, _ := newTestEstimator(series)
+		_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+		assert.True(t, errors.Is(err, ErrInvalidSample), name)
+	}
+
+	// the negative values are clamped to the floor
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(-1),
+		"memory": newSeries(-mebibyte),
+	})
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0", resources.Cpu().String())
+	assert.Equal(t, "0", resources.Memory().String())
+}
+
+func TestEstimateResourcesSaturatedSample(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(1e30),
+		"memory": newSeries(1e30),
+	})
+
+	// saturated at the largest convertible value rather than overflowing
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(maxMilliSampleValue*1000), resources.Cpu().MilliValue())
+	assert.Equal(t, "4Ei", resources.Memory().String())
+
+	config := map[string]string{"cpu-max-sample-value": "64", "mem-max-sample-value": "256Gi"}
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "64", estimation.Resources.Cpu().String())
+	assert.Equal(t, "256Gi", estimation.Resources.Memory().String())
+
+	// the values below the max are kept
+	config["cpu-max-sample-value"] = "1e40"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(maxMilliSampleValue*1000), resources.Cpu().MilliValue())
+
+	for _, value := range []string{"0", "-1", "many"} {
+		_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"mem-max-sample-value": value}, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, value)
+	}
+}
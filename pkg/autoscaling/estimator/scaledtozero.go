@@ -0,0 +1,91 @@
+package estimator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
+)
+
+const (
+	replicasMetricName = "replicas"
+)
+
+// scaledToZeroConfig excludes the samples of the periods the workload is scaled to zero, so the long zero usage
+// stretches of the serverless-style workloads don't drag down the percentile
+type scaledToZeroConfig struct {
+	// replicasQuery is the PromQL of the replicas of the workload, the kube-state-metrics replicas by default
+	replicasQuery string
+	// namer is bound to the target of the evpa before the estimation
+	namer metricnaming.MetricNamer
+}
+
+// getScaledToZeroConfig returns nil if 'exclude-scaled-to-zero' is not set
+func getScaledToZeroConfig(config map[string]string) (*scaledToZeroConfig, error) {
+	value, exists := config["exclude-scaled-to-zero"]
+	if !exists {
+		return nil, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("parse exclude-scaled-to-zero failed: %v", err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+	return &scaledToZeroConfig{replicasQuery: config["replicas-query"]}, nil
+}
+
+// bind builds the namer of the replicas of the evpa target
+func (c *scaledToZeroConfig) bind(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, caller string) {
+	c.namer = newReplicasMetricNamer(evpa, caller, c.replicasQuery)
+}
+
+// newReplicasMetricNamer builds the namer of the replicas of the evpa target, the kube-state-metrics replicas if
+// the query is empty
+func newReplicasMetricNamer(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, caller string, queryExpr string) metricnaming.MetricNamer {
+	if queryExpr == "" {
+		kind := strings.ToLower(evpa.Spec.TargetRef.Kind)
+		queryExpr = fmt.Sprintf("kube_%s_status_replicas{namespace=\"%s\",%s=\"%s\"}", kind, evpa.Namespace, kind, evpa.Spec.TargetRef.Name)
+	}
+	return &metricnaming.GeneralMetricNamer{
+		CallerName: caller,
+		Metric: &metricquery.Metric{
+			Type:       metricquery.PromQLMetricType,
+			MetricName: replicasMetricName,
+			Prom: &metricquery.PromNamerInfo{
+				QueryExpr: queryExpr,
+				Namespace: evpa.Namespace,
+			},
+		},
+	}
+}
+
+// zeroReplicasTimestamps returns the timestamps the replicas are zero
+func zeroReplicasTimestamps(tsList []*common.TimeSeries) map[int64]struct{} {
+	result := map[int64]struct{}{}
+	for _, ts := range tsList {
+		for _, sample := range ts.Samples {
+			if sample.Value <= 0 {
+				result[sample.Timestamp] = struct{}{}
+			}
+		}
+	}
+	return result
+}
+
+// excludeTimestamps drops the samples at the timestamps
+func excludeTimestamps(samples []common.Sample, timestamps map[int64]struct{}) []common.Sample {
+	var result []common.Sample
+	for _, sample := range samples {
+		if _, exists := timestamps[sample.Timestamp]; !exists {
+			result = append(result, sample)
+		}
+	}
+	return result
+}
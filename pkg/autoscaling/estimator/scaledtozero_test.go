@@ -0,0 +1,84 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+)
+
+func TestExcludeScaledToZero(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	e.Clock = clock.NewFakeClock(now)
+	// scaled to zero in the first 18 hours of the day
+	active := func(t time.Time) bool {
+		return t.After(now.Add(-6 * time.Hour))
+	}
+	history := &stepHistory{usage: map[string]func(time.Time) float64{
+		"cpu": func(t time.Time) float64 {
+			if active(t) {
+				return 0.8
+			}
+			return 0
+		},
+		"memory": func(t time.Time) float64 {
+			if active(t) {
+				return 512 * 1024 * 1024
+			}
+			return 0
+		},
+		"replicas": func(t time.Time) float64 {
+			if active(t) {
+				return 2
+			}
+			return 0
+		},
+	}}
+	e.History = history
+
+	config := map[string]string{
+		"exclude-scaled-to-zero":      "true",
+		"cpu-model-history-length":    "24h",
+		"mem-model-history-length":    "24h",
+		"cpu-request-percentile":      "0.5",
+		"cpu-request-margin-fraction": "0",
+		"mem-request-percentile":      "0.5",
+		"mem-request-margin-fraction": "0",
+	}
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "800m", resources.Cpu().String())
+	assert.Equal(t, "512Mi", resources.Memory().String())
+
+	// the zero usage stretch drags down the median
+	config["exclude-scaled-to-zero"] = "false"
+	config["cpu-winsorize-bounds"] = "0,1"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0", resources.Cpu().String())
+}
+
+func TestScaledToZeroReplicasQuery(t *testing.T) {
+	cfg, err := getScaledToZeroConfig(map[string]string{"exclude-scaled-to-zero": "true"})
+	assert.NoError(t, err)
+	cfg.bind(newTestEVPA("nginx"), "caller")
+	assert.Equal(t, `kube_deployment_status_replicas{namespace="default",deployment="nginx"}`, cfg.namer.(*metricnaming.GeneralMetricNamer).Metric.Prom.QueryExpr)
+
+	cfg, err = getScaledToZeroConfig(map[string]string{"exclude-scaled-to-zero": "true", "replicas-query": "sum(up)"})
+	assert.NoError(t, err)
+	cfg.bind(newTestEVPA("nginx"), "caller")
+	assert.Equal(t, "sum(up)", cfg.namer.(*metricnaming.GeneralMetricNamer).Metric.Prom.QueryExpr)
+
+	_, err = getScaledToZeroConfig(map[string]string{"exclude-scaled-to-zero": "no"})
+	assert.Error(t, err)
+}
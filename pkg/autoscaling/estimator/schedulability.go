@@ -0,0 +1,121 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ReasonUnschedulable means the pod with the recommended resources would not fit any node
+	ReasonUnschedulable = "Unschedulable"
+)
+
+// CheckSchedulable simulates whether a pod with the spec would still schedule on any node. It is a lightweight
+// check of the scheduler predicates: node schedulable, node selector, taints and the free allocatable resources.
+// Affinity, topology spread and preemption are not considered. The message explains why it does not fit.
+func CheckSchedulable(ctx context.Context, kubeClient client.Client, podSpec *corev1.PodSpec) (bool, string, error) {
+	nodeList := &corev1.NodeList{}
+	if err := kubeClient.List(ctx, nodeList); err != nil {
+		return false, "", err
+	}
+	podList := &corev1.PodList{}
+	if err := kubeClient.List(ctx, podList); err != nil {
+		return false, "", err
+	}
+
+	requested := map[string]corev1.ResourceList{}
+	for _, pod := range podList.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, exists := requested[pod.Spec.NodeName]; !exists {
+			requested[pod.Spec.NodeName] = corev1.ResourceList{}
+		}
+		addResourceList(requested[pod.Spec.NodeName], podRequests(&pod.Spec))
+	}
+
+	podRequest := podRequests(podSpec)
+	var reasons []string
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		fits, reason := nodeFits(node, podSpec, podRequest, requested[node.Name])
+		if fits {
+			return true, "", nil
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %s", node.Name, reason))
+	}
+
+	return false, fmt.Sprintf("pod with requests %v does not fit any node %v", podRequest, reasons), nil
+}
+
+func nodeFits(node *corev1.Node, podSpec *corev1.PodSpec, podRequest corev1.ResourceList, requested corev1.ResourceList) (bool, string) {
+	if node.Spec.Unschedulable {
+		return false, "node is unschedulable"
+	}
+
+	if !labels.SelectorFromSet(podSpec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return false, "node selector mismatch"
+	}
+
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		if !toleratesTaint(podSpec.Tolerations, taint) {
+			return false, fmt.Sprintf("untolerated taint %s", taint.ToString())
+		}
+	}
+
+	for resourceName, quantity := range podRequest {
+		free := node.Status.Allocatable[resourceName].DeepCopy()
+		if used, exists := requested[resourceName]; exists {
+			free.Sub(used)
+		}
+		if quantity.Cmp(free) > 0 {
+			return false, fmt.Sprintf("insufficient %s", resourceName)
+		}
+	}
+
+	return true, ""
+}
+
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// podRequests returns the effective requests of the pod, the greater of the sum of containers and any init container
+func podRequests(podSpec *corev1.PodSpec) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, container := range podSpec.Containers {
+		addResourceList(result, container.Resources.Requests)
+	}
+	for _, container := range podSpec.InitContainers {
+		for resourceName, quantity := range container.Resources.Requests {
+			if current, exists := result[resourceName]; !exists || quantity.Cmp(current) > 0 {
+				result[resourceName] = quantity.DeepCopy()
+			}
+		}
+	}
+	return result
+}
+
+func addResourceList(list corev1.ResourceList, newList corev1.ResourceList) {
+	for resourceName, quantity := range newList {
+		if current, exists := list[resourceName]; exists {
+			current.Add(quantity)
+			list[resourceName] = current
+		} else {
+			list[resourceName] = quantity.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestNode(name string, cpu string, memory string, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+func newTestPodSpec(cpu string, memory string) *corev1.PodSpec {
+	return &corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name: "nginx",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		}},
+	}
+}
+
+func TestCheckSchedulable(t *testing.T) {
+	tainted := newTestNode("node-tainted", "16", "64Gi", corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule})
+	existing := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "default"},
+		Spec:       *newTestPodSpec("2", "4Gi"),
+	}
+	existing.Spec.NodeName = "node-1"
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		newTestNode("node-1", "4", "8Gi"),
+		tainted,
+		existing,
+	).Build()
+
+	// fits the free resources of node-1
+	schedulable, _, err := CheckSchedulable(context.TODO(), kubeClient, newTestPodSpec("2", "4Gi"))
+	assert.NoError(t, err)
+	assert.True(t, schedulable)
+
+	// node-1 is occupied by the existing pod and the pod doesn't tolerate the large tainted node
+	schedulable, msg, err := CheckSchedulable(context.TODO(), kubeClient, newTestPodSpec("3", "4Gi"))
+	assert.NoError(t, err)
+	assert.False(t, schedulable)
+	assert.Contains(t, msg, "insufficient cpu")
+	assert.Contains(t, msg, "untolerated taint")
+
+	podSpec := newTestPodSpec("3", "4Gi")
+	podSpec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+	schedulable, _, err = CheckSchedulable(context.TODO(), kubeClient, podSpec)
+	assert.NoError(t, err)
+	assert.True(t, schedulable)
+
+	podSpec.NodeSelector = map[string]string{"zone": "a"}
+	schedulable, msg, err = CheckSchedulable(context.TODO(), kubeClient, podSpec)
+	assert.NoError(t, err)
+	assert.False(t, schedulable)
+	assert.Contains(t, msg, "node selector mismatch")
+}
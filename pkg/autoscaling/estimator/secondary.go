@@ -0,0 +1,64 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/gocrane/crane/pkg/metricnaming"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+)
+
+const (
+	primaryPredictor   = "primary"
+	secondaryPredictor = "secondary"
+	cachedPredictor    = "cache"
+)
+
+// registerSecondary registers the queries with the secondary predictor, so its models warm up along with the primary
+// ones. The secondary is a best effort backup, the failures are logged rather than failing the estimation.
+func (e *PercentileResourceEstimator) registerSecondary(namers map[corev1.ResourceName]metricnaming.MetricNamer, caller string, configs map[corev1.ResourceName]*predictionconfig.Config) map[corev1.ResourceName]metricnaming.MetricNamer {
+	if e.Secondary == nil {
+		return nil
+	}
+	registered := map[corev1.ResourceName]metricnaming.MetricNamer{}
+	for resourceName, namer := range namers {
+		cfg := configs[resourceName]
+		if cfg == nil {
+			continue
+		}
+		if err := e.Secondary.WithQuery(namer, caller, *cfg); err != nil {
+			klog.ErrorS(err, "Failed to register the query with the secondary predictor.", "queryExpr", namer.BuildUniqueKey())
+			continue
+		}
+		registered[resourceName] = namer
+	}
+	return registered
+}
+
+// servedBySecondary tells whether the query failed by the primary predictor is served by the secondary one, the
+// primary may be down for a while or its model is not ready. The missing samples are not retried, the secondary has
+// the same data.
+func (e *PercentileResourceEstimator) servedBySecondary(ctx context.Context, namer metricnaming.MetricNamer, err error) bool {
+	if e.Secondary == nil || err == nil || ctx.Err() != nil || errors.Is(err, ErrNoSamples) {
+		return false
+	}
+	return errors.Is(err, ErrModelNotReady) || isTransientQueryError(ctx, e.Predictor, namer, err)
+}
+
+// querySecondary queries the secondary predictor, the error of the primary is kept if the secondary fails too or has
+// no samples
+func (e *PercentileResourceEstimator) querySecondary(ctx context.Context, namer metricnaming.MetricNamer, primaryErr error, retry queryRetry) predictedValues {
+	tsList, err := retry.queryRealtimePredictedValues(ctx, e.Secondary, namer)
+	if err != nil {
+		return predictedValues{err: fmt.Errorf("%w, the secondary predictor %s failed too: %v", primaryErr, e.Secondary.Name(), err), predictor: secondaryPredictor}
+	}
+	if len(tsList) == 0 {
+		return predictedValues{err: fmt.Errorf("%w, the secondary predictor %s has no samples", primaryErr, e.Secondary.Name()), predictor: secondaryPredictor}
+	}
+	klog.V(estimationLogLevel).InfoS("Served the query by the secondary predictor.", "queryExpr", namer.BuildUniqueKey(), "predictor", e.Secondary.Name(), "primaryErr", primaryErr)
+	return predictedValues{tsList: tsList, predictor: secondaryPredictor}
+}
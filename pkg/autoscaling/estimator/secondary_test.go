@@ -0,0 +1,85 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/prediction"
+)
+
+func TestSecondaryPredictor(t *testing.T) {
+	config := map[string]string{"query-max-retries": "0"}
+	e, primary := newTestEstimator(map[string][]*common.TimeSeries{
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	secondary := newFakePredictor(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.5),
+		"memory": newSeries(512 * 1024 * 1024),
+	})
+	e.Secondary = secondary
+	evpa := newTestEVPA("nginx")
+
+	// the primary is down for the cpu, the secondary serves it
+	primary.errs["cpu"] = fmt.Errorf("connection refused")
+	resources, err := e.GetResourceEstimation(context.TODO(), evpa, config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+	assert.Equal(t, "256Mi", resources.Memory().String())
+	assert.Equal(t, 1, secondary.called["nginx/cpu"])
+	assert.Zero(t, secondary.called["nginx/memory"])
+	// the secondary models warm up along with the primary ones
+	assert.Len(t, secondary.registered, 2)
+
+	// the missing samples are not served by the secondary
+	_, predictor := newTestEstimator(map[string][]*common.TimeSeries{})
+	e.Predictor = predictor
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{"query-max-retries": "0", "warmup-duration": "0"}, "nginx", &corev1.ResourceRequirements{})
+	assert.ErrorIs(t, err, ErrNoSamples)
+	assert.Equal(t, 1, secondary.called["nginx/cpu"])
+
+	// the primary is not ready
+	e.Predictor = primary
+	primary.statuses["cpu"] = prediction.StatusInitializing
+	primary.errs["cpu"] = fmt.Errorf("wrapped: %w", ErrModelNotReady)
+	resources, err = e.GetResourceEstimation(context.TODO(), evpa, config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+
+	// both fail, the error of the primary is kept
+	e.Secondary = newFakePredictor(map[string][]*common.TimeSeries{})
+	e.Secondary.(*fakePredictor).errs["cpu"] = fmt.Errorf("secondary down")
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	primary.errs["memory"] = fmt.Errorf("connection refused")
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, config, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrModelNotReady)
+	assert.Contains(t, err.Error(), "secondary down")
+	assert.Contains(t, err.Error(), "the secondary predictor fake has no samples")
+}
+
+func TestDeleteEstimationSecondaryPredictor(t *testing.T) {
+	e, primary := newTestEstimator(map[string][]*common.TimeSeries{})
+	secondary := newFakePredictor(map[string][]*common.TimeSeries{})
+	e.Secondary = secondary
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), newTestEVPA("nginx")))
+	assert.Len(t, primary.deleted, 3)
+	assert.Equal(t, primary.deleted, secondary.deleted)
+
+	// the shared queries of the primary are released by the registry, the secondary ones are deleted
+	e, primary = newTestEstimator(map[string][]*common.TimeSeries{})
+	e.Registry = NewQueryRegistry(primary, "")
+	secondary = newFakePredictor(map[string][]*common.TimeSeries{})
+	secondary.deleteErrs["nginx/cpu"] = fmt.Errorf("delete failed")
+	e.Secondary = secondary
+	err := e.DeleteEstimation(context.TODO(), newTestEVPA("nginx"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "of the secondary predictor failed")
+	assert.Empty(t, primary.deleted)
+	assert.Len(t, secondary.deleted, 3)
+}
@@ -0,0 +1,62 @@
+package estimator
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/utils/target"
+)
+
+type selectorCacheKey struct{}
+
+// selectorCache memoizes the pod selectors of the evpa target workloads by the evpa uid
+type selectorCache struct {
+	mu        sync.Mutex
+	selectors map[types.UID]fetchedSelector
+}
+
+type fetchedSelector struct {
+	selector labels.Selector
+	err      error
+}
+
+// WithSelectorCache returns a context memoizing the pod selectors of the evpa target workloads, so an estimation
+// pass, such as the containers of a multi-container workload, fetches the workload once. The context is returned as
+// is if it already has a selector cache.
+func WithSelectorCache(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(selectorCacheKey{}).(*selectorCache); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, selectorCacheKey{}, &selectorCache{selectors: map[types.UID]fetchedSelector{}})
+}
+
+// fetchSelector returns the pod selector of the evpa target workload, it is memoized if the context has a selector
+// cache. The failure is memoized as well, the next pass fetches again.
+func fetchSelector(ctx context.Context, fetcher target.SelectorFetcher, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) (labels.Selector, error) {
+	fetch := func() (labels.Selector, error) {
+		return fetcher.Fetch(&corev1.ObjectReference{
+			APIVersion: evpa.Spec.TargetRef.APIVersion,
+			Kind:       evpa.Spec.TargetRef.Kind,
+			Name:       evpa.Spec.TargetRef.Name,
+			Namespace:  evpa.Namespace,
+		})
+	}
+	cache, ok := ctx.Value(selectorCacheKey{}).(*selectorCache)
+	if !ok {
+		return fetch()
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if fetched, exists := cache.selectors[evpa.UID]; exists {
+		return fetched.selector, fetched.err
+	}
+	selector, err := fetch()
+	cache.selectors[evpa.UID] = fetchedSelector{selector: selector, err: err}
+	return selector, err
+}
@@ -0,0 +1,73 @@
+package estimator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/utils/target"
+)
+
+// countingFetcher counts the fetches of the wrapped fetcher
+type countingFetcher struct {
+	target.SelectorFetcher
+	fetched int
+}
+
+func (f *countingFetcher) Fetch(targetRef *corev1.ObjectReference) (labels.Selector, error) {
+	f.fetched++
+	return f.SelectorFetcher.Fetch(targetRef)
+}
+
+func TestEstimateResourcesWorkloadSelector(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}},
+		},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment).Build()
+	fetcher := &countingFetcher{SelectorFetcher: target.NewSelectorFetcher(scheme.Scheme, nil, nil, kubeClient)}
+
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	e.TargetFetcher = fetcher
+
+	_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	// the selector of the deployment is in both the cpu and the memory queries
+	var keys []string
+	for key := range predictor.registered {
+		assert.True(t, strings.HasSuffix(key, "_app=nginx"), key)
+		keys = append(keys, key)
+	}
+	assert.Len(t, keys, 2)
+	assert.Equal(t, 1, fetcher.fetched)
+
+	// the containers share the selector within a pass
+	fetcher.fetched = 0
+	ctx := WithSelectorCache(context.TODO())
+	evpa := newTestEVPA("nginx", "sidecar")
+	for _, containerName := range []string{"nginx", "sidecar"} {
+		_, err := e.GetResourceEstimation(ctx, evpa, map[string]string{}, containerName, &corev1.ResourceRequirements{})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, fetcher.fetched)
+
+	// the next pass fetches again
+	_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fetcher.fetched)
+}
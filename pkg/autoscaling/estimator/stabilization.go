@@ -0,0 +1,122 @@
+package estimator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// ReasonStabilized means the recommendation is held at the previously applied one because the estimate changed
+	// within the stabilization threshold
+	ReasonStabilized = "Stabilized"
+
+	// maxStabilizedEntries bounds the applied recommendations remembered by the stabilizer
+	maxStabilizedEntries = 10000
+)
+
+// getStabilizationThresholds returns the fractions of 'cpu-stabilization-threshold' and 'mem-stabilization-threshold',
+// the resources without the config are not stabilized
+func getStabilizationThresholds(config map[string]string) (map[corev1.ResourceName]float64, error) {
+	thresholds := map[corev1.ResourceName]float64{}
+	for resourceName, prefix := range map[corev1.ResourceName]string{corev1.ResourceCPU: "cpu", corev1.ResourceMemory: "mem"} {
+		thresholdStr, exists := config[prefix+"-stabilization-threshold"]
+		if !exists {
+			continue
+		}
+		threshold, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s-stabilization-threshold failed: %v", prefix, err)
+		}
+		if math.IsNaN(threshold) || math.IsInf(threshold, 0) || threshold < 0 {
+			return nil, fmt.Errorf("%s-stabilization-threshold must be finite and not negative, got %s", prefix, thresholdStr)
+		}
+		if threshold > 0 {
+			thresholds[resourceName] = threshold
+		}
+	}
+	return thresholds, nil
+}
+
+type appliedRecommendation struct {
+	quantity resource.Quantity
+	updated  time.Time
+}
+
+// stabilizer remembers the applied recommendation by evpa, container and resource. The zero value is ready to use.
+type stabilizer struct {
+	mu      sync.Mutex
+	applied map[string]appliedRecommendation
+}
+
+// stabilize returns the applied recommendation of the key if the estimate differs from it by no more than the
+// threshold fraction, otherwise the estimate is applied and returned
+func (s *stabilizer) stabilize(key string, estimate resource.Quantity, threshold float64, now time.Time) (resource.Quantity, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.applied == nil {
+		s.applied = map[string]appliedRecommendation{}
+	}
+	if applied, exists := s.applied[key]; exists && applied.quantity.Sign() > 0 {
+		delta := math.Abs(estimate.AsApproximateFloat64()-applied.quantity.AsApproximateFloat64()) / applied.quantity.AsApproximateFloat64()
+		if delta <= threshold {
+			s.applied[key] = appliedRecommendation{quantity: applied.quantity, updated: now}
+			return applied.quantity.DeepCopy(), true
+		}
+	}
+	if _, exists := s.applied[key]; !exists && len(s.applied) >= maxStabilizedEntries {
+		s.evictOldest()
+	}
+	s.applied[key] = appliedRecommendation{quantity: estimate.DeepCopy(), updated: now}
+	return estimate, false
+}
+
+// evictOldest deletes the least recently updated recommendation
+func (s *stabilizer) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, applied := range s.applied {
+		if oldestKey == "" || applied.updated.Before(oldest) {
+			oldestKey, oldest = key, applied.updated
+		}
+	}
+	delete(s.applied, oldestKey)
+}
+
+// forget deletes the applied recommendations of the keys with the prefix
+func (s *stabilizer) forget(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.applied {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.applied, key)
+		}
+	}
+}
+
+// stabilize holds the resources changed within the thresholds at the previously applied ones, it returns the names
+// of the held resources
+func (r *ResourceEstimation) stabilize(s *stabilizer, keyPrefix string, thresholds map[corev1.ResourceName]float64, now time.Time) []corev1.ResourceName {
+	var held []corev1.ResourceName
+	for resourceName, threshold := range thresholds {
+		quantity, exists := r.Resources[resourceName]
+		if !exists {
+			continue
+		}
+		stabilized, isHeld := s.stabilize(keyPrefix+"/"+resourceName.String(), quantity, threshold, now)
+		if isHeld {
+			r.Resources[resourceName] = stabilized
+			held = append(held, resourceName)
+		}
+	}
+	if len(held) > 0 && r.Reason == "" {
+		r.Reason = ReasonStabilized
+	}
+	return held
+}
@@ -0,0 +1,84 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestEstimateResourcesStabilization(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.238),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	config := map[string]string{"cpu-stabilization-threshold": "0.05"}
+	evpa := newTestEVPA("nginx")
+
+	estimate := func() *ResourceEstimation {
+		estimation, err := e.EstimateResources(context.TODO(), evpa, config, "nginx", &corev1.ResourceRequirements{})
+		assert.NoError(t, err)
+		return estimation
+	}
+	estimation := estimate()
+	assert.Equal(t, "238m", estimation.Resources.Cpu().String())
+	assert.Empty(t, estimation.Reason)
+
+	// the small oscillations are held at the applied recommendation
+	for _, cpu := range []float64{0.241, 0.236, 0.249, 0.227} {
+		predictor.series["cpu"] = newSeries(cpu)
+		estimation = estimate()
+		assert.Equal(t, "238m", estimation.Resources.Cpu().String(), cpu)
+		assert.Equal(t, ReasonStabilized, estimation.Reason)
+		assert.Equal(t, fmt.Sprintf("%dm", int64(cpu*1000)), estimation.Computed.Cpu().String())
+	}
+
+	// the threshold is crossed, then the new value is applied and held
+	predictor.series["cpu"] = newSeries(0.26)
+	assert.Equal(t, "260m", estimate().Resources.Cpu().String())
+	predictor.series["cpu"] = newSeries(0.255)
+	assert.Equal(t, "260m", estimate().Resources.Cpu().String())
+
+	// the memory is not stabilized without its threshold
+	predictor.series["memory"] = newSeries(257 * 1024 * 1024)
+	assert.Equal(t, "257Mi", estimate().Resources.Memory().String())
+
+	// the applied recommendations are forgotten with the evpa
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), evpa))
+	assert.Equal(t, "255m", estimate().Resources.Cpu().String())
+
+	for _, invalid := range []map[string]string{{"cpu-stabilization-threshold": "-0.1"}, {"mem-stabilization-threshold": "small"}} {
+		_, err := e.EstimateResources(context.TODO(), evpa, invalid, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestStabilizerBounded(t *testing.T) {
+	var s stabilizer
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < maxStabilizedEntries+10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.stabilize(fmt.Sprintf("key-%d", i), resource.MustParse("100m"), 0.1, now.Add(time.Duration(i)*time.Second))
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, s.applied, maxStabilizedEntries)
+
+	quantity, held := s.stabilize("key", resource.MustParse("100m"), 0.1, now)
+	assert.False(t, held)
+	assert.Equal(t, "100m", quantity.String())
+	quantity, held = s.stabilize("key", resource.MustParse("105m"), 0.1, now)
+	assert.True(t, held)
+	assert.Equal(t, "100m", quantity.String())
+	assert.Len(t, s.applied, maxStabilizedEntries)
+}
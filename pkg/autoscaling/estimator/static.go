@@ -0,0 +1,34 @@
+package estimator
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// ReasonStatic means the recommendation is pinned by the static config instead of estimated
+	ReasonStatic = "Static"
+)
+
+// getStaticResources returns the resources pinned by 'static-cpu' and 'static-mem', for the containers the
+// estimates are known to be bad
+func getStaticResources(config map[string]string) (corev1.ResourceList, error) {
+	result := corev1.ResourceList{}
+	for resourceName, key := range map[corev1.ResourceName]string{corev1.ResourceCPU: "static-cpu", corev1.ResourceMemory: "static-mem"} {
+		value, exists := config[key]
+		if !exists {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s failed: %v", key, err)
+		}
+		if quantity.Sign() <= 0 {
+			return nil, fmt.Errorf("%s must be positive, got %s", key, value)
+		}
+		result[resourceName] = quantity
+	}
+	return result, nil
+}
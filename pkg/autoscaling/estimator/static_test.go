@@ -0,0 +1,56 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestStaticResources(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.25),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	config := map[string]string{"static-cpu": "2", "static-mem": "3Gi"}
+
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonStatic, estimation.Reason)
+	assert.Equal(t, "2", estimation.Resources.Cpu().String())
+	assert.Equal(t, "3Gi", estimation.Resources.Memory().String())
+	// no query is issued for the container
+	assert.Empty(t, predictor.queries)
+
+	// the clamps are still honored
+	config["no-downscale"] = "true"
+	currRes := &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}}
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonDownscaleLocked, estimation.Reason)
+	assert.Equal(t, "4", estimation.Resources.Cpu().String())
+
+	// only cpu is pinned, memory is estimated
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"static-cpu": "2"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", estimation.Resources.Cpu().String())
+	assert.Equal(t, "256Mi", estimation.Resources.Memory().String())
+
+	// the static resources are clamped to the allowed range of the container policy
+	evpa := newTestEVPA("nginx")
+	evpa.Spec.ResourcePolicy.ContainerPolicies[0].MinAllowed = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}
+	evpa.Spec.ResourcePolicy.ContainerPolicies[0].MaxAllowed = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+	estimation, err = e.EstimateResources(context.TODO(), evpa, map[string]string{"static-cpu": "2", "static-mem": "3Gi"}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", estimation.Resources.Cpu().String())
+	assert.Equal(t, "4Gi", estimation.Resources.Memory().String())
+
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"static-cpu": "two"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"static-mem": "0"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
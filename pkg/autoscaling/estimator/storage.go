@@ -0,0 +1,83 @@
+package estimator
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+	predictionapi "github.com/gocrane/api/prediction/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/metricnaming"
+	predictionconfig "github.com/gocrane/crane/pkg/prediction/config"
+)
+
+const ephemeralStoragePrefix = "ephemeral-storage"
+
+// getEphemeralStorageConfig returns nil if no 'ephemeral-storage-' key is set, the ephemeral storage is not
+// estimated by default
+func getEphemeralStorageConfig(props map[string]string) (*predictionconfig.Config, error) {
+	enabled := false
+	for key := range props {
+		if strings.HasPrefix(key, ephemeralStoragePrefix+"-") {
+			enabled = true
+			break
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	return getPrefixedConfig(props, ephemeralStoragePrefix, "48h", defaultEphemeralStorageHistogram)
+}
+
+// getPrefixedConfig returns the percentile config of the '<prefix>-' keys, the unset ones fall back to the defaults
+func getPrefixedConfig(props map[string]string, prefix string, defaultHistoryLength string, defaultHistogram predictionapi.HistogramConfig) (*predictionconfig.Config, error) {
+	sampleInterval, exists := props[prefix+"-sample-interval"]
+	if !exists {
+		sampleInterval = "1m"
+	}
+	percentile, err := getPercentile(props, prefix+"-request-percentile", "0.99")
+	if err != nil {
+		return nil, err
+	}
+	marginFraction, err := getMarginFraction(props, prefix+"-request-margin-fraction", "0.15")
+	if err != nil {
+		return nil, err
+	}
+
+	initMode, err := getModelInitMode(props, prefix+"-model-init-mode")
+	if err != nil {
+		return nil, err
+	}
+	aggregated, err := getAggregated(props, prefix+"-aggregated")
+	if err != nil {
+		return nil, err
+	}
+	histogram, err := getHistogramConfig(props, prefix, defaultHistogram)
+	if err != nil {
+		return nil, err
+	}
+
+	historyLength, exists := props[prefix+"-model-history-length"]
+	if !exists {
+		historyLength = defaultHistoryLength
+	}
+
+	return &predictionconfig.Config{
+		InitMode: &initMode,
+		Percentile: &predictionapi.Percentile{
+			Aggregated:     aggregated,
+			HistoryLength:  historyLength,
+			SampleInterval: sampleInterval,
+			MarginFraction: marginFraction,
+			Percentile:     percentile,
+			Histogram:      histogram,
+		},
+	}, nil
+}
+
+func newEphemeralStorageMetricNamer(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, caller string, containerName string, selector labels.Selector) *metricnaming.GeneralMetricNamer {
+	return newContainerMetricNamer(evpa, caller, containerName, corev1.ResourceEphemeralStorage, selector)
+}
@@ -0,0 +1,61 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestEstimateResourcesEphemeralStorage(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":               newSeries(0.25),
+		"memory":            newSeries(256 * 1024 * 1024),
+		"ephemeral-storage": newSeries(2 * 1024 * 1024 * 1024),
+	})
+
+	// skipped if not configured
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.NotContains(t, estimation.Resources, corev1.ResourceEphemeralStorage)
+	assert.NotContains(t, predictor.queries, "nginx/ephemeral-storage")
+
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{
+		"ephemeral-storage-request-percentile": "0.95",
+	}, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	storage := estimation.Resources[corev1.ResourceEphemeralStorage]
+	assert.True(t, resource.MustParse("2Gi").Equal(storage), storage.String())
+	assert.Equal(t, resource.BinarySI, storage.Format)
+	assert.Equal(t, "0.95", predictor.queries["nginx/ephemeral-storage"].Percentile.Percentile)
+	assert.Contains(t, estimation.Resources, corev1.ResourceCPU)
+	assert.Contains(t, estimation.Resources, corev1.ResourceMemory)
+}
+
+func TestGetEphemeralStorageConfig(t *testing.T) {
+	cfg, err := getEphemeralStorageConfig(map[string]string{"cpu-request-percentile": "0.9"})
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	cfg, err = getEphemeralStorageConfig(map[string]string{
+		"ephemeral-storage-sample-interval":         "5m",
+		"ephemeral-storage-request-margin-fraction": "0.3",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "5m", cfg.Percentile.SampleInterval)
+	assert.Equal(t, "0.3", cfg.Percentile.MarginFraction)
+	assert.Equal(t, "0.99", cfg.Percentile.Percentile)
+	assert.Equal(t, "48h", cfg.Percentile.HistoryLength)
+	assert.True(t, cfg.Percentile.Aggregated)
+
+	cfg, err = getEphemeralStorageConfig(map[string]string{"ephemeral-storage-aggregated": "false"})
+	assert.NoError(t, err)
+	assert.False(t, cfg.Percentile.Aggregated)
+
+	_, err = getEphemeralStorageConfig(map[string]string{"ephemeral-storage-model-init-mode": "unknown"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package estimator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dailyWindow is a recurring time range in a day, such as 09:00-18:00. A window with end before start crosses midnight.
+type dailyWindow struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// dailyWindows is a set of recurring daily time ranges in a location
+type dailyWindows struct {
+	windows  []dailyWindow
+	location *time.Location
+}
+
+// parseDailyWindows parse comma separated time ranges like "02:00-04:00,22:00-23:30" in the timezone
+func parseDailyWindows(windowsStr string, timezone string) (*dailyWindows, error) {
+	location := time.UTC
+	if timezone != "" {
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("load timezone %s failed: %v", timezone, err)
+		}
+	}
+
+	result := &dailyWindows{location: location}
+	for _, windowStr := range strings.Split(windowsStr, ",") {
+		windowStr = strings.TrimSpace(windowStr)
+		if windowStr == "" {
+			continue
+		}
+		parts := strings.Split(windowStr, "-")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid time range %q, expect HH:MM-HH:MM", windowStr)
+		}
+		start, err := parseClock(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, fmt.Errorf("invalid time range %q, start equals end", windowStr)
+		}
+		result.windows = append(result.windows, dailyWindow{start: start, end: end})
+	}
+
+	if len(result.windows) == 0 {
+		return nil, fmt.Errorf("no time range found in %q", windowsStr)
+	}
+	return result, nil
+}
+
+func parseClock(clockStr string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clockStr))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expect HH:MM", clockStr)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains returns true if the time is in any of the windows
+func (w *dailyWindows) Contains(t time.Time) bool {
+	t = t.In(w.location)
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	for _, window := range w.windows {
+		if window.start < window.end {
+			if offset >= window.start && offset < window.end {
+				return true
+			}
+		} else if offset >= window.start || offset < window.end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestDailyWindows(t *testing.T) {
+	windows, err := parseDailyWindows("02:00-04:00, 22:00-01:00", "Asia/Shanghai")
+	assert.NoError(t, err)
+
+	location, _ := time.LoadLocation("Asia/Shanghai")
+	tests := []struct {
+		time   time.Time
+		expect bool
+	}{
+		{time: time.Date(2022, 7, 1, 2, 0, 0, 0, location), expect: true},
+		{time: time.Date(2022, 7, 1, 3, 59, 0, 0, location), expect: true},
+		{time: time.Date(2022, 7, 1, 4, 0, 0, 0, location), expect: false},
+		{time: time.Date(2022, 7, 1, 23, 0, 0, 0, location), expect: true},
+		{time: time.Date(2022, 7, 1, 0, 30, 0, 0, location), expect: true},
+		{time: time.Date(2022, 7, 1, 12, 0, 0, 0, location), expect: false},
+		// 03:00 in Shanghai
+		{time: time.Date(2022, 7, 1, 19, 0, 0, 0, time.UTC), expect: true},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expect, windows.Contains(test.time), test.time.String())
+	}
+
+	for _, invalid := range []string{"", "02:00", "25:00-26:00", "02:00-02:00"} {
+		_, err := parseDailyWindows(invalid, "")
+		assert.Error(t, err, invalid)
+	}
+	_, err = parseDailyWindows("02:00-04:00", "Mars/Olympus")
+	assert.Error(t, err)
+}
+
+func TestEstimateResourcesBusinessHours(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.05),
+		"memory": newSeries(100 * 1024 * 1024),
+	})
+	location, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Date(2022, 7, 1, 23, 0, 0, 0, location)
+	e.Clock = clock.NewFakeClock(now)
+	// busy in the work hours of Shanghai, idle in the off-hours
+	busy := func(t time.Time) bool {
+		hour := t.In(location).Hour()
+		return hour >= 9 && hour < 18
+	}
+	e.History = &stepHistory{usage: map[string]func(time.Time) float64{
+		"cpu": func(t time.Time) float64 {
+			if busy(t) {
+				return 1
+			}
+			return 0.05
+		},
+		"memory": func(t time.Time) float64 {
+			if busy(t) {
+				return 1024 * 1024 * 1024
+			}
+			return 100 * 1024 * 1024
+		},
+	}}
+
+	config := map[string]string{
+		"business-hours":              "09:00-18:00",
+		"business-hours-timezone":     "Asia/Shanghai",
+		"cpu-model-history-length":    "24h",
+		"mem-model-history-length":    "24h",
+		"cpu-request-percentile":      "0.5",
+		"cpu-request-margin-fraction": "0",
+		"mem-request-percentile":      "0.5",
+		"mem-request-margin-fraction": "0",
+	}
+	// the off-hours samples are excluded
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+	assert.Equal(t, "1Gi", resources.Memory().String())
+
+	// the work hours in UTC are mostly off-hours in Shanghai
+	config["business-hours-timezone"] = "UTC"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "50m", resources.Cpu().String())
+
+	// without business hours, the idle off-hours drag down the median
+	delete(config, "business-hours")
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "50m", resources.Cpu().String())
+
+	_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"business-hours": "9am-6pm"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
+
+func TestSamplesWithin(t *testing.T) {
+	windows, err := parseDailyWindows("09:00-18:00", "")
+	assert.NoError(t, err)
+	// an hourly series of a day
+	start := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	var samples []common.Sample
+	for i := 0; i < 24; i++ {
+		samples = append(samples, common.Sample{Timestamp: start.Add(time.Duration(i) * time.Hour).Unix(), Value: float64(i)})
+	}
+	within := samplesWithin(samples, windows)
+	if assert.Len(t, within, 9) {
+		assert.Equal(t, float64(9), within[0].Value)
+		assert.Equal(t, float64(17), within[8].Value)
+	}
+	// no sample in the window
+	assert.Empty(t, samplesWithin(samples[:9], windows))
+}
+
+func TestEstimateResourcesActiveWindow(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.05),
+		"memory": newSeries(100 * 1024 * 1024),
+	})
+	location, _ := time.LoadLocation("Asia/Shanghai")
+	e.Clock = clock.NewFakeClock(time.Date(2022, 7, 1, 23, 0, 0, 0, location))
+	e.History = &stepHistory{usage: map[string]func(time.Time) float64{
+		"cpu": func(t time.Time) float64 {
+			if hour := t.In(location).Hour(); hour >= 9 && hour < 18 {
+				return 1
+			}
+			return 0.05
+		},
+		"memory": func(t time.Time) float64 {
+			return 100 * 1024 * 1024
+		},
+	}}
+
+	config := map[string]string{
+		"cpu-active-window":           "09:00-18:00",
+		"active-window-timezone":      "Asia/Shanghai",
+		"cpu-model-history-length":    "24h",
+		"cpu-request-percentile":      "0.5",
+		"cpu-request-margin-fraction": "0",
+	}
+	// only the cpu samples in the active window are used, the memory is predicted as usual
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+	assert.Equal(t, "100Mi", resources.Memory().String())
+
+	// the active window of the resource takes the place of the business hours
+	config["business-hours"] = "00:00-06:00"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resources.Cpu().String())
+	delete(config, "business-hours")
+
+	// no sample of the last hour is in the window, the predicted value is kept
+	config["cpu-model-history-length"] = "1h"
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "50m", resources.Cpu().String())
+
+	for _, invalid := range []map[string]string{
+		{"cpu-active-window": "9am-6pm"},
+		{"mem-active-window": "09:00-18:00", "active-window-timezone": "Mars/Olympus"},
+	} {
+		_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), invalid, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,111 @@
+package estimator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// TShirtSizeOverflowError fails the estimation if the recommendation exceeds the largest size
+	TShirtSizeOverflowError = "error"
+	// TShirtSizeOverflowLargest picks the largest size if the recommendation exceeds it
+	TShirtSizeOverflowLargest = "largest"
+
+	// MetadataTShirtSize is the metadata key of the size the recommendation snapped to
+	MetadataTShirtSize = "tshirt-size"
+)
+
+type tshirtSize struct {
+	name   string
+	cpu    resource.Quantity
+	memory resource.Quantity
+}
+
+type tshirtSizeConfig struct {
+	// sizes are sorted from the smallest to the largest
+	sizes    []tshirtSize
+	overflow string
+}
+
+// getTShirtSizeConfig parses 'tshirt-sizes' such as "small=500m/512Mi,medium=1/1Gi,large=2/4Gi", the value of a
+// size is cpu/memory. Returns nil if it is not set.
+func getTShirtSizeConfig(config map[string]string) (*tshirtSizeConfig, error) {
+	sizesStr, exists := config["tshirt-sizes"]
+	if !exists || sizesStr == "" {
+		return nil, nil
+	}
+
+	cfg := &tshirtSizeConfig{overflow: TShirtSizeOverflowError}
+	if overflow, exists := config["tshirt-size-overflow"]; exists {
+		if overflow != TShirtSizeOverflowError && overflow != TShirtSizeOverflowLargest {
+			return nil, fmt.Errorf("unknown tshirt-size-overflow %q", overflow)
+		}
+		cfg.overflow = overflow
+	}
+
+	for _, sizeStr := range strings.Split(sizesStr, ",") {
+		parts := strings.Split(strings.TrimSpace(sizeStr), "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid tshirt size %q, must be name=cpu/memory", sizeStr)
+		}
+		quantities := strings.Split(parts[1], "/")
+		if len(quantities) != 2 {
+			return nil, fmt.Errorf("invalid tshirt size %q, must be name=cpu/memory", sizeStr)
+		}
+		cpu, err := resource.ParseQuantity(quantities[0])
+		if err != nil {
+			return nil, fmt.Errorf("parse cpu of tshirt size %s failed: %v", parts[0], err)
+		}
+		memory, err := resource.ParseQuantity(quantities[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse memory of tshirt size %s failed: %v", parts[0], err)
+		}
+		cfg.sizes = append(cfg.sizes, tshirtSize{name: parts[0], cpu: cpu, memory: memory})
+	}
+
+	sort.SliceStable(cfg.sizes, func(i, j int) bool {
+		if cmp := cfg.sizes[i].cpu.Cmp(cfg.sizes[j].cpu); cmp != 0 {
+			return cmp < 0
+		}
+		return cfg.sizes[i].memory.Cmp(cfg.sizes[j].memory) < 0
+	})
+	return cfg, nil
+}
+
+// snap returns the smallest size that covers the recommendation, resources not recommended are not considered
+func (c *tshirtSizeConfig) snap(resources corev1.ResourceList) (*tshirtSize, error) {
+	for i := range c.sizes {
+		size := &c.sizes[i]
+		if cpu, exists := resources[corev1.ResourceCPU]; exists && cpu.Cmp(size.cpu) > 0 {
+			continue
+		}
+		if memory, exists := resources[corev1.ResourceMemory]; exists && memory.Cmp(size.memory) > 0 {
+			continue
+		}
+		return size, nil
+	}
+
+	if c.overflow == TShirtSizeOverflowLargest {
+		return &c.sizes[len(c.sizes)-1], nil
+	}
+	return nil, fmt.Errorf("recommendation %v exceeds the largest tshirt size %s", resources, c.sizes[len(c.sizes)-1].name)
+}
+
+// snapToTShirtSize replaces the recommended resources with the snapped size, and returns the size name
+func snapToTShirtSize(resources corev1.ResourceList, cfg *tshirtSizeConfig) (string, error) {
+	size, err := cfg.snap(resources)
+	if err != nil {
+		return "", err
+	}
+	if _, exists := resources[corev1.ResourceCPU]; exists {
+		resources[corev1.ResourceCPU] = size.cpu.DeepCopy()
+	}
+	if _, exists := resources[corev1.ResourceMemory]; exists {
+		resources[corev1.ResourceMemory] = size.memory.DeepCopy()
+	}
+	return size.name, nil
+}
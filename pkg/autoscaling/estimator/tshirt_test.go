@@ -0,0 +1,44 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestEstimationTShirtSize(t *testing.T) {
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.8),
+		"memory": newSeries(600 * 1024 * 1024),
+	})
+	config := map[string]string{"tshirt-sizes": "large=2/4Gi,small=500m/512Mi,medium=1/1Gi"}
+
+	// cpu needs at least medium, memory needs at least medium
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "medium", estimation.Metadata[MetadataTShirtSize])
+	assert.Equal(t, "1", estimation.Resources.Cpu().String())
+	assert.Equal(t, "1Gi", estimation.Resources.Memory().String())
+
+	// memory exceeds the largest size
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.8),
+		"memory": newSeries(8 * 1024 * 1024 * 1024),
+	})
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+
+	config["tshirt-size-overflow"] = TShirtSizeOverflowLargest
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), config, "nginx", &corev1.ResourceRequirements{})
+	assert.NoError(t, err)
+	assert.Equal(t, "large", estimation.Metadata[MetadataTShirtSize])
+	assert.Equal(t, "2", estimation.Resources.Cpu().String())
+	assert.Equal(t, "4Gi", estimation.Resources.Memory().String())
+
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"tshirt-sizes": "small=500m"}, "nginx", &corev1.ResourceRequirements{})
+	assert.Error(t, err)
+}
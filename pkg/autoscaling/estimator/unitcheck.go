@@ -0,0 +1,49 @@
+package estimator
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const (
+	// ReasonUnitMismatchSuspected means the recommendation differs from the current requests by orders of magnitude,
+	// such as a query returning cpu in millicores or memory in megabytes, so it is not emitted
+	ReasonUnitMismatchSuspected = "UnitMismatchSuspected"
+
+	defaultUnitMismatchRatio = 500.0
+)
+
+// getUnitMismatchRatio returns the 'unit-mismatch-ratio', the recommendation that is this many times greater or
+// smaller than the current request is suspected as a unit mismatch. Zero disables the check.
+func getUnitMismatchRatio(config map[string]string) (float64, error) {
+	ratio, err := utils.ParseFloat(config["unit-mismatch-ratio"], defaultUnitMismatchRatio)
+	if err != nil {
+		return 0, fmt.Errorf("parse unit-mismatch-ratio failed: %v", err)
+	}
+	if ratio != 0 && ratio <= 1 {
+		return 0, fmt.Errorf("unit-mismatch-ratio must be greater than 1 or 0 to disable, got %v", ratio)
+	}
+	return ratio, nil
+}
+
+// unitMismatchSuspected returns the resources whose order of magnitude differs wildly from the current requests
+func unitMismatchSuspected(currRes *corev1.ResourceRequirements, resources corev1.ResourceList, ratio float64) []corev1.ResourceName {
+	if currRes == nil || ratio == 0 {
+		return nil
+	}
+	var suspected []corev1.ResourceName
+	for resourceName, quantity := range resources {
+		current, exists := currRes.Requests[resourceName]
+		if !exists || current.IsZero() || quantity.IsZero() {
+			continue
+		}
+		r := quantityValue(resourceName, quantity) / quantityValue(resourceName, current)
+		if r >= ratio || r <= 1/ratio {
+			suspected = append(suspected, resourceName)
+		}
+	}
+	return suspected
+}
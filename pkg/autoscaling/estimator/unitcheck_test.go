@@ -0,0 +1,59 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gocrane/crane/pkg/common"
+)
+
+func TestUnitMismatchSuspected(t *testing.T) {
+	// the query returns cpu in millicores, read as cores
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(500),
+		"memory": newSeries(256 * 1024 * 1024),
+	})
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+	}
+
+	estimation, err := e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonUnitMismatchSuspected, estimation.Reason)
+	assert.Equal(t, "500m", estimation.Resources.Cpu().String())
+	assert.Equal(t, "500", estimation.Computed.Cpu().String())
+
+	// memory in megabytes
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(0.5),
+		"memory": newSeries(256),
+	})
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, ReasonUnitMismatchSuspected, estimation.Reason)
+
+	// disabled
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"unit-mismatch-ratio": "0"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Empty(t, estimation.Reason)
+	assert.Equal(t, "256", estimation.Resources.Memory().String())
+
+	// a plausible change is emitted
+	e, _ = newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(2),
+		"memory": newSeries(1024 * 1024 * 1024),
+	})
+	estimation, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Empty(t, estimation.Reason)
+
+	_, err = e.EstimateResources(context.TODO(), newTestEVPA("nginx"), map[string]string{"unit-mismatch-ratio": "0.5"}, "nginx", currRes)
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package estimator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+const defaultWarmupDuration = 30 * time.Minute
+
+// getWarmupDuration returns the 'warmup-duration' a new model falls back to the current requests for, 30m by
+// default, zero disables it
+func getWarmupDuration(config map[string]string) (time.Duration, error) {
+	value, exists := config["warmup-duration"]
+	if !exists {
+		return defaultWarmupDuration, nil
+	}
+	warmup, err := utils.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse warmup-duration failed: %v", err)
+	}
+	if warmup < 0 {
+		return 0, fmt.Errorf("warmup-duration must not be negative, got %v", warmup)
+	}
+	return warmup, nil
+}
+
+// warmingUp returns whether the model of the query is first seen within the warmup, the first estimation records it
+func (e *PercentileResourceEstimator) warmingUp(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, namer metricnaming.MetricNamer, warmup time.Duration) bool {
+	now := e.now()
+	firstSeen, _ := e.firstSeen.LoadOrStore(evpaReferent(evpa)+"/"+namer.BuildUniqueKey(), now)
+	return now.Before(firstSeen.(time.Time).Add(warmup))
+}
+
+// forgetFirstSeen deletes the first seen times of all the models of the evpa
+func (e *PercentileResourceEstimator) forgetFirstSeen(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) {
+	prefix := evpaReferent(evpa) + "/"
+	e.firstSeen.Range(func(key, value interface{}) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			e.firstSeen.Delete(key)
+		}
+		return true
+	})
+}
+
+// warmupFallback adds the current requests of the resources whose models are warming up to the fallback, so the
+// workload keeps its requests rather than failing until the models have enough samples
+func (e *PercentileResourceEstimator) warmupFallback(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, fallback corev1.ResourceList, current corev1.ResourceList, warmup time.Duration, queryNamers map[corev1.ResourceName]metricnaming.MetricNamer) corev1.ResourceList {
+	if warmup <= 0 {
+		return fallback
+	}
+	withWarmup := corev1.ResourceList{}
+	for resourceName, quantity := range fallback {
+		withWarmup[resourceName] = quantity
+	}
+	for resourceName, namer := range queryNamers {
+		// the first seen is recorded even if the resource falls back anyway
+		if !e.warmingUp(evpa, namer, warmup) {
+			continue
+		}
+		if _, exists := withWarmup[resourceName]; exists {
+			continue
+		}
+		if quantity, exists := current[resourceName]; exists {
+			withWarmup[resourceName] = quantity
+		}
+	}
+	return withWarmup
+}
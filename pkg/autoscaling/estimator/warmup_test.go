@@ -0,0 +1,96 @@
+package estimator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/prediction"
+)
+
+func TestEstimateResourcesWarmup(t *testing.T) {
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(now)
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{})
+	e.Clock = fakeClock
+	predictor.statuses["cpu"] = prediction.StatusInitializing
+	predictor.statuses["memory"] = prediction.StatusInitializing
+
+	// the new models keep the current requests within the warmup
+	for _, elapsed := range []time.Duration{0, 29 * time.Minute} {
+		fakeClock.SetTime(now.Add(elapsed))
+		resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+		assert.NoError(t, err, elapsed)
+		assert.Equal(t, "500m", resources.Cpu().String(), elapsed)
+		assert.Equal(t, "1Gi", resources.Memory().String(), elapsed)
+	}
+
+	// the warmup expires, the not ready models fail
+	fakeClock.SetTime(now.Add(31 * time.Minute))
+	_, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.True(t, errors.Is(err, ErrModelNotReady), err)
+
+	// the models are ready after the warmup
+	predictor.series = map[string][]*common.TimeSeries{"cpu": newSeries(0.25), "memory": newSeries(256 * mebibyte)}
+	delete(predictor.statuses, "cpu")
+	delete(predictor.statuses, "memory")
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "250m", resources.Cpu().String())
+	assert.Equal(t, "256Mi", resources.Memory().String())
+}
+
+func TestEstimateResourcesWarmupDuration(t *testing.T) {
+	currRes := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+	}
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(now)
+	e, _ := newTestEstimator(map[string][]*common.TimeSeries{"memory": newSeries(256 * mebibyte)})
+	e.Clock = fakeClock
+	config := map[string]string{"warmup-duration": "2h"}
+
+	resources, err := e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+	assert.Equal(t, "256Mi", resources.Memory().String())
+
+	fakeClock.SetTime(now.Add(time.Hour))
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+
+	fakeClock.SetTime(now.Add(3 * time.Hour))
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.NotContains(t, resources, corev1.ResourceCPU)
+
+	// the deleted evpa warms up again
+	assert.NoError(t, e.DeleteEstimation(context.TODO(), newTestEVPA("nginx")))
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), config, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.Equal(t, "500m", resources.Cpu().String())
+
+	// disabled
+	resources, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"warmup-duration": "0"}, "nginx", currRes)
+	assert.NoError(t, err)
+	assert.NotContains(t, resources, corev1.ResourceCPU)
+
+	for _, value := range []string{"-1m", "soon"} {
+		_, err = e.GetResourceEstimation(context.TODO(), newTestEVPA("nginx"), map[string]string{"warmup-duration": value}, "nginx", currRes)
+		assert.Error(t, err, value)
+	}
+}
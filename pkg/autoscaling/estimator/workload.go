@@ -0,0 +1,42 @@
+package estimator
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+// targetWorkloadKinds are the kinds of the apps group the container metrics are matched for, the pods of them are
+// named after the workload
+var targetWorkloadKinds = map[string]func() client.Object{
+	"Deployment":  func() client.Object { return &appsv1.Deployment{} },
+	"StatefulSet": func() client.Object { return &appsv1.StatefulSet{} },
+	"DaemonSet":   func() client.Object { return &appsv1.DaemonSet{} },
+	"ReplicaSet":  func() client.Object { return &appsv1.ReplicaSet{} },
+}
+
+// resolveTargetWorkload checks the kind of the evpa target is supported, and gets the workload if the client is set,
+// so the container metrics are not queried with the metadata of a mismatched workload
+func resolveTargetWorkload(ctx context.Context, kubeClient client.Client, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) error {
+	targetRef := evpa.Spec.TargetRef
+	if targetRef == nil {
+		return fmt.Errorf("evpa %s/%s has no target ref", evpa.Namespace, evpa.Name)
+	}
+	newObject, supported := targetWorkloadKinds[targetRef.Kind]
+	gv, err := schema.ParseGroupVersion(targetRef.APIVersion)
+	if !supported || err != nil || gv.Group != appsv1.GroupName {
+		return fmt.Errorf("unsupported target %s %s, the supported kinds are the apps Deployment, StatefulSet, DaemonSet and ReplicaSet", targetRef.APIVersion, targetRef.Kind)
+	}
+	if kubeClient == nil {
+		return nil
+	}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: evpa.Namespace, Name: targetRef.Name}, newObject()); err != nil {
+		return fmt.Errorf("failed to get the target %s %s: %v", targetRef.Kind, targetRef.Name, err)
+	}
+	return nil
+}
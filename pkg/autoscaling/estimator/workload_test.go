@@ -0,0 +1,92 @@
+package estimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
+	"github.com/gocrane/crane/pkg/prediction/config"
+)
+
+// containerRecorder records the container info of the registered queries
+type containerRecorder struct {
+	*fakePredictor
+	containers map[string]metricquery.ContainerNamerInfo
+}
+
+func (r *containerRecorder) WithQuery(namer metricnaming.MetricNamer, caller string, cfg config.Config) error {
+	gmn := namer.(*metricnaming.GeneralMetricNamer)
+	r.containers[gmn.Metric.MetricName] = *gmn.Metric.Container
+	return r.fakePredictor.WithQuery(namer, caller, cfg)
+}
+
+func TestEstimateResourcesTargetKinds(t *testing.T) {
+	meta := metav1.ObjectMeta{Name: "nginx", Namespace: "default"}
+	for kind, workload := range map[string]client.Object{
+		"Deployment":  &appsv1.Deployment{ObjectMeta: meta},
+		"StatefulSet": &appsv1.StatefulSet{ObjectMeta: meta},
+		"DaemonSet":   &appsv1.DaemonSet{ObjectMeta: meta},
+		"ReplicaSet":  &appsv1.ReplicaSet{ObjectMeta: meta},
+	} {
+		e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+			"cpu":    newSeries(1),
+			"memory": newSeries(mebibyte),
+		})
+		recorder := &containerRecorder{fakePredictor: predictor, containers: map[string]metricquery.ContainerNamerInfo{}}
+		e.Predictor = recorder
+		e.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(workload).Build()
+		evpa := newTestEVPA("nginx")
+		evpa.Spec.TargetRef.Kind = kind
+
+		resources, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+		assert.NoError(t, err, kind)
+		assert.Equal(t, "1", resources.Cpu().String(), kind)
+		for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			container := recorder.containers[resourceName.String()]
+			assert.Equal(t, "default", container.Namespace, kind)
+			assert.Equal(t, "nginx", container.WorkloadName, kind)
+			assert.Equal(t, kind, container.WorkloadKind, kind)
+			assert.Equal(t, "apps/v1", container.APIVersion, kind)
+			assert.Equal(t, "nginx", container.Name, kind)
+		}
+
+		// the kind of the target ref mismatches the workload
+		evpa.Spec.TargetRef.Kind = "StatefulSet"
+		if kind == "StatefulSet" {
+			evpa.Spec.TargetRef.Kind = "DaemonSet"
+		}
+		_, err = e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+		assert.Error(t, err, kind)
+	}
+}
+
+func TestEstimateResourcesUnsupportedTargetKind(t *testing.T) {
+	e, predictor := newTestEstimator(map[string][]*common.TimeSeries{
+		"cpu":    newSeries(1),
+		"memory": newSeries(mebibyte),
+	})
+	for _, targetRef := range [][2]string{
+		{"batch/v1", "Job"},
+		{"argoproj.io/v1alpha1", "Rollout"},
+		{"extensions/v1beta1", "Deployment"},
+	} {
+		evpa := newTestEVPA("nginx")
+		evpa.Spec.TargetRef.APIVersion, evpa.Spec.TargetRef.Kind = targetRef[0], targetRef[1]
+		_, err := e.GetResourceEstimation(context.TODO(), evpa, map[string]string{}, "nginx", &corev1.ResourceRequirements{})
+		if assert.Error(t, err, targetRef[1]) {
+			assert.Contains(t, err.Error(), "unsupported target "+targetRef[0]+" "+targetRef[1])
+		}
+	}
+	// nothing is queried with the mismatched metadata
+	assert.Empty(t, predictor.queries)
+}
@@ -7,8 +7,11 @@ import (
 )
 
 const (
+	// DefaultComponentScaleDownStabWindowSeconds is the cooldown of scale down, the quiet period since the last
+	// scaling of any direction. It is much longer than the scale up, the scale down is riskier.
 	DefaultComponentScaleDownStabWindowSeconds = int32(43200)
-	DefaultComponentScaleUpStabWindowSeconds   = int32(150)
+	// DefaultComponentScaleUpStabWindowSeconds is the cooldown of scale up since the last scale up
+	DefaultComponentScaleUpStabWindowSeconds = int32(150)
 
 	// DefaultScaleDownCPUUtilPercentageThreshold defines the cpu scaledown threshold,,
 	// If the ratio of actual used cpu resources divided by request resources is less than DefaultScaleDownCPUUtilPercentageThreshold,
@@ -38,12 +41,51 @@ const (
 
 	// DefaultEVPARsyncPeriod defines the rsync period for EVPA controller
 	DefaultEVPARsyncPeriod = time.Second * 60
+
+	// DefaultEVPAModelNotReadyRsyncPeriod defines the rsync period for EVPA controller when a prediction model is not ready
+	DefaultEVPAModelNotReadyRsyncPeriod = time.Minute * 5
+
+	// estimatorReadinessPeriod defines the period of checking the readiness of the estimators
+	estimatorReadinessPeriod = time.Second * 30
+
+	// estimatorReadinessTimeout defines the timeout of a readiness check of the estimators
+	estimatorReadinessTimeout = time.Second * 10
+
+	// estimatorReadinessFailureThreshold defines the consecutive failed checks before the estimators are not ready
+	estimatorReadinessFailureThreshold = 3
+
+	// inPlaceResizeDetectionPeriod defines the period of detecting the resize subresource of the pods again
+	inPlaceResizeDetectionPeriod = time.Minute * 10
 )
 
 const (
 	EffectiveVPAConditionTypeReady = "Ready"
+	// EffectiveVPAConditionTypeConfident tells whether the recommendations are based on enough samples
+	EffectiveVPAConditionTypeConfident = "Confident"
 )
 
+type EvpaControllerConfig struct {
+	// ChangeBudgetLimit is the max recommendation changes emitted in a ChangeBudgetPeriod, zero means unlimited
+	ChangeBudgetLimit int
+	// ChangeBudgetPeriod is the window of the change budget
+	ChangeBudgetPeriod time.Duration
+	// SchedulingCheck drops the recommendations with which the pod would not fit any node
+	SchedulingCheck bool
+	// ApprovalWebhookURL is the webhook to approve the recommendation before it is surfaced, empty means no approval
+	ApprovalWebhookURL string
+	// ApprovalWebhookTimeout is the timeout of calling the approval webhook
+	ApprovalWebhookTimeout time.Duration
+	// KillSwitch stops all the recommendation changes, the estimations are still computed
+	KillSwitch bool
+	// KillSwitchConfigMap is the namespace/name of the watched ConfigMap of the kill switch, empty means not watched
+	KillSwitchConfigMap string
+	// ChangeDampeningPercentage is the min change in percent of the applied recommendation to emit a new one, unless
+	// the container was OOMKilled since its last scaling, zero means no dampening
+	ChangeDampeningPercentage int32
+	// CallerPrefix prefixes the predictor callers of the evpas, such as the tenant of a shared predictor
+	CallerPrefix string
+}
+
 var (
 	DefaultControlledResources = []autoscalingapi.ResourceName{autoscalingapi.ResourceName("cpu"), autoscalingapi.ResourceName("memory")}
 
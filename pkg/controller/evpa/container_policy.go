@@ -1,19 +1,26 @@
 package evpa
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	vpatypes "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
 
 	"github.com/gocrane/crane/pkg/autoscaling/estimator"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
 	"github.com/gocrane/crane/pkg/utils"
 )
 
@@ -24,10 +31,24 @@ const (
 	ScaleDown ScaleDirection = "ScaleDown"
 )
 
-func (c *EffectiveVPAController) ReconcileContainerPolicies(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, podTemplate *corev1.PodTemplateSpec, resourceEstimators []estimator.ResourceEstimatorInstance) (currentEstimatorStatus []autoscalingapi.ResourceEstimatorStatus, recommendation *vpatypes.RecommendedPodResources, err error) {
+const (
+	// observedCpuUsageExprTemplate is the cpu usage of the container in the pods, param is namespace,pods,container
+	observedCpuUsageExprTemplate = `irate(container_cpu_usage_seconds_total{container!="POD",namespace="%s",pod=~"^(%s)$",container="%s"}[3m])`
+	// observedMemoryUsageExprTemplate is the memory usage of the container in the pods, param is namespace,pods,container
+	observedMemoryUsageExprTemplate = `container_memory_working_set_bytes{container!="POD",namespace="%s",pod=~"^(%s)$",container="%s"}`
+	observedUsageCaller             = "evpa-observed-usage"
+	// observedUsageLookback bounds the observed usage queried since the last scaling
+	observedUsageLookback = time.Hour
+	// observedUsagePodBatchSize is the max pods of an observed usage query
+	observedUsagePodBatchSize = 50
+)
+
+func (c *EffectiveVPAController) ReconcileContainerPolicies(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, podTemplate *corev1.PodTemplateSpec, resourceEstimators []estimator.ResourceEstimatorInstance) (currentEstimatorStatus []autoscalingapi.ResourceEstimatorStatus, recommendation *vpatypes.RecommendedPodResources, modelNotReady bool, err error) {
 	recommendation = evpa.Status.Recommendation
 
 	rankedEstimators := RankEstimators(resourceEstimators)
+	changedContainers := make(map[string]corev1.ResourceList)
+	changedDirections := make(map[string]ScaleDirection)
 	needReconciledContainers := make(map[string]autoscalingapi.ContainerResourcePolicy)
 	containerResourceRequirement := make(map[string]*corev1.ResourceRequirements)
 	for _, container := range podTemplate.Spec.Containers {
@@ -49,7 +70,8 @@ func (c *EffectiveVPAController) ReconcileContainerPolicies(evpa *autoscalingapi
 		}
 
 		// loop estimator and get final estimated resource for container
-		recommendResourceContainer, currentStatus := GetEstimatedResourceForContainer(evpa, containerPolicy, resourceRequirement, rankedEstimators, currentEstimatorStatus)
+		recommendResourceContainer, currentStatus, notReady := GetEstimatedResourceForContainer(ctx, evpa, containerPolicy, resourceRequirement, rankedEstimators, currentEstimatorStatus)
+		modelNotReady = modelNotReady || notReady
 		// record the recommended resource each time to do estimating. so we can get more observability
 		recordResourceRecommendation(evpa, containerPolicy, recommendResourceContainer)
 		currentEstimatorStatus = currentStatus
@@ -60,26 +82,348 @@ func (c *EffectiveVPAController) ReconcileContainerPolicies(evpa *autoscalingapi
 			klog.V(4).Infof("Container %s recommend resource %v", containerPolicy.ContainerName, recommendResourceContainer)
 		}
 
-		shouldScaleUp, msg := c.CheckContainerScalingCondition(evpa, containerPolicy, containerPolicy.ScaleUpPolicy, ScaleUp, resourceRequirement.Requests, recommendResourceContainer)
-		if !shouldScaleUp {
-			klog.Infof("Should not %s container %s: %s", ScaleUp, containerPolicy.ContainerName, msg)
-		} else {
-			klog.V(4).Infof("Should %s container %s, resource %v", ScaleUp, containerPolicy.ContainerName, recommendResourceContainer)
-			UpdateRecommendStatus(recommendation, containerPolicy.ContainerName, recommendResourceContainer)
+		// only the policy of the direction the recommendation moves the requests is evaluated
+		direction := GetScaleDirection(resourceRequirement.Requests, recommendResourceContainer)
+		scalingPolicy := containerPolicy.ScaleUpPolicy
+		if direction == ScaleDown {
+			scalingPolicy = containerPolicy.ScaleDownPolicy
+		}
+		shouldScale, msg := c.CheckContainerScalingCondition(evpa, containerPolicy, scalingPolicy, direction, resourceRequirement.Requests, recommendResourceContainer)
+		if !shouldScale {
+			klog.Infof("Should not %s container %s: %s", direction, containerPolicy.ContainerName, msg)
+			continue
+		}
+		klog.V(4).Infof("Should %s container %s, resource %v", direction, containerPolicy.ContainerName, recommendResourceContainer)
+		changedContainers[containerPolicy.ContainerName] = recommendResourceContainer
+		changedDirections[containerPolicy.ContainerName] = direction
+	}
+
+	c.skipAppliedChanges(evpa, changedContainers)
+	c.dampenSmallChanges(ctx, evpa, containerResourceRequirement, changedContainers)
+	c.dropGloballyDisabledChanges(ctx, evpa, changedContainers)
+	c.dropUnschedulableChanges(ctx, evpa, podTemplate, changedContainers)
+	c.holdUnapprovedChanges(ctx, evpa, containerResourceRequirement, changedContainers)
+	for _, containerName := range c.admitChanges(ctx, evpa, podTemplate, changedContainers) {
+		UpdateRecommendStatus(recommendation, containerName, changedContainers[containerName])
+		c.SetLastScaleTime(evpa.Namespace, evpa.Spec.TargetRef.Name, containerName, string(changedDirections[containerName]), metav1.Now())
+	}
+
+	return
+}
+
+// skipAppliedChanges skips the changes already applied to the recommendation status, so the retries don't re-apply
+// the same recommendation redundantly
+func (c *EffectiveVPAController) skipAppliedChanges(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, changedContainers map[string]corev1.ResourceList) {
+	if evpa.Status.Recommendation == nil {
+		return
+	}
+
+	for _, containerRecommendation := range evpa.Status.Recommendation.ContainerRecommendations {
+		recommendResource, exists := changedContainers[containerRecommendation.ContainerName]
+		if !exists {
+			continue
+		}
+		appliedKey := estimator.RecommendationIdempotencyKey(evpa.Namespace, evpa.Spec.TargetRef.Name, containerRecommendation.ContainerName, containerRecommendation.Target)
+		if estimator.RecommendationIdempotencyKey(evpa.Namespace, evpa.Spec.TargetRef.Name, containerRecommendation.ContainerName, recommendResource) == appliedKey {
+			klog.V(4).Infof("Skip recommendation for container %s, evpa %s: already applied %s", containerRecommendation.ContainerName, klog.KObj(evpa), appliedKey)
+			delete(changedContainers, containerRecommendation.ContainerName)
+		}
+	}
+}
+
+// dampenSmallChanges drops the changes within the dampening percentage of the applied recommendation, or of the
+// current requests if none is applied yet, so the drift of the percentile doesn't restart the pods over and over. The
+// changes of a container whose requests are exceeded are kept.
+func (c *EffectiveVPAController) dampenSmallChanges(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerResourceRequirement map[string]*corev1.ResourceRequirements, changedContainers map[string]corev1.ResourceList) {
+	if c.Config.ChangeDampeningPercentage <= 0 {
+		return
+	}
+
+	for containerName, recommendResource := range changedContainers {
+		var applied, requests corev1.ResourceList
+		if resourceRequirement, exists := containerResourceRequirement[containerName]; exists {
+			requests = resourceRequirement.Requests
+		}
+		if evpa.Status.Recommendation != nil {
+			applied = GetContainerTargetResource(evpa.Status.Recommendation, containerName)
+		}
+		if applied == nil {
+			applied = requests
+		}
+		if isSignificantChange(applied, recommendResource, c.Config.ChangeDampeningPercentage) {
+			continue
+		}
+		if exceeded, resourceName := c.requestExceeded(ctx, evpa, containerName, requests); exceeded {
+			klog.V(4).Infof("Keep recommendation for container %s, evpa %s: the %s request is exceeded", containerName, klog.KObj(evpa), resourceName)
+			continue
+		}
+		klog.V(4).Infof("Dampen recommendation for container %s, evpa %s: %v is within %d%% of %v", containerName, klog.KObj(evpa), recommendResource, c.Config.ChangeDampeningPercentage, applied)
+		delete(changedContainers, containerName)
+	}
+}
+
+// isSignificantChange tells whether any resource of the recommendation changes by more than the percentage of the
+// applied, a resource not applied yet is always significant
+func isSignificantChange(applied corev1.ResourceList, recommendResource corev1.ResourceList, percentage int32) bool {
+	for resourceName, quantity := range recommendResource {
+		appliedQuantity, exists := applied[resourceName]
+		if !exists || appliedQuantity.IsZero() {
+			return true
+		}
+		change := math.Abs(float64(quantity.MilliValue())-float64(appliedQuantity.MilliValue())) / float64(appliedQuantity.MilliValue())
+		if change*100 > float64(percentage) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestExceeded tells whether a request of the container is exceeded, and which one. A request is exceeded if the
+// upper bound of the recommendation is above it, or the usage observed in the target pods since the last scaling is.
+func (c *EffectiveVPAController) requestExceeded(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, requests corev1.ResourceList) (bool, corev1.ResourceName) {
+	if evpa.Status.Recommendation != nil {
+		for _, containerRecommendation := range evpa.Status.Recommendation.ContainerRecommendations {
+			if containerRecommendation.ContainerName != containerName {
+				continue
+			}
+			for resourceName, upperBound := range containerRecommendation.UpperBound {
+				if request, exists := requests[resourceName]; exists && upperBound.Cmp(request) > 0 {
+					return true, resourceName
+				}
+			}
+		}
+	}
+
+	if c.HistoryProvider == nil || c.TargetFetcher == nil {
+		return false, ""
+	}
+	pods, err := c.listTargetPods(ctx, evpa)
+	if err != nil {
+		klog.Errorf("Failed to list the target pods, evpa %s: %v", klog.KObj(evpa), err)
+		return false, ""
+	}
+	var podNames []string
+	for _, pod := range pods {
+		podNames = append(podNames, regexp.QuoteMeta(pod.Name))
+	}
+	if len(podNames) == 0 {
+		return false, ""
+	}
+	sort.Strings(podNames)
+
+	now := c.now()
+	since := c.lastScaleTimeOfAnyDirection(evpa, containerName)
+	if since.Before(now.Add(-observedUsageLookback)) {
+		since = now.Add(-observedUsageLookback)
+	}
+	for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		request, exists := requests[resourceName]
+		if !exists || request.IsZero() {
+			continue
+		}
+		// the pods are queried in batches to bound the length of the pod regex
+		for start := 0; start < len(podNames); start += observedUsagePodBatchSize {
+			if ctx.Err() != nil {
+				return false, ""
+			}
+			end := start + observedUsagePodBatchSize
+			if end > len(podNames) {
+				end = len(podNames)
+			}
+			namer := newObservedUsageMetricNamer(evpa, containerName, resourceName, podNames[start:end])
+			tsList, err := c.HistoryProvider.QueryTimeSeries(namer, since, now, time.Minute)
+			if err != nil {
+				klog.Errorf("Failed to query the observed %s usage, evpa %s: %v", resourceName, klog.KObj(evpa), err)
+				continue
+			}
+			for _, ts := range tsList {
+				for _, sample := range ts.Samples {
+					if sample.Value > request.AsApproximateFloat64() {
+						return true, resourceName
+					}
+				}
+			}
+		}
+	}
+	return false, ""
+}
+
+func (c *EffectiveVPAController) lastScaleTimeOfAnyDirection(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string) time.Time {
+	lastScaleTime := c.GetLastScaleTime(evpa.Namespace, evpa.Spec.TargetRef.Name, containerName, string(ScaleUp))
+	lastScaleDownTime := c.GetLastScaleTime(evpa.Namespace, evpa.Spec.TargetRef.Name, containerName, string(ScaleDown))
+	if lastScaleDownTime.After(lastScaleTime.Time) {
+		lastScaleTime = lastScaleDownTime
+	}
+	return lastScaleTime.Time
+}
+
+// newObservedUsageMetricNamer builds the namer of the usage of the container in the pods, cpu in cores and memory in
+// bytes as the requests
+func newObservedUsageMetricNamer(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerName string, resourceName corev1.ResourceName, podNames []string) metricnaming.MetricNamer {
+	exprTemplate := observedMemoryUsageExprTemplate
+	if resourceName == corev1.ResourceCPU {
+		exprTemplate = observedCpuUsageExprTemplate
+	}
+	return &metricnaming.GeneralMetricNamer{
+		CallerName: observedUsageCaller,
+		Metric: &metricquery.Metric{
+			Type:       metricquery.PromQLMetricType,
+			MetricName: resourceName.String(),
+			Prom: &metricquery.PromNamerInfo{
+				QueryExpr: fmt.Sprintf(exprTemplate, evpa.Namespace, strings.Join(podNames, "|"), containerName),
+				Namespace: evpa.Namespace,
+			},
+		},
+	}
+}
+
+// listTargetPods lists the pods of the evpa target by the selector of the target
+func (c *EffectiveVPAController) listTargetPods(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) ([]corev1.Pod, error) {
+	selector, err := c.TargetFetcher.Fetch(&corev1.ObjectReference{
+		APIVersion: evpa.Spec.TargetRef.APIVersion,
+		Kind:       evpa.Spec.TargetRef.Kind,
+		Name:       evpa.Spec.TargetRef.Name,
+		Namespace:  evpa.Namespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetch the target selector failed: %v", err)
+	}
+	podList := &corev1.PodList{}
+	if err := c.Client.List(ctx, podList, client.InNamespace(evpa.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+// dropGloballyDisabledChanges drops all the changes when the kill switch is active, the recommendations are still
+// recorded as metrics
+func (c *EffectiveVPAController) dropGloballyDisabledChanges(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, changedContainers map[string]corev1.ResourceList) {
+	if len(changedContainers) == 0 || !c.KillSwitch.Active(ctx) {
+		return
+	}
+
+	klog.Infof("Drop recommendation of %d containers for evpa %s, globally disabled", len(changedContainers), klog.KObj(evpa))
+	if c.Recorder != nil {
+		c.Recorder.Event(evpa, corev1.EventTypeNormal, estimator.ReasonGloballyDisabled, "Recommendation changes are stopped by the kill switch")
+	}
+	for containerName := range changedContainers {
+		delete(changedContainers, containerName)
+	}
+}
+
+// dropUnschedulableChanges drops the changes with which the pod would not fit any node, so the recommendation
+// doesn't strand pods
+func (c *EffectiveVPAController) dropUnschedulableChanges(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, podTemplate *corev1.PodTemplateSpec, changedContainers map[string]corev1.ResourceList) {
+	if !c.Config.SchedulingCheck {
+		return
+	}
+
+	for containerName, recommendResource := range changedContainers {
+		podSpec := podTemplate.Spec.DeepCopy()
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name != containerName {
+				continue
+			}
+			if podSpec.Containers[i].Resources.Requests == nil {
+				podSpec.Containers[i].Resources.Requests = corev1.ResourceList{}
+			}
+			for resourceName, quantity := range recommendResource {
+				podSpec.Containers[i].Resources.Requests[resourceName] = quantity
+			}
+		}
+
+		schedulable, msg, err := estimator.CheckSchedulable(ctx, c.Client, podSpec)
+		if err != nil {
+			klog.Errorf("Failed to check schedulable for container %s, evpa %s: %v", containerName, klog.KObj(evpa), err)
 			continue
 		}
+		if !schedulable {
+			klog.Infof("Drop recommendation for container %s, evpa %s: %s", containerName, klog.KObj(evpa), msg)
+			if c.Recorder != nil {
+				c.Recorder.Event(evpa, corev1.EventTypeWarning, estimator.ReasonUnschedulable, fmt.Sprintf("Container %s: %s", containerName, msg))
+			}
+			delete(changedContainers, containerName)
+		}
+	}
+}
 
-		shouldScaleDown, msg := c.CheckContainerScalingCondition(evpa, containerPolicy, containerPolicy.ScaleUpPolicy, ScaleDown, resourceRequirement.Requests, recommendResourceContainer)
-		if !shouldScaleDown {
-			klog.Infof("Should not %s container %s: %s", ScaleDown, containerPolicy.ContainerName, msg)
+// holdUnapprovedChanges holds the changes not approved by the approver, they are proposed again in the next reconcile
+func (c *EffectiveVPAController) holdUnapprovedChanges(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerResourceRequirement map[string]*corev1.ResourceRequirements, changedContainers map[string]corev1.ResourceList) {
+	if c.Approver == nil {
+		return
+	}
+
+	for containerName, recommendResource := range changedContainers {
+		request := &estimator.ApprovalRequest{
+			Namespace:     evpa.Namespace,
+			Name:          evpa.Name,
+			TargetKind:    evpa.Spec.TargetRef.Kind,
+			TargetName:    evpa.Spec.TargetRef.Name,
+			ContainerName: containerName,
+			Proposed:      recommendResource,
+		}
+		if resourceRequirement, exists := containerResourceRequirement[containerName]; exists {
+			request.Current = resourceRequirement.Requests
+		}
+
+		response, err := c.Approver.Approve(ctx, request)
+		var msg string
+		if err != nil {
+			msg = fmt.Sprintf("Container %s: approval failed: %v", containerName, err)
+		} else if !response.Approved {
+			msg = fmt.Sprintf("Container %s: not approved: %s", containerName, response.Reason)
 		} else {
-			klog.V(4).Infof("Should %s container %s, resource %v", ScaleDown, containerPolicy.ContainerName, recommendResourceContainer)
-			UpdateRecommendStatus(recommendation, containerPolicy.ContainerName, recommendResourceContainer)
 			continue
 		}
+
+		klog.Infof("Hold recommendation for evpa %s, %s", klog.KObj(evpa), msg)
+		if c.Recorder != nil {
+			c.Recorder.Event(evpa, corev1.EventTypeNormal, estimator.ReasonPendingApproval, msg)
+		}
+		delete(changedContainers, containerName)
 	}
+}
 
-	return
+// admitChanges return the containers whose changes are admitted by the change budget
+func (c *EffectiveVPAController) admitChanges(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, podTemplate *corev1.PodTemplateSpec, changedContainers map[string]corev1.ResourceList) []string {
+	var containerNames []string
+	for containerName := range changedContainers {
+		containerNames = append(containerNames, containerName)
+	}
+	sort.Strings(containerNames)
+
+	if c.ChangeBudget == nil || len(containerNames) == 0 {
+		return containerNames
+	}
+
+	priority := estimator.GetPodPriority(ctx, c.Client, &podTemplate.Spec)
+	var candidates []estimator.ChangeCandidate
+	for _, containerName := range containerNames {
+		candidates = append(candidates, estimator.ChangeCandidate{
+			Key:      GetScaleEventKey(evpa.Namespace, evpa.Spec.TargetRef.Name, containerName, ""),
+			Priority: priority,
+		})
+	}
+
+	admitted := c.ChangeBudget.Admit(candidates)
+	var admittedContainers []string
+	for _, containerName := range containerNames {
+		key := GetScaleEventKey(evpa.Namespace, evpa.Spec.TargetRef.Name, containerName, "")
+		isAdmitted := false
+		for _, candidate := range admitted {
+			if candidate.Key == key {
+				isAdmitted = true
+				break
+			}
+		}
+		if isAdmitted {
+			admittedContainers = append(admittedContainers, containerName)
+		} else {
+			klog.Infof("Change budget exhausted, defer recommendation for container %s, evpa %s priority %d", containerName, klog.KObj(evpa), priority)
+		}
+	}
+
+	return admittedContainers
 }
 
 func UpdateCurrentEstimatorStatus(estimator estimator.ResourceEstimatorInstance, containerName string, resourceList corev1.ResourceList, currentEstimatorStatus []autoscalingapi.ResourceEstimatorStatus) []autoscalingapi.ResourceEstimatorStatus {
@@ -120,7 +464,22 @@ func UpdateCurrentEstimatorStatus(estimator estimator.ResourceEstimatorInstance,
 	return newStatus
 }
 
-// CheckContainerScalingCondition check the conditions for container with scale direction
+// GetScaleDirection returns the direction the recommendation moves the container requests. It is ScaleUp if any
+// recommended resource is above its request or not requested yet, the raise of a resource is riskier to be held than
+// the drop of another one.
+func GetScaleDirection(containerResource corev1.ResourceList, recommendContainerResource corev1.ResourceList) ScaleDirection {
+	for resourceName, recommended := range recommendContainerResource {
+		requested, exists := containerResource[resourceName]
+		if !exists || recommended.Cmp(requested) > 0 {
+			return ScaleUp
+		}
+	}
+	return ScaleDown
+}
+
+// CheckContainerScalingCondition check the conditions for container with scale direction. The stabilization window
+// is the cooldown of the direction, a scale up waits for the window since the last scale up, while a scale down
+// waits for a quiet period of the window since the last scaling of any direction, so the riskier scale down is rarer.
 func (c *EffectiveVPAController) CheckContainerScalingCondition(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerPolicy autoscalingapi.ContainerResourcePolicy, scalingPolicy *autoscalingapi.ContainerScalingPolicy, direction ScaleDirection, containerResource corev1.ResourceList, recommendContainerResource corev1.ResourceList) (bool, string) {
 	if scalingPolicy == nil {
 		return true, ""
@@ -135,6 +494,12 @@ func (c *EffectiveVPAController) CheckContainerScalingCondition(evpa *autoscalin
 	}
 
 	lastScaleTime := c.GetLastScaleTime(evpa.Namespace, evpa.Spec.TargetRef.Name, containerPolicy.ContainerName, string(direction))
+	if direction == ScaleDown {
+		lastScaleUpTime := c.GetLastScaleTime(evpa.Namespace, evpa.Spec.TargetRef.Name, containerPolicy.ContainerName, string(ScaleUp))
+		if lastScaleUpTime.After(lastScaleTime.Time) {
+			lastScaleTime = lastScaleUpTime
+		}
+	}
 	stabilizationWindowSeconds := DefaultStabWindowSeconds
 	if scalingPolicy.StabilizationWindowSeconds != nil {
 		stabilizationWindowSeconds = *scalingPolicy.StabilizationWindowSeconds
@@ -184,6 +549,16 @@ func (c *EffectiveVPAController) GetLastScaleTime(namespace string, workload str
 	return c.lastScaleTime[GetScaleEventKey(namespace, workload, container, direction)]
 }
 
+func (c *EffectiveVPAController) SetLastScaleTime(namespace string, workload string, container string, direction string, scaleTime metav1.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lastScaleTime == nil {
+		c.lastScaleTime = map[string]metav1.Time{}
+	}
+	c.lastScaleTime[GetScaleEventKey(namespace, workload, container, direction)] = scaleTime
+}
+
 func (c *EffectiveVPAController) DeleteLastScaleTime(namespace string, workload string, container string, direction string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -226,12 +601,20 @@ func UpdateRecommendStatus(recommendation *vpatypes.RecommendedPodResources, con
 // GetEstimatedResourceForContainer iterate resources based on the result from estimator
 // If priority is equal, use the larger resource value
 // If priority is larger, use the larger estimator's value if value is not Zero
-func GetEstimatedResourceForContainer(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerPolicy autoscalingapi.ContainerResourcePolicy, containerResource *corev1.ResourceRequirements, rankedEstimators []ResourceEstimatorInstanceRanked, currentEstimatorStatus []autoscalingapi.ResourceEstimatorStatus) (corev1.ResourceList, []autoscalingapi.ResourceEstimatorStatus) {
+// It also returns whether any estimator is skipped because its prediction model is not ready yet
+func GetEstimatedResourceForContainer(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, containerPolicy autoscalingapi.ContainerResourcePolicy, containerResource *corev1.ResourceRequirements, rankedEstimators []ResourceEstimatorInstanceRanked, currentEstimatorStatus []autoscalingapi.ResourceEstimatorStatus) (corev1.ResourceList, []autoscalingapi.ResourceEstimatorStatus, bool) {
 	var resourcePrePriorityList []corev1.ResourceList
+	modelNotReady := false
 	for _, estimatorList := range rankedEstimators {
 		resourcePrePriority := corev1.ResourceList{}
 		for _, estimator := range estimatorList.Estimators {
-			resourcesEstimated, err := estimator.GetResourceEstimation(evpa, estimator.GetSpec().Config, containerPolicy.ContainerName, containerResource)
+			resourcesEstimated, err := estimator.GetResourceEstimation(ctx, evpa, estimator.GetSpec().Config, containerPolicy.ContainerName, containerResource)
+			if isModelNotReady(err) {
+				// the model is warming up, back off quietly
+				klog.V(4).Infof("Prediction model not ready, type %s container %s: %v", estimator.GetSpec().Type, containerPolicy.ContainerName, err)
+				modelNotReady = true
+				continue
+			}
 			if err != nil {
 				klog.Warningf("Get resource estimator failed, type %s config %v container %s error %v", estimator.GetSpec().Type, estimator.GetSpec().Config, containerPolicy.ContainerName, err)
 				continue
@@ -255,7 +638,12 @@ func GetEstimatedResourceForContainer(evpa *autoscalingapi.EffectiveVerticalPodA
 	}
 
 	// Use the highest priority value
-	return CalculateResourceByPriority(resourcePrePriorityList), currentEstimatorStatus
+	return CalculateResourceByPriority(resourcePrePriorityList), currentEstimatorStatus, modelNotReady
+}
+
+// isModelNotReady tells whether the estimation failed because the prediction model is warming up
+func isModelNotReady(err error) bool {
+	return errors.Is(err, estimator.ErrModelNotReady)
 }
 
 func CalculateResourceByValue(resourceByValue corev1.ResourceList, resourcesEstimated corev1.ResourceList) {
@@ -1,15 +1,28 @@
 package evpa
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	autoscalingv2 "k8s.io/api/autoscaling/v2beta2"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	vpatypes "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
 
 	"github.com/gocrane/crane/pkg/autoscaling/estimator"
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
 )
 
 type TestResourceEstimatorInstance struct {
@@ -249,3 +262,412 @@ func TestResourceWithTolerance(t *testing.T) {
 		}
 	}
 }
+
+// fakeApprover approves the containers in the approved set
+type fakeApprover struct {
+	approved map[string]bool
+	err      error
+}
+
+func (a *fakeApprover) Approve(ctx context.Context, request *estimator.ApprovalRequest) (*estimator.ApprovalResponse, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &estimator.ApprovalResponse{Approved: a.approved[request.ContainerName], Reason: "rejected by test"}, nil
+}
+
+func TestHoldUnapprovedChanges(t *testing.T) {
+	evpa := &autoscalingapi.EffectiveVerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "evpa", Namespace: "default"},
+		Spec: autoscalingapi.EffectiveVerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "nginx"},
+		},
+	}
+	newChanges := func() map[string]v1.ResourceList {
+		return map[string]v1.ResourceList{
+			"approved": {v1.ResourceCPU: resource.MustParse("1")},
+			"rejected": {v1.ResourceCPU: resource.MustParse("2")},
+		}
+	}
+	recorder := record.NewFakeRecorder(10)
+	c := &EffectiveVPAController{
+		Recorder: recorder,
+		Approver: &fakeApprover{approved: map[string]bool{"approved": true}},
+	}
+
+	changes := newChanges()
+	c.holdUnapprovedChanges(context.TODO(), evpa, map[string]*v1.ResourceRequirements{}, changes)
+	assert.Len(t, changes, 1)
+	assert.Contains(t, changes, "approved")
+	assert.Contains(t, <-recorder.Events, estimator.ReasonPendingApproval)
+
+	// the webhook is unavailable, hold all
+	c.Approver = &fakeApprover{err: fmt.Errorf("timeout")}
+	changes = newChanges()
+	c.holdUnapprovedChanges(context.TODO(), evpa, map[string]*v1.ResourceRequirements{}, changes)
+	assert.Empty(t, changes)
+
+	// no approver, surfaced directly
+	c.Approver = nil
+	changes = newChanges()
+	c.holdUnapprovedChanges(context.TODO(), evpa, map[string]*v1.ResourceRequirements{}, changes)
+	assert.Len(t, changes, 2)
+}
+
+func TestDropGloballyDisabledChanges(t *testing.T) {
+	evpa := &autoscalingapi.EffectiveVerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "evpa", Namespace: "default"},
+	}
+	newChanges := func() map[string]v1.ResourceList {
+		return map[string]v1.ResourceList{"nginx": {v1.ResourceCPU: resource.MustParse("1")}}
+	}
+	recorder := record.NewFakeRecorder(10)
+	c := &EffectiveVPAController{Recorder: recorder, KillSwitch: &estimator.KillSwitch{Disabled: true}}
+
+	changes := newChanges()
+	c.dropGloballyDisabledChanges(context.TODO(), evpa, changes)
+	assert.Empty(t, changes)
+	assert.Contains(t, <-recorder.Events, estimator.ReasonGloballyDisabled)
+
+	// switched off, the changes resume
+	c.KillSwitch.Disabled = false
+	changes = newChanges()
+	c.dropGloballyDisabledChanges(context.TODO(), evpa, changes)
+	assert.Len(t, changes, 1)
+
+	c.KillSwitch = nil
+	changes = newChanges()
+	c.dropGloballyDisabledChanges(context.TODO(), evpa, changes)
+	assert.Len(t, changes, 1)
+}
+
+func TestSkipAppliedChanges(t *testing.T) {
+	evpa := &autoscalingapi.EffectiveVerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "evpa", Namespace: "default"},
+		Spec: autoscalingapi.EffectiveVerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "nginx"},
+		},
+		Status: autoscalingapi.EffectiveVerticalPodAutoscalerStatus{
+			Recommendation: &vpatypes.RecommendedPodResources{
+				ContainerRecommendations: []vpatypes.RecommendedContainerResources{{
+					ContainerName: "applied",
+					Target:        v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+				}, {
+					ContainerName: "changed",
+					Target:        v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+				}},
+			},
+		},
+	}
+	changes := map[string]v1.ResourceList{
+		"applied": {v1.ResourceCPU: resource.MustParse("1000m")},
+		"changed": {v1.ResourceCPU: resource.MustParse("2")},
+		"new":     {v1.ResourceCPU: resource.MustParse("1")},
+	}
+
+	c := &EffectiveVPAController{}
+	c.skipAppliedChanges(evpa, changes)
+	assert.Len(t, changes, 2)
+	assert.NotContains(t, changes, "applied")
+}
+
+func TestCheckContainerScalingConditionCooldown(t *testing.T) {
+	evpa := &autoscalingapi.EffectiveVerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "evpa", Namespace: "default"},
+		Spec: autoscalingapi.EffectiveVerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "nginx"},
+		},
+	}
+	upWindow, downWindow := int32(150), int32(43200)
+	containerPolicy := autoscalingapi.ContainerResourcePolicy{
+		ContainerName:   "nginx",
+		ScaleUpPolicy:   &autoscalingapi.ContainerScalingPolicy{StabilizationWindowSeconds: &upWindow},
+		ScaleDownPolicy: &autoscalingapi.ContainerScalingPolicy{StabilizationWindowSeconds: &downWindow},
+	}
+	current := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+	up := v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}
+	down := v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")}
+	c := &EffectiveVPAController{}
+
+	// never scaled
+	shouldScale, _ := c.CheckContainerScalingCondition(evpa, containerPolicy, containerPolicy.ScaleUpPolicy, ScaleUp, current, up)
+	assert.True(t, shouldScale)
+	shouldScale, _ = c.CheckContainerScalingCondition(evpa, containerPolicy, containerPolicy.ScaleDownPolicy, ScaleDown, current, down)
+	assert.True(t, shouldScale)
+
+	// scaled up 10 minutes ago, a scale up is prompt but a scale down waits for the longer quiet period
+	c.SetLastScaleTime("default", "nginx", "nginx", string(ScaleUp), metav1.NewTime(time.Now().Add(-10*time.Minute)))
+	shouldScale, _ = c.CheckContainerScalingCondition(evpa, containerPolicy, containerPolicy.ScaleUpPolicy, ScaleUp, current, up)
+	assert.True(t, shouldScale)
+	shouldScale, msg := c.CheckContainerScalingCondition(evpa, containerPolicy, containerPolicy.ScaleDownPolicy, ScaleDown, current, down)
+	assert.False(t, shouldScale)
+	assert.Equal(t, "In stabilization window", msg)
+
+	// scaled up a minute ago, still in the scale up cooldown
+	c.SetLastScaleTime("default", "nginx", "nginx", string(ScaleUp), metav1.NewTime(time.Now().Add(-time.Minute)))
+	shouldScale, _ = c.CheckContainerScalingCondition(evpa, containerPolicy, containerPolicy.ScaleUpPolicy, ScaleUp, current, up)
+	assert.False(t, shouldScale)
+
+	// quiet for 13 hours, both directions are allowed
+	c.SetLastScaleTime("default", "nginx", "nginx", string(ScaleUp), metav1.NewTime(time.Now().Add(-13*time.Hour)))
+	c.SetLastScaleTime("default", "nginx", "nginx", string(ScaleDown), metav1.NewTime(time.Now().Add(-14*time.Hour)))
+	shouldScale, _ = c.CheckContainerScalingCondition(evpa, containerPolicy, containerPolicy.ScaleUpPolicy, ScaleUp, current, up)
+	assert.True(t, shouldScale)
+	shouldScale, _ = c.CheckContainerScalingCondition(evpa, containerPolicy, containerPolicy.ScaleDownPolicy, ScaleDown, current, down)
+	assert.True(t, shouldScale)
+
+	c.CleanLastScaleTime(&autoscalingapi.EffectiveVerticalPodAutoscaler{
+		ObjectMeta: evpa.ObjectMeta,
+		Spec: autoscalingapi.EffectiveVerticalPodAutoscalerSpec{
+			TargetRef:      evpa.Spec.TargetRef,
+			ResourcePolicy: &autoscalingapi.PodResourcePolicy{ContainerPolicies: []autoscalingapi.ContainerResourcePolicy{containerPolicy}},
+		},
+	})
+	lastScaleTime := c.GetLastScaleTime("default", "nginx", "nginx", string(ScaleUp))
+	assert.True(t, lastScaleTime.IsZero())
+}
+
+// notReadyEstimator fails as its prediction model is warming up
+type notReadyEstimator struct {
+	estimator.ProportionalResourceEstimator
+}
+
+func (e *notReadyEstimator) GetResourceEstimation(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, config map[string]string, containerName string, currRes *v1.ResourceRequirements) (v1.ResourceList, error) {
+	return nil, fmt.Errorf("all resource predicted failed: %w", estimator.ErrModelNotReady)
+}
+
+func TestGetEstimatedResourceForContainerModelNotReady(t *testing.T) {
+	evpa := &autoscalingapi.EffectiveVerticalPodAutoscaler{}
+	containerPolicy := autoscalingapi.ContainerResourcePolicy{ContainerName: "nginx"}
+	currRes := &v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}
+	proportional := &TestResourceEstimatorInstance{
+		ResourceEstimator: &estimator.ProportionalResourceEstimator{},
+		Spec:              autoscalingapi.ResourceEstimator{Type: "Proportional", Priority: 1},
+	}
+	notReady := &TestResourceEstimatorInstance{
+		ResourceEstimator: &notReadyEstimator{},
+		Spec:              autoscalingapi.ResourceEstimator{Type: "NotReady", Priority: 1},
+	}
+
+	resources, _, modelNotReady := GetEstimatedResourceForContainer(context.TODO(), evpa, containerPolicy, currRes, RankEstimators([]estimator.ResourceEstimatorInstance{notReady, proportional}), nil)
+	assert.True(t, modelNotReady)
+	assert.Equal(t, "500m", resources.Cpu().String())
+
+	_, _, modelNotReady = GetEstimatedResourceForContainer(context.TODO(), evpa, containerPolicy, currRes, RankEstimators([]estimator.ResourceEstimatorInstance{proportional}), nil)
+	assert.False(t, modelNotReady)
+}
+
+// fakeUsageHistory serves the observed usage by the resource, it records the queries
+type fakeUsageHistory struct {
+	usage   map[string]float64
+	queries []string
+	ranges  [][2]time.Time
+}
+
+func (h *fakeUsageHistory) QueryTimeSeries(namer metricnaming.MetricNamer, startTime time.Time, endTime time.Time, step time.Duration) ([]*common.TimeSeries, error) {
+	metric := namer.(*metricnaming.GeneralMetricNamer).Metric
+	h.queries = append(h.queries, metric.Prom.QueryExpr)
+	h.ranges = append(h.ranges, [2]time.Time{startTime, endTime})
+	usage, exists := h.usage[metric.MetricName]
+	if !exists {
+		return nil, nil
+	}
+	ts := common.NewTimeSeries()
+	ts.AppendSample(endTime.Unix(), usage)
+	return []*common.TimeSeries{ts}, nil
+}
+
+func TestDampenSmallChanges(t *testing.T) {
+	evpa := &autoscalingapi.EffectiveVerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "evpa", Namespace: "default"},
+		Spec: autoscalingapi.EffectiveVerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "nginx"},
+		},
+		Status: autoscalingapi.EffectiveVerticalPodAutoscalerStatus{
+			Recommendation: &vpatypes.RecommendedPodResources{
+				ContainerRecommendations: []vpatypes.RecommendedContainerResources{{
+					ContainerName: "drift",
+					Target:        v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")},
+				}, {
+					ContainerName: "jump",
+					Target:        v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+				}},
+			},
+		},
+	}
+	requirements := map[string]*v1.ResourceRequirements{
+		"unapplied": {Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}},
+	}
+	newChanges := func() map[string]v1.ResourceList {
+		return map[string]v1.ResourceList{
+			"drift":     {v1.ResourceCPU: resource.MustParse("1050m"), v1.ResourceMemory: resource.MustParse("1000Mi")},
+			"jump":      {v1.ResourceCPU: resource.MustParse("1200m")},
+			"unapplied": {v1.ResourceCPU: resource.MustParse("1900m")},
+			"new":       {v1.ResourceCPU: resource.MustParse("1")},
+		}
+	}
+
+	// the changes within 10% are dampened, compared to the current requests if no recommendation is applied
+	c := &EffectiveVPAController{Config: EvpaControllerConfig{ChangeDampeningPercentage: 10}}
+	changes := newChanges()
+	c.dampenSmallChanges(context.TODO(), evpa, requirements, changes)
+	assert.Len(t, changes, 2)
+	assert.Contains(t, changes, "jump")
+	assert.Contains(t, changes, "new")
+
+	// the upper bound of the recommendation exceeds the request, the change is kept
+	evpa.Status.Recommendation.ContainerRecommendations[0].UpperBound = v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")}
+	requirements["drift"] = &v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")}}
+	changes = newChanges()
+	c.dampenSmallChanges(context.TODO(), evpa, requirements, changes)
+	assert.Len(t, changes, 3)
+	assert.Contains(t, changes, "drift")
+	evpa.Status.Recommendation.ContainerRecommendations[0].UpperBound = nil
+
+	// the cpu usage observed in the pods of the target selector exceeds the request
+	pod := newRunningPod("nginx-a", time.Now(), "2")
+	pod.Spec.Containers[0].Name = "unapplied"
+	otherPod := newRunningPod("nginx-other", time.Now(), "1")
+	otherPod.Labels = map[string]string{"app": "other"}
+	history := &fakeUsageHistory{usage: map[string]float64{"cpu": 2.5}}
+	c.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(pod, otherPod).Build()
+	c.TargetFetcher = &fakeSelectorFetcher{}
+	c.HistoryProvider = history
+	changes = newChanges()
+	c.dampenSmallChanges(context.TODO(), evpa, requirements, changes)
+	assert.Len(t, changes, 4)
+	assert.Contains(t, changes, "unapplied")
+	assert.Contains(t, history.queries, `irate(container_cpu_usage_seconds_total{container!="POD",namespace="default",pod=~"^(nginx-a)$",container="unapplied"}[3m])`)
+
+	// the usage is within the requests
+	history.usage = map[string]float64{"cpu": 0.5}
+	changes = newChanges()
+	c.dampenSmallChanges(context.TODO(), evpa, requirements, changes)
+	assert.Len(t, changes, 2)
+
+	// no dampening
+	c.Config.ChangeDampeningPercentage = 0
+	changes = newChanges()
+	c.dampenSmallChanges(context.TODO(), evpa, requirements, changes)
+	assert.Len(t, changes, 4)
+}
+
+func TestRequestExceededBatches(t *testing.T) {
+	evpa := &autoscalingapi.EffectiveVerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "evpa", Namespace: "default"},
+		Spec: autoscalingapi.EffectiveVerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "nginx"},
+		},
+	}
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	builder := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+	for i := 0; i < observedUsagePodBatchSize+1; i++ {
+		builder = builder.WithObjects(newRunningPod(fmt.Sprintf("nginx-%d", i), now, "1"))
+	}
+	history := &fakeUsageHistory{}
+	c := &EffectiveVPAController{
+		Client:          builder.Build(),
+		TargetFetcher:   &fakeSelectorFetcher{},
+		HistoryProvider: history,
+		Clock:           clock.NewFakeClock(now),
+	}
+	requests := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")}
+
+	// the pods are queried in two batches of each resource, since the lookback of the injected clock
+	exceeded, _ := c.requestExceeded(context.TODO(), evpa, "nginx", requests)
+	assert.False(t, exceeded)
+	assert.Len(t, history.queries, 4)
+	assert.Equal(t, observedUsagePodBatchSize-1, strings.Count(history.queries[0], "|"))
+	assert.NotContains(t, history.queries[1], "|")
+	for _, queryRange := range history.ranges {
+		assert.Equal(t, [2]time.Time{now.Add(-observedUsageLookback), now}, queryRange)
+	}
+
+	// the usage of the first batch exceeds the request, the other batches are not queried
+	history.queries, history.usage = nil, map[string]float64{"cpu": 1.5}
+	exceeded, resourceName := c.requestExceeded(context.TODO(), evpa, "nginx", requests)
+	assert.True(t, exceeded)
+	assert.Equal(t, v1.ResourceCPU, resourceName)
+	assert.Len(t, history.queries, 1)
+
+	// no query after the reconcile is canceled
+	history.queries = nil
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	exceeded, _ = c.requestExceeded(ctx, evpa, "nginx", requests)
+	assert.False(t, exceeded)
+	assert.Empty(t, history.queries)
+}
+
+func TestGetScaleDirection(t *testing.T) {
+	current := v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")}
+	assert.Equal(t, ScaleUp, GetScaleDirection(current, v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}))
+	assert.Equal(t, ScaleDown, GetScaleDirection(current, v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")}))
+	// the raise of any resource is a scale up
+	assert.Equal(t, ScaleUp, GetScaleDirection(current, v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("2Gi")}))
+	assert.Equal(t, ScaleUp, GetScaleDirection(v1.ResourceList{}, v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")}))
+}
+
+// reconcileProportional reconciles the container nginx requesting 1 cpu, the proportional estimator scales it down
+func reconcileProportional(t *testing.T, c *EffectiveVPAController, containerPolicy autoscalingapi.ContainerResourcePolicy) *vpatypes.RecommendedPodResources {
+	evpa := &autoscalingapi.EffectiveVerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "evpa", Namespace: "default"},
+		Spec: autoscalingapi.EffectiveVerticalPodAutoscalerSpec{
+			TargetRef:      &autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "nginx"},
+			ResourcePolicy: &autoscalingapi.PodResourcePolicy{ContainerPolicies: []autoscalingapi.ContainerResourcePolicy{containerPolicy}},
+		},
+		Status: autoscalingapi.EffectiveVerticalPodAutoscalerStatus{Recommendation: &vpatypes.RecommendedPodResources{}},
+	}
+	podTemplate := &v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{{
+		Name:      "nginx",
+		Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}},
+	}}}}
+	proportional := &TestResourceEstimatorInstance{
+		ResourceEstimator: &estimator.ProportionalResourceEstimator{},
+		Spec:              autoscalingapi.ResourceEstimator{Type: "Proportional", Priority: 1},
+	}
+	_, recommendation, _, err := c.ReconcileContainerPolicies(context.TODO(), evpa, podTemplate, []estimator.ResourceEstimatorInstance{proportional})
+	assert.NoError(t, err)
+	return recommendation
+}
+
+func TestReconcileContainerPoliciesDirection(t *testing.T) {
+	window := int32(3600)
+	containerPolicy := autoscalingapi.ContainerResourcePolicy{
+		ContainerName:   "nginx",
+		ScaleDownPolicy: &autoscalingapi.ContainerScalingPolicy{StabilizationWindowSeconds: &window},
+	}
+
+	// the scale down is held by the scale down policy although no scale up policy is set
+	c := &EffectiveVPAController{}
+	c.SetLastScaleTime("default", "nginx", "nginx", string(ScaleDown), metav1.NewTime(time.Now().Add(-time.Minute)))
+	recommendation := reconcileProportional(t, c, containerPolicy)
+	assert.Empty(t, recommendation.ContainerRecommendations)
+
+	// out of the window, the scale down is recorded in its direction
+	c = &EffectiveVPAController{}
+	recommendation = reconcileProportional(t, c, containerPolicy)
+	if assert.Len(t, recommendation.ContainerRecommendations, 1) {
+		assert.Equal(t, "500m", recommendation.ContainerRecommendations[0].Target.Cpu().String())
+	}
+	lastScaleTime := c.GetLastScaleTime("default", "nginx", "nginx", string(ScaleDown))
+	assert.False(t, lastScaleTime.IsZero())
+	lastScaleTime = c.GetLastScaleTime("default", "nginx", "nginx", string(ScaleUp))
+	assert.True(t, lastScaleTime.IsZero())
+}
+
+func TestReconcileContainerPoliciesScaleDownPolicy(t *testing.T) {
+	off := vpatypes.ContainerScalingModeOff
+	// the scale down is checked by the scale down policy, turning off the scale up doesn't hold it
+	recommendation := reconcileProportional(t, &EffectiveVPAController{}, autoscalingapi.ContainerResourcePolicy{
+		ContainerName: "nginx",
+		ScaleUpPolicy: &autoscalingapi.ContainerScalingPolicy{ScaleMode: &off},
+	})
+	assert.Len(t, recommendation.ContainerRecommendations, 1)
+
+	recommendation = reconcileProportional(t, &EffectiveVPAController{}, autoscalingapi.ContainerResourcePolicy{
+		ContainerName:   "nginx",
+		ScaleDownPolicy: &autoscalingapi.ContainerScalingPolicy{ScaleMode: &off},
+	})
+	assert.Empty(t, recommendation.ContainerRecommendations)
+}
@@ -3,19 +3,25 @@ package evpa
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
 	vpatypes "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
@@ -25,6 +31,7 @@ import (
 	"github.com/gocrane/crane/pkg/metrics"
 	"github.com/gocrane/crane/pkg/oom"
 	"github.com/gocrane/crane/pkg/prediction"
+	"github.com/gocrane/crane/pkg/providers"
 	"github.com/gocrane/crane/pkg/utils"
 	"github.com/gocrane/crane/pkg/utils/target"
 )
@@ -38,8 +45,33 @@ type EffectiveVPAController struct {
 	EstimatorManager estimator.ResourceEstimatorManager
 	lastScaleTime    map[string]metav1.Time
 	Predictor        prediction.Interface
-	TargetFetcher    target.SelectorFetcher
-	mu               sync.Mutex
+	// ForecastPredictor serves the forecasting estimators, such as the dsp predictor, it is optional
+	ForecastPredictor prediction.Interface
+	TargetFetcher     target.SelectorFetcher
+	HistoryProvider   providers.History
+	Config            EvpaControllerConfig
+	ChangeBudget      *estimator.ChangeBudget
+	// Approver approves the recommendation before it is surfaced, it is optional
+	Approver estimator.Approver
+	// KillSwitch stops all the recommendation changes when active, it is optional
+	KillSwitch *estimator.KillSwitch
+	// KubeClient resizes or evicts the pods of the evpas in the InPlace update mode
+	KubeClient kubernetes.Interface
+	mu         sync.Mutex
+	// resizeSupported caches whether the apiserver serves the resize subresource of the pods, detected at the
+	// resizeDetectedAt
+	resizeSupported  bool
+	resizeDetectedAt time.Time
+	resizeMu         sync.Mutex
+	// Clock is the clock of the observed usage queries and the detection cache, it defaults to the real clock
+	Clock clock.Clock
+}
+
+func (c *EffectiveVPAController) now() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock.Now()
 }
 
 func (c *EffectiveVPAController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -87,8 +119,11 @@ func (c *EffectiveVPAController) Reconcile(ctx context.Context, req ctrl.Request
 	}
 
 	if evpa.DeletionTimestamp != nil {
-		c.EstimatorManager.DeleteEstimators(evpa) // release estimators
-		c.CleanLastScaleTime(evpa)                // clean last scale time
+		// release estimators, the failed deletions don't block the finalizer, the queries are released on restart
+		if err := c.EstimatorManager.DeleteEstimators(ctx, evpa); err != nil {
+			klog.ErrorS(err, "Failed to delete estimators.", "evpa", klog.KObj(evpa))
+		}
+		c.CleanLastScaleTime(evpa) // clean last scale time
 
 		evpaCopy := evpa.DeepCopy()
 		evpaCopy.Finalizers = utils.RemoveString(evpaCopy.Finalizers, known.AutoscalingFinalizer)
@@ -118,7 +153,8 @@ func (c *EffectiveVPAController) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, nil
 	}
 
-	currentEstimatorStatus, recommend, err := c.ReconcileContainerPolicies(evpa, podTemplate, estimators)
+	// the containers and the estimators share the target workload selector within the reconcile
+	currentEstimatorStatus, recommend, modelNotReady, err := c.ReconcileContainerPolicies(estimator.WithSelectorCache(ctx), evpa, podTemplate, estimators)
 	if err != nil {
 		c.Recorder.Event(evpa, v1.EventTypeWarning, "FailedReconcileContainerPolicies", err.Error())
 		klog.Errorf("Failed to reconcile container policies, evpa %s", klog.KObj(evpa))
@@ -130,10 +166,22 @@ func (c *EffectiveVPAController) Reconcile(ctx context.Context, req ctrl.Request
 	newStatus.Recommendation = recommend
 	newStatus.CurrentEstimators = currentEstimatorStatus
 
+	setConfidenceCondition(evpa, newStatus, estimators)
 	recordMetric(evpa, newStatus, podTemplate)
 	setCondition(newStatus, EffectiveVPAConditionTypeReady, metav1.ConditionTrue, "EffectiveVerticalPodAutoscaler", "EffectiveVerticalPodAutoscaler is ready")
 	c.UpdateStatus(ctx, evpa, newStatus)
 
+	if isInPlaceUpdateMode(evpa) {
+		c.applyInPlace(ctx, evpa, newStatus.Recommendation)
+	}
+
+	// the prediction models take a while to warm up, don't poll them at the regular rsync period
+	if modelNotReady {
+		return ctrl.Result{
+			RequeueAfter: DefaultEVPAModelNotReadyRsyncPeriod,
+		}, nil
+	}
+
 	return ctrl.Result{
 		RequeueAfter: DefaultEVPARsyncPeriod,
 	}, nil
@@ -156,8 +204,47 @@ func (c *EffectiveVPAController) UpdateStatus(ctx context.Context, evpa *autosca
 }
 
 func (c *EffectiveVPAController) SetupWithManager(mgr ctrl.Manager) error {
-	estimatorManager := estimator.NewResourceEstimatorManager(mgr.GetClient(), c.TargetFetcher, c.OOMRecorder, c.Predictor)
+	if c.KillSwitch == nil && (c.Config.KillSwitch || c.Config.KillSwitchConfigMap != "") {
+		c.KillSwitch = &estimator.KillSwitch{Disabled: c.Config.KillSwitch, Client: mgr.GetClient()}
+		if c.Config.KillSwitchConfigMap != "" {
+			namespace, name, err := cache.SplitMetaNamespaceKey(c.Config.KillSwitchConfigMap)
+			if err != nil {
+				return fmt.Errorf("parse kill switch configmap failed: %v", err)
+			}
+			c.KillSwitch.Namespace, c.KillSwitch.Name = namespace, name
+		}
+	}
+	estimatorManager := estimator.NewResourceEstimatorManager(mgr.GetClient(), c.TargetFetcher, c.OOMRecorder, c.Predictor, c.ForecastPredictor, c.HistoryProvider, c.KillSwitch, c.Config.CallerPrefix, c.Recorder)
 	c.EstimatorManager = estimatorManager
+	// the connections of the estimators are closed when the manager stops
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return estimatorManager.Close()
+	})); err != nil {
+		return fmt.Errorf("add evpa estimators closer failed: %v", err)
+	}
+	// the evpas are not reconciled well until the predictor can serve, the health of each estimator is also reported
+	// by the crane_estimator_ready metric. The probe returns the result of the periodic checks.
+	readiness := newEstimatorReadiness(estimatorManager)
+	if err := mgr.Add(readiness); err != nil {
+		return fmt.Errorf("add evpa estimators readiness checker failed: %v", err)
+	}
+	if err := mgr.AddReadyzCheck("evpa-estimators", readiness.Checker); err != nil {
+		return fmt.Errorf("add evpa estimators readiness check failed: %v", err)
+	}
+	if c.Config.ChangeBudgetLimit > 0 {
+		c.ChangeBudget = estimator.NewChangeBudget(c.Config.ChangeBudgetLimit, c.Config.ChangeBudgetPeriod)
+	}
+	if c.Approver == nil && c.Config.ApprovalWebhookURL != "" {
+		c.Approver = estimator.NewWebhookApprover(c.Config.ApprovalWebhookURL, c.Config.ApprovalWebhookTimeout)
+	}
+	if c.KubeClient == nil {
+		kubeClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			return err
+		}
+		c.KubeClient = kubeClient
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&autoscalingapi.EffectiveVerticalPodAutoscaler{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Complete(c)
@@ -233,6 +320,32 @@ func recordMetric(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, status *a
 	}
 }
 
+// setConfidenceCondition surfaces the confidences of the estimators reporting them, the condition is false if any
+// recommendation is deferred for the low confidence
+func setConfidenceCondition(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, status *autoscalingapi.EffectiveVerticalPodAutoscalerStatus, estimators []estimator.ResourceEstimatorInstance) {
+	var confidences []string
+	confident := true
+	for _, containerPolicy := range evpa.Spec.ResourcePolicy.ContainerPolicies {
+		for _, resourceEstimator := range estimators {
+			confidence, found := estimator.ConfidenceOf(resourceEstimator, evpa, containerPolicy.ContainerName)
+			if !found {
+				continue
+			}
+			confidences = append(confidences, fmt.Sprintf("%s/%s: %.2f (%d samples)", resourceEstimator.GetSpec().Type, containerPolicy.ContainerName, confidence.Score, confidence.Samples))
+			confident = confident && confidence.Confident
+		}
+	}
+	if len(confidences) == 0 {
+		return
+	}
+	message := "Confidence " + strings.Join(confidences, ", ")
+	if confident {
+		setCondition(status, EffectiveVPAConditionTypeConfident, metav1.ConditionTrue, "EnoughSamples", message)
+	} else {
+		setCondition(status, EffectiveVPAConditionTypeConfident, metav1.ConditionFalse, estimator.ReasonLowConfidence, message)
+	}
+}
+
 // nolint:unparam
 func setCondition(status *autoscalingapi.EffectiveVerticalPodAutoscalerStatus, conditionType string, conditionStatus metav1.ConditionStatus, reason string, message string) {
 	for i := range status.Conditions {
@@ -246,7 +359,7 @@ func setCondition(status *autoscalingapi.EffectiveVerticalPodAutoscalerStatus, c
 	}
 
 	status.Conditions = append(status.Conditions, metav1.Condition{
-		Type:               "Ready",
+		Type:               conditionType,
 		Status:             conditionStatus,
 		Reason:             reason,
 		Message:            message,
@@ -0,0 +1,170 @@
+package evpa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	vpatypes "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog/v2"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/utils"
+)
+
+// UpdateModeInPlace is the ScaleUpdateMode that resizes the requests of the running pods by the resize subresource
+// instead of recreating them, it needs the InPlacePodVerticalScaling of kubernetes. The pods are evicted as the
+// Recreate mode if the cluster doesn't support it.
+const UpdateModeInPlace vpatypes.UpdateMode = "InPlace"
+
+// podResizeSubresource is served by the apiserver if the InPlacePodVerticalScaling is enabled
+const podResizeSubresource = "resize"
+
+// inPlaceResizeSupported tells whether the apiserver serves the resize subresource of the pods
+func inPlaceResizeSupported(discoveryClient discovery.DiscoveryInterface) (bool, error) {
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(corev1.SchemeGroupVersion.String())
+	if err != nil {
+		return false, err
+	}
+	for _, apiResource := range resources.APIResources {
+		if apiResource.Name == "pods/"+podResizeSubresource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isInPlaceUpdateMode(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler) bool {
+	return evpa.Spec.UpdatePolicy != nil && evpa.Spec.UpdatePolicy.UpdateMode != nil && *evpa.Spec.UpdatePolicy.UpdateMode == UpdateModeInPlace
+}
+
+// inPlaceResizeSupportedCached returns whether the apiserver serves the resize subresource of the pods. The result is
+// cached for the inPlaceResizeDetectionPeriod, so the clusters upgraded later are resized without restarting the
+// controller. The failed detections are not cached.
+func (c *EffectiveVPAController) inPlaceResizeSupportedCached() (bool, error) {
+	c.resizeMu.Lock()
+	defer c.resizeMu.Unlock()
+	if !c.resizeDetectedAt.IsZero() && c.now().Sub(c.resizeDetectedAt) < inPlaceResizeDetectionPeriod {
+		return c.resizeSupported, nil
+	}
+	supported, err := inPlaceResizeSupported(c.KubeClient.Discovery())
+	if err != nil {
+		return false, err
+	}
+	c.resizeSupported, c.resizeDetectedAt = supported, c.now()
+	return supported, nil
+}
+
+// applyInPlace resizes the requests of the target pods to the recommendation. Without the resize subresource it
+// falls back to evict a pod created before the last scaling per reconcile, the recreated pods are not evicted again.
+func (c *EffectiveVPAController) applyInPlace(ctx context.Context, evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, recommendation *vpatypes.RecommendedPodResources) {
+	if recommendation == nil || c.KubeClient == nil {
+		return
+	}
+
+	supported, err := c.inPlaceResizeSupportedCached()
+	if err != nil {
+		klog.Errorf("Failed to detect the in-place pod resize, evpa %s: %v", klog.KObj(evpa), err)
+		return
+	}
+
+	pods, err := c.listTargetPods(ctx, evpa)
+	if err != nil {
+		klog.Errorf("Failed to list the target pods, evpa %s: %v", klog.KObj(evpa), err)
+		return
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		resized := resizedContainers(pod, recommendation)
+		if len(resized) == 0 {
+			continue
+		}
+
+		if supported {
+			if err := c.resizePod(ctx, pod, resized); err != nil {
+				klog.Errorf("Failed to resize pod %s, evpa %s: %v", klog.KObj(pod), klog.KObj(evpa), err)
+				c.Recorder.Event(evpa, corev1.EventTypeWarning, "FailedResizePod", fmt.Sprintf("Pod %s: %v", pod.Name, err))
+				continue
+			}
+			klog.V(4).Infof("Resized pod %s, evpa %s: %v", klog.KObj(pod), klog.KObj(evpa), resized)
+			c.Recorder.Event(evpa, corev1.EventTypeNormal, "ResizedPod", fmt.Sprintf("Resized pod %s in place", pod.Name))
+			continue
+		}
+
+		if !c.createdBeforeLastScale(evpa, pod, resized) {
+			continue
+		}
+		if err := utils.EvictPodWithGracePeriod(c.KubeClient, pod, nil); err != nil {
+			klog.Errorf("Failed to evict pod %s, evpa %s: %v", klog.KObj(pod), klog.KObj(evpa), err)
+			c.Recorder.Event(evpa, corev1.EventTypeWarning, "FailedEvictPod", fmt.Sprintf("Pod %s: %v", pod.Name, err))
+			continue
+		}
+		klog.Infof("Evicted pod %s, evpa %s: in-place resize is not supported", klog.KObj(pod), klog.KObj(evpa))
+		c.Recorder.Event(evpa, corev1.EventTypeNormal, "EvictedPod", fmt.Sprintf("Evicted pod %s, in-place resize is not supported", pod.Name))
+		// evict one pod per reconcile, so the workload is not disrupted at once
+		return
+	}
+}
+
+// resizedContainers returns the recommended requests of the containers whose requests differ from the recommendation
+func resizedContainers(pod *corev1.Pod, recommendation *vpatypes.RecommendedPodResources) map[string]corev1.ResourceList {
+	resized := make(map[string]corev1.ResourceList)
+	for _, container := range pod.Spec.Containers {
+		target := GetContainerTargetResource(recommendation, container.Name)
+		if len(target) == 0 || utils.IsResourceEqual(container.Resources.Requests, target) {
+			continue
+		}
+		requests := corev1.ResourceList{}
+		for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if quantity, exists := target[resourceName]; exists {
+				requests[resourceName] = quantity
+			}
+		}
+		if len(requests) != 0 {
+			resized[container.Name] = requests
+		}
+	}
+	return resized
+}
+
+// resizePod patches the requests of the containers by the resize subresource, the limits are kept
+func (c *EffectiveVPAController) resizePod(ctx context.Context, pod *corev1.Pod, resized map[string]corev1.ResourceList) error {
+	var containers []map[string]interface{}
+	for containerName, requests := range resized {
+		containers = append(containers, map[string]interface{}{
+			"name":      containerName,
+			"resources": map[string]interface{}{"requests": requests},
+		})
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"containers": containers},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.KubeClient.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, podResizeSubresource)
+	return err
+}
+
+// createdBeforeLastScale tells whether the pod is created before the last scaling of any resized container, the
+// pods created since then are not evicted again
+func (c *EffectiveVPAController) createdBeforeLastScale(evpa *autoscalingapi.EffectiveVerticalPodAutoscaler, pod *corev1.Pod, resized map[string]corev1.ResourceList) bool {
+	for containerName := range resized {
+		for _, direction := range []ScaleDirection{ScaleUp, ScaleDown} {
+			lastScaleTime := c.GetLastScaleTime(evpa.Namespace, evpa.Spec.TargetRef.Name, containerName, string(direction))
+			if pod.CreationTimestamp.Before(&lastScaleTime) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package evpa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	autoscalingv2 "k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	vpatypes "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	autoscalingapi "github.com/gocrane/api/autoscaling/v1alpha1"
+)
+
+type fakeSelectorFetcher struct{}
+
+func (f *fakeSelectorFetcher) Fetch(targetRef *v1.ObjectReference) (labels.Selector, error) {
+	return labels.SelectorFromSet(labels.Set{"app": targetRef.Name}), nil
+}
+
+func newRunningPod(name string, created time.Time, cpu string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "nginx"}, CreationTimestamp: metav1.NewTime(created)},
+		Spec: v1.PodSpec{Containers: []v1.Container{{
+			Name:      "nginx",
+			Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse("1Gi")}},
+		}}},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func TestApplyInPlace(t *testing.T) {
+	inPlace := UpdateModeInPlace
+	evpa := &autoscalingapi.EffectiveVerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "evpa", Namespace: "default"},
+		Spec: autoscalingapi.EffectiveVerticalPodAutoscalerSpec{
+			TargetRef:    &autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "nginx"},
+			UpdatePolicy: &vpatypes.PodUpdatePolicy{UpdateMode: &inPlace},
+		},
+	}
+	assert.True(t, isInPlaceUpdateMode(evpa))
+	recommendation := &vpatypes.RecommendedPodResources{
+		ContainerRecommendations: []vpatypes.RecommendedContainerResources{{
+			ContainerName: "nginx",
+			Target:        v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("1Gi")},
+		}},
+	}
+	now := time.Now()
+	pods := []*v1.Pod{
+		newRunningPod("nginx-a", now.Add(-time.Hour), "1"),
+		newRunningPod("nginx-b", now.Add(-time.Hour), "1"),
+		newRunningPod("nginx-c", now.Add(-time.Hour), "2"),
+	}
+	newController := func() (*EffectiveVPAController, *kubefake.Clientset) {
+		kubeClient := kubefake.NewSimpleClientset(pods[0], pods[1], pods[2])
+		c := &EffectiveVPAController{
+			Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(pods[0], pods[1], pods[2]).Build(),
+			Recorder:      record.NewFakeRecorder(10),
+			TargetFetcher: &fakeSelectorFetcher{},
+			KubeClient:    kubeClient,
+		}
+		return c, kubeClient
+	}
+
+	// the pods differing from the recommendation are resized by the resize subresource
+	c, kubeClient := newController()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "pods"}, {Name: "pods/resize"}},
+	}}
+	c.applyInPlace(context.TODO(), evpa, recommendation)
+	var resized []string
+	for _, action := range kubeClient.Actions() {
+		if action.GetVerb() == "patch" && action.GetSubresource() == "resize" {
+			resized = append(resized, action.(interface{ GetName() string }).GetName())
+		}
+	}
+	assert.ElementsMatch(t, []string{"nginx-a", "nginx-b"}, resized)
+
+	// not supported, one pod created before the last scaling is evicted per reconcile
+	c, kubeClient = newController()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "pods"}},
+	}}
+	c.applyInPlace(context.TODO(), evpa, recommendation)
+	for _, action := range kubeClient.Actions() {
+		assert.NotEqual(t, "patch", action.GetVerb())
+		assert.NotEqual(t, "eviction", action.GetSubresource(), "the pods are created after the last scaling")
+	}
+
+	c.SetLastScaleTime(evpa.Namespace, "nginx", "nginx", string(ScaleUp), metav1.NewTime(now))
+	kubeClient.ClearActions()
+	c.applyInPlace(context.TODO(), evpa, recommendation)
+	var evicted, discovered int
+	for _, action := range kubeClient.Actions() {
+		if action.GetVerb() == "create" && action.GetSubresource() == "eviction" {
+			evicted++
+		}
+		if action.GetVerb() == "get" && action.GetResource().Resource == "resource" {
+			discovered++
+		}
+	}
+	assert.Equal(t, 1, evicted)
+	// the detection is cached
+	assert.Equal(t, 0, discovered)
+	assert.Contains(t, <-c.Recorder.(*record.FakeRecorder).Events, "EvictedPod")
+}
@@ -0,0 +1,84 @@
+package evpa
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// readyChecker checks whether the backends of the estimators can serve, it is the estimator.ResourceEstimatorManager
+type readyChecker interface {
+	Ready(ctx context.Context) error
+}
+
+var _ manager.Runnable = &estimatorReadiness{}
+var _ manager.LeaderElectionRunnable = &estimatorReadiness{}
+
+// estimatorReadiness checks the estimators periodically and caches the result for the readyz probe, so the probe
+// doesn't wait for a slow backend. It turns not ready only after the checks failed failureThreshold times in a row,
+// a single slow or failed check doesn't flap the probe.
+type estimatorReadiness struct {
+	checker          readyChecker
+	period           time.Duration
+	timeout          time.Duration
+	failureThreshold int
+
+	mu       sync.RWMutex
+	err      error
+	failures int
+}
+
+func newEstimatorReadiness(checker readyChecker) *estimatorReadiness {
+	return &estimatorReadiness{
+		checker:          checker,
+		period:           estimatorReadinessPeriod,
+		timeout:          estimatorReadinessTimeout,
+		failureThreshold: estimatorReadinessFailureThreshold,
+		err:              fmt.Errorf("the estimators are not checked yet"),
+	}
+}
+
+// Start checks the estimators until the context is done
+func (r *estimatorReadiness) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, r.check, r.period)
+	return nil
+}
+
+// NeedLeaderElection returns false, the readiness of every replica is checked
+func (r *estimatorReadiness) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *estimatorReadiness) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	err := r.checker.Ready(ctx)
+	if err != nil {
+		klog.Warningf("EVPA estimators are not ready: %v", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		r.err, r.failures = nil, 0
+		return
+	}
+	r.failures++
+	// the probe stays not ready until the first check succeeds
+	if r.failures >= r.failureThreshold || r.err != nil {
+		r.err = err
+	}
+}
+
+// Checker is the readyz check returning the cached result
+func (r *estimatorReadiness) Checker(_ *http.Request) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.err
+}
@@ -0,0 +1,40 @@
+package evpa
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeReadyChecker struct {
+	err error
+}
+
+func (f *fakeReadyChecker) Ready(ctx context.Context) error {
+	return f.err
+}
+
+func TestEstimatorReadiness(t *testing.T) {
+	checker := &fakeReadyChecker{err: fmt.Errorf("predictor is down")}
+	readiness := newEstimatorReadiness(checker)
+	assert.False(t, readiness.NeedLeaderElection())
+	// not ready until the first check succeeds
+	assert.Error(t, readiness.Checker(nil))
+	readiness.check(context.TODO())
+	assert.EqualError(t, readiness.Checker(nil), "predictor is down")
+
+	checker.err = nil
+	readiness.check(context.TODO())
+	assert.NoError(t, readiness.Checker(nil))
+
+	// the failed checks below the threshold don't flap the probe
+	checker.err = fmt.Errorf("timeout")
+	for i := 1; i < estimatorReadinessFailureThreshold; i++ {
+		readiness.check(context.TODO())
+		assert.NoError(t, readiness.Checker(nil))
+	}
+	readiness.check(context.TODO())
+	assert.EqualError(t, readiness.Checker(nil), "timeout")
+}
@@ -10,3 +10,10 @@ const (
 	EffectiveHorizontalPodAutoscalerCurrentMetricsAnnotation        = "autoscaling.crane.io/effective-hpa-current-metrics"
 	EffectiveHorizontalPodAutoscalerExternalMetricsAnnotationPrefix = "metric-query.autoscaling.crane.io"
 )
+
+const (
+	// EffectiveVerticalPodAutoscalerBurstableAnnotation marks the workload relies on the node-shared cpu to burst
+	EffectiveVerticalPodAutoscalerBurstableAnnotation = "autoscaling.crane.io/effective-vpa-burstable"
+	// EffectiveVerticalPodAutoscalerRecommendationOverrideAnnotation forces the recommendation temporarily until the expiry
+	EffectiveVerticalPodAutoscalerRecommendationOverrideAnnotation = "autoscaling.crane.io/effective-vpa-recommendation-override"
+)
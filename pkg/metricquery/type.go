@@ -24,6 +24,8 @@ const (
 	ContainerMetricType MetricType = "container"
 	NodeMetricType      MetricType = "node"
 	PromQLMetricType    MetricType = "promql"
+	// DeviceMetricType is the usage of the devices allocated to a container, such as nvidia.com/gpu
+	DeviceMetricType MetricType = "device"
 )
 
 var (
@@ -32,6 +34,7 @@ var (
 	NotMatchPodError       = fmt.Errorf("metric type %v, but no PodNamerInfo provided", PodMetricType)
 	NotMatchNodeError      = fmt.Errorf("metric type %v, but no NodeNamerInfo provided", NodeMetricType)
 	NotMatchPromError      = fmt.Errorf("metric type %v, but no PromNamerInfo provided", PromQLMetricType)
+	NotMatchDeviceError    = fmt.Errorf("metric type %v, but no DeviceNamerInfo provided", DeviceMetricType)
 )
 
 type Metric struct {
@@ -40,7 +43,7 @@ type Metric struct {
 	MetricName string
 	// Workload only support for MetricName cpu/memory
 	Workload *WorkloadNamerInfo
-	// Container only support for MetricName cpu/memory
+	// Container only support for MetricName cpu/memory/ephemeral-storage, or any MetricName with a QueryTemplate
 	Container *ContainerNamerInfo
 	// Pod only support for MetricName cpu/memory
 	Pod *PodNamerInfo
@@ -48,6 +51,8 @@ type Metric struct {
 	Node *NodeNamerInfo
 	// Prom can support any MetricName, user give the promQL
 	Prom *PromNamerInfo
+	// Device support for the MetricName of the extended resource of the device, such as nvidia.com/gpu
+	Device *DeviceNamerInfo
 }
 
 type WorkloadNamerInfo struct {
@@ -67,6 +72,9 @@ type ContainerNamerInfo struct {
 	Name         string
 	// used to fetch workload pods and containers, when use metric server, it is required
 	Selector labels.Selector
+	// QueryTemplate is the promQL of a metric other than cpu/memory/ephemeral-storage, it takes the namespace,
+	// the workload name and the container name by %s in order
+	QueryTemplate string
 }
 
 type PodNamerInfo struct {
@@ -80,6 +88,17 @@ type NodeNamerInfo struct {
 	Selector labels.Selector
 }
 
+// DeviceNamerInfo is the container the devices are allocated to, the usage is in the devices
+type DeviceNamerInfo struct {
+	Namespace     string
+	WorkloadName  string
+	WorkloadKind  string
+	APIVersion    string
+	ContainerName string
+	// used to fetch workload pods and containers, when use metric server, it is required
+	Selector labels.Selector
+}
+
 type PromNamerInfo struct {
 	QueryExpr string
 	Namespace string
@@ -114,6 +133,10 @@ func (m *Metric) ValidateMetric() error {
 		if m.Prom == nil {
 			return NotMatchPromError
 		}
+	case DeviceMetricType:
+		if m.Device == nil {
+			return NotMatchDeviceError
+		}
 	default:
 		return fmt.Errorf("not supported metric type %v, %+v", m.Type, *m)
 	}
@@ -137,6 +160,8 @@ func (m *Metric) BuildUniqueKey() string {
 		return m.keyByNode()
 	case PromQLMetricType:
 		return m.keyByPromQL()
+	case DeviceMetricType:
+		return m.keyByDevice()
 	default:
 		klog.Errorf("Failed to build unique key, not supported metric type %v", m.Type)
 		return ""
@@ -163,12 +188,31 @@ func (m *Metric) keyByContainer() string {
 	if m.Container.Selector != nil {
 		selectorStr = m.Container.Selector.String()
 	}
-	return strings.Join([]string{
+	parts := []string{
 		string(m.Type),
 		strings.ToLower(m.MetricName),
 		m.Container.Namespace,
 		m.Container.WorkloadName,
 		m.Container.Name,
+		selectorStr}
+	// the builtin metrics have no template, their keys are kept
+	if m.Container.QueryTemplate != "" {
+		parts = append(parts, m.Container.QueryTemplate)
+	}
+	return strings.Join(parts, "_")
+}
+
+func (m *Metric) keyByDevice() string {
+	selectorStr := ""
+	if m.Device.Selector != nil {
+		selectorStr = m.Device.Selector.String()
+	}
+	return strings.Join([]string{
+		string(m.Type),
+		strings.ToLower(m.MetricName),
+		m.Device.Namespace,
+		m.Device.WorkloadName,
+		m.Device.ContainerName,
 		selectorStr}, "_")
 }
 
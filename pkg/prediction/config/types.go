@@ -22,8 +22,23 @@ const (
 	ModelInitModeCheckpoint ModelInitMode = "checkpoint"
 )
 
+// PercentileInterpolation is how the percentile is read from the histogram
+type PercentileInterpolation string
+
+const (
+	// the value is the end of the bucket the percentile falls in, it is the default
+	PercentileInterpolationBoundary PercentileInterpolation = "boundary"
+	// the value is interpolated linearly within the bucket the percentile falls in
+	PercentileInterpolationLinear PercentileInterpolation = "linear"
+)
+
 type Config struct {
 	InitMode   *ModelInitMode
 	DSP        *v1alpha1.DSP
 	Percentile *v1alpha1.Percentile
+	// SeasonalityPeriod forces the period of the DSP prediction and skips the auto-detection, zero means auto-detected
+	SeasonalityPeriod time.Duration
+	// PercentileInterpolation is the interpolation of the percentile prediction, empty means boundary. It is omitted
+	// from the json if empty so the hash of the existing configs is unchanged.
+	PercentileInterpolation PercentileInterpolation `json:",omitempty"`
 }
@@ -30,14 +30,20 @@ type internalConfig struct {
 	historyResolution time.Duration
 	historyDuration   time.Duration
 	estimators        []Estimator
+	// seasonalityPeriod is the forced period, zero means auto-detected
+	seasonalityPeriod time.Duration
 }
 
 func (i internalConfig) String() string {
-	return fmt.Sprintf("DSP internal Config: {historyResolution: %s, historyDuration: %v, estimators: %v",
-		i.historyResolution.String(), i.historyDuration.String(), i.estimators)
+	return fmt.Sprintf("DSP internal Config: {historyResolution: %s, historyDuration: %v, estimators: %v, seasonalityPeriod: %v",
+		i.historyResolution.String(), i.historyDuration.String(), i.estimators, i.seasonalityPeriod)
 }
 
-func makeInternalConfig(d *v1alpha1.DSP) (*internalConfig, error) {
+func makeInternalConfig(d *v1alpha1.DSP, seasonalityPeriod time.Duration) (*internalConfig, error) {
+	if seasonalityPeriod < 0 {
+		return nil, fmt.Errorf("seasonalityPeriod must not be negative")
+	}
+
 	historyResolution, err := utils.ParseDuration(d.SampleInterval)
 	if err != nil {
 		return nil, err
@@ -104,5 +110,5 @@ func makeInternalConfig(d *v1alpha1.DSP) (*internalConfig, error) {
 		estimators = defaultEstimators
 	}
 
-	return &internalConfig{historyResolution, historyDuration, estimators}, nil
+	return &internalConfig{historyResolution, historyDuration, estimators, seasonalityPeriod}, nil
 }
@@ -13,7 +13,7 @@ import (
 )
 
 func Debug(predictor prediction.Interface, namer metricnaming.MetricNamer, config *config.Config) (*Signal, *Signal, *Signal, error) {
-	internalConfig, err := makeInternalConfig(config.DSP)
+	internalConfig, err := makeInternalConfig(config.DSP, config.SeasonalityPeriod)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -29,8 +29,8 @@ func Debug(predictor prediction.Interface, namer metricnaming.MetricNamer, confi
 	var nPeriods int
 	var chosenEstimator Estimator
 	for _, ts := range historyTimeSeriesList {
-		periodLength := findPeriod(ts, internalConfig.historyResolution)
-		if periodLength == Day || periodLength == Week {
+		periodLength := detectPeriod(ts, internalConfig)
+		if periodLength > 0 {
 			signal = SamplesToSignal(ts.Samples, internalConfig.historyResolution)
 			signal, nPeriods = signal.Truncate(periodLength)
 			if nPeriods >= 2 {
@@ -69,6 +69,19 @@ func findPeriod(ts *common.TimeSeries, sampleInterval time.Duration) time.Durati
 	return -1
 }
 
+// detectPeriod returns the forced seasonality period if set, otherwise the auto-detected daily or weekly period,
+// zero if the time series is not periodic
+func detectPeriod(ts *common.TimeSeries, config *internalConfig) time.Duration {
+	if config.seasonalityPeriod > 0 {
+		return config.seasonalityPeriod
+	}
+	p := findPeriod(ts, config.historyResolution)
+	if p == Day || p == Week {
+		return p
+	}
+	return 0
+}
+
 func SamplesToSignal(samples []common.Sample, sampleInterval time.Duration) *Signal {
 	values := make([]float64, len(samples))
 	for i := range samples {
@@ -219,12 +232,11 @@ func (p *periodicSignalPrediction) updateAggregateSignals(queryExpr string, hist
 		var chosenEstimator Estimator
 		var signal *Signal
 		var nPeriods int
-		var periodLength time.Duration = 0
+		var periodLength time.Duration
 
-		p := findPeriod(ts, config.historyResolution)
-		if p == Day || p == Week {
-			periodLength = p
-			klog.V(4).InfoS("This is a periodic time series.", "queryExpr", queryExpr, "labels", ts.Labels, "periodLength", periodLength)
+		periodLength = detectPeriod(ts, config)
+		if periodLength > 0 {
+			klog.V(4).InfoS("This is a periodic time series.", "queryExpr", queryExpr, "labels", ts.Labels, "periodLength", periodLength, "forced", config.seasonalityPeriod > 0)
 		} else {
 			klog.V(4).InfoS("This is not a periodic time series.", "queryExpr", queryExpr, "labels", ts.Labels)
 		}
@@ -7,9 +7,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gocrane/api/prediction/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gocrane/crane/pkg/common"
 	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
+	"github.com/gocrane/crane/pkg/prediction"
+	"github.com/gocrane/crane/pkg/prediction/config"
 	"github.com/gocrane/crane/pkg/providers/csv"
-	"github.com/stretchr/testify/assert"
 )
 
 func TestPreProcessTimeSeries(t *testing.T) {
@@ -56,3 +62,46 @@ func TestPreProcessTimeSeries(t *testing.T) {
 		assert.Equal(t, int64(60), timeSeries.Samples[i].Timestamp-timeSeries.Samples[i-1].Timestamp)
 	}
 }
+
+func TestSeasonalityPeriodOverride(t *testing.T) {
+	// a ramp is not periodic, the auto-detection finds no period
+	end := time.Now().Truncate(time.Minute)
+	ts := common.NewTimeSeries()
+	for i := 0; i < 3*24*60; i++ {
+		ts.AppendSample(end.Add(time.Duration(i-3*24*60)*time.Minute).Unix(), float64(i))
+	}
+
+	dspConfig := &v1alpha1.DSP{SampleInterval: "1m", HistoryLength: "72h"}
+	autoConfig, err := makeInternalConfig(dspConfig, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), detectPeriod(ts, autoConfig))
+
+	forcedConfig, err := makeInternalConfig(dspConfig, Day)
+	assert.NoError(t, err)
+	assert.Equal(t, Day, detectPeriod(ts, forcedConfig))
+
+	_, err = makeInternalConfig(dspConfig, -Day)
+	assert.Error(t, err)
+
+	for _, test := range []struct {
+		seasonalityPeriod time.Duration
+		expectSignals     int
+	}{
+		{seasonalityPeriod: 0, expectSignals: 0},
+		{seasonalityPeriod: Day, expectSignals: 1},
+	} {
+		p := NewPrediction(nil, nil, config.AlgorithmModelConfig{}).(*periodicSignalPrediction)
+		namer := &metricnaming.GeneralMetricNamer{CallerName: "test", Metric: &metricquery.Metric{Type: metricquery.WorkloadMetricType, MetricName: "cpu", Workload: &metricquery.WorkloadNamerInfo{Name: "nginx"}}}
+		p.a.Add(prediction.QueryExprWithCaller{
+			MetricNamer: namer,
+			Caller:      "test",
+			Config:      config.Config{DSP: dspConfig, SeasonalityPeriod: test.seasonalityPeriod},
+		})
+		internalConfig := p.a.GetConfig(namer.BuildUniqueKey())
+		assert.Equal(t, test.seasonalityPeriod, internalConfig.seasonalityPeriod)
+
+		p.updateAggregateSignals(namer.BuildUniqueKey(), []*common.TimeSeries{ts}, internalConfig)
+		signals, _ := p.a.GetSignals(namer.BuildUniqueKey())
+		assert.Len(t, signals, test.expectSignals)
+	}
+}
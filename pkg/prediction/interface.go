@@ -33,3 +33,9 @@ type Interface interface {
 
 	Name() string
 }
+
+// HealthChecker is optionally implemented by the predictors able to tell whether they can serve the queries
+type HealthChecker interface {
+	// Healthy returns nil if the predictor is running and can serve
+	Healthy(ctx context.Context) error
+}
@@ -37,7 +37,7 @@ func (a *aggregateSignals) Add(qc prediction.QueryExprWithCaller) bool {
 
 	QueryExpr := qc.MetricNamer.BuildUniqueKey()
 	if qc.Config.Percentile != nil {
-		cfg, err := makeInternalConfig(qc.Config.Percentile, qc.Config.InitMode)
+		cfg, err := makeInternalConfig(qc.Config.Percentile, qc.Config.InitMode, qc.Config.PercentileInterpolation)
 		if err != nil {
 			klog.ErrorS(err, "Failed to make internal config.", "queryExpr", QueryExpr)
 		} else {
@@ -29,6 +29,7 @@ var defaultInternalConfig = internalConfig{
 	histogramOptions:       defaultHistogramOptions,
 	targetUtilization:      defaultTargetUtilization,
 	historyLength:          time.Hour * 24 * 7,
+	interpolation:          config.PercentileInterpolationBoundary,
 }
 
 type internalConfig struct {
@@ -42,16 +43,17 @@ type internalConfig struct {
 	percentile             float64
 	targetUtilization      float64
 	initMode               config.ModelInitMode
+	interpolation          config.PercentileInterpolation
 }
 
 func (c *internalConfig) String() string {
-	return fmt.Sprintf("{aggregated: %v, historyLength: %v, sampleInterval: %v, histogramDecayHalfLife: %v, minSampleWeight: %v, marginFraction: %v, percentile: %v, targetUtilization: %v}",
-		c.aggregated, c.historyLength, c.sampleInterval, c.histogramDecayHalfLife, c.minSampleWeight, c.marginFraction, c.percentile, c.targetUtilization)
+	return fmt.Sprintf("{aggregated: %v, historyLength: %v, sampleInterval: %v, histogramDecayHalfLife: %v, minSampleWeight: %v, marginFraction: %v, percentile: %v, targetUtilization: %v, interpolation: %v}",
+		c.aggregated, c.historyLength, c.sampleInterval, c.histogramDecayHalfLife, c.minSampleWeight, c.marginFraction, c.percentile, c.targetUtilization, c.interpolation)
 }
 
 // todo: later better to refine the algorithm params to a map not a struct to get more extendability,
 // if not, we add some param is very difficult because it will modify crane api
-func makeInternalConfig(p *v1alpha1.Percentile, initMode *config.ModelInitMode) (*internalConfig, error) {
+func makeInternalConfig(p *v1alpha1.Percentile, initMode *config.ModelInitMode, interpolation config.PercentileInterpolation) (*internalConfig, error) {
 	sampleInterval, err := utils.ParseDuration(p.SampleInterval)
 	if err != nil {
 		return nil, err
@@ -139,6 +141,14 @@ func makeInternalConfig(p *v1alpha1.Percentile, initMode *config.ModelInitMode)
 		return nil, err
 	}
 
+	switch interpolation {
+	case "":
+		interpolation = config.PercentileInterpolationBoundary
+	case config.PercentileInterpolationBoundary, config.PercentileInterpolationLinear:
+	default:
+		return nil, fmt.Errorf("unknown percentile interpolation %q", interpolation)
+	}
+
 	// default use history
 	mode := config.ModelInitModeHistory
 	if initMode != nil {
@@ -155,6 +165,7 @@ func makeInternalConfig(p *v1alpha1.Percentile, initMode *config.ModelInitMode)
 		marginFraction:         marginFraction,
 		percentile:             percentile,
 		targetUtilization:      targetUtilization,
+		interpolation:          interpolation,
 	}
 	klog.InfoS("Made an internal config.", "internalConfig", c)
 
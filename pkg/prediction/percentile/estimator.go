@@ -1,6 +1,8 @@
 package percentile
 
 import (
+	"sort"
+
 	vpa "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/util"
 )
 
@@ -12,6 +14,13 @@ type percentileEstimator struct {
 	percentile float64
 }
 
+// linearPercentileEstimator interpolates the percentile linearly within the bucket it falls in, the histogram
+// options are needed for the bucket boundaries
+type linearPercentileEstimator struct {
+	percentile float64
+	options    vpa.HistogramOptions
+}
+
 type marginEstimator struct {
 	marginFraction float64
 	baseEstimator  Estimator
@@ -26,6 +35,10 @@ func NewPercentileEstimator(percentile float64) Estimator {
 	return &percentileEstimator{percentile}
 }
 
+func NewLinearPercentileEstimator(percentile float64, options vpa.HistogramOptions) Estimator {
+	return &linearPercentileEstimator{percentile, options}
+}
+
 func WithMargin(marginFraction float64, baseEstimator Estimator) Estimator {
 	return &marginEstimator{marginFraction, baseEstimator}
 }
@@ -38,6 +51,44 @@ func (e *percentileEstimator) GetEstimation(h vpa.Histogram) float64 {
 	return h.Percentile(e.percentile)
 }
 
+// GetEstimation reads the bucket weights from the checkpoint of the histogram, they are normalized but keep the
+// proportions, then the percentile is placed in its bucket by the share of the bucket weight below it
+func (e *linearPercentileEstimator) GetEstimation(h vpa.Histogram) float64 {
+	if h.IsEmpty() {
+		return 0.0
+	}
+	checkpoint, err := h.SaveToChekpoint()
+	if err != nil || len(checkpoint.BucketWeights) == 0 {
+		return h.Percentile(e.percentile)
+	}
+	buckets := make([]int, 0, len(checkpoint.BucketWeights))
+	totalWeight := 0.0
+	for bucket, weight := range checkpoint.BucketWeights {
+		buckets = append(buckets, bucket)
+		totalWeight += float64(weight)
+	}
+	sort.Ints(buckets)
+
+	threshold := e.percentile * totalWeight
+	partialSum := 0.0
+	for _, bucket := range buckets {
+		weight := float64(checkpoint.BucketWeights[bucket])
+		if partialSum+weight >= threshold {
+			// the last bucket has no upper bound
+			if bucket >= e.options.NumBuckets()-1 {
+				return e.options.GetBucketStart(bucket)
+			}
+			start, end := e.options.GetBucketStart(bucket), e.options.GetBucketStart(bucket+1)
+			if weight == 0 {
+				return start
+			}
+			return start + (end-start)*(threshold-partialSum)/weight
+		}
+		partialSum += weight
+	}
+	return h.Percentile(e.percentile)
+}
+
 func (e *marginEstimator) GetEstimation(h vpa.Histogram) float64 {
 	return e.baseEstimator.GetEstimation(h) * (1 + e.marginFraction)
 }
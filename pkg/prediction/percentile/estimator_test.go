@@ -0,0 +1,62 @@
+package percentile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	vpa "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/recommender/util"
+
+	"github.com/gocrane/api/prediction/v1alpha1"
+
+	"github.com/gocrane/crane/pkg/prediction/config"
+)
+
+func TestPercentileInterpolation(t *testing.T) {
+	options, err := vpa.NewLinearHistogramOptions(10.0, 1.0, 1e-10)
+	assert.NoError(t, err)
+	// the buckets [0, 1), [1, 2) and [2, 3) weigh 1, 1 and 2
+	h := vpa.NewHistogram(options)
+	now := time.Now()
+	h.AddSample(0.5, 1, now)
+	h.AddSample(1.5, 1, now)
+	h.AddSample(2.5, 2, now)
+
+	tests := []struct {
+		percentile float64
+		boundary   float64
+		linear     float64
+	}{
+		{percentile: 0.25, boundary: 1, linear: 1},
+		{percentile: 0.5, boundary: 2, linear: 2},
+		{percentile: 0.75, boundary: 3, linear: 2.5},
+		{percentile: 0.9, boundary: 3, linear: 2.8},
+		{percentile: 1, boundary: 3, linear: 3},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.boundary, NewPercentileEstimator(tt.percentile).GetEstimation(h), 1e-9, tt.percentile)
+		assert.InDelta(t, tt.linear, NewLinearPercentileEstimator(tt.percentile, options).GetEstimation(h), 1e-9, tt.percentile)
+	}
+
+	// the margin applies to the interpolated value
+	assert.InDelta(t, 2.75, WithMargin(0.1, NewLinearPercentileEstimator(0.75, options)).GetEstimation(h), 1e-9)
+	assert.Equal(t, 0.0, NewLinearPercentileEstimator(0.9, options).GetEstimation(vpa.NewHistogram(options)))
+}
+
+func TestMakeInternalConfigInterpolation(t *testing.T) {
+	p := &v1alpha1.Percentile{
+		SampleInterval: "1m",
+		HistoryLength:  "24h",
+		Histogram:      v1alpha1.HistogramConfig{HalfLife: "24h"},
+	}
+	cfg, err := makeInternalConfig(p, nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, config.PercentileInterpolationBoundary, cfg.interpolation)
+
+	cfg, err = makeInternalConfig(p, nil, config.PercentileInterpolationLinear)
+	assert.NoError(t, err)
+	assert.Equal(t, config.PercentileInterpolationLinear, cfg.interpolation)
+
+	_, err = makeInternalConfig(p, nil, "cubic")
+	assert.Error(t, err)
+}
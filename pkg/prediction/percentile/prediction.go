@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -16,6 +17,7 @@ import (
 )
 
 var _ prediction.Interface = &percentilePrediction{}
+var _ prediction.HealthChecker = &percentilePrediction{}
 var keyAll = "__all__"
 
 type percentilePrediction struct {
@@ -24,6 +26,16 @@ type percentilePrediction struct {
 	// record the query routine already started
 	queryRoutines sync.Map
 	stopChMap     sync.Map
+	// running is 1 once the routines are started until stopped
+	running int32
+}
+
+// Healthy returns an error if the prediction routines are not running
+func (p *percentilePrediction) Healthy(_ context.Context) error {
+	if atomic.LoadInt32(&p.running) == 0 {
+		return fmt.Errorf("predictor %v is not running", p.Name())
+	}
+	return nil
 }
 
 func (p *percentilePrediction) QueryPredictionStatus(_ context.Context, metricNamer metricnaming.MetricNamer) (prediction.Status, error) {
@@ -65,6 +77,9 @@ func (p *percentilePrediction) getPredictedValuesFromSignals(queryExpr string, s
 		cfg = p.a.GetConfig(queryExpr)
 	}
 	estimator := NewPercentileEstimator(cfg.percentile)
+	if cfg.interpolation == config.PercentileInterpolationLinear {
+		estimator = NewLinearPercentileEstimator(cfg.percentile, cfg.histogramOptions)
+	}
 	estimator = WithMargin(cfg.marginFraction, estimator)
 	estimator = WithTargetUtilization(cfg.targetUtilization, estimator)
 	now := time.Now().Unix()
@@ -141,7 +156,7 @@ func (p *percentilePrediction) QueryRealtimePredictedValues(ctx context.Context,
 func (p *percentilePrediction) QueryRealtimePredictedValuesOnce(_ context.Context, namer metricnaming.MetricNamer, config config.Config) ([]*common.TimeSeries, error) {
 	queryExpr := namer.BuildUniqueKey()
 
-	cfg, err := makeInternalConfig(config.Percentile, config.InitMode)
+	cfg, err := makeInternalConfig(config.Percentile, config.InitMode, config.PercentileInterpolation)
 	if err != nil {
 		return nil, err
 	}
@@ -299,10 +314,12 @@ func (p *percentilePrediction) Run(stopCh <-chan struct{}) {
 		}
 	}()
 
+	atomic.StoreInt32(&p.running, 1)
 	klog.Infof("predictor %v started", p.Name())
 
 	<-stopCh
 
+	atomic.StoreInt32(&p.running, 0)
 	klog.Infof("predictor %v stopped", p.Name())
 
 }
@@ -58,4 +58,6 @@ const (
 	PrometheusDataSource   DataSourceType = "prom"
 	MetricServerDataSource DataSourceType = "metricserver"
 	GrpcDataSource         DataSourceType = "grpc"
+	// VictoriaMetricsDataSource is configured by the PromConfig, its query api is compatible with prometheus
+	VictoriaMetricsDataSource DataSourceType = "victoriametrics"
 )
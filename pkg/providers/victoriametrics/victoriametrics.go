@@ -0,0 +1,195 @@
+package victoriametrics
+
+import (
+	"bufio"
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	prometheus "github.com/prometheus/client_golang/api"
+	promapiv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
+	"github.com/gocrane/crane/pkg/providers"
+	"github.com/gocrane/crane/pkg/providers/prom"
+)
+
+const exportEndpoint = "/api/v1/export"
+
+// seriesSelectorPattern matches the plain series selectors, such as 'metric{label="value"}', which are exported
+// natively, the other queries are evaluated by MetricsQL
+var seriesSelectorPattern = regexp.MustCompile(`^\s*([a-zA-Z_:][a-zA-Z0-9_:]*)?\s*(\{[^{}]*\})?\s*$`)
+
+// queryContext evaluates the MetricsQL queries by the prometheus compatible query api
+type queryContext interface {
+	QueryRangeSync(ctx gocontext.Context, query string, start, end time.Time, step time.Duration) ([]*common.TimeSeries, error)
+	QuerySync(ctx gocontext.Context, query string) ([]*common.TimeSeries, error)
+}
+
+var _ prom.Provider = &victoriaMetrics{}
+
+type victoriaMetrics struct {
+	client prometheus.Client
+	ctx    queryContext
+	config *providers.PromConfig
+}
+
+// NewProvider return a victoria metrics data provider, the queries are built as prometheus queries since MetricsQL
+// is backward compatible with PromQL
+func NewProvider(config *providers.PromConfig) (providers.Interface, error) {
+	client, err := prom.NewPrometheusClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &victoriaMetrics{
+		client: client,
+		ctx:    prom.NewContext(client, config.MaxPointsLimitPerTimeSeries),
+		config: config,
+	}, nil
+}
+
+// GetPromClient returns the prometheus api of victoria metrics, it serves the prometheus compatible queries of the
+// craned server
+func (v *victoriaMetrics) GetPromClient() promapiv1.API {
+	return promapiv1.NewAPI(v.client)
+}
+
+func (v *victoriaMetrics) QueryTimeSeries(namer metricnaming.MetricNamer, startTime time.Time, endTime time.Time, step time.Duration) ([]*common.TimeSeries, error) {
+	query, err := buildQuery(namer)
+	if err != nil {
+		return nil, err
+	}
+	klog.V(6).Infof("QueryTimeSeries metricNamer %v, timeout: %v, query: %v", namer.BuildUniqueKey(), v.config.Timeout, query)
+	timeoutCtx, cancelFunc := gocontext.WithTimeout(gocontext.Background(), v.config.Timeout)
+	defer cancelFunc()
+
+	var timeSeries []*common.TimeSeries
+	if seriesSelectorPattern.MatchString(query) {
+		timeSeries, err = v.export(timeoutCtx, query, startTime, endTime, step)
+	} else {
+		timeSeries, err = v.ctx.QueryRangeSync(timeoutCtx, query, startTime, endTime, step)
+	}
+	if err != nil {
+		klog.Errorf("Failed to QueryTimeSeries: %v, metricNamer: %v, query: %v", err, namer.BuildUniqueKey(), query)
+		return nil, err
+	}
+	return timeSeries, nil
+}
+
+func (v *victoriaMetrics) QueryLatestTimeSeries(namer metricnaming.MetricNamer) ([]*common.TimeSeries, error) {
+	query, err := buildQuery(namer)
+	if err != nil {
+		return nil, err
+	}
+	klog.V(6).Infof("QueryLatestTimeSeries metricNamer %v, timeout: %v, query: %v", namer.BuildUniqueKey(), v.config.Timeout, query)
+	timeoutCtx, cancelFunc := gocontext.WithTimeout(gocontext.Background(), v.config.Timeout)
+	defer cancelFunc()
+	timeSeries, err := v.ctx.QuerySync(timeoutCtx, query)
+	if err != nil {
+		klog.Errorf("Failed to QueryLatestTimeSeries: %v, metricNamer: %v, query: %v", err, namer.BuildUniqueKey(), query)
+		return nil, err
+	}
+	return timeSeries, nil
+}
+
+func buildQuery(namer metricnaming.MetricNamer) (string, error) {
+	promQuery, err := namer.QueryBuilder().Builder(metricquery.PrometheusMetricSource).BuildQuery()
+	if err != nil {
+		klog.Errorf("Failed to BuildQuery: %v", err)
+		return "", err
+	}
+	return promQuery.Prometheus.Query, nil
+}
+
+// exportedSeries is a line of the export api, the timestamps are in milliseconds
+type exportedSeries struct {
+	Metric     map[string]string `json:"metric"`
+	Values     []float64         `json:"values"`
+	Timestamps []int64           `json:"timestamps"`
+}
+
+// export reads the raw samples of the series selector by the native export api, the samples are downsampled to the
+// last one of each step so they are aligned as the range query
+func (v *victoriaMetrics) export(ctx gocontext.Context, selector string, startTime time.Time, endTime time.Time, step time.Duration) ([]*common.TimeSeries, error) {
+	args := url.Values{}
+	args.Set("match[]", selector)
+	args.Set("start", strconv.FormatInt(startTime.Unix(), 10))
+	args.Set("end", strconv.FormatInt(endTime.Unix(), 10))
+	u := v.client.URL(exportEndpoint, nil)
+	u.RawQuery = args.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, body, err := v.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("export %s failed, status %d: %s", selector, resp.StatusCode, string(body))
+	}
+	return parseExport(body, step)
+}
+
+// parseExport converts the json lines of the export api to the time series
+func parseExport(body []byte, step time.Duration) ([]*common.TimeSeries, error) {
+	var results []*common.TimeSeries
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		series := &exportedSeries{}
+		if err := json.Unmarshal(line, series); err != nil {
+			return nil, fmt.Errorf("parse the exported series failed: %v", err)
+		}
+		if len(series.Values) != len(series.Timestamps) {
+			return nil, fmt.Errorf("the exported series has %d values but %d timestamps", len(series.Values), len(series.Timestamps))
+		}
+
+		ts := common.NewTimeSeries()
+		for key, val := range series.Metric {
+			ts.AppendLabel(key, val)
+		}
+		for i := range series.Timestamps {
+			ts.AppendSample(series.Timestamps[i]/1000, series.Values[i])
+		}
+		ts.SortSampleAsc()
+		results = append(results, downsample(ts, step))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// downsample keeps the last sample of each step
+func downsample(ts *common.TimeSeries, step time.Duration) *common.TimeSeries {
+	stepSeconds := int64(step / time.Second)
+	if stepSeconds <= 1 || len(ts.Samples) == 0 {
+		return ts
+	}
+	samples := make([]common.Sample, 0, len(ts.Samples))
+	for _, sample := range ts.Samples {
+		bucket := sample.Timestamp - sample.Timestamp%stepSeconds
+		if len(samples) != 0 && samples[len(samples)-1].Timestamp == bucket {
+			samples[len(samples)-1].Value = sample.Value
+			continue
+		}
+		samples = append(samples, common.Sample{Timestamp: bucket, Value: sample.Value})
+	}
+	ts.SetSamples(samples)
+	return ts
+}
@@ -0,0 +1,114 @@
+package victoriametrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gocrane/crane/pkg/common"
+	"github.com/gocrane/crane/pkg/metricnaming"
+	"github.com/gocrane/crane/pkg/metricquery"
+	"github.com/gocrane/crane/pkg/providers"
+	"github.com/gocrane/crane/pkg/providers/prom"
+	_ "github.com/gocrane/crane/pkg/querybuilder-providers/prometheus"
+)
+
+func newPromQLNamer(query string) metricnaming.MetricNamer {
+	return &metricnaming.GeneralMetricNamer{
+		Metric: &metricquery.Metric{
+			Type: metricquery.PromQLMetricType,
+			Prom: &metricquery.PromNamerInfo{QueryExpr: query},
+		},
+	}
+}
+
+func TestVictoriaMetricsProvider(t *testing.T) {
+	requests := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		switch r.URL.Path {
+		case exportEndpoint:
+			requests[r.URL.Path] = r.Form.Get("match[]")
+			fmt.Fprintln(w, `{"metric":{"__name__":"up","pod":"nginx-a"},"values":[1,0,1],"timestamps":[1656633600000,1656633615000,1656633660000]}`)
+		case "/api/v1/query_range":
+			requests[r.URL.Path] = r.Form.Get("query")
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"pod":"nginx-a"},"values":[[1656633600,"0.5"],[1656633660,"0.7"]]}]}}`)
+		case "/api/v1/query":
+			requests[r.URL.Path] = r.Form.Get("query")
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"pod":"nginx-a"},"value":[1656633660,"0.7"]}]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(&providers.PromConfig{Address: server.URL, Timeout: 10 * time.Second, MaxPointsLimitPerTimeSeries: 11000})
+	assert.NoError(t, err)
+	start := time.Unix(1656633600, 0)
+	end := start.Add(2 * time.Minute)
+
+	// the plain series selector is read by the export api, the raw samples are aligned to the steps
+	tsList, err := provider.QueryTimeSeries(newPromQLNamer(`up{pod="nginx-a"}`), start, end, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, `up{pod="nginx-a"}`, requests[exportEndpoint])
+	if assert.Len(t, tsList, 1) {
+		assert.Equal(t, []common.Sample{{Timestamp: 1656633600, Value: 0}, {Timestamp: 1656633660, Value: 1}}, tsList[0].Samples)
+	}
+
+	// the other queries are evaluated by MetricsQL
+	query := `sum(rate(container_cpu_usage_seconds_total{pod=~"nginx-.*"}[5m]))`
+	tsList, err = provider.QueryTimeSeries(newPromQLNamer(query), start, end, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, query, requests["/api/v1/query_range"])
+	if assert.Len(t, tsList, 1) {
+		assert.Len(t, tsList[0].Samples, 2)
+	}
+
+	tsList, err = provider.QueryLatestTimeSeries(newPromQLNamer(query))
+	assert.NoError(t, err)
+	assert.Equal(t, query, requests["/api/v1/query"])
+	if assert.Len(t, tsList, 1) {
+		assert.Equal(t, 0.7, tsList[0].Samples[0].Value)
+	}
+
+	// the craned server queries by the prometheus api
+	promProvider, ok := provider.(prom.Provider)
+	if assert.True(t, ok) {
+		delete(requests, "/api/v1/query")
+		_, _, err = promProvider.GetPromClient().Query(context.TODO(), query, end)
+		assert.NoError(t, err)
+		assert.Equal(t, query, requests["/api/v1/query"])
+	}
+}
+
+func TestParseExport(t *testing.T) {
+	_, err := parseExport([]byte(`{"metric":{},"values":[1,2],"timestamps":[1000]}`), time.Minute)
+	assert.Error(t, err)
+
+	_, err = parseExport([]byte(`not json`), time.Minute)
+	assert.Error(t, err)
+
+	tsList, err := parseExport([]byte("\n"), time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, tsList)
+}
+
+func TestSeriesSelectorPattern(t *testing.T) {
+	for query, selector := range map[string]bool{
+		`up`:                         true,
+		`up{pod="nginx-a"}`:          true,
+		`{__name__="up"}`:            true,
+		`rate(up[5m])`:               false,
+		`up{pod="nginx-a"}[5m]`:      false,
+		`sum(up) by (pod)`:           false,
+		`up{pod="nginx-a"} / 100`:    false,
+		`up{pod="a"} or up{pod="b"}`: false,
+	} {
+		assert.Equal(t, selector, seriesSelectorPattern.MatchString(query), query)
+	}
+}
@@ -34,10 +34,23 @@ const (
 	ContainerCpuUsageExprTemplate = `irate(container_cpu_usage_seconds_total{container!="POD",namespace="%s",pod=~"^%s.*$",container="%s"}[%s])`
 	// ContainerMemUsageExprTemplate is used to query container cpu usage by promql,  param is namespace,pod,container
 	ContainerMemUsageExprTemplate = `container_memory_working_set_bytes{container!="POD",namespace="%s",pod=~"^%s.*$",container="%s"}`
+	// ContainerEphemeralStorageUsageExprTemplate is used to query container ephemeral storage usage by promql,  param is namespace,pod,container
+	ContainerEphemeralStorageUsageExprTemplate = `container_fs_usage_bytes{container!="POD",namespace="%s",pod=~"^%s.*$",container="%s"}`
+
+	// ContainerGpuUsageExprTemplate is used to query container gpu usage in gpus by the dcgm exporter metric, param is namespace,pod,container
+	ContainerGpuUsageExprTemplate = `sum(DCGM_FI_DEV_GPU_UTIL{namespace="%s",pod=~"^%s.*$",container="%s"}) by (namespace, pod, container) / 100`
 )
 
+// deviceUsageExprTemplates are the container usage templates of the device resources
+var deviceUsageExprTemplates = map[string]string{
+	"nvidia.com/gpu": ContainerGpuUsageExprTemplate,
+}
+
 var supportedResources = sets.NewString(v1.ResourceCPU.String(), v1.ResourceMemory.String())
 
+// supportedContainerResources are the builtin container metrics, the others are queried by their QueryTemplate
+var supportedContainerResources = sets.NewString(v1.ResourceCPU.String(), v1.ResourceMemory.String(), v1.ResourceEphemeralStorage.String())
+
 var _ querybuilder.Builder = &builder{}
 
 type builder struct {
@@ -62,6 +75,8 @@ func (b *builder) BuildQuery() (*metricquery.Query, error) {
 		return b.nodeQuery(b.metric)
 	case metricquery.PromQLMetricType:
 		return b.promQuery(b.metric)
+	case metricquery.DeviceMetricType:
+		return b.deviceQuery(b.metric)
 	default:
 		return nil, fmt.Errorf("metric type %v not supported", b.metric.Type)
 	}
@@ -98,11 +113,33 @@ func (b *builder) containerQuery(metric *metricquery.Metric) (*metricquery.Query
 		return promQuery(&metricquery.PrometheusQuery{
 			Query: fmt.Sprintf(ContainerMemUsageExprTemplate, metric.Container.Namespace, metric.Container.WorkloadName, metric.Container.Name),
 		}), nil
+	case v1.ResourceEphemeralStorage.String():
+		return promQuery(&metricquery.PrometheusQuery{
+			Query: fmt.Sprintf(ContainerEphemeralStorageUsageExprTemplate, metric.Container.Namespace, metric.Container.WorkloadName, metric.Container.Name),
+		}), nil
 	default:
-		return nil, fmt.Errorf("metric type %v do not support resource metric %v. only support %v now", metric.Type, metric.MetricName, supportedResources.List())
+		if metric.Container.QueryTemplate != "" {
+			return promQuery(&metricquery.PrometheusQuery{
+				Query: fmt.Sprintf(metric.Container.QueryTemplate, metric.Container.Namespace, metric.Container.WorkloadName, metric.Container.Name),
+			}), nil
+		}
+		return nil, fmt.Errorf("metric type %v do not support resource metric %v without a query template. only support %v now", metric.Type, metric.MetricName, supportedContainerResources.List())
 	}
 }
 
+func (b *builder) deviceQuery(metric *metricquery.Metric) (*metricquery.Query, error) {
+	if metric.Device == nil {
+		return nil, fmt.Errorf("metric type %v, but no DeviceNamerInfo provided", metric.Type)
+	}
+	template, exists := deviceUsageExprTemplates[strings.ToLower(metric.MetricName)]
+	if !exists {
+		return nil, fmt.Errorf("metric type %v do not support device metric %v. only support %v now", metric.Type, metric.MetricName, sets.StringKeySet(deviceUsageExprTemplates).List())
+	}
+	return promQuery(&metricquery.PrometheusQuery{
+		Query: fmt.Sprintf(template, metric.Device.Namespace, metric.Device.WorkloadName, metric.Device.ContainerName),
+	}), nil
+}
+
 func (b *builder) podQuery(metric *metricquery.Metric) (*metricquery.Query, error) {
 	if metric.Pod == nil {
 		return nil, fmt.Errorf("metric type %v, but no PodNamerInfo provided", metric.Type)
@@ -88,6 +88,19 @@ func TestBuildQuery(t *testing.T) {
 			},
 			want: fmt.Sprintf(ContainerMemUsageExprTemplate, "default", "workload", "container"),
 		},
+		{
+			desc: "tc4-container-ephemeral-storage",
+			metric: &metricquery.Metric{
+				MetricName: v1.ResourceEphemeralStorage.String(),
+				Type:       metricquery.ContainerMetricType,
+				Container: &metricquery.ContainerNamerInfo{
+					Namespace:    "default",
+					WorkloadName: "workload",
+					Name:         "container",
+				},
+			},
+			want: fmt.Sprintf(ContainerEphemeralStorageUsageExprTemplate, "default", "workload", "container"),
+		},
 		{
 			desc: "tc5-node-cpu",
 			metric: &metricquery.Metric{
@@ -145,6 +158,33 @@ func TestBuildQuery(t *testing.T) {
 			},
 			want: "irate(http_requests{}[3m])",
 		},
+		{
+			desc: "tc10-device-gpu",
+			metric: &metricquery.Metric{
+				MetricName: "nvidia.com/gpu",
+				Type:       metricquery.DeviceMetricType,
+				Device: &metricquery.DeviceNamerInfo{
+					Namespace:     "default",
+					WorkloadName:  "workload",
+					ContainerName: "container",
+				},
+			},
+			want: fmt.Sprintf(ContainerGpuUsageExprTemplate, "default", "workload", "container"),
+		},
+		{
+			desc: "tc11-container-custom",
+			metric: &metricquery.Metric{
+				MetricName: "connections",
+				Type:       metricquery.ContainerMetricType,
+				Container: &metricquery.ContainerNamerInfo{
+					Namespace:     "default",
+					WorkloadName:  "workload",
+					Name:          "container",
+					QueryTemplate: `sum(nginx_connections{namespace="%s",pod=~"^%s-.*$",container="%s"})`,
+				},
+			},
+			want: `sum(nginx_connections{namespace="default",pod=~"^workload-.*$",container="container"})`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -158,3 +198,20 @@ func TestBuildQuery(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildContainerQueryWithoutTemplate(t *testing.T) {
+	metric := &metricquery.Metric{
+		MetricName: "connections",
+		Type:       metricquery.ContainerMetricType,
+		Container: &metricquery.ContainerNamerInfo{
+			Namespace:    "default",
+			WorkloadName: "workload",
+			Name:         "container",
+		},
+	}
+	_, err := NewPromQueryBuilder(metric).BuildQuery()
+	want := "metric type container do not support resource metric connections without a query template. only support [cpu ephemeral-storage memory] now"
+	if err == nil || err.Error() != want {
+		t.Fatalf("got error: %v, want error: %v", err, want)
+	}
+}